@@ -0,0 +1,275 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (r *implementation) DeleteUser(_ context.Context, em string, m domain.DeletionMode) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		// Everything only the user could see goes either way
+		for _, rel := range []relation{following, blocking, muting} {
+			if err := rel.removeAll(tx, uk); err != nil {
+				return err
+			}
+		}
+		if err := favorites.removeFrom(tx, uk); err != nil {
+			return err
+		}
+		if err := deletePrefixed(tx.Bucket(readingListsBucket), key(uk)); err != nil {
+			return err
+		}
+		owned, err := apiTokensByOwner(tx, uk)
+		if err != nil {
+			return err
+		}
+		for _, tr := range owned {
+			if err := deleteAPIToken(tx, tr); err != nil {
+				return err
+			}
+		}
+		if err := deleteWhere(tx.Bucket(identitiesBucket), func(v []byte) (bool, error) {
+			return bytes.Equal(v, key(uk)), nil
+		}); err != nil {
+			return err
+		}
+		if err := deleteWhere(tx.Bucket(previousUsernamesBucket), func(v []byte) (bool, error) {
+			var pu previousUsernameRecord
+			return pu.User == uk, json.Unmarshal(v, &pu)
+		}); err != nil {
+			return err
+		}
+
+		if m == domain.DeleteContent {
+			return deleteContent(tx, uk, ur)
+		}
+
+		u := ur.toDomain()
+		if err := u.Anonymize(); err != nil {
+			return err
+		}
+
+		// The internal key stays the same so their articles and comments stay attached to the placeholder
+		return putUser(tx, uk, ur, newUserRecord(u))
+	})
+}
+
+// deleteContent deletes the user along with their articles, comments and reports.
+func deleteContent(tx *bolt.Tx, uk uint64, ur *userRecord) error {
+	for _, ak := range authored.targets(tx, uk) {
+		if err := deleteArticle(tx, ak); err != nil {
+			return err
+		}
+	}
+	if err := deleteWhere(tx.Bucket(commentsBucket), func(v []byte) (bool, error) {
+		var cr commentRecord
+		return cr.Author == uk, json.Unmarshal(v, &cr)
+	}); err != nil {
+		return err
+	}
+
+	b := tx.Bucket(reportsBucket)
+	if err := deleteWhere(b, func(v []byte) (bool, error) {
+		var rr reportRecord
+		return rr.Reporter == uk, json.Unmarshal(v, &rr)
+	}); err != nil {
+		return err
+	}
+	resolved := make([]*reportRecord, 0)
+	err := b.ForEach(func(_ []byte, v []byte) error {
+		rr := new(reportRecord)
+		if err := json.Unmarshal(v, rr); err != nil {
+			return err
+		}
+		if rr.Resolver == uk {
+			resolved = append(resolved, rr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, rr := range resolved {
+		rr.Resolver = 0
+		if err := put(b, key(uint64(rr.ID)), rr); err != nil {
+			return err
+		}
+	}
+
+	for _, ik := range []struct {
+		index []byte
+		k     string
+	}{
+		{userIDsBucket, ur.ID},
+		{userEmailsBucket, strings.ToLower(ur.Email)},
+		{usernamesBucket, strings.ToLower(ur.Username)},
+	} {
+		if err := tx.Bucket(ik.index).Delete([]byte(ik.k)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(usersBucket).Delete(key(uk))
+}
+
+// deleteWhere deletes every key in the bucket with a value matching the predicate.
+func deleteWhere(b *bolt.Bucket, matches func(v []byte) (bool, error)) error {
+	var ks [][]byte
+	err := b.ForEach(func(k []byte, v []byte) error {
+		ok, err := matches(v)
+		if ok {
+			ks = append(ks, append([]byte{}, k...))
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range ks {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportUserByEmail gathers everything kept about the user with the given email.
+func (r *implementation) ExportUserByEmail(_ context.Context, em string) (*domain.UserExport, error) {
+	var x *domain.UserExport
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		x = &domain.UserExport{
+			User:              *ur.toDomain(),
+			PreviousUsernames: make([]string, 0),
+			Identities:        make([]domain.ExternalIdentity, 0),
+			Following:         make([]string, 0),
+			Favorites:         make([]string, 0),
+			Articles:          make([]domain.Article, 0),
+			Comments:          make([]domain.ExportedComment, 0),
+			CreatedAtUTC:      time.Now().UTC(),
+		}
+
+		previous := make([]previousUsernameRecord, 0)
+		err = tx.Bucket(previousUsernamesBucket).ForEach(func(_ []byte, v []byte) error {
+			var pu previousUsernameRecord
+			if err := json.Unmarshal(v, &pu); err != nil {
+				return err
+			}
+			if pu.User == uk {
+				previous = append(previous, pu)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.Slice(previous, func(i, j int) bool {
+			return previous[i].ChangedAtUTC.Before(previous[j].ChangedAtUTC)
+		})
+		for _, pu := range previous {
+			x.PreviousUsernames = append(x.PreviousUsernames, pu.Username)
+		}
+
+		// Identities are keyed by provider then subject so they're already in order
+		err = tx.Bucket(identitiesBucket).ForEach(func(k []byte, v []byte) error {
+			if !bytes.Equal(v, key(uk)) {
+				return nil
+			}
+			ps := strings.SplitN(string(k), "\x00", 2)
+			x.Identities = append(x.Identities, domain.ExternalIdentity{Provider: ps[0], Subject: ps[1]})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range following.targets(tx, uk) {
+			if fr, err := getUser(tx, k); err == nil {
+				x.Following = append(x.Following, fr.Username)
+			}
+		}
+		sort.Strings(x.Following)
+		for _, k := range favorites.targets(tx, uk) {
+			if ar, err := getArticle(tx, k); err == nil {
+				x.Favorites = append(x.Favorites, ar.Slug)
+			}
+		}
+		sort.Strings(x.Favorites)
+
+		for _, k := range authored.targets(tx, uk) {
+			ar, err := getArticle(tx, k)
+			if err != nil {
+				return err
+			}
+			aa, err := authoredArticle(tx, k, ar)
+			if err != nil {
+				return err
+			}
+			x.Articles = append(x.Articles, aa.Article)
+		}
+		sort.Slice(x.Articles, func(i, j int) bool {
+			return x.Articles[i].CreatedAtUTC.Before(x.Articles[j].CreatedAtUTC)
+		})
+
+		err = tx.Bucket(commentsBucket).ForEach(func(k []byte, v []byte) error {
+			var cr commentRecord
+			if err := json.Unmarshal(v, &cr); err != nil {
+				return err
+			}
+			if cr.Author != uk {
+				return nil
+			}
+
+			ar, err := getArticle(tx, unkey(k))
+			if err != nil {
+				return err
+			}
+			x.Comments = append(x.Comments, domain.ExportedComment{
+				ArticleSlug: ar.Slug,
+				Comment: domain.Comment{
+					ID:           cr.ID,
+					UID:          cr.UID,
+					Body:         cr.Body,
+					BodyHTML:     cr.BodyHTML,
+					CreatedAtUTC: cr.CreatedAtUTC,
+					AuthorEmail:  ur.Email,
+					Hidden:       cr.Hidden,
+				},
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		sort.Slice(x.Comments, func(i, j int) bool {
+			return x.Comments[i].CreatedAtUTC.Before(x.Comments[j].CreatedAtUTC)
+		})
+
+		x.ReadingLists, err = readingListsByOwner(tx, uk, ur.Email)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
@@ -0,0 +1,191 @@
+package boltdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+func (tr *apiTokenRecord) toDomain(em string) *domain.APIToken {
+	scopes := make([]domain.Scope, 0, len(tr.Scopes))
+	for _, s := range tr.Scopes {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return &domain.APIToken{
+		ID:           tr.ID,
+		OwnerEmail:   em,
+		Name:         tr.Name,
+		Hash:         tr.Hash,
+		Scopes:       scopes,
+		CreatedAtUTC: tr.CreatedAtUTC,
+	}
+}
+
+// apiTokensByOwner reads all the API tokens of the user with the internal key.
+func apiTokensByOwner(tx *bolt.Tx, uk uint64) ([]*apiTokenRecord, error) {
+	found := make([]*apiTokenRecord, 0)
+	for _, id := range apiTokensOwned.targets(tx, uk) {
+		tr := new(apiTokenRecord)
+		if _, err := get(tx.Bucket(apiTokensBucket), key(id), tr); err != nil {
+			return nil, err
+		}
+		found = append(found, tr)
+	}
+	return found, nil
+}
+
+// deleteAPIToken revokes the API token and removes it from the indexes.
+func deleteAPIToken(tx *bolt.Tx, tr *apiTokenRecord) error {
+	if err := tx.Bucket(apiTokenHashesBucket).Delete([]byte(tr.Hash)); err != nil {
+		return err
+	}
+	if err := apiTokensOwned.remove(tx, tr.Owner, uint64(tr.ID)); err != nil {
+		return err
+	}
+	return tx.Bucket(apiTokensBucket).Delete(key(uint64(tr.ID)))
+}
+
+// CreateAPIToken creates a new personal API token.
+func (r *implementation) CreateAPIToken(_ context.Context, t *domain.APIToken) (*domain.APIToken, error) {
+	var created *domain.APIToken
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, t.OwnerEmail)
+		if err != nil {
+			return err
+		}
+
+		owned, err := apiTokensByOwner(tx, uk)
+		if err != nil {
+			return err
+		}
+		for _, tr := range owned {
+			if strings.EqualFold(tr.Name, t.Name) {
+				return domain.ErrDuplicateAPIToken
+			}
+		}
+		if tx.Bucket(apiTokenHashesBucket).Get([]byte(t.Hash)) != nil {
+			return domain.ErrDuplicateAPIToken
+		}
+
+		b := tx.Bucket(apiTokensBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		scopes := make([]string, 0, len(t.Scopes))
+		for _, s := range t.Scopes {
+			scopes = append(scopes, string(s))
+		}
+
+		tr := &apiTokenRecord{
+			int(id),
+			uk,
+			t.Name,
+			t.Hash,
+			scopes,
+			time.Now().UTC(),
+		}
+		if err := put(b, key(id), tr); err != nil {
+			return err
+		}
+		if err := tx.Bucket(apiTokenHashesBucket).Put([]byte(t.Hash), key(id)); err != nil {
+			return err
+		}
+		if err := apiTokensOwned.add(tx, uk, id); err != nil {
+			return err
+		}
+
+		created = tr.toDomain(ur.Email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (r *implementation) APITokensByOwner(_ context.Context, em string) ([]domain.APIToken, error) {
+	var results []domain.APIToken
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		owned, err := apiTokensByOwner(tx, uk)
+		if err != nil {
+			return err
+		}
+
+		results = make([]domain.APIToken, 0, len(owned))
+		for _, tr := range owned {
+			results = append(results, *tr.toDomain(ur.Email))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+	return results, nil
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (r *implementation) GetAPITokenByHash(_ context.Context, h string) (*domain.APIToken, error) {
+	var found *domain.APIToken
+	err := r.db.View(func(tx *bolt.Tx) error {
+		id, ok := lookup(tx, apiTokenHashesBucket, h)
+		if !ok {
+			return domain.ErrAPITokenNotFound
+		}
+
+		tr := new(apiTokenRecord)
+		if ok, err := get(tx.Bucket(apiTokensBucket), key(id), tr); err != nil {
+			return err
+		} else if !ok {
+			return domain.ErrAPITokenNotFound
+		}
+
+		ur, err := getUser(tx, tr.Owner)
+		if err != nil {
+			return err
+		}
+
+		found = tr.toDomain(ur.Email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (r *implementation) DeleteAPIToken(_ context.Context, em string, id int) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(em))
+		if !ok || id <= 0 || !apiTokensOwned.has(tx, uk, uint64(id)) {
+			return domain.ErrAPITokenNotFound
+		}
+
+		tr := new(apiTokenRecord)
+		if _, err := get(tx.Bucket(apiTokensBucket), key(uint64(id)), tr); err != nil {
+			return err
+		}
+
+		return deleteAPIToken(tx, tr)
+	})
+}
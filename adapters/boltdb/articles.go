@@ -0,0 +1,675 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// CreateArticle creates a new article.
+func (r *implementation) CreateArticle(_ context.Context, a *domain.Article) (*domain.AuthoredArticle, error) {
+	var created domain.AuthoredArticle
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(a.AuthorEmail))
+		if !ok {
+			return domain.ErrNoAuthor
+		}
+
+		k, err := tx.Bucket(articlesBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		a.RenderBody()
+		now := time.Now().UTC()
+		ar := &articleRecord{
+			a.ID,
+			a.Slug,
+			a.Title,
+			a.Description,
+			a.Body,
+			a.BodyHTML,
+			a.TagList,
+			now,
+			now,
+			uk,
+			a.Hidden,
+		}
+		if err := putArticle(tx, k, nil, ar); err != nil {
+			return err
+		}
+
+		created, err = authoredArticle(tx, k, ar)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (r *implementation) ImportArticle(_ context.Context, a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+	var imported *domain.CommentedArticle
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(a.AuthorEmail))
+		if !ok {
+			return domain.ErrNoAuthor
+		}
+
+		k, err := tx.Bucket(articlesBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		a.RenderBody()
+		ar := &articleRecord{
+			a.ID,
+			a.Slug,
+			a.Title,
+			a.Description,
+			a.Body,
+			a.BodyHTML,
+			a.TagList,
+			a.CreatedAtUTC.UTC(),
+			a.UpdatedAtUTC.UTC(),
+			uk,
+			a.Hidden,
+		}
+		if err := putArticle(tx, k, nil, ar); err != nil {
+			return err
+		}
+
+		b := tx.Bucket(commentsBucket)
+		for _, c := range a.Comments {
+			cuk, ok := lookup(tx, userEmailsBucket, strings.ToLower(c.AuthorEmail))
+			if !ok {
+				return domain.ErrUserNotFound
+			}
+
+			// Comment ids are unique across all articles so they don't depend on the slug
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			err = put(b, join(key(k), key(id)), &commentRecord{
+				int(id),
+				c.UID,
+				c.Body,
+				c.HTML(),
+				c.CreatedAtUTC.UTC(),
+				cuk,
+				c.Hidden,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		imported, err = getCommentsBySlug(tx, a.Slug, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return imported, nil
+}
+
+// putArticle writes the article and keeps the indexes of its id, slug, tags, update time and author up to date.
+// prev is the article as it was before or nil if it's new.
+func putArticle(tx *bolt.Tx, k uint64, prev *articleRecord, next *articleRecord) error {
+	if sk, ok := lookup(tx, articleSlugsBucket, strings.ToLower(next.Slug)); ok && sk != k {
+		return domain.ErrDuplicateArticle
+	}
+
+	if prev != nil {
+		if err := unindexArticle(tx, k, prev); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Bucket(articleIDsBucket).Put([]byte(next.ID), key(k)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(articleSlugsBucket).Put([]byte(strings.ToLower(next.Slug)), key(k)); err != nil {
+		return err
+	}
+	for _, t := range next.TagList {
+		if err := tx.Bucket(articleTagsBucket).Put(tagKey(t, k), []byte(t)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Bucket(articlesUpdatedBucket).Put(updatedKey(next.UpdatedAtUTC, k), nil); err != nil {
+		return err
+	}
+	if err := authored.add(tx, next.Author, k); err != nil {
+		return err
+	}
+
+	return put(tx.Bucket(articlesBucket), key(k), next)
+}
+
+// unindexArticle removes the article from the indexes of its id, slug, tags, update time and author.
+func unindexArticle(tx *bolt.Tx, k uint64, ar *articleRecord) error {
+	if err := tx.Bucket(articleIDsBucket).Delete([]byte(ar.ID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(articleSlugsBucket).Delete([]byte(strings.ToLower(ar.Slug))); err != nil {
+		return err
+	}
+	for _, t := range ar.TagList {
+		if err := tx.Bucket(articleTagsBucket).Delete(tagKey(t, k)); err != nil {
+			return err
+		}
+	}
+	if err := tx.Bucket(articlesUpdatedBucket).Delete(updatedKey(ar.UpdatedAtUTC, k)); err != nil {
+		return err
+	}
+	return authored.remove(tx, ar.Author, k)
+}
+
+// deleteArticle deletes the article along with its comments and everything indexing it.
+func deleteArticle(tx *bolt.Tx, k uint64) error {
+	ar, err := getArticle(tx, k)
+	if err != nil {
+		return err
+	}
+
+	if err := unindexArticle(tx, k, ar); err != nil {
+		return err
+	}
+	if err := favorites.removeTo(tx, k); err != nil {
+		return err
+	}
+	if err := deletePrefixed(tx.Bucket(commentsBucket), key(k)); err != nil {
+		return err
+	}
+
+	return tx.Bucket(articlesBucket).Delete(key(k))
+}
+
+// tagPrefix is the start of the keys in the tag index for the tag.
+func tagPrefix(t string) []byte {
+	return join([]byte(strings.ToLower(t)), []byte{0})
+}
+
+func tagKey(t string, k uint64) []byte {
+	return join(tagPrefix(t), key(k))
+}
+
+func updatedKey(u time.Time, k uint64) []byte {
+	return join(key(uint64(u.UnixNano())), key(k))
+}
+
+// getArticle reads the article with the internal key.
+func getArticle(tx *bolt.Tx, k uint64) (*articleRecord, error) {
+	ar := new(articleRecord)
+	ok, err := get(tx.Bucket(articlesBucket), key(k), ar)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrArticleNotFound
+	}
+	return ar, nil
+}
+
+// getArticleBy reads the article with the internal key found in the index bucket.
+func getArticleBy(tx *bolt.Tx, index []byte, k string) (uint64, *articleRecord, error) {
+	ak, ok := lookup(tx, index, k)
+	if !ok {
+		return 0, nil, domain.ErrArticleNotFound
+	}
+
+	ar, err := getArticle(tx, ak)
+	return ak, ar, err
+}
+
+func getArticleBySlug(tx *bolt.Tx, s string) (uint64, *articleRecord, error) {
+	return getArticleBy(tx, articleSlugsBucket, strings.ToLower(s))
+}
+
+// authoredArticle includes the author and favorite count of the article.
+func authoredArticle(tx *bolt.Tx, k uint64, ar *articleRecord) (domain.AuthoredArticle, error) {
+	ur, err := getUser(tx, ar.Author)
+	if err != nil {
+		return domain.AuthoredArticle{}, domain.ErrNoAuthor
+	}
+
+	return domain.AuthoredArticle{
+		Article: domain.Article{
+			ID:           ar.ID,
+			Slug:         ar.Slug,
+			Title:        ar.Title,
+			Description:  ar.Description,
+			Body:         ar.Body,
+			BodyHTML:     ar.BodyHTML,
+			TagList:      ar.TagList,
+			CreatedAtUTC: ar.CreatedAtUTC,
+			UpdatedAtUTC: ar.UpdatedAtUTC,
+			AuthorEmail:  ur.Email,
+			Hidden:       ar.Hidden,
+		},
+		Author:        ur.toDomain(),
+		FavoriteCount: len(favorites.sources(tx, k)),
+	}, nil
+}
+
+// LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
+// The tag, author and favorite indexes narrow down the candidates before any article is read,
+// without any of them every article is read newest first until the page is full.
+func (r *implementation) LatestArticlesByCriteria(_ context.Context, lc domain.ListCriteria) ([]domain.AuthoredArticle, error) {
+	results := make([]domain.AuthoredArticle, 0, max(lc.Limit, 0))
+	if lc.Limit < 1 {
+		return results, nil
+	}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		// candidates is nil until one of the criteria narrows it down
+		var candidates map[uint64]interface{}
+		narrow := func(ks []uint64) {
+			next := make(map[uint64]interface{}, len(ks))
+			for _, k := range ks {
+				if _, ok := candidates[k]; ok || candidates == nil {
+					next[k] = nil
+				}
+			}
+			candidates = next
+		}
+
+		if lc.Tag != "" {
+			tagged := make([]uint64, 0)
+			prefixed(tx.Bucket(articleTagsBucket), tagPrefix(lc.Tag), func(k []byte, _ []byte) error {
+				tagged = append(tagged, unkey(k[len(k)-8:]))
+				return nil
+			})
+			narrow(tagged)
+		}
+		if len(lc.AuthorEmails) > 0 {
+			written := make([]uint64, 0)
+			for _, ae := range lc.AuthorEmails {
+				if uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(ae)); ok {
+					written = append(written, authored.targets(tx, uk)...)
+				}
+			}
+			narrow(written)
+		}
+		if lc.FavoritedByUserEmail != "" {
+			faved := make([]uint64, 0)
+			if uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(lc.FavoritedByUserEmail)); ok {
+				faved = favorites.targets(tx, uk)
+			}
+			narrow(faved)
+		}
+
+		ignored := make(map[uint64]interface{})
+		if vk, ok := lookup(tx, userEmailsBucket, strings.ToLower(lc.ViewerEmail)); ok {
+			for _, k := range blocking.targets(tx, vk) {
+				ignored[k] = nil
+			}
+			for _, k := range muting.targets(tx, vk) {
+				ignored[k] = nil
+			}
+		}
+
+		off := 0
+		// visit adds the article to the page when it's visible, it returns false once the page is full
+		visit := func(k uint64, ar *articleRecord) (bool, error) {
+			if ar.Hidden && !lc.WithHidden {
+				return true, nil
+			}
+			if _, ok := ignored[ar.Author]; ok {
+				return true, nil
+			}
+			if off < lc.Offset {
+				off++
+				return true, nil
+			}
+			if len(results) >= lc.Limit {
+				return false, nil
+			}
+
+			aa, err := authoredArticle(tx, k, ar)
+			if err != nil {
+				return false, err
+			}
+			results = append(results, aa)
+			return len(results) < lc.Limit, nil
+		}
+
+		if candidates == nil {
+			c := tx.Bucket(articlesUpdatedBucket).Cursor()
+			for uk, _ := c.Last(); uk != nil; uk, _ = c.Prev() {
+				k := unkey(uk[8:])
+				ar, err := getArticle(tx, k)
+				if err != nil {
+					return err
+				}
+				if more, err := visit(k, ar); err != nil || !more {
+					return err
+				}
+			}
+			return nil
+		}
+
+		type candidate struct {
+			k  uint64
+			ar *articleRecord
+		}
+		ordered := make([]candidate, 0, len(candidates))
+		for k := range candidates {
+			ar, err := getArticle(tx, k)
+			if err != nil {
+				return err
+			}
+			ordered = append(ordered, candidate{k, ar})
+		}
+		sort.Slice(ordered, func(i, j int) bool {
+			if ordered[i].ar.UpdatedAtUTC.Equal(ordered[j].ar.UpdatedAtUTC) {
+				return ordered[i].k > ordered[j].k
+			}
+			return ordered[i].ar.UpdatedAtUTC.After(ordered[j].ar.UpdatedAtUTC)
+		})
+		for _, c := range ordered {
+			if more, err := visit(c.k, c.ar); err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *implementation) getArticleBy(index []byte, k string) (*domain.AuthoredArticle, error) {
+	var found domain.AuthoredArticle
+	err := r.db.View(func(tx *bolt.Tx) error {
+		ak, ar, err := getArticleBy(tx, index, k)
+		if err != nil {
+			return err
+		}
+
+		found, err = authoredArticle(tx, ak, ar)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &found, nil
+}
+
+// GetArticleBySlug gets a single article with the given slug.
+func (r *implementation) GetArticleBySlug(_ context.Context, s string) (*domain.AuthoredArticle, error) {
+	return r.getArticleBy(articleSlugsBucket, strings.ToLower(s))
+}
+
+// GetArticleByID gets a single article with the given id.
+func (r *implementation) GetArticleByID(_ context.Context, id string) (*domain.AuthoredArticle, error) {
+	return r.getArticleBy(articleIDsBucket, id)
+}
+
+// GetCommentsBySlug gets a single article and its comments with the given slug.
+func (r *implementation) GetCommentsBySlug(_ context.Context, s string) (*domain.CommentedArticle, error) {
+	var found *domain.CommentedArticle
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getCommentsBySlug(tx, s, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (r *implementation) GetCommentsWithHiddenBySlug(_ context.Context, s string) (*domain.CommentedArticle, error) {
+	var found *domain.CommentedArticle
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getCommentsBySlug(tx, s, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+func getCommentsBySlug(tx *bolt.Tx, s string, withHidden bool) (*domain.CommentedArticle, error) {
+	ak, ar, err := getArticleBySlug(tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	aa, err := authoredArticle(tx, ak, ar)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]domain.Comment, 0)
+	err = prefixed(tx.Bucket(commentsBucket), key(ak), func(_ []byte, v []byte) error {
+		var cr commentRecord
+		if err := json.Unmarshal(v, &cr); err != nil {
+			return err
+		}
+		if cr.Hidden && !withHidden {
+			return nil
+		}
+
+		ur, err := getUser(tx, cr.Author)
+		if err != nil {
+			return err
+		}
+		comments = append(comments, domain.Comment{
+			ID:           cr.ID,
+			UID:          cr.UID,
+			Body:         cr.Body,
+			BodyHTML:     cr.BodyHTML,
+			CreatedAtUTC: cr.CreatedAtUTC,
+			AuthorEmail:  ur.Email,
+			Hidden:       cr.Hidden,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CommentedArticle{
+		Article:  aa.Article,
+		Comments: comments,
+	}, nil
+}
+
+// UpdateArticleBySlug finds a single article based on its slug
+// then applies the provide mutations.
+func (r *implementation) UpdateArticleBySlug(_ context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (*domain.AuthoredArticle, error) {
+	var updated domain.AuthoredArticle
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		ak, prev, err := getArticleBySlug(tx, s)
+		if err != nil {
+			return err
+		}
+
+		aa, err := authoredArticle(tx, ak, prev)
+		if err != nil {
+			return err
+		}
+
+		a, err := update(&aa.Article)
+		if err != nil {
+			return err
+		}
+
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(a.AuthorEmail))
+		if !ok {
+			return domain.ErrNoAuthor
+		}
+
+		a.RenderBody()
+		next := &articleRecord{
+			// Ids never change once the article is created
+			prev.ID,
+			a.Slug,
+			a.Title,
+			a.Description,
+			a.Body,
+			a.BodyHTML,
+			a.TagList,
+			prev.CreatedAtUTC,
+			time.Now().UTC(),
+			uk,
+			a.Hidden,
+		}
+		if err := putArticle(tx, ak, prev, next); err != nil {
+			return err
+		}
+
+		updated, err = authoredArticle(tx, ak, next)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// UpdateCommentsBySlug finds a single article based on its slug
+// then applies the provide mutations to its comments.
+func (r *implementation) UpdateCommentsBySlug(_ context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (*domain.Comment, error) {
+	var created *domain.Comment
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		ak, _, err := getArticleBySlug(tx, s)
+		if err != nil {
+			return err
+		}
+
+		a, err := getCommentsBySlug(tx, s, true)
+		if err != nil {
+			return err
+		}
+
+		a, err = update(a)
+		if err != nil {
+			return err
+		}
+
+		b := tx.Bucket(commentsBucket)
+		prev := make(map[int]commentRecord)
+		err = prefixed(b, key(ak), func(_ []byte, v []byte) error {
+			var cr commentRecord
+			if err := json.Unmarshal(v, &cr); err != nil {
+				return err
+			}
+			prev[cr.ID] = cr
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := deletePrefixed(b, key(ak)); err != nil {
+			return err
+		}
+
+		for _, c := range a.Comments {
+			if c.ID > 0 {
+				// Only hiding existing comments is allowed, the rest of them stays as it was
+				cr, ok := prev[c.ID]
+				if !ok {
+					continue
+				}
+				cr.Hidden = c.Hidden
+				if err := put(b, join(key(ak), key(uint64(cr.ID))), &cr); err != nil {
+					return err
+				}
+				continue
+			}
+
+			uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(c.AuthorEmail))
+			if !ok {
+				return domain.ErrUserNotFound
+			}
+
+			// Comment ids are unique across all articles so they don't depend on the slug
+			id, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			c := c
+			c.ID = int(id)
+			c.BodyHTML = c.HTML()
+			c.CreatedAtUTC = time.Now().UTC()
+			err = put(b, join(key(ak), key(id)), &commentRecord{
+				c.ID,
+				c.UID,
+				c.Body,
+				c.BodyHTML,
+				c.CreatedAtUTC,
+				uk,
+				c.Hidden,
+			})
+			if err != nil {
+				return err
+			}
+			created = &c
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// DeleteArticle deletes the article if it exists.
+func (r *implementation) DeleteArticle(_ context.Context, a *domain.Article) error {
+	if a == nil {
+		return nil
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		ak, ok := lookup(tx, articleSlugsBucket, strings.ToLower(a.Slug))
+		if !ok {
+			return domain.ErrArticleNotFound
+		}
+
+		return deleteArticle(tx, ak)
+	})
+}
+
+// DistinctTags returns a distinct list of tags on all articles
+func (r *implementation) DistinctTags(_ context.Context) ([]string, error) {
+	tags := make([]string, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		seen := make(map[string]interface{})
+		return tx.Bucket(articleTagsBucket).ForEach(func(_ []byte, v []byte) error {
+			if _, ok := seen[string(v)]; !ok {
+				seen[string(v)] = nil
+				tags = append(tags, string(v))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,313 @@
+package boltdb_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/boltdb"
+	"github.com/brycekbargar/realworld-backend/adapters/testcases"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+var uut domain.Repository
+var dir string
+
+func TestMain(m *testing.M) {
+	var err error
+	dir, err = os.MkdirTemp("", "realworld_backend_test_")
+	if err != nil {
+		panic(err)
+	}
+
+	db := boltdb.MustNewInstance(filepath.Join(dir, "conduit.db"))
+	uut = db
+	res := m.Run()
+
+	db.Close()
+	if res != 0 {
+		// Save the test database when tests fail
+		fmt.Print(dir)
+	} else if err := os.RemoveAll(dir); err != nil {
+		fmt.Print(err)
+	}
+	os.Exit(res)
+}
+
+func Test_SnapshotRestore(t *testing.T) {
+	t.Parallel()
+
+	// Restoring replaces everything so it gets its own database
+	db := boltdb.MustNewInstance(filepath.Join(dir, "snapshot.db"))
+	defer db.Close()
+
+	ctx := context.Background()
+	u, err := domain.NewUserWithPassword("snapshot@example.com", "snapshot", "Password123!@#")
+	require.NoError(t, err)
+	_, err = db.CreateUser(ctx, u)
+	require.NoError(t, err)
+	a, err := domain.NewArticle("Snapshot Title", "description", "body", u.Email, "snapshot")
+	require.NoError(t, err)
+	_, err = db.CreateArticle(ctx, a)
+	require.NoError(t, err)
+
+	var snap bytes.Buffer
+	require.NoError(t, db.Snapshot(&snap))
+
+	require.NoError(t, db.DeleteArticle(ctx, a))
+	u2, err := domain.NewUserWithPassword("after@example.com", "after", "Password123!@#")
+	require.NoError(t, err)
+	_, err = db.CreateUser(ctx, u2)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Restore(&snap))
+
+	found, err := db.GetArticleBySlug(ctx, "snapshot-title")
+	require.NoError(t, err)
+	assert.Equal(t, u.Email, found.AuthorEmail)
+	latest, err := db.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: "snapshot", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, latest, 1)
+	_, err = db.GetUserByEmail(ctx, u2.Email)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	// Keys keep counting up from where the snapshot left off
+	_, err = db.CreateUser(ctx, u2)
+	require.NoError(t, err)
+	_, err = db.GetUserByEmail(ctx, u.Email)
+	require.NoError(t, err)
+}
+
+func Test_Reindex(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(dir, "reindex.db")
+	db := boltdb.MustNewInstance(path)
+
+	ctx := context.Background()
+	u, err := domain.NewUserWithPassword("reindex@example.com", "reindex", "Password123!@#")
+	require.NoError(t, err)
+	_, err = db.CreateUser(ctx, u)
+	require.NoError(t, err)
+	f, err := domain.NewUserWithPassword("follower@example.com", "follower", "Password123!@#")
+	require.NoError(t, err)
+	_, err = db.CreateUser(ctx, f)
+	require.NoError(t, err)
+	require.NoError(t, db.UpdateFanboyByEmail(ctx, f.Email, func(fb *domain.Fanboy) (*domain.Fanboy, error) {
+		fb.StartFollowing(u.Email)
+		return fb, nil
+	}))
+	a, err := domain.NewArticle("Reindex Title", "description", "body", u.Email, "reindex")
+	require.NoError(t, err)
+	_, err = db.CreateArticle(ctx, a)
+	require.NoError(t, err)
+	token, raw, err := domain.NewAPIToken(u.Email, "reindex", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = db.CreateAPIToken(ctx, token)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// Lose every index, as if they'd been corrupted
+	corrupt, err := bolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, corrupt.Update(func(tx *bolt.Tx) error {
+		for _, b := range []string{"user_emails", "article_slugs", "article_tags", "followers", "authored", "api_token_hashes"} {
+			if err := tx.DeleteBucket([]byte(b)); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket([]byte(b)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, corrupt.Close())
+
+	db = boltdb.MustNewInstance(path)
+	defer db.Close()
+	_, err = db.GetUserByEmail(ctx, u.Email)
+	require.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	require.NoError(t, db.Reindex(ctx))
+
+	_, err = db.GetUserByEmail(ctx, u.Email)
+	assert.NoError(t, err)
+	_, err = db.GetArticleBySlug(ctx, "reindex-title")
+	assert.NoError(t, err)
+	tagged, err := db.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: "reindex", Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, tagged, 1)
+	authored, err := db.LatestArticlesByCriteria(ctx, domain.ListCriteria{AuthorEmails: []string{u.Email}, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, authored, 1)
+	followers, err := db.FollowersByEmail(ctx, u.Email, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, followers, 1)
+	assert.Equal(t, f.Email, followers[0].Email)
+	_, err = db.GetAPITokenByHash(ctx, domain.HashAPIToken(raw))
+	assert.NoError(t, err)
+}
+
+func Test_Users(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update User", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_CreateUser(t, uut)
+	})
+	t.Run("Get and Update User By Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByEmail(t, uut)
+	})
+	t.Run("Get and Update User By Username", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByUsername(t, uut)
+	})
+	t.Run("Get User By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByID(t, uut)
+	})
+	t.Run("Username History", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_UsernameHistory(t, uut)
+	})
+	t.Run("Fanboy Following Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Following(t, uut)
+	})
+	t.Run("Fanboy Favoriting Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Favorites(t, uut)
+	})
+	t.Run("Listing Followers", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_FollowersByEmail(t, uut)
+	})
+	t.Run("Listing Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ListUsers(t, uut)
+	})
+	t.Run("Fanboy Blocking and Muting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Blocking(t, uut)
+	})
+	t.Run("Changing An Email Inside Another", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_EmailInsideAnother(t, uut)
+	})
+	t.Run("Roles and Bans", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Roles(t, uut)
+	})
+	t.Run("Verifying Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Verified(t, uut)
+	})
+	t.Run("Two-Factor Authentication", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_TwoFactor(t, uut)
+	})
+	t.Run("Deleting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_DeleteUser_Anonymize(t, uut)
+		testcases.Users_DeleteUser_Delete(t, uut)
+	})
+	t.Run("Exporting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ExportUserByEmail(t, uut)
+	})
+	t.Run("Linking External Identities", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_LinkIdentity(t, uut)
+	})
+}
+
+func Test_Articles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_CreateArticle(t, uut)
+	})
+	t.Run("Get and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleBySlug(t, uut)
+	})
+	t.Run("Get Article By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleByID(t, uut)
+	})
+	t.Run("Delete Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DeleteArticle(t, uut)
+	})
+	t.Run("Hide Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateArticleBySlug_Hidden(t, uut)
+	})
+	t.Run("Hidden Content", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_HiddenContent(t, uut)
+	})
+	t.Run("Query Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria(t, uut)
+	})
+	t.Run("Rendered Article Bodies", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_BodyHTML(t, uut)
+	})
+	t.Run("Query Articles As Viewer", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria_Viewer(t, uut)
+	})
+	t.Run("Create and Delete Comments", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateCommentsBySlug(t, uut)
+	})
+	t.Run("Import Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_ImportArticle(t, uut)
+	})
+	t.Run("Query Tags", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DistinctTags(t, uut)
+	})
+}
+
+func Test_Reports(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Resolve Report", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateReport(t, uut)
+	})
+	t.Run("Audit Trail", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateAuditEntry(t, uut)
+	})
+}
+
+func Test_ReadingLists(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Reading Lists", func(t *testing.T) {
+		t.Parallel()
+		testcases.ReadingLists_CreateReadingList(t, uut)
+	})
+}
+
+func Test_APITokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Revoke API Tokens", func(t *testing.T) {
+		t.Parallel()
+		testcases.APITokens_CreateAPIToken(t, uut)
+	})
+}
@@ -0,0 +1,336 @@
+// Package boltdb is a file-backed implementation of the adapters layer on an embedded bbolt key-value store.
+//
+// Users and articles are keyed by an internal sequence number that never changes (or gets reused),
+// everything else refers to them by that key so emails, usernames and slugs can change without rekeying.
+// Lookups by anything other than the key go through secondary index buckets kept in the same transaction.
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// MustNewInstance creates a new instance of the bbolt store with the repository interface implementations. Panics on error.
+// The database is created at path if it doesn't exist.
+func MustNewInstance(path string) Instance {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &implementation{db}
+}
+
+// Instance is the repository along with point-in-time snapshots of everything in it.
+type Instance interface {
+	domain.Repository
+	// Snapshot writes a consistent copy of everything in the store as it is now.
+	Snapshot(io.Writer) error
+	// Restore replaces everything in the store with a snapshot.
+	Restore(io.Reader) error
+	// Reindex rebuilds every secondary index from the records they index.
+	Reindex(context.Context) error
+	// Close releases the database file.
+	Close() error
+}
+
+type implementation struct {
+	db *bolt.DB
+}
+
+// Close releases the database file.
+func (r *implementation) Close() error {
+	return r.db.Close()
+}
+
+// Ping checks the database is still open and has all of its buckets
+// (which is all there is to migrate in bolt, they're created when it's opened).
+func (r *implementation) Ping(context.Context) error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		for _, b := range buckets {
+			if tx.Bucket(b) == nil {
+				return fmt.Errorf("%w: bucket %s is missing", domain.ErrMigrationsPending, b)
+			}
+		}
+		return nil
+	})
+}
+
+var (
+	// users are userRecords keyed by their internal key.
+	usersBucket = []byte("users")
+	// userIDs, userEmails and usernames are the internal keys of users by their id, lowercased email and lowercased username.
+	userIDsBucket    = []byte("user_ids")
+	userEmailsBucket = []byte("user_emails")
+	usernamesBucket  = []byte("usernames")
+	// previousUsernames are previousUsernameRecords keyed by the lowercased username.
+	previousUsernamesBucket = []byte("previous_usernames")
+	// identities are the internal keys of users by the provider and subject of their linked external identities.
+	identitiesBucket = []byte("identities")
+
+	// articles are articleRecords keyed by their internal key.
+	articlesBucket = []byte("articles")
+	// articleIDs and articleSlugs are the internal keys of articles by their id and lowercased slug.
+	articleIDsBucket   = []byte("article_ids")
+	articleSlugsBucket = []byte("article_slugs")
+	// articleTags index articles by their lowercased tag followed by the article key, the value is the tag as it was written.
+	articleTagsBucket = []byte("article_tags")
+	// articlesUpdated index articles by when they were last updated followed by the article key, so they can be read newest first.
+	articlesUpdatedBucket = []byte("articles_updated")
+	// comments are commentRecords keyed by their article key followed by their id.
+	commentsBucket = []byte("comments")
+
+	readingListsBucket = []byte("reading_lists")
+	reportsBucket      = []byte("reports")
+	auditBucket        = []byte("audit")
+	apiTokensBucket    = []byte("api_tokens")
+	// apiTokenHashes are the ids of API tokens by their hash.
+	apiTokenHashesBucket = []byte("api_token_hashes")
+
+	buckets = [][]byte{
+		usersBucket,
+		userIDsBucket,
+		userEmailsBucket,
+		usernamesBucket,
+		previousUsernamesBucket,
+		identitiesBucket,
+		articlesBucket,
+		articleIDsBucket,
+		articleSlugsBucket,
+		articleTagsBucket,
+		articlesUpdatedBucket,
+		commentsBucket,
+		readingListsBucket,
+		reportsBucket,
+		auditBucket,
+		apiTokensBucket,
+		apiTokenHashesBucket,
+	}
+)
+
+func init() {
+	for _, r := range []relation{following, blocking, muting, favorites, authored, apiTokensOwned} {
+		buckets = append(buckets, r.forward, r.reverse)
+	}
+}
+
+type userRecord struct {
+	ID              string    `json:"id"`
+	Email           string    `json:"email"`
+	Username        string    `json:"username"`
+	Bio             string    `json:"bio"`
+	Image           string    `json:"image"`
+	Password        []byte    `json:"password"`
+	Role            string    `json:"role"`
+	Banned          bool      `json:"banned"`
+	Verified        bool      `json:"verified"`
+	TOTPSecret      string    `json:"totpSecret"`
+	TOTPEnabled     bool      `json:"totpEnabled"`
+	RecoveryCodes   []string  `json:"recoveryCodes"`
+	TOTPLastCounter int64     `json:"totpLastCounter"`
+	TOTPFailures    int       `json:"totpFailures"`
+	TOTPLockedUntil time.Time `json:"totpLockedUntil"`
+}
+
+func newUserRecord(u *domain.User) *userRecord {
+	return &userRecord{
+		u.ID,
+		u.Email,
+		u.Username,
+		u.Bio,
+		u.Image,
+		u.Password,
+		string(u.Role),
+		u.Banned,
+		u.Verified,
+		u.TwoFactor.Secret,
+		u.TwoFactor.Enabled,
+		u.TwoFactor.RecoveryCodes,
+		u.TwoFactor.LastCounter,
+		u.TwoFactor.FailedAttempts,
+		u.TwoFactor.LockedUntilUTC,
+	}
+}
+
+func (ur *userRecord) toDomain() *domain.User {
+	return &domain.User{
+		ID:       ur.ID,
+		Email:    ur.Email,
+		Username: ur.Username,
+		Bio:      ur.Bio,
+		Image:    ur.Image,
+		Password: ur.Password,
+		Role:     domain.Role(ur.Role),
+		Banned:   ur.Banned,
+		Verified: ur.Verified,
+		TwoFactor: domain.TwoFactor{
+			Secret:         ur.TOTPSecret,
+			Enabled:        ur.TOTPEnabled,
+			RecoveryCodes:  ur.RecoveryCodes,
+			LastCounter:    ur.TOTPLastCounter,
+			FailedAttempts: ur.TOTPFailures,
+			LockedUntilUTC: ur.TOTPLockedUntil,
+		},
+	}
+}
+
+type previousUsernameRecord struct {
+	Username     string    `json:"username"`
+	User         uint64    `json:"user"`
+	ChangedAtUTC time.Time `json:"changedAtUTC"`
+}
+
+type articleRecord struct {
+	ID           string    `json:"id"`
+	Slug         string    `json:"slug"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Body         string    `json:"body"`
+	BodyHTML     string    `json:"bodyHTML"`
+	TagList      []string  `json:"tagList"`
+	CreatedAtUTC time.Time `json:"createdAtUTC"`
+	UpdatedAtUTC time.Time `json:"updatedAtUTC"`
+	Author       uint64    `json:"author"`
+	Hidden       bool      `json:"hidden"`
+}
+
+type commentRecord struct {
+	ID           int       `json:"id"`
+	UID          string    `json:"uid"`
+	Body         string    `json:"body"`
+	BodyHTML     string    `json:"bodyHTML"`
+	CreatedAtUTC time.Time `json:"createdAtUTC"`
+	Author       uint64    `json:"author"`
+	Hidden       bool      `json:"hidden"`
+}
+
+type readingListRecord struct {
+	Slug         string    `json:"slug"`
+	Name         string    `json:"name"`
+	Articles     []uint64  `json:"articles"`
+	CreatedAtUTC time.Time `json:"createdAtUTC"`
+	UpdatedAtUTC time.Time `json:"updatedAtUTC"`
+}
+
+type reportRecord struct {
+	ID        int    `json:"id"`
+	Reporter  uint64 `json:"reporter"`
+	Article   uint64 `json:"article"`
+	Slug      string `json:"slug"`
+	CommentID int    `json:"commentId"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	// Resolver is 0 until the report is resolved.
+	Resolver      uint64    `json:"resolver"`
+	Resolution    string    `json:"resolution"`
+	CreatedAtUTC  time.Time `json:"createdAtUTC"`
+	ResolvedAtUTC time.Time `json:"resolvedAtUTC"`
+}
+
+type auditRecord struct {
+	ID           int       `json:"id"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	Slug         string    `json:"slug"`
+	CommentID    int       `json:"commentId"`
+	User         string    `json:"user"`
+	ReportID     int       `json:"reportId"`
+	Note         string    `json:"note"`
+	CreatedAtUTC time.Time `json:"createdAtUTC"`
+}
+
+type apiTokenRecord struct {
+	ID           int       `json:"id"`
+	Owner        uint64    `json:"owner"`
+	Name         string    `json:"name"`
+	Hash         string    `json:"hash"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAtUTC time.Time `json:"createdAtUTC"`
+}
+
+// key encodes an internal key or id so keys sort in numeric order.
+func key(k uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, k)
+	return b
+}
+
+// unkey decodes the internal key or id at the start of b.
+func unkey(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b[:8])
+}
+
+// join concatenates the parts of a composite key.
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// get reads the json record at k into v, it returns false if there isn't one.
+func get(b *bolt.Bucket, k []byte, v interface{}) (bool, error) {
+	raw := b.Get(k)
+	if raw == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+// put writes v as a json record at k.
+func put(b *bolt.Bucket, k []byte, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, raw)
+}
+
+// prefixed calls fn for every key in the bucket starting with the prefix, in order.
+// The bucket must not be changed by fn.
+func prefixed(b *bolt.Bucket, prefix []byte, fn func(k []byte, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePrefixed deletes every key in the bucket starting with the prefix.
+func deletePrefixed(b *bolt.Bucket, prefix []byte) error {
+	var ks [][]byte
+	err := prefixed(b, prefix, func(k []byte, _ []byte) error {
+		ks = append(ks, append([]byte{}, k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range ks {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// readingListKey is the owner's internal key followed by the lowercased slug.
+func readingListKey(uk uint64, s string) []byte {
+	return join(key(uk), []byte(strings.ToLower(s)))
+}
+
+// readingListArticles finds the internal keys of the articles with the slugs, skipping any that don't exist.
+func readingListArticles(tx *bolt.Tx, slugs []string) []uint64 {
+	ks := make([]uint64, 0, len(slugs))
+	for _, s := range slugs {
+		if k, ok := lookup(tx, articleSlugsBucket, strings.ToLower(s)); ok {
+			ks = append(ks, k)
+		}
+	}
+	return ks
+}
+
+func (lr *readingListRecord) toDomain(tx *bolt.Tx, em string) *domain.ReadingList {
+	slugs := make([]string, 0, len(lr.Articles))
+	for _, k := range lr.Articles {
+		// Articles are left in the list when they're deleted
+		if ar, err := getArticle(tx, k); err == nil {
+			slugs = append(slugs, ar.Slug)
+		}
+	}
+
+	return &domain.ReadingList{
+		OwnerEmail:   em,
+		Slug:         lr.Slug,
+		Name:         lr.Name,
+		ArticleSlugs: slugs,
+		CreatedAtUTC: lr.CreatedAtUTC,
+		UpdatedAtUTC: lr.UpdatedAtUTC,
+	}
+}
+
+// CreateReadingList creates a new reading list.
+func (r *implementation) CreateReadingList(_ context.Context, l *domain.ReadingList) (*domain.ReadingList, error) {
+	var created *domain.ReadingList
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, l.OwnerEmail)
+		if err != nil {
+			return err
+		}
+
+		b := tx.Bucket(readingListsBucket)
+		lk := readingListKey(uk, l.Slug)
+		if b.Get(lk) != nil {
+			return domain.ErrDuplicateReadingList
+		}
+
+		now := time.Now().UTC()
+		lr := &readingListRecord{
+			l.Slug,
+			l.Name,
+			readingListArticles(tx, l.ArticleSlugs),
+			now,
+			now,
+		}
+		if err := put(b, lk, lr); err != nil {
+			return err
+		}
+
+		created = lr.toDomain(tx, ur.Email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+func (r *implementation) ReadingListsByOwner(_ context.Context, em string) ([]domain.ReadingList, error) {
+	var results []domain.ReadingList
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		results, err = readingListsByOwner(tx, uk, ur.Email)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func readingListsByOwner(tx *bolt.Tx, uk uint64, em string) ([]domain.ReadingList, error) {
+	results := make([]domain.ReadingList, 0)
+	err := prefixed(tx.Bucket(readingListsBucket), key(uk), func(_ []byte, v []byte) error {
+		var lr readingListRecord
+		if err := json.Unmarshal(v, &lr); err != nil {
+			return err
+		}
+		results = append(results, *lr.toDomain(tx, em))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+	return results, nil
+}
+
+// getReadingList reads the reading list with the given owner email and slug.
+func getReadingList(tx *bolt.Tx, em string, s string) (uint64, *readingListRecord, error) {
+	uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(em))
+	if !ok {
+		return 0, nil, domain.ErrReadingListNotFound
+	}
+
+	lr := new(readingListRecord)
+	ok, err := get(tx.Bucket(readingListsBucket), readingListKey(uk, s), lr)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !ok {
+		return 0, nil, domain.ErrReadingListNotFound
+	}
+
+	return uk, lr, nil
+}
+
+// GetReadingList gets a single reading list with the given owner email and slug.
+func (r *implementation) GetReadingList(_ context.Context, em string, s string) (*domain.ReadingList, error) {
+	var found *domain.ReadingList
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, lr, err := getReadingList(tx, em, s)
+		if err != nil {
+			return err
+		}
+
+		ur, err := getUser(tx, uk)
+		if err != nil {
+			return err
+		}
+
+		found = lr.toDomain(tx, ur.Email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+// in the order of the list. Hidden articles are not included.
+func (r *implementation) ReadingListArticles(_ context.Context, em string, s string) ([]domain.AuthoredArticle, error) {
+	var results []domain.AuthoredArticle
+	err := r.db.View(func(tx *bolt.Tx) error {
+		_, lr, err := getReadingList(tx, em, s)
+		if err != nil {
+			return err
+		}
+
+		results = make([]domain.AuthoredArticle, 0, len(lr.Articles))
+		for _, k := range lr.Articles {
+			ar, err := getArticle(tx, k)
+			if err == domain.ErrArticleNotFound || (err == nil && ar.Hidden) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			aa, err := authoredArticle(tx, k, ar)
+			if err != nil {
+				return err
+			}
+			results = append(results, aa)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpdateReadingList finds a single reading list based on its owner email and slug,
+// then applies the provide mutations.
+func (r *implementation) UpdateReadingList(_ context.Context, em string, s string, update func(*domain.ReadingList) (*domain.ReadingList, error)) (*domain.ReadingList, error) {
+	var updated *domain.ReadingList
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, prev, err := getReadingList(tx, em, s)
+		if err != nil {
+			return err
+		}
+
+		ur, err := getUser(tx, uk)
+		if err != nil {
+			return err
+		}
+
+		l, err := update(prev.toDomain(tx, ur.Email))
+		if err != nil {
+			return err
+		}
+
+		b := tx.Bucket(readingListsBucket)
+		lk := readingListKey(uk, l.Slug)
+		if !strings.EqualFold(l.Slug, prev.Slug) {
+			if b.Get(lk) != nil {
+				return domain.ErrDuplicateReadingList
+			}
+			if err := b.Delete(readingListKey(uk, prev.Slug)); err != nil {
+				return err
+			}
+		}
+
+		next := &readingListRecord{
+			l.Slug,
+			l.Name,
+			readingListArticles(tx, l.ArticleSlugs),
+			prev.CreatedAtUTC,
+			time.Now().UTC(),
+		}
+		if err := put(b, lk, next); err != nil {
+			return err
+		}
+
+		updated = next.toDomain(tx, ur.Email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// DeleteReadingList deletes the reading list if it exists.
+func (r *implementation) DeleteReadingList(_ context.Context, l *domain.ReadingList) error {
+	if l == nil {
+		return nil
+	}
+
+	return r.db.Update(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(l.OwnerEmail))
+		if !ok {
+			return nil
+		}
+
+		return tx.Bucket(readingListsBucket).Delete(readingListKey(uk, l.Slug))
+	})
+}
@@ -0,0 +1,99 @@
+package boltdb
+
+import (
+	"context"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Reindex rebuilds every secondary index from the users, articles, API tokens and relations they index.
+// Everything is rebuilt in a single transaction so readers never see a half built index.
+func (r *implementation) Reindex(context.Context) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		rebuilt := [][]byte{
+			userIDsBucket,
+			userEmailsBucket,
+			usernamesBucket,
+			articleIDsBucket,
+			articleSlugsBucket,
+			articleTagsBucket,
+			articlesUpdatedBucket,
+			apiTokenHashesBucket,
+			// Both directions of these are derived from the author and owner on the records
+			authored.forward,
+			authored.reverse,
+			apiTokensOwned.forward,
+			apiTokensOwned.reverse,
+		}
+		for _, rel := range []relation{following, blocking, muting, favorites} {
+			// The forward direction is what the users chose, the reverse is derived from it
+			rebuilt = append(rebuilt, rel.reverse)
+		}
+		for _, b := range rebuilt {
+			if err := tx.DeleteBucket(b); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(b); err != nil {
+				return err
+			}
+		}
+
+		for _, rel := range []relation{following, blocking, muting, favorites} {
+			reverse := tx.Bucket(rel.reverse)
+			err := tx.Bucket(rel.forward).ForEach(func(k []byte, _ []byte) error {
+				return reverse.Put(join(k[8:], k[:8]), nil)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Buckets can't be changed while they're being read so the records are read first
+		users := make(map[uint64]*userRecord)
+		err := tx.Bucket(usersBucket).ForEach(func(k []byte, _ []byte) error {
+			ur := new(userRecord)
+			if _, err := get(tx.Bucket(usersBucket), k, ur); err != nil {
+				return err
+			}
+			users[unkey(k)] = ur
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for k, ur := range users {
+			if err := putUser(tx, k, nil, ur); err != nil {
+				return err
+			}
+		}
+
+		articles := make(map[uint64]*articleRecord)
+		err = tx.Bucket(articlesBucket).ForEach(func(k []byte, _ []byte) error {
+			ar := new(articleRecord)
+			if _, err := get(tx.Bucket(articlesBucket), k, ar); err != nil {
+				return err
+			}
+			articles[unkey(k)] = ar
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for k, ar := range articles {
+			if err := putArticle(tx, k, nil, ar); err != nil {
+				return err
+			}
+		}
+
+		return tx.Bucket(apiTokensBucket).ForEach(func(k []byte, _ []byte) error {
+			tr := new(apiTokenRecord)
+			if _, err := get(tx.Bucket(apiTokensBucket), k, tr); err != nil {
+				return err
+			}
+			if err := tx.Bucket(apiTokenHashesBucket).Put([]byte(tr.Hash), k); err != nil {
+				return err
+			}
+			return apiTokensOwned.add(tx, tr.Owner, unkey(k))
+		})
+	})
+}
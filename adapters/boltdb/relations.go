@@ -0,0 +1,108 @@
+package boltdb
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// relation links internal keys to other internal keys.
+// It is indexed in both directions so it can be listed, counted and removed from either end.
+type relation struct {
+	// forward keys are the from key followed by the to key.
+	forward []byte
+	// reverse keys are the to key followed by the from key.
+	reverse []byte
+}
+
+var (
+	// following links followers to the users they follow.
+	following = relation{[]byte("following"), []byte("followers")}
+	// blocking links users to the users they've blocked.
+	blocking = relation{[]byte("blocking"), []byte("blocked_by")}
+	// muting links users to the users they've muted.
+	muting = relation{[]byte("muting"), []byte("muted_by")}
+	// favorites links users to the articles they favorited.
+	favorites = relation{[]byte("favorites"), []byte("favorited_by")}
+	// authored links users to the articles they wrote, the reverse is the author on the articleRecord.
+	authored = relation{[]byte("authored"), []byte("authored_by")}
+	// apiTokensOwned links users to the ids of their API tokens.
+	apiTokensOwned = relation{[]byte("api_tokens_owned"), []byte("api_tokens_owner")}
+)
+
+func (rel relation) add(tx *bolt.Tx, from uint64, to uint64) error {
+	if err := tx.Bucket(rel.forward).Put(join(key(from), key(to)), nil); err != nil {
+		return err
+	}
+	return tx.Bucket(rel.reverse).Put(join(key(to), key(from)), nil)
+}
+
+func (rel relation) remove(tx *bolt.Tx, from uint64, to uint64) error {
+	if err := tx.Bucket(rel.forward).Delete(join(key(from), key(to))); err != nil {
+		return err
+	}
+	return tx.Bucket(rel.reverse).Delete(join(key(to), key(from)))
+}
+
+// targets lists the keys linked from the key, in key order.
+func (rel relation) targets(tx *bolt.Tx, from uint64) []uint64 {
+	return linked(tx.Bucket(rel.forward), from)
+}
+
+// sources lists the keys linking to the key, in key order.
+func (rel relation) sources(tx *bolt.Tx, to uint64) []uint64 {
+	return linked(tx.Bucket(rel.reverse), to)
+}
+
+func (rel relation) has(tx *bolt.Tx, from uint64, to uint64) bool {
+	return tx.Bucket(rel.forward).Get(join(key(from), key(to))) != nil
+}
+
+// replace makes the keys linked from the key exactly the given keys.
+func (rel relation) replace(tx *bolt.Tx, from uint64, to []uint64) error {
+	if err := rel.removeFrom(tx, from); err != nil {
+		return err
+	}
+	for _, k := range to {
+		if err := rel.add(tx, from, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeFrom removes every link from the key.
+func (rel relation) removeFrom(tx *bolt.Tx, from uint64) error {
+	for _, to := range rel.targets(tx, from) {
+		if err := rel.remove(tx, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeTo removes every link to the key.
+func (rel relation) removeTo(tx *bolt.Tx, to uint64) error {
+	for _, from := range rel.sources(tx, to) {
+		if err := rel.remove(tx, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeAll removes every link from or to the key, for relations between users.
+func (rel relation) removeAll(tx *bolt.Tx, k uint64) error {
+	if err := rel.removeFrom(tx, k); err != nil {
+		return err
+	}
+	return rel.removeTo(tx, k)
+}
+
+func linked(b *bolt.Bucket, k uint64) []uint64 {
+	ks := make([]uint64, 0)
+	c := b.Cursor()
+	prefix := key(k)
+	for ck, _ := c.Seek(prefix); ck != nil && len(ck) == 16 && unkey(ck) == k; ck, _ = c.Next() {
+		ks = append(ks, unkey(ck[8:]))
+	}
+	return ks
+}
@@ -0,0 +1,248 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+func (rr *reportRecord) toDomain(tx *bolt.Tx) *domain.Report {
+	rep := &domain.Report{
+		ID: rr.ID,
+		// The slug from when it was reported is kept in case the article is deleted
+		ArticleSlug:   rr.Slug,
+		CommentID:     rr.CommentID,
+		Reason:        rr.Reason,
+		Status:        domain.ReportStatus(rr.Status),
+		CreatedAtUTC:  rr.CreatedAtUTC,
+		Resolution:    domain.ModerationAction(rr.Resolution),
+		ResolvedAtUTC: rr.ResolvedAtUTC,
+	}
+	if ur, err := getUser(tx, rr.Reporter); err == nil {
+		rep.ReporterEmail = ur.Email
+	}
+	if ar, err := getArticle(tx, rr.Article); err == nil {
+		rep.ArticleSlug = ar.Slug
+	}
+	if ur, err := getUser(tx, rr.Resolver); err == nil {
+		rep.ResolverEmail = ur.Email
+	}
+	return rep
+}
+
+// CreateReport creates a new report.
+func (r *implementation) CreateReport(_ context.Context, rep *domain.Report) (*domain.Report, error) {
+	var created *domain.Report
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		ak, ar, err := getArticleBySlug(tx, rep.ArticleSlug)
+		if err != nil {
+			return err
+		}
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(rep.ReporterEmail))
+		if !ok {
+			return domain.ErrArticleNotFound
+		}
+
+		b := tx.Bucket(reportsBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		rr := &reportRecord{
+			ID:           int(id),
+			Reporter:     uk,
+			Article:      ak,
+			Slug:         ar.Slug,
+			CommentID:    rep.CommentID,
+			Reason:       rep.Reason,
+			Status:       string(domain.ReportOpen),
+			CreatedAtUTC: time.Now().UTC(),
+		}
+		if err := put(b, key(id), rr); err != nil {
+			return err
+		}
+
+		created = rr.toDomain(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func getReport(tx *bolt.Tx, id int) (*reportRecord, error) {
+	rr := new(reportRecord)
+	ok, err := get(tx.Bucket(reportsBucket), key(uint64(id)), rr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || id <= 0 {
+		return nil, domain.ErrReportNotFound
+	}
+	return rr, nil
+}
+
+// GetReportByID gets a single report with the given id.
+func (r *implementation) GetReportByID(_ context.Context, id int) (*domain.Report, error) {
+	var found *domain.Report
+	err := r.db.View(func(tx *bolt.Tx) error {
+		rr, err := getReport(tx, id)
+		if err != nil {
+			return err
+		}
+
+		found = rr.toDomain(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (r *implementation) ReportsByStatus(_ context.Context, s domain.ReportStatus, limit int, offset int) ([]domain.Report, error) {
+	found := []domain.Report{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		off := 0
+		c := tx.Bucket(reportsBucket).Cursor()
+		for k, v := c.First(); k != nil && len(found) < limit; k, v = c.Next() {
+			var rr reportRecord
+			if err := json.Unmarshal(v, &rr); err != nil {
+				return err
+			}
+			if rr.Status != string(s) {
+				continue
+			}
+			if off < offset {
+				off++
+				continue
+			}
+			found = append(found, *rr.toDomain(tx))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (r *implementation) UpdateReportByID(_ context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (*domain.Report, error) {
+	var updated *domain.Report
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		rr, err := getReport(tx, id)
+		if err != nil {
+			return err
+		}
+
+		rep, err := update(rr.toDomain(tx))
+		if err != nil {
+			return err
+		}
+
+		rr.Status = string(rep.Status)
+		rr.Resolution = string(rep.Resolution)
+		rr.ResolvedAtUTC = rep.ResolvedAtUTC
+		rr.Resolver, _ = lookup(tx, userEmailsBucket, strings.ToLower(rep.ResolverEmail))
+		if err := put(tx.Bucket(reportsBucket), key(uint64(id)), rr); err != nil {
+			return err
+		}
+
+		updated = rr.toDomain(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (ar *auditRecord) toDomain() *domain.AuditEntry {
+	return &domain.AuditEntry{
+		ID:           ar.ID,
+		ActorEmail:   ar.Actor,
+		Action:       domain.ModerationAction(ar.Action),
+		ArticleSlug:  ar.Slug,
+		CommentID:    ar.CommentID,
+		UserEmail:    ar.User,
+		ReportID:     ar.ReportID,
+		Note:         ar.Note,
+		CreatedAtUTC: ar.CreatedAtUTC,
+	}
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (r *implementation) CreateAuditEntry(_ context.Context, e *domain.AuditEntry) (*domain.AuditEntry, error) {
+	var created *domain.AuditEntry
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(auditBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		ar := &auditRecord{
+			int(id),
+			e.ActorEmail,
+			string(e.Action),
+			e.ArticleSlug,
+			e.CommentID,
+			e.UserEmail,
+			e.ReportID,
+			e.Note,
+			time.Now().UTC(),
+		}
+		if err := put(b, key(id), ar); err != nil {
+			return err
+		}
+
+		created = ar.toDomain()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (r *implementation) LatestAuditEntries(_ context.Context, limit int, offset int) ([]domain.AuditEntry, error) {
+	found := []domain.AuditEntry{}
+	err := r.db.View(func(tx *bolt.Tx) error {
+		off := 0
+		c := tx.Bucket(auditBucket).Cursor()
+		for k, v := c.Last(); k != nil && len(found) < limit; k, v = c.Prev() {
+			if off < offset {
+				off++
+				continue
+			}
+
+			var ar auditRecord
+			if err := json.Unmarshal(v, &ar); err != nil {
+				return err
+			}
+			found = append(found, *ar.toDomain())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
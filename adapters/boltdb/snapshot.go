@@ -0,0 +1,98 @@
+package boltdb
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Snapshot writes a consistent copy of everything in the store as it is now.
+// Writes can keep happening while the snapshot is taken, they just aren't included in it.
+func (r *implementation) Snapshot(w io.Writer) error {
+	return r.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(w)
+		return err
+	})
+}
+
+// Restore replaces everything in the store with a snapshot.
+// Everything is replaced in a single transaction so readers see either all of the old data or all of the snapshot.
+func (r *implementation) Restore(rd io.Reader) error {
+	// Snapshots are whole bolt databases which can only be opened from a file
+	f, err := os.CreateTemp(filepath.Dir(r.db.Path()), "restore_*.db")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, rd); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	snap, err := bolt.Open(f.Name(), 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+
+	return snap.View(func(from *bolt.Tx) error {
+		return r.db.Update(func(to *bolt.Tx) error {
+			var existing [][]byte
+			err := to.ForEach(func(name []byte, _ *bolt.Bucket) error {
+				existing = append(existing, append([]byte{}, name...))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, name := range existing {
+				if err := to.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+
+			err = from.ForEach(func(name []byte, b *bolt.Bucket) error {
+				copied, err := to.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(b, copied)
+			})
+			if err != nil {
+				return err
+			}
+
+			// Snapshots from before a bucket was added won't have it
+			for _, b := range buckets {
+				if _, err := to.CreateBucketIfNotExists(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// copyBucket copies every key, nested bucket and the sequence from one bucket to another.
+func copyBucket(from *bolt.Bucket, to *bolt.Bucket) error {
+	if err := to.SetSequence(from.Sequence()); err != nil {
+		return err
+	}
+
+	return from.ForEach(func(k []byte, v []byte) error {
+		if nested := from.Bucket(k); nested != nil {
+			copied, err := to.CreateBucket(k)
+			if err != nil {
+				return err
+			}
+			return copyBucket(nested, copied)
+		}
+		return to.Put(k, v)
+	})
+}
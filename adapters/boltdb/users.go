@@ -0,0 +1,459 @@
+package boltdb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// CreateUser creates a new user.
+func (r *implementation) CreateUser(_ context.Context, u *domain.User) (*domain.User, error) {
+	var created *domain.User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		k, err := tx.Bucket(usersBucket).NextSequence()
+		if err != nil {
+			return err
+		}
+
+		ur := newUserRecord(u)
+		if err := putUser(tx, k, nil, ur); err != nil {
+			return err
+		}
+
+		created = ur.toDomain()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// putUser writes the user and keeps the indexes of their id, email and username up to date.
+// prev is the user as it was before or nil if they're new.
+func putUser(tx *bolt.Tx, k uint64, prev *userRecord, next *userRecord) error {
+	em := []byte(strings.ToLower(next.Email))
+	un := []byte(strings.ToLower(next.Username))
+
+	if ek := tx.Bucket(userEmailsBucket).Get(em); ek != nil && unkey(ek) != k {
+		return domain.ErrDuplicateUser
+	}
+	if uk := tx.Bucket(usernamesBucket).Get(un); uk != nil && unkey(uk) != k {
+		return domain.ErrDuplicateUser
+	}
+	var pu previousUsernameRecord
+	if ok, err := get(tx.Bucket(previousUsernamesBucket), un, &pu); err != nil {
+		return err
+	} else if ok && pu.User != k {
+		return domain.ErrDuplicateUser
+	}
+
+	if prev != nil {
+		if err := tx.Bucket(userIDsBucket).Delete([]byte(prev.ID)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(userEmailsBucket).Delete([]byte(strings.ToLower(prev.Email))); err != nil {
+			return err
+		}
+		if err := tx.Bucket(usernamesBucket).Delete([]byte(strings.ToLower(prev.Username))); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Bucket(userIDsBucket).Put([]byte(next.ID), key(k)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(userEmailsBucket).Put(em, key(k)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(usernamesBucket).Put(un, key(k)); err != nil {
+		return err
+	}
+
+	return put(tx.Bucket(usersBucket), key(k), next)
+}
+
+// lookup finds the internal key in the index bucket.
+func lookup(tx *bolt.Tx, index []byte, k string) (uint64, bool) {
+	found := tx.Bucket(index).Get([]byte(k))
+	if found == nil {
+		return 0, false
+	}
+	return unkey(found), true
+}
+
+// getUser reads the user with the internal key.
+func getUser(tx *bolt.Tx, k uint64) (*userRecord, error) {
+	ur := new(userRecord)
+	ok, err := get(tx.Bucket(usersBucket), key(k), ur)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return ur, nil
+}
+
+// getUserBy reads the user with the internal key found in the index bucket.
+func getUserBy(tx *bolt.Tx, index []byte, k string) (uint64, *userRecord, error) {
+	uk, ok := lookup(tx, index, k)
+	if !ok {
+		return 0, nil, domain.ErrUserNotFound
+	}
+
+	ur, err := getUser(tx, uk)
+	return uk, ur, err
+}
+
+func getUserByEmail(tx *bolt.Tx, em string) (uint64, *userRecord, error) {
+	return getUserBy(tx, userEmailsBucket, strings.ToLower(em))
+}
+
+// GetUserByEmail finds a single user based on their email address.
+func (r *implementation) GetUserByEmail(_ context.Context, em string) (*domain.Fanboy, error) {
+	var found *domain.Fanboy
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		found = fanboy(tx, uk, ur)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// fanboy reads the users and articles the user is related to.
+func fanboy(tx *bolt.Tx, uk uint64, ur *userRecord) *domain.Fanboy {
+	emails := func(ks []uint64) map[string]interface{} {
+		found := make(map[string]interface{}, len(ks))
+		for _, k := range ks {
+			if u, err := getUser(tx, k); err == nil {
+				found[strings.ToLower(u.Email)] = nil
+			}
+		}
+		return found
+	}
+
+	slugs := make(map[string]interface{})
+	for _, k := range favorites.targets(tx, uk) {
+		if a, err := getArticle(tx, k); err == nil {
+			slugs[strings.ToLower(a.Slug)] = nil
+		}
+	}
+
+	return &domain.Fanboy{
+		User:      *ur.toDomain(),
+		Following: emails(following.targets(tx, uk)),
+		Favorites: slugs,
+		Blocking:  emails(blocking.targets(tx, uk)),
+		Muting:    emails(muting.targets(tx, uk)),
+	}
+}
+
+// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
+func (r *implementation) GetAuthorByEmail(_ context.Context, em string) domain.Author {
+	var found *domain.User
+	r.db.View(func(tx *bolt.Tx) error {
+		if _, ur, err := getUserByEmail(tx, em); err == nil {
+			found = ur.toDomain()
+		}
+		return nil
+	})
+	if found == nil {
+		return nil
+	}
+	return found
+}
+
+func (r *implementation) getUserBy(index []byte, k string) (*domain.User, error) {
+	var found *domain.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		_, ur, err := getUserBy(tx, index, k)
+		if err != nil {
+			return err
+		}
+
+		found = ur.toDomain()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// GetUserByID finds a single user based on their id.
+func (r *implementation) GetUserByID(_ context.Context, id string) (*domain.User, error) {
+	return r.getUserBy(userIDsBucket, id)
+}
+
+// GetUserByUsername finds a single user based on their username.
+func (r *implementation) GetUserByUsername(_ context.Context, un string) (*domain.User, error) {
+	return r.getUserBy(usernamesBucket, strings.ToLower(un))
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (r *implementation) GetUserByPreviousUsername(_ context.Context, un string) (*domain.User, error) {
+	var found *domain.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var pu previousUsernameRecord
+		ok, err := get(tx.Bucket(previousUsernamesBucket), []byte(strings.ToLower(un)), &pu)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrUserNotFound
+		}
+
+		ur, err := getUser(tx, pu.User)
+		if err != nil {
+			return err
+		}
+
+		found = ur.toDomain()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// UpdateUserByEmail finds a single user based on their email address,
+// then applies the provide mutations.
+// Usernames they stop using stay reserved for them.
+func (r *implementation) UpdateUserByEmail(_ context.Context, em string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
+	var updated *domain.User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		uk, prev, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		u, err := update(prev.toDomain())
+		if err != nil {
+			return err
+		}
+
+		next := newUserRecord(u)
+		// Ids never change once the user is created
+		next.ID = prev.ID
+
+		pun := []byte(strings.ToLower(prev.Username))
+		if !strings.EqualFold(next.Username, prev.Username) {
+			// Users can take back their own previous usernames
+			var pu previousUsernameRecord
+			nun := []byte(strings.ToLower(next.Username))
+			if ok, err := get(tx.Bucket(previousUsernamesBucket), nun, &pu); err != nil {
+				return err
+			} else if ok && pu.User == uk {
+				if err := tx.Bucket(previousUsernamesBucket).Delete(nun); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := putUser(tx, uk, prev, next); err != nil {
+			return err
+		}
+
+		if !strings.EqualFold(next.Username, prev.Username) {
+			err := put(tx.Bucket(previousUsernamesBucket), pun, &previousUsernameRecord{
+				prev.Username,
+				uk,
+				time.Now().UTC(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		updated = next.toDomain()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// UpdateFanboyByEmail finds a single user based on their email address,
+// then applies the provide mutations (probably to the follower list).
+func (r *implementation) UpdateFanboyByEmail(_ context.Context, em string, update func(*domain.Fanboy) (*domain.Fanboy, error)) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		uk, ur, err := getUserByEmail(tx, em)
+		if err != nil {
+			return err
+		}
+
+		f, err := update(fanboy(tx, uk, ur))
+		if err != nil {
+			return err
+		}
+
+		users := func(emails map[string]interface{}) []uint64 {
+			ks := make([]uint64, 0, len(emails))
+			for e := range emails {
+				if k, ok := lookup(tx, userEmailsBucket, strings.ToLower(e)); ok {
+					ks = append(ks, k)
+				}
+			}
+			return ks
+		}
+
+		articles := make([]uint64, 0, len(f.Favorites))
+		for s := range f.Favorites {
+			if k, ok := lookup(tx, articleSlugsBucket, strings.ToLower(s)); ok {
+				articles = append(articles, k)
+			}
+		}
+
+		if err := following.replace(tx, uk, users(f.Following)); err != nil {
+			return err
+		}
+		if err := favorites.replace(tx, uk, articles); err != nil {
+			return err
+		}
+		if err := blocking.replace(tx, uk, users(f.Blocking)); err != nil {
+			return err
+		}
+		return muting.replace(tx, uk, users(f.Muting))
+	})
+}
+
+// ListUsers lists every user, ordered by username.
+func (r *implementation) ListUsers(_ context.Context, limit int, offset int) ([]domain.User, error) {
+	users := make([]domain.User, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		// The usernames index is already in order
+		c := tx.Bucket(usernamesBucket).Cursor()
+		off := 0
+		for k, v := c.First(); k != nil && len(users) < limit; k, v = c.Next() {
+			if off < offset {
+				off++
+				continue
+			}
+
+			ur, err := getUser(tx, unkey(v))
+			if err != nil {
+				return err
+			}
+			users = append(users, *ur.toDomain())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (r *implementation) FollowersByEmail(_ context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return r.pageUsers(em, following.sources, limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (r *implementation) FollowingByEmail(_ context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return r.pageUsers(em, following.targets, limit, offset)
+}
+
+func (r *implementation) pageUsers(em string, linked func(*bolt.Tx, uint64) []uint64, limit int, offset int) ([]domain.User, error) {
+	users := make([]domain.User, 0)
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(em))
+		if !ok {
+			return domain.ErrUserNotFound
+		}
+
+		for _, k := range linked(tx, uk) {
+			if ur, err := getUser(tx, k); err == nil {
+				users = append(users, *ur.toDomain())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Username) < strings.ToLower(users[j].Username)
+	})
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(users) {
+		return []domain.User{}, nil
+	}
+	users = users[offset:]
+	if limit >= 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (r *implementation) GetFollowCountsByEmail(_ context.Context, em string) (*domain.FollowCounts, error) {
+	var counts *domain.FollowCounts
+	err := r.db.View(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(em))
+		if !ok {
+			return domain.ErrUserNotFound
+		}
+
+		counts = &domain.FollowCounts{
+			Followers: len(following.sources(tx, uk)),
+			Following: len(following.targets(tx, uk)),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func identityKey(provider string, subject string) string {
+	return provider + "\x00" + subject
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (r *implementation) GetUserByIdentity(_ context.Context, provider string, subject string) (*domain.User, error) {
+	return r.getUserBy(identitiesBucket, identityKey(provider, subject))
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (r *implementation) LinkIdentity(_ context.Context, em string, i *domain.ExternalIdentity) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		uk, ok := lookup(tx, userEmailsBucket, strings.ToLower(em))
+		if !ok {
+			return domain.ErrUserNotFound
+		}
+
+		ik := identityKey(i.Provider, i.Subject)
+		if linked, ok := lookup(tx, identitiesBucket, ik); ok && linked != uk {
+			return domain.ErrDuplicateIdentity
+		}
+
+		return tx.Bucket(identitiesBucket).Put([]byte(ik), key(uk))
+	})
+}
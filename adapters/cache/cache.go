@@ -0,0 +1,350 @@
+// Package cache is a decorator for the repository adapters that caches the hottest reads.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// Backend stores the cached values, either in-process (like the LRU) or shared between instances (like Redis).
+type Backend interface {
+	// Get gets the value stored at the key, false if it isn't there or has expired.
+	Get(context.Context, string) ([]byte, bool, error)
+	// Set stores the value at the key until the ttl passes.
+	Set(context.Context, string, []byte, time.Duration) error
+	// Delete removes the values at the keys.
+	Delete(context.Context, ...string) error
+}
+
+// TTLs are how long each kind of value is cached for.
+type TTLs struct {
+	Users    time.Duration
+	Articles time.Duration
+	Tags     time.Duration
+}
+
+// DefaultTTLs are short enough that anything invalidation misses isn't stale for long.
+var DefaultTTLs = TTLs{
+	Users:    time.Minute,
+	Articles: time.Minute,
+	Tags:     5 * time.Minute,
+}
+
+// Counts are how many cached reads of a kind of value found it in the cache.
+type Counts struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// Stats are the hits and misses of each kind of cached value,
+// Errors counts the times the backend failed (which are treated as misses).
+type Stats struct {
+	Users    Counts
+	Articles Counts
+	Tags     Counts
+	Errors   uint64
+}
+
+type counts struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func (c *counts) load() Counts {
+	return Counts{c.hits.Load(), c.misses.Load()}
+}
+
+// Repository is a domain.Repository that caches users (by email and id), articles (by slug) and tags from another one.
+// Everything else goes straight through to the wrapped repository.
+//
+// Cached values are invalidated by the Update* and Delete* methods of this instance,
+// a read racing a write can put back what the write invalidated so the ttls are what bound staleness.
+type Repository struct {
+	domain.Repository
+	backend Backend
+	ttls    TTLs
+	// The generations are part of every key so changes that touch an unknown number of values
+	// (like a user changing their email) can invalidate all of that kind at once.
+	// They're kept in-process so other instances sharing the backend only see them after the ttls.
+	userGen    atomic.Int64
+	articleGen atomic.Int64
+	users      counts
+	articles   counts
+	tags       counts
+	errors     atomic.Uint64
+}
+
+// NewInstance wraps the repository with a cache kept in the backend.
+func NewInstance(r domain.Repository, b Backend, ttls TTLs) *Repository {
+	return &Repository{
+		Repository: r,
+		backend:    b,
+		ttls:       ttls,
+	}
+}
+
+// Stats gets the hits and misses of the cache so far.
+func (c *Repository) Stats() Stats {
+	return Stats{
+		c.users.load(),
+		c.articles.load(),
+		c.tags.load(),
+		c.errors.Load(),
+	}
+}
+
+func (c *Repository) userKey(e string) string {
+	return fmt.Sprintf("user:%v:%v", c.userGen.Load(), strings.ToLower(e))
+}
+
+func (c *Repository) userIDKey(id string) string {
+	return fmt.Sprintf("userid:%v:%v", c.userGen.Load(), id)
+}
+
+func (c *Repository) articleKey(s string) string {
+	return fmt.Sprintf("article:%v:%v", c.articleGen.Load(), strings.ToLower(s))
+}
+
+func (c *Repository) tagsKey() string {
+	return fmt.Sprintf("tags:%v", c.articleGen.Load())
+}
+
+func (c *Repository) get(ctx context.Context, n *counts, k string, v interface{}) bool {
+	b, ok, err := c.backend.Get(ctx, k)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	if err == nil && ok && json.Unmarshal(b, v) == nil {
+		n.hits.Add(1)
+		return true
+	}
+
+	n.misses.Add(1)
+	return false
+}
+
+func (c *Repository) set(ctx context.Context, k string, v interface{}, ttl time.Duration) {
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = c.backend.Set(ctx, k, b, ttl)
+	}
+	if err != nil {
+		c.errors.Add(1)
+	}
+}
+
+func (c *Repository) invalidate(ctx context.Context, ks ...string) {
+	if err := c.backend.Delete(ctx, ks...); err != nil {
+		c.errors.Add(1)
+	}
+}
+
+// GetUserByEmail finds a single user based on their email address.
+func (c *Repository) GetUserByEmail(ctx context.Context, e string) (*domain.Fanboy, error) {
+	k := c.userKey(e)
+	var f domain.Fanboy
+	if c.get(ctx, &c.users, k, &f) {
+		return &f, nil
+	}
+
+	u, err := c.Repository.GetUserByEmail(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, k, u, c.ttls.Users)
+	return u, nil
+}
+
+// GetUserByID finds a single user based on their id.
+func (c *Repository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	k := c.userIDKey(id)
+	var u domain.User
+	if c.get(ctx, &c.users, k, &u) {
+		return &u, nil
+	}
+
+	fu, err := c.Repository.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, k, fu, c.ttls.Users)
+	return fu, nil
+}
+
+// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
+func (c *Repository) GetAuthorByEmail(ctx context.Context, e string) domain.Author {
+	if f, err := c.GetUserByEmail(ctx, e); err == nil {
+		return f
+	}
+
+	return nil
+}
+
+// UpdateUserByEmail finds a single user based on their email address,
+// then applies the provide mutations.
+func (c *Repository) UpdateUserByEmail(ctx context.Context, e string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
+	var id string
+	u, err := c.Repository.UpdateUserByEmail(ctx, e, func(u *domain.User) (*domain.User, error) {
+		id = u.ID
+		return update(u)
+	})
+
+	if u != nil && !strings.EqualFold(u.Email, e) {
+		// Other users follow, block and mute them by email and articles are authored by email
+		c.userGen.Add(1)
+		c.articleGen.Add(1)
+	}
+	ks := []string{c.userKey(e)}
+	if id != "" {
+		ks = append(ks, c.userIDKey(id))
+	}
+	c.invalidate(ctx, ks...)
+	return u, err
+}
+
+// UpdateFanboyByEmail finds a single user based on their email address,
+// then applies the provide mutations (probably to the follower list).
+func (c *Repository) UpdateFanboyByEmail(ctx context.Context, e string, update func(*domain.Fanboy) (*domain.Fanboy, error)) error {
+	changed := make([]string, 0)
+	var id string
+	err := c.Repository.UpdateFanboyByEmail(ctx, e, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+		id = f.ID
+		// The update can change the favorites in place so they're copied first
+		before := make(map[string]interface{}, len(f.Favorites))
+		for s := range f.Favorites {
+			before[s] = nil
+		}
+
+		uf, err := update(f)
+		if err != nil {
+			return uf, err
+		}
+
+		// The favorite counts of the articles they (un)favorited change
+		for s := range before {
+			if _, ok := uf.Favorites[s]; !ok {
+				changed = append(changed, s)
+			}
+		}
+		for s := range uf.Favorites {
+			if _, ok := before[s]; !ok {
+				changed = append(changed, s)
+			}
+		}
+		return uf, nil
+	})
+
+	ks := make([]string, 0, len(changed)+2)
+	ks = append(ks, c.userKey(e))
+	if id != "" {
+		ks = append(ks, c.userIDKey(id))
+	}
+	for _, s := range changed {
+		ks = append(ks, c.articleKey(s))
+	}
+	c.invalidate(ctx, ks...)
+	return err
+}
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (c *Repository) DeleteUser(ctx context.Context, e string, m domain.DeletionMode) error {
+	err := c.Repository.DeleteUser(ctx, e, m)
+
+	// Everything they followed, favorited and authored changes (along with their cached ids)
+	c.userGen.Add(1)
+	c.articleGen.Add(1)
+	return err
+}
+
+// cachedArticle is an article without its author,
+// who is read from the cached users so their changes don't have to invalidate every article they wrote.
+type cachedArticle struct {
+	Article       domain.Article
+	FavoriteCount int
+}
+
+// GetArticleBySlug gets a single article with the given slug.
+func (c *Repository) GetArticleBySlug(ctx context.Context, s string) (*domain.AuthoredArticle, error) {
+	k := c.articleKey(s)
+	var ca cachedArticle
+	if c.get(ctx, &c.articles, k, &ca) {
+		if f, err := c.GetUserByEmail(ctx, ca.Article.AuthorEmail); err == nil {
+			return &domain.AuthoredArticle{
+				Article:       ca.Article,
+				Author:        f,
+				FavoriteCount: ca.FavoriteCount,
+			}, nil
+		}
+	}
+
+	a, err := c.Repository.GetArticleBySlug(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, k, cachedArticle{a.Article, a.FavoriteCount}, c.ttls.Articles)
+	return a, nil
+}
+
+// CreateArticle creates a new article.
+func (c *Repository) CreateArticle(ctx context.Context, a *domain.Article) (*domain.AuthoredArticle, error) {
+	ca, err := c.Repository.CreateArticle(ctx, a)
+
+	c.invalidate(ctx, c.articleKey(a.Slug), c.tagsKey())
+	return ca, err
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (c *Repository) ImportArticle(ctx context.Context, a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+	ca, err := c.Repository.ImportArticle(ctx, a)
+
+	c.invalidate(ctx, c.articleKey(a.Slug), c.tagsKey())
+	return ca, err
+}
+
+// UpdateArticleBySlug finds a single article based on its slug
+// then applies the provide mutations.
+func (c *Repository) UpdateArticleBySlug(ctx context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (*domain.AuthoredArticle, error) {
+	a, err := c.Repository.UpdateArticleBySlug(ctx, s, update)
+
+	ks := []string{c.articleKey(s), c.tagsKey()}
+	if a != nil && !strings.EqualFold(a.Slug, s) {
+		ks = append(ks, c.articleKey(a.Slug))
+		// Users favorite articles by slug
+		c.userGen.Add(1)
+	}
+	c.invalidate(ctx, ks...)
+	return a, err
+}
+
+// DeleteArticle deletes the article if it exists.
+func (c *Repository) DeleteArticle(ctx context.Context, a *domain.Article) error {
+	err := c.Repository.DeleteArticle(ctx, a)
+
+	if a != nil {
+		c.invalidate(ctx, c.articleKey(a.Slug), c.tagsKey())
+	}
+	return err
+}
+
+// DistinctTags returns a distinct list of tags on all articles
+func (c *Repository) DistinctTags(ctx context.Context) ([]string, error) {
+	k := c.tagsKey()
+	var tags []string
+	if c.get(ctx, &c.tags, k, &tags) {
+		return tags, nil
+	}
+
+	tags, err := c.Repository.DistinctTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, k, tags, c.ttls.Tags)
+	return tags, nil
+}
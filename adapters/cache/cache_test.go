@@ -0,0 +1,287 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/adapters/cache"
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/testcases"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The shared testcases make sure nothing the cache returns is stale after the writes they do.
+var uut = cache.NewInstance(inmemory.NewInstance(), cache.NewLRU(1000), cache.DefaultTTLs)
+
+func Test_Users(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update User", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_CreateUser(t, uut)
+	})
+	t.Run("Get and Update User By Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByEmail(t, uut)
+	})
+	t.Run("Get and Update User By Username", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByUsername(t, uut)
+	})
+	t.Run("Get User By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByID(t, uut)
+	})
+	t.Run("Username History", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_UsernameHistory(t, uut)
+	})
+	t.Run("Fanboy Following Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Following(t, uut)
+	})
+	t.Run("Fanboy Favoriting Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Favorites(t, uut)
+	})
+	t.Run("Listing Followers", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_FollowersByEmail(t, uut)
+	})
+	t.Run("Listing Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ListUsers(t, uut)
+	})
+	t.Run("Fanboy Blocking and Muting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Blocking(t, uut)
+	})
+	t.Run("Changing An Email Inside Another", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_EmailInsideAnother(t, uut)
+	})
+	t.Run("Roles and Bans", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Roles(t, uut)
+	})
+	t.Run("Verifying Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Verified(t, uut)
+	})
+	t.Run("Two-Factor Authentication", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_TwoFactor(t, uut)
+	})
+	t.Run("Deleting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_DeleteUser_Anonymize(t, uut)
+		testcases.Users_DeleteUser_Delete(t, uut)
+	})
+	t.Run("Exporting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ExportUserByEmail(t, uut)
+	})
+	t.Run("Linking External Identities", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_LinkIdentity(t, uut)
+	})
+}
+
+func Test_Articles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_CreateArticle(t, uut)
+	})
+	t.Run("Get and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleBySlug(t, uut)
+	})
+	t.Run("Get Article By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleByID(t, uut)
+	})
+	t.Run("Delete Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DeleteArticle(t, uut)
+	})
+	t.Run("Hide Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateArticleBySlug_Hidden(t, uut)
+	})
+	t.Run("Hidden Content", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_HiddenContent(t, uut)
+	})
+	t.Run("Query Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria(t, uut)
+	})
+	t.Run("Rendered Article Bodies", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_BodyHTML(t, uut)
+	})
+	t.Run("Query Articles As Viewer", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria_Viewer(t, uut)
+	})
+	t.Run("Create and Delete Comments", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateCommentsBySlug(t, uut)
+	})
+	t.Run("Import Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_ImportArticle(t, uut)
+	})
+	t.Run("Query Tags", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DistinctTags(t, uut)
+	})
+}
+
+func Test_Reports(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Resolve Report", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateReport(t, uut)
+	})
+	t.Run("Audit Trail", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateAuditEntry(t, uut)
+	})
+}
+
+func Test_ReadingLists(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Reading Lists", func(t *testing.T) {
+		t.Parallel()
+		testcases.ReadingLists_CreateReadingList(t, uut)
+	})
+}
+
+func Test_APITokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Revoke API Tokens", func(t *testing.T) {
+		t.Parallel()
+		testcases.APITokens_CreateAPIToken(t, uut)
+	})
+}
+
+func Test_Caching(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	r := cache.NewInstance(inmemory.NewInstance(), cache.NewLRU(100), cache.DefaultTTLs)
+	u, err := domain.NewUserWithPassword("cached@user.com", "cached", "not a guessable password")
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	a, err := domain.NewArticle("Cached Title", "Cached description", "Cached body", u.Email, "cached")
+	require.NoError(t, err)
+	_, err = r.CreateArticle(ctx, a)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.GetArticleBySlug(ctx, a.Slug)
+		require.NoError(t, err)
+		_, err = r.DistinctTags(ctx)
+		require.NoError(t, err)
+	}
+	s := r.Stats()
+	assert.Equal(t, cache.Counts{Hits: 2, Misses: 1}, s.Articles)
+	assert.Equal(t, cache.Counts{Hits: 2, Misses: 1}, s.Tags)
+	assert.Equal(t, cache.Counts{Hits: 1, Misses: 1}, s.Users, "cached articles read their author from the cached users")
+
+	// Favoriting changes the favorite count of the cached article
+	err = r.UpdateFanboyByEmail(ctx, u.Email, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+		f.Favorite(a.Slug)
+		return f, nil
+	})
+	require.NoError(t, err)
+	ca, err := r.GetArticleBySlug(ctx, a.Slug)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ca.FavoriteCount)
+
+	// Updating the author changes the author of the cached article
+	_, err = r.UpdateUserByEmail(ctx, u.Email, func(u *domain.User) (*domain.User, error) {
+		u.Bio = "Cached bio"
+		return u, nil
+	})
+	require.NoError(t, err)
+	ca, err = r.GetArticleBySlug(ctx, a.Slug)
+	require.NoError(t, err)
+	assert.Equal(t, "Cached bio", ca.Author.GetBio())
+
+	// Sessions read users by id
+	before := r.Stats().Users
+	for i := 0; i < 2; i++ {
+		cu, err := r.GetUserByID(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Cached bio", cu.Bio)
+	}
+	after := r.Stats().Users
+	assert.Equal(t, cache.Counts{Hits: 1, Misses: 1}, cache.Counts{Hits: after.Hits - before.Hits, Misses: after.Misses - before.Misses})
+	_, err = r.UpdateUserByEmail(ctx, u.Email, func(u *domain.User) (*domain.User, error) {
+		u.Role = domain.RoleModerator
+		return u, nil
+	})
+	require.NoError(t, err)
+	cu, err := r.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleModerator, cu.Role)
+
+	// Changing their email changes everything keyed by it
+	_, err = r.UpdateUserByEmail(ctx, u.Email, func(u *domain.User) (*domain.User, error) {
+		u.Email = "recached@user.com"
+		return u, nil
+	})
+	require.NoError(t, err)
+	ca, err = r.GetArticleBySlug(ctx, a.Slug)
+	require.NoError(t, err)
+	assert.Equal(t, "recached@user.com", ca.AuthorEmail)
+	_, err = r.GetUserByEmail(ctx, u.Email)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	require.NoError(t, r.DeleteArticle(ctx, &ca.Article))
+	_, err = r.GetArticleBySlug(ctx, a.Slug)
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+	tags, err := r.DistinctTags(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, tags, "cached")
+}
+
+func Test_LRU(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c := cache.NewLRU(2)
+	require.NoError(t, c.Set(ctx, "a", []byte("a"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("b"), time.Minute))
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// b is the least recently used
+	require.NoError(t, c.Set(ctx, "c", []byte("c"), time.Minute))
+	assert.Equal(t, 2, c.Len())
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	v, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), v)
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "d", []byte("d"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, _ = c.Get(ctx, "d")
+	assert.False(t, ok, "expired values aren't returned")
+}
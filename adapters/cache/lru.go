@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process Backend that keeps a fixed number of values,
+// evicting the least recently used one to make room for new ones.
+type LRU struct {
+	mu      *sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates a new LRU Backend keeping at most size values.
+func NewLRU(size int) *LRU {
+	return &LRU{
+		&sync.Mutex{},
+		size,
+		list.New(),
+		make(map[string]*list.Element, size),
+	}
+}
+
+// Get gets the value stored at the key, false if it isn't there or has expired.
+func (c *LRU) Get(_ context.Context, k string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*lruEntry)
+	if !time.Now().Before(e.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, k)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set stores the value at the key until the ttl passes.
+func (c *LRU) Set(_ context.Context, k string, v []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return nil
+	}
+
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*lruEntry)
+		e.value = v
+		e.expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	for c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	c.entries[k] = c.order.PushFront(&lruEntry{k, v, time.Now().Add(ttl)})
+	return nil
+}
+
+// Delete removes the values at the keys.
+func (c *LRU) Delete(_ context.Context, ks ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, k := range ks {
+		if el, ok := c.entries[k]; ok {
+			c.order.Remove(el)
+			delete(c.entries, k)
+		}
+	}
+	return nil
+}
+
+// Len is how many values are stored, including expired ones that haven't been evicted yet.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
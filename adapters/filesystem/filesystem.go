@@ -0,0 +1,88 @@
+// Package filesystem is a local directory implementation of the blob storage adapter.
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// contentTypeSuffix names the file next to each blob that holds its content type.
+const contentTypeSuffix = ".content-type"
+
+// MustNewInstance creates a new instance of the filesystem store rooted at the given directory. Panics on error.
+func MustNewInstance(dir string) domain.BlobStore {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		panic(err)
+	}
+
+	return &implementation{dir}
+}
+
+type implementation struct {
+	dir string
+}
+
+// isBlobKey checks the key is valid and isn't one of the content type files.
+func isBlobKey(key string) bool {
+	return domain.IsValidBlobKey(key) && !strings.HasSuffix(key, contentTypeSuffix)
+}
+
+// PutBlob stores the data with its content type under the key, replacing anything already there.
+func (r *implementation) PutBlob(_ context.Context, key string, contentType string, data []byte) error {
+	if !isBlobKey(key) {
+		return domain.ErrInvalidBlobKey
+	}
+
+	// Content types are written first so a blob is never visible without one
+	if err := r.write(key+contentTypeSuffix, []byte(contentType)); err != nil {
+		return err
+	}
+	return r.write(key, data)
+}
+
+// write replaces the file atomically so readers never see a partial blob.
+func (r *implementation) write(name string, data []byte) error {
+	tmp, err := os.CreateTemp(r.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(r.dir, name))
+}
+
+// GetBlob gets the data and its content type stored under the key.
+func (r *implementation) GetBlob(_ context.Context, key string) ([]byte, string, error) {
+	if !isBlobKey(key) {
+		return nil, "", domain.ErrBlobNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", domain.ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	ct, err := os.ReadFile(filepath.Join(r.dir, key+contentTypeSuffix))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+
+	return data, string(ct), nil
+}
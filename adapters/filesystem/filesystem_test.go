@@ -0,0 +1,47 @@
+package filesystem_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/filesystem"
+	"github.com/brycekbargar/realworld-backend/adapters/testcases"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var uut domain.BlobStore
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "realworld_backend_test_")
+	if err != nil {
+		panic(err)
+	}
+
+	uut = filesystem.MustNewInstance(dir)
+	res := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(res)
+}
+
+func Test_Blobs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Put and Get Blob", func(t *testing.T) {
+		t.Parallel()
+		testcases.Blobs_PutBlob(t, uut)
+	})
+	t.Run("Invalid Keys", func(t *testing.T) {
+		t.Parallel()
+		testcases.Blobs_InvalidKeys(t, uut)
+	})
+	t.Run("Content Type Files Aren't Blobs", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, uut.PutBlob(context.Background(), "sidecar.png", "image/png", []byte("sidecar data")))
+		_, _, err := uut.GetBlob(context.Background(), "sidecar.png.content-type")
+		assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+	})
+}
@@ -0,0 +1,220 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (r *implementation) DeleteUser(_ context.Context, e string, m domain.DeletionMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	em := strings.ToLower(e)
+	f, err := r.getUserByEmail(em)
+	if err != nil {
+		return err
+	}
+
+	// Everything only the user could see goes either way
+	r.unindexFollowing(f.ID, r.users[em].following)
+	r.unindexFavorites(em, r.users[em].favorites)
+	delete(r.followers, f.ID)
+	for _, v := range r.users {
+		v.following = replaceKey(v.following, f.ID, "")
+		v.blocking = replaceKey(v.blocking, f.ID, "")
+		v.muting = replaceKey(v.muting, f.ID, "")
+	}
+	for k, v := range r.readingLists {
+		if strings.ToLower(v.owner) == em {
+			delete(r.readingLists, k)
+		}
+	}
+	for k, v := range r.apiTokens {
+		if strings.ToLower(v.owner) == em {
+			delete(r.apiTokens, k)
+		}
+	}
+	for k, v := range r.identities {
+		if v == em {
+			delete(r.identities, k)
+		}
+	}
+	for k, v := range r.previousUsernames {
+		if v.user == f.ID {
+			delete(r.previousUsernames, k)
+		}
+	}
+
+	delete(r.userIDs, f.ID)
+	if m == domain.DeleteContent {
+		delete(r.users, em)
+		for k, v := range r.articles {
+			if strings.ToLower(v.author) == em {
+				r.unindexArticle(v)
+				for _, l := range r.readingLists {
+					l.articles = replaceKey(l.articles, k, "")
+				}
+				continue
+			}
+
+			kept := make([]commentRecord, 0, len(v.comments))
+			for _, c := range v.comments {
+				if strings.ToLower(c.author) != em {
+					kept = append(kept, c)
+				}
+			}
+			v.comments = kept
+		}
+		for k, v := range r.reports {
+			if strings.ToLower(v.reporter) == em {
+				delete(r.reports, k)
+			}
+			if strings.ToLower(v.resolver) == em {
+				v.resolver = ""
+			}
+		}
+
+		return nil
+	}
+
+	u := f.User
+	if err := u.Anonymize(); err != nil {
+		return err
+	}
+
+	delete(r.users, em)
+	r.users[strings.ToLower(u.Email)] = &userRecord{
+		u.ID,
+		u.Email,
+		u.Username,
+		u.Bio,
+		u.Image,
+		"",
+		"",
+		u.Password,
+		string(u.Role),
+		u.Banned,
+		"",
+		"",
+		u.Verified,
+		"",
+		false,
+		"",
+		0,
+		0,
+		time.Time{},
+	}
+	r.userIDs[u.ID] = strings.ToLower(u.Email)
+	r.rekeyUser(em, u.Email)
+
+	return nil
+}
+
+// ExportUserByEmail gathers everything kept about the user with the given email.
+func (r *implementation) ExportUserByEmail(_ context.Context, e string) (*domain.UserExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	em := strings.ToLower(e)
+	f, err := r.getUserByEmail(em)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &domain.UserExport{
+		User:              f.User,
+		PreviousUsernames: r.previousUsernamesOf(f.ID),
+		Identities:        r.identitiesOf(em),
+		Following:         make([]string, 0, len(f.Following)),
+		Favorites:         make([]string, 0, len(f.Favorites)),
+		Articles:          make([]domain.Article, 0),
+		Comments:          make([]domain.ExportedComment, 0),
+		ReadingLists:      make([]domain.ReadingList, 0),
+		CreatedAtUTC:      time.Now().UTC(),
+	}
+
+	for k := range f.Following {
+		if u, ok := r.users[k]; ok {
+			x.Following = append(x.Following, u.username)
+		}
+	}
+	sort.Strings(x.Following)
+	for k := range f.Favorites {
+		x.Favorites = append(x.Favorites, k)
+	}
+	sort.Strings(x.Favorites)
+
+	for k := range r.articles {
+		ca, err := r.getCommentsBySlug(k, true)
+		if err != nil {
+			continue
+		}
+		if strings.ToLower(ca.AuthorEmail) == em {
+			x.Articles = append(x.Articles, ca.Article)
+		}
+		for _, c := range ca.Comments {
+			if strings.ToLower(c.AuthorEmail) == em {
+				x.Comments = append(x.Comments, domain.ExportedComment{
+					ArticleSlug: ca.Slug,
+					Comment:     c,
+				})
+			}
+		}
+	}
+	sort.Slice(x.Articles, func(i, j int) bool {
+		return x.Articles[i].CreatedAtUTC.Before(x.Articles[j].CreatedAtUTC)
+	})
+	sort.Slice(x.Comments, func(i, j int) bool {
+		return x.Comments[i].CreatedAtUTC.Before(x.Comments[j].CreatedAtUTC)
+	})
+
+	for _, v := range r.readingLists {
+		if strings.ToLower(v.owner) == em {
+			x.ReadingLists = append(x.ReadingLists, *v.toDomain())
+		}
+	}
+	sort.Slice(x.ReadingLists, func(i, j int) bool {
+		return strings.ToLower(x.ReadingLists[i].Name) < strings.ToLower(x.ReadingLists[j].Name)
+	})
+
+	return x, nil
+}
+
+// previousUsernamesOf lists the usernames the user with the given id used to have, oldest first.
+func (r *implementation) previousUsernamesOf(id string) []string {
+	usernames := make([]string, 0)
+	for _, v := range r.previousUsernames {
+		if v.user == id {
+			usernames = append(usernames, v.username)
+		}
+	}
+	sort.Slice(usernames, func(i, j int) bool {
+		return r.previousUsernames[strings.ToLower(usernames[i])].changedAtUTC.Before(
+			r.previousUsernames[strings.ToLower(usernames[j])].changedAtUTC)
+	})
+
+	return usernames
+}
+
+// identitiesOf lists the external identities linked to the user with the given lowercased email.
+func (r *implementation) identitiesOf(em string) []domain.ExternalIdentity {
+	identities := make([]domain.ExternalIdentity, 0)
+	for k, v := range r.identities {
+		if v == em {
+			ps := strings.SplitN(k, "\x00", 2)
+			identities = append(identities, domain.ExternalIdentity{Provider: ps[0], Subject: ps[1]})
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		return identityKey(identities[i].Provider, identities[i].Subject) <
+			identityKey(identities[j].Provider, identities[j].Subject)
+	})
+
+	return identities
+}
@@ -0,0 +1,110 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// CreateAPIToken creates a new personal API token.
+func (r *implementation) CreateAPIToken(_ context.Context, t *domain.APIToken) (*domain.APIToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[strings.ToLower(t.OwnerEmail)]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	for _, v := range r.apiTokens {
+		if strings.EqualFold(v.owner, t.OwnerEmail) && strings.EqualFold(v.name, t.Name) {
+			return nil, domain.ErrDuplicateAPIToken
+		}
+	}
+	r.lastAPITokenID++
+	id := r.lastAPITokenID
+
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	r.apiTokens[id] = &apiTokenRecord{
+		id,
+		t.OwnerEmail,
+		t.Name,
+		t.Hash,
+		strings.Join(scopes, ","),
+		time.Now().UTC(),
+	}
+
+	return r.apiTokens[id].toDomain(), nil
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (r *implementation) APITokensByOwner(_ context.Context, e string) ([]domain.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.users[strings.ToLower(e)]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	results := make([]domain.APIToken, 0)
+	for _, v := range r.apiTokens {
+		if strings.EqualFold(v.owner, e) {
+			results = append(results, *v.toDomain())
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+
+	return results, nil
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (r *implementation) GetAPITokenByHash(_ context.Context, h string) (*domain.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.apiTokens {
+		if v.hash == h {
+			return v.toDomain(), nil
+		}
+	}
+
+	return nil, domain.ErrAPITokenNotFound
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (r *implementation) DeleteAPIToken(_ context.Context, e string, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.apiTokens[id]
+	if !ok || !strings.EqualFold(t.owner, e) {
+		return domain.ErrAPITokenNotFound
+	}
+
+	delete(r.apiTokens, id)
+	return nil
+}
+
+func (tr *apiTokenRecord) toDomain() *domain.APIToken {
+	scopes := make([]domain.Scope, 0)
+	for _, s := range splitList(tr.scopes) {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return &domain.APIToken{
+		ID:           tr.id,
+		OwnerEmail:   tr.owner,
+		Name:         tr.name,
+		Hash:         tr.hash,
+		Scopes:       scopes,
+		CreatedAtUTC: tr.createdAtUTC,
+	}
+}
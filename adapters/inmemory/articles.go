@@ -2,7 +2,6 @@ package inmemory
 
 import (
 	"context"
-	"sort"
 	"strings"
 	"time"
 
@@ -10,41 +9,98 @@ import (
 )
 
 // Create creates a new article.
-func (r *implementation) CreateArticle(ctx context.Context, a *domain.Article) (*domain.AuthoredArticle, error) {
+func (r *implementation) CreateArticle(_ context.Context, a *domain.Article) (*domain.AuthoredArticle, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	for s := range r.articles {
-		if s == strings.ToLower(a.Slug) {
-			return nil, domain.ErrDuplicateArticle
-		}
+	if _, ok := r.articles[strings.ToLower(a.Slug)]; ok {
+		return nil, domain.ErrDuplicateArticle
 	}
 
 	if _, ok := r.users[strings.ToLower(a.AuthorEmail)]; !ok {
 		return nil, domain.ErrNoAuthor
 	}
 
+	a.RenderBody()
 	now := time.Now().UTC()
-	r.articles[strings.ToLower(a.Slug)] = &articleRecord{
+	r.indexArticle(&articleRecord{
+		a.ID,
 		a.Slug,
 		a.Title,
 		a.Description,
 		a.Body,
+		a.BodyHTML,
 		strings.Join(a.TagList, ","),
 		now,
 		now,
 		a.AuthorEmail,
 		make([]commentRecord, 0),
+		a.Hidden,
+	})
+	return r.getArticleBySlug(a.Slug)
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (r *implementation) ImportArticle(_ context.Context, a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.articles[strings.ToLower(a.Slug)]; ok {
+		return nil, domain.ErrDuplicateArticle
+	}
+
+	if _, ok := r.users[strings.ToLower(a.AuthorEmail)]; !ok {
+		return nil, domain.ErrNoAuthor
 	}
-	return r.GetArticleBySlug(ctx, a.Slug)
+	cs := make([]commentRecord, 0, len(a.Comments))
+	for _, c := range a.Comments {
+		if _, ok := r.users[strings.ToLower(c.AuthorEmail)]; !ok {
+			return nil, domain.ErrUserNotFound
+		}
+
+		// Comment ids are unique across all articles so they don't depend on the slug
+		r.lastCommentID++
+		cs = append(cs, commentRecord{
+			id:           r.lastCommentID,
+			uid:          c.UID,
+			body:         c.Body,
+			bodyHTML:     c.HTML(),
+			createdAtUTC: c.CreatedAtUTC.UTC(),
+			author:       c.AuthorEmail,
+			hidden:       c.Hidden,
+		})
+	}
+
+	a.RenderBody()
+	r.indexArticle(&articleRecord{
+		a.ID,
+		a.Slug,
+		a.Title,
+		a.Description,
+		a.Body,
+		a.BodyHTML,
+		strings.Join(a.TagList, ","),
+		a.CreatedAtUTC.UTC(),
+		a.UpdatedAtUTC.UTC(),
+		a.AuthorEmail,
+		cs,
+		a.Hidden,
+	})
+	return r.getCommentsBySlug(a.Slug, true)
 }
 
 // LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
-func (r *implementation) LatestArticlesByCriteria(ctx context.Context, query domain.ListCriteria) (
+func (r *implementation) LatestArticlesByCriteria(_ context.Context, query domain.ListCriteria) (
 	[]domain.AuthoredArticle,
 	error,
 ) {
-	// i wish this was sql qq
+	if query.Limit < 1 {
+		return make([]domain.AuthoredArticle, 0), nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	results := make([]domain.AuthoredArticle, 0, query.Limit)
 
 	off := 0
@@ -56,40 +112,84 @@ func (r *implementation) LatestArticlesByCriteria(ctx context.Context, query dom
 		return nil, domain.ErrUserNotFound
 	}
 
+	ignored := make(map[string]interface{})
+	if viewer, ok := r.users[strings.ToLower(query.ViewerEmail)]; ok {
+		for k := range r.userEmailsOf(splitKeys(viewer.blocking)) {
+			ignored[k] = nil
+		}
+		for k := range r.userEmailsOf(splitKeys(viewer.muting)) {
+			ignored[k] = nil
+		}
+	}
+
 	lt := strings.ToLower(query.Tag)
 	am := make(map[string]interface{}, len(query.AuthorEmails))
 	for _, ae := range query.AuthorEmails {
 		am[strings.ToLower(ae)] = nil
 	}
 
-	ordered := make([]articleRecord, 0, len(r.articles))
-	for _, ar := range r.articles {
-		ordered = append(ordered, *ar)
+	// Read from the smallest index that has every matching article in it,
+	// the rest of the criteria are checked as it's read so only a page worth is ever looked at.
+	next := merged(r.latest)
+	size := len(r.latest)
+	if lt != "" {
+		next, size = merged(r.byTag[lt]), len(r.byTag[lt])
+	}
+	if len(am) > 0 {
+		ixs := make([]articleIndex, 0, len(am))
+		n := 0
+		for ae := range am {
+			ixs = append(ixs, r.byAuthor[ae])
+			n += len(r.byAuthor[ae])
+		}
+		if n < size {
+			next, size = merged(ixs...), n
+		}
+	}
+	var favorites map[string]interface{}
+	if lf != "" {
+		favorites = splitKeys(faveUser.favorites)
+		if len(favorites) < size {
+			fs := make(articleIndex, 0, len(favorites))
+			for s := range favorites {
+				if ar, ok := r.articles[s]; ok {
+					fs = fs.insert(ar)
+				}
+			}
+			next = merged(fs)
+		}
 	}
-	sort.Slice(ordered, func(i, j int) bool {
-		return ordered[i].createdAtUTC.After(ordered[j].createdAtUTC)
-	})
 
-	for _, ar := range ordered {
-		if off < query.Offset {
-			off++
+	for ar := next(); ar != nil; ar = next() {
+		if ar.hidden && !query.WithHidden {
+			continue
+		}
+
+		ae := strings.ToLower(ar.author)
+		if _, a := am[ae]; len(am) > 0 && !a {
 			continue
 		}
 
-		_, a := am[ar.author]
-		if len(query.AuthorEmails) > 0 && !a {
+		if _, i := ignored[ae]; i {
 			continue
 		}
 
-		if lf != "" && !strings.Contains(faveUser.favorites, strings.ToLower(ar.slug)) {
+		if _, f := favorites[strings.ToLower(ar.slug)]; favorites != nil && !f {
 			continue
 		}
 
-		if lt != "" && !strings.Contains(strings.ToLower(ar.tagList), lt) {
+		if lt != "" {
+			if _, t := splitKeys(strings.ToLower(ar.tagList))[lt]; !t {
+				continue
+			}
+		}
+
+		if off < query.Offset {
+			off++
 			continue
 		}
 
-		da, err := r.GetArticleBySlug(ctx, ar.slug)
+		da, err := r.getArticleBySlug(ar.slug)
 		if err != nil {
 			continue
 		}
@@ -106,6 +206,13 @@ func (r *implementation) LatestArticlesByCriteria(ctx context.Context, query dom
 
 // GetArticleBySlug gets a single article with the given slug.
 func (r *implementation) GetArticleBySlug(_ context.Context, s string) (*domain.AuthoredArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getArticleBySlug(s)
+}
+
+func (r *implementation) getArticleBySlug(s string) (*domain.AuthoredArticle, error) {
 	if a, ok := r.articles[strings.ToLower(s)]; ok {
 
 		aa, ok := r.users[strings.ToLower(a.author)]
@@ -113,35 +220,58 @@ func (r *implementation) GetArticleBySlug(_ context.Context, s string) (*domain.
 			return nil, domain.ErrNoAuthor
 		}
 
-		fc := 0
-		for _, f := range r.users {
-			if strings.Contains(f.favorites, strings.ToLower(a.slug)) {
-				fc++
-			}
-		}
-
 		return &domain.AuthoredArticle{
 			Article: domain.Article{
+				ID:           a.id,
 				Slug:         a.slug,
 				Title:        a.title,
 				Description:  a.description,
 				Body:         a.body,
+				BodyHTML:     a.bodyHTML,
 				TagList:      strings.Split(a.tagList, ","),
 				CreatedAtUTC: a.createdAtUTC,
 				UpdatedAtUTC: a.updatedAtUTC,
 				AuthorEmail:  a.author,
+				Hidden:       a.hidden,
 			},
 			Author:        aa,
-			FavoriteCount: fc,
+			FavoriteCount: len(r.favoriters[strings.ToLower(a.slug)]),
 		}, nil
 	}
 
 	return nil, domain.ErrArticleNotFound
 }
 
+// GetArticleByID gets a single article with the given id.
+func (r *implementation) GetArticleByID(_ context.Context, id string) (*domain.AuthoredArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if s, ok := r.articleIDs[id]; ok {
+		return r.getArticleBySlug(s)
+	}
+
+	return nil, domain.ErrArticleNotFound
+}
+
 // GetCommentsBySlug gets a single article and its comments with the given slug.
-func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*domain.CommentedArticle, error) {
-	a, err := r.GetArticleBySlug(ctx, s)
+func (r *implementation) GetCommentsBySlug(_ context.Context, s string) (*domain.CommentedArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getCommentsBySlug(s, false)
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (r *implementation) GetCommentsWithHiddenBySlug(_ context.Context, s string) (*domain.CommentedArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getCommentsBySlug(s, true)
+}
+
+func (r *implementation) getCommentsBySlug(s string, withHidden bool) (*domain.CommentedArticle, error) {
+	a, err := r.getArticleBySlug(s)
 	if err != nil {
 		return nil, err
 	}
@@ -149,11 +279,17 @@ func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*doma
 	if ar, ok := r.articles[strings.ToLower(s)]; ok {
 		cs := make([]domain.Comment, 0, len(ar.comments))
 		for _, c := range ar.comments {
+			if c.hidden && !withHidden {
+				continue
+			}
 			cs = append(cs, domain.Comment{
 				ID:           c.id,
+				UID:          c.uid,
 				Body:         c.body,
+				BodyHTML:     c.bodyHTML,
 				CreatedAtUTC: c.createdAtUTC,
 				AuthorEmail:  c.author,
+				Hidden:       c.hidden,
 			})
 		}
 
@@ -167,11 +303,11 @@ func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*doma
 
 // UpdateArticleBySlug finds a single article based on its slug
 // then applies the provide mutations.
-func (r *implementation) UpdateArticleBySlug(ctx context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (*domain.AuthoredArticle, error) {
+func (r *implementation) UpdateArticleBySlug(_ context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (*domain.AuthoredArticle, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	f, err := r.GetArticleBySlug(ctx, s)
+	f, err := r.getArticleBySlug(s)
 	if err != nil {
 		return nil, err
 	}
@@ -187,47 +323,60 @@ func (r *implementation) UpdateArticleBySlug(ctx context.Context, s string, upda
 	}
 
 	removed := r.articles[strings.ToLower(s)]
-	delete(r.articles, strings.ToLower(s))
-
-	for s := range r.articles {
-		if s == strings.ToLower(a.Slug) {
-
-			// Add the deleted article back if they've become a duplicate
-			r.articles[strings.ToLower(removed.slug)] = removed
-			return nil, domain.ErrDuplicateArticle
-		}
+	if other, ok := r.articles[strings.ToLower(a.Slug)]; ok && other != removed {
+		return nil, domain.ErrDuplicateArticle
 	}
 
 	if strings.ToLower(a.Slug) != prevSlug {
+		if fs, ok := r.favoriters[prevSlug]; ok {
+			// Make sure the reverse index of users favoriting this one gets an updated key
+			delete(r.favoriters, prevSlug)
+			r.favoriters[strings.ToLower(a.Slug)] = fs
+		}
 		for _, v := range r.users {
 			// Make sure users favoriting this one get an updated key
-			v.favorites = strings.ReplaceAll(v.favorites, prevSlug, strings.ToLower(a.Slug))
+			v.favorites = replaceKey(v.favorites, prevSlug, strings.ToLower(a.Slug))
+		}
+		for _, v := range r.readingLists {
+			// Make sure reading lists saving this one get an updated key
+			v.articles = replaceKey(v.articles, prevSlug, strings.ToLower(a.Slug))
+		}
+		for _, v := range r.reports {
+			// Make sure reports about this one stay attached to it
+			if v.slug == prevSlug {
+				v.slug = strings.ToLower(a.Slug)
+			}
 		}
 	}
 
+	a.RenderBody()
 	now := time.Now().UTC()
-	r.articles[strings.ToLower(a.Slug)] = &articleRecord{
+	r.unindexArticle(removed)
+	r.indexArticle(&articleRecord{
+		removed.id,
 		a.Slug,
 		a.Title,
 		a.Description,
 		a.Body,
+		a.BodyHTML,
 		strings.Join(a.TagList, ","),
 		a.CreatedAtUTC,
 		now,
 		a.AuthorEmail,
-		make([]commentRecord, 0),
-	}
+		removed.comments,
+		a.Hidden,
+	})
 
-	return r.GetArticleBySlug(ctx, a.Slug)
+	return r.getArticleBySlug(a.Slug)
 }
 
 // UpdateCommentsBySlug finds a single article based on its slug
 // then applies the provide mutations to its comments.
-func (r *implementation) UpdateCommentsBySlug(ctx context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (*domain.Comment, error) {
+func (r *implementation) UpdateCommentsBySlug(_ context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (*domain.Comment, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	f, err := r.GetCommentsBySlug(ctx, s)
+	f, err := r.getCommentsBySlug(s, true)
 
 	if err != nil {
 		return nil, err
@@ -238,27 +387,24 @@ func (r *implementation) UpdateCommentsBySlug(ctx context.Context, s string, upd
 		return nil, err
 	}
 
-	id := 0
-	for _, c := range a.Comments {
-		if c.ID > id {
-			id = c.ID
-		}
-	}
-
 	ncs := make([]domain.Comment, 0, len(a.Comments))
 	cs := make([]commentRecord, 0, len(a.Comments))
 	for _, c := range a.Comments {
 		if c.ID == 0 {
-			id++
-			c.ID = id
+			// Comment ids are unique across all articles so they don't depend on the slug
+			r.lastCommentID++
+			c.ID = r.lastCommentID
 			c.CreatedAtUTC = time.Now().UTC()
 			ncs = append(ncs, c)
 		}
 		cs = append(cs, commentRecord{
 			id:           c.ID,
+			uid:          c.UID,
 			body:         c.Body,
+			bodyHTML:     c.HTML(),
 			createdAtUTC: c.CreatedAtUTC,
 			author:       c.AuthorEmail,
+			hidden:       c.Hidden,
 		})
 	}
 	r.articles[strings.ToLower(a.Slug)].comments = cs
@@ -279,21 +425,22 @@ func (r *implementation) DeleteArticle(_ context.Context, a *domain.Article) err
 		return nil
 	}
 
-	delete(r.articles, strings.ToLower(a.Slug))
+	if ar, ok := r.articles[strings.ToLower(a.Slug)]; ok {
+		r.unindexArticle(ar)
+	}
+	for _, v := range r.readingLists {
+		v.articles = replaceKey(v.articles, strings.ToLower(a.Slug), "")
+	}
 	return nil
 }
 
 // DistinctTags returns a distinct list of tags on articles
 func (r *implementation) DistinctTags(_ context.Context) ([]string, error) {
-	tm := make(map[string]interface{})
-	for _, ar := range r.articles {
-		for _, t := range strings.Split(ar.tagList, ",") {
-			tm[strings.ToLower(t)] = nil
-		}
-	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	tags := make([]string, 0, len(tm))
-	for t := range tm {
+	tags := make([]string, 0, len(r.byTag))
+	for t := range r.byTag {
 		tags = append(tags, t)
 	}
 
@@ -301,8 +448,11 @@ func (r *implementation) DistinctTags(_ context.Context) ([]string, error) {
 }
 
 // GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
-func (r *implementation) GetAuthorByEmail(ctx context.Context, e string) domain.Author {
-	if a, err := r.GetUserByEmail(ctx, e); err == nil {
+func (r *implementation) GetAuthorByEmail(_ context.Context, e string) domain.Author {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if a, err := r.getUserByEmail(e); err == nil {
 		return a
 	}
 
@@ -0,0 +1,255 @@
+package inmemory_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seeded(tb testing.TB, scale inmemory.SeedScale) (inmemory.Instance, *inmemory.Fixture) {
+	tb.Helper()
+
+	fx, err := inmemory.Seed(scale, "seed-password", rand.New(rand.NewSource(42)))
+	require.NoError(tb, err)
+
+	r := inmemory.NewInstance()
+	require.NoError(tb, r.Import(fx))
+	return r, fx
+}
+
+// Test_Concurrency is mostly for running with -race,
+// it also checks the indexes still agree with the articles after lots of concurrent writes.
+func Test_Concurrency(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	r, fx := seeded(t, inmemory.SeedScale{
+		Users:              20,
+		Articles:           100,
+		CommentsPerArticle: 2,
+	})
+	fave := fx.Articles[0].Slug
+
+	var wg sync.WaitGroup
+	for i, u := range fx.Users {
+		wg.Add(3)
+		go func(e string) {
+			defer wg.Done()
+			err := r.UpdateFanboyByEmail(ctx, e, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+				f.Favorite(fave)
+				return f, nil
+			})
+			assert.NoError(t, err)
+		}(u.Email)
+		go func(s string) {
+			defer wg.Done()
+			_, err := r.UpdateArticleBySlug(ctx, s, func(a *domain.Article) (*domain.Article, error) {
+				a.Body = a.Body + "\n\nUpdated."
+				return a, nil
+			})
+			assert.NoError(t, err)
+		}(fx.Articles[i].Slug)
+		go func(tag string) {
+			defer wg.Done()
+			_, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: tag, Limit: 10})
+			assert.NoError(t, err)
+			_, err = r.GetArticleBySlug(ctx, fave)
+			assert.NoError(t, err)
+			_, err = r.DistinctTags(ctx)
+			assert.NoError(t, err)
+		}(fx.Articles[i].TagList[0])
+	}
+	wg.Wait()
+
+	a, err := r.GetArticleBySlug(ctx, fave)
+	require.NoError(t, err)
+	assert.Equal(t, len(fx.Users), a.FavoriteCount)
+
+	all, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Limit: len(fx.Articles)})
+	require.NoError(t, err)
+	assert.Len(t, all, len(fx.Articles))
+	assert.True(t, sort.SliceIsSorted(all, func(i, j int) bool {
+		return all[i].UpdatedAtUTC.After(all[j].UpdatedAtUTC)
+	}), "articles are listed most recently updated first")
+	for i := range fx.Users {
+		assert.Contains(t, all[i].Body, "Updated.", "updated articles move to the front")
+	}
+
+	// Every way of narrowing the list finds the same articles as checking all of them
+	u := fx.Users[1]
+	tag := fx.Articles[1].TagList[0]
+	for _, c := range []domain.ListCriteria{
+		{Tag: tag},
+		{AuthorEmails: []string{u.Email, fx.Users[2].Email}},
+		{FavoritedByUserEmail: u.Email},
+		{Tag: tag, AuthorEmails: []string{u.Email}},
+	} {
+		expected := make([]string, 0)
+		for _, a := range all {
+			if matches(ctx, r, a, c) {
+				expected = append(expected, a.Slug)
+			}
+		}
+
+		c.Limit = len(fx.Articles)
+		listed, err := r.LatestArticlesByCriteria(ctx, c)
+		require.NoError(t, err)
+		actual := make([]string, 0, len(listed))
+		for _, a := range listed {
+			actual = append(actual, a.Slug)
+		}
+		assert.Equal(t, expected, actual, fmt.Sprintf("%+v", c))
+	}
+
+	// Rebuilding the indexes from the records finds the same articles in the same order
+	require.NoError(t, r.Reindex(ctx))
+	reindexed, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Limit: len(fx.Articles)})
+	require.NoError(t, err)
+	assert.Equal(t, all, reindexed)
+	a, err = r.GetArticleByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, len(fx.Users), a.FavoriteCount)
+	f, err := r.GetUserByEmail(ctx, u.Email)
+	require.NoError(t, err)
+	fu, err := r.GetUserByID(ctx, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.Email, fu.Email)
+}
+
+// Test_RenamingArticles checks favorites and their reverse index agree after a slug changes,
+// even when it's the start of another slug.
+func Test_RenamingArticles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	r := inmemory.NewInstance()
+
+	u, err := domain.NewUserWithPassword("renaming@user.com", "renaming", "not a guessable password")
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	for _, title := range []string{"Foo", "Foo Bar"} {
+		a, err := domain.NewArticle(title, "Renamed description", "Renamed body", u.Email)
+		require.NoError(t, err)
+		_, err = r.CreateArticle(ctx, a)
+		require.NoError(t, err)
+	}
+	err = r.UpdateFanboyByEmail(ctx, u.Email, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+		f.Favorite("foo")
+		f.Favorite("foo-bar")
+		return f, nil
+	})
+	require.NoError(t, err)
+
+	_, err = r.UpdateArticleBySlug(ctx, "foo", func(a *domain.Article) (*domain.Article, error) {
+		a.SetTitle("Baz")
+		return a, nil
+	})
+	require.NoError(t, err)
+
+	f, err := r.GetUserByEmail(ctx, u.Email)
+	require.NoError(t, err)
+	assert.True(t, f.Favors("baz"))
+	assert.True(t, f.Favors("foo-bar"))
+	assert.False(t, f.Favors("baz-bar"))
+	for _, s := range []string{"baz", "foo-bar"} {
+		a, err := r.GetArticleBySlug(ctx, s)
+		require.NoError(t, err)
+		assert.Equal(t, 1, a.FavoriteCount, s)
+	}
+	favorited, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{FavoritedByUserEmail: u.Email, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, favorited, 2)
+}
+
+func matches(ctx context.Context, r inmemory.Instance, a domain.AuthoredArticle, c domain.ListCriteria) bool {
+	if c.Tag != "" {
+		tagged := false
+		for _, t := range a.TagList {
+			tagged = tagged || t == c.Tag
+		}
+		if !tagged {
+			return false
+		}
+	}
+	if len(c.AuthorEmails) > 0 {
+		authored := false
+		for _, e := range c.AuthorEmails {
+			authored = authored || e == a.AuthorEmail
+		}
+		if !authored {
+			return false
+		}
+	}
+	if c.FavoritedByUserEmail != "" {
+		f, err := r.GetUserByEmail(ctx, c.FavoritedByUserEmail)
+		if err != nil || !f.Favors(a.Slug) {
+			return false
+		}
+	}
+	return true
+}
+
+func benchmarkLatestArticles(b *testing.B, articles int) {
+	ctx := context.Background()
+	r, fx := seeded(b, inmemory.SeedScale{
+		Users:            100,
+		Articles:         articles,
+		FavoritesPerUser: 20,
+	})
+
+	for _, c := range []struct {
+		name     string
+		criteria domain.ListCriteria
+	}{
+		{"Latest", domain.ListCriteria{Limit: 20}},
+		{"Tag", domain.ListCriteria{Tag: "go", Limit: 20}},
+		{"Author", domain.ListCriteria{AuthorEmails: []string{fx.Users[0].Email}, Limit: 20}},
+		{"Feed", domain.ListCriteria{AuthorEmails: []string{fx.Users[0].Email, fx.Users[1].Email, fx.Users[2].Email}, Limit: 20}},
+		{"Favorited", domain.ListCriteria{FavoritedByUserEmail: fx.Users[0].Email, Limit: 20}},
+		{"Offset", domain.ListCriteria{Limit: 20, Offset: 100}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := r.LatestArticlesByCriteria(ctx, c.criteria); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_LatestArticlesByCriteria_1k(b *testing.B) {
+	benchmarkLatestArticles(b, 1_000)
+}
+
+func Benchmark_LatestArticlesByCriteria_10k(b *testing.B) {
+	benchmarkLatestArticles(b, 10_000)
+}
+
+func Benchmark_GetArticleBySlug_Parallel(b *testing.B) {
+	ctx := context.Background()
+	r, fx := seeded(b, inmemory.SeedScale{
+		Users:            100,
+		Articles:         1_000,
+		FavoritesPerUser: 20,
+	})
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := r.GetArticleBySlug(ctx, fx.Articles[i%len(fx.Articles)].Slug); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,698 @@
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// Fixture is everything in a store: the users (and everything that belongs to them), articles, comments, reports and the audit trail.
+// Stores can be started from one and dumped back out to one.
+type Fixture struct {
+	Users    []FixtureUser    `json:"users" yaml:"users"`
+	Articles []FixtureArticle `json:"articles" yaml:"articles"`
+	// Reports are in the order they were made.
+	Reports []FixtureReport `json:"reports,omitempty" yaml:"reports,omitempty"`
+	// Audit is the moderation audit trail, oldest first.
+	Audit []FixtureAuditEntry `json:"audit,omitempty" yaml:"audit,omitempty"`
+}
+
+// FixtureUser is a user along with who they follow, what they favorited and everything else that belongs to them.
+type FixtureUser struct {
+	// ID is generated on import when it's empty.
+	ID       string `json:"id,omitempty" yaml:"id,omitempty"`
+	Email    string `json:"email" yaml:"email"`
+	Username string `json:"username" yaml:"username"`
+	Bio      string `json:"bio,omitempty" yaml:"bio,omitempty"`
+	Image    string `json:"image,omitempty" yaml:"image,omitempty"`
+	// Password is hashed on import, it's never exported.
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// PasswordHash is used as is on import when there isn't a Password.
+	PasswordHash string `json:"passwordHash,omitempty" yaml:"passwordHash,omitempty"`
+	// Role is a regular user when it's empty.
+	Role     domain.Role `json:"role,omitempty" yaml:"role,omitempty"`
+	Verified bool        `json:"verified,omitempty" yaml:"verified,omitempty"`
+	Banned   bool        `json:"banned,omitempty" yaml:"banned,omitempty"`
+	// Following are the emails of the users they follow.
+	Following []string `json:"following,omitempty" yaml:"following,omitempty"`
+	// Favorites are the slugs of the articles they favorited.
+	Favorites []string `json:"favorites,omitempty" yaml:"favorites,omitempty"`
+	// Blocking and Muting are the emails of the users they block and mute.
+	Blocking []string `json:"blocking,omitempty" yaml:"blocking,omitempty"`
+	Muting   []string `json:"muting,omitempty" yaml:"muting,omitempty"`
+	// PreviousUsernames are the usernames they used to have, oldest first.
+	PreviousUsernames []string `json:"previousUsernames,omitempty" yaml:"previousUsernames,omitempty"`
+	// TwoFactor is only set once they've started enrolling in two-factor authentication.
+	TwoFactor    *FixtureTwoFactor    `json:"twoFactor,omitempty" yaml:"twoFactor,omitempty"`
+	Identities   []FixtureIdentity    `json:"identities,omitempty" yaml:"identities,omitempty"`
+	APITokens    []FixtureAPIToken    `json:"apiTokens,omitempty" yaml:"apiTokens,omitempty"`
+	ReadingLists []FixtureReadingList `json:"readingLists,omitempty" yaml:"readingLists,omitempty"`
+}
+
+// FixtureTwoFactor is the TOTP secret and unused recovery codes of a user, lockouts aren't kept.
+type FixtureTwoFactor struct {
+	Secret  string `json:"secret" yaml:"secret"`
+	Enabled bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// RecoveryCodes are the hashes of the codes, like the password hash they're credentials.
+	RecoveryCodes []string `json:"recoveryCodes,omitempty" yaml:"recoveryCodes,omitempty"`
+	// LastCounter stops the last code they used from being replayed.
+	LastCounter int64 `json:"lastCounter,omitempty" yaml:"lastCounter,omitempty"`
+}
+
+// FixtureIdentity is an external identity a user can login with.
+type FixtureIdentity struct {
+	Provider string `json:"provider" yaml:"provider"`
+	Subject  string `json:"subject" yaml:"subject"`
+}
+
+// FixtureAPIToken is a personal API token, only its hash is kept so the token itself keeps working.
+type FixtureAPIToken struct {
+	Name   string         `json:"name" yaml:"name"`
+	Hash   string         `json:"hash" yaml:"hash"`
+	Scopes []domain.Scope `json:"scopes" yaml:"scopes"`
+}
+
+// FixtureReadingList is a reading list along with the slugs of its articles, in order.
+type FixtureReadingList struct {
+	Slug     string   `json:"slug" yaml:"slug"`
+	Name     string   `json:"name" yaml:"name"`
+	Articles []string `json:"articles,omitempty" yaml:"articles,omitempty"`
+}
+
+// FixtureArticle is an article along with its comments.
+type FixtureArticle struct {
+	// ID is generated on import when it's empty.
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Slug is made from the title on import when it's empty.
+	Slug        string   `json:"slug,omitempty" yaml:"slug,omitempty"`
+	Title       string   `json:"title" yaml:"title"`
+	Description string   `json:"description" yaml:"description"`
+	Body        string   `json:"body" yaml:"body"`
+	TagList     []string `json:"tagList,omitempty" yaml:"tagList,omitempty"`
+	// Author is the email of the user who wrote it.
+	Author string `json:"author" yaml:"author"`
+	Hidden bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	// CreatedAtUTC is the time of the import when it's zero, UpdatedAtUTC is CreatedAtUTC when it's zero.
+	CreatedAtUTC time.Time        `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+	UpdatedAtUTC time.Time        `json:"updatedAt,omitempty" yaml:"updatedAt,omitempty"`
+	Comments     []FixtureComment `json:"comments,omitempty" yaml:"comments,omitempty"`
+}
+
+// FixtureComment is a comment on an article.
+type FixtureComment struct {
+	// ID is the next unused comment id on import when it's zero.
+	ID int `json:"id,omitempty" yaml:"id,omitempty"`
+	// UID is generated on import when it's empty.
+	UID  string `json:"uid,omitempty" yaml:"uid,omitempty"`
+	Body string `json:"body" yaml:"body"`
+	// Author is the email of the user who wrote it.
+	Author string `json:"author" yaml:"author"`
+	Hidden bool   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	// CreatedAtUTC is the time of the import when it's zero.
+	CreatedAtUTC time.Time `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+}
+
+// FixtureReport is a report on an article or one of its comments.
+type FixtureReport struct {
+	// ID is only used to find the report from the audit trail, reports get new ids on import.
+	ID int `json:"id" yaml:"id"`
+	// Reporter is the email of the user who made it.
+	Reporter string `json:"reporter" yaml:"reporter"`
+	Article  string `json:"article" yaml:"article"`
+	// Comment is the id of the reported comment, it's zero when the article was reported.
+	Comment int                 `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Reason  string              `json:"reason" yaml:"reason"`
+	Status  domain.ReportStatus `json:"status" yaml:"status"`
+	// Resolver is the email of the moderator who resolved it.
+	Resolver      string                  `json:"resolver,omitempty" yaml:"resolver,omitempty"`
+	Resolution    domain.ModerationAction `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+	ResolvedAtUTC time.Time               `json:"resolvedAt,omitempty" yaml:"resolvedAt,omitempty"`
+	// CreatedAtUTC is only for reference, reports are made again on import.
+	CreatedAtUTC time.Time `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+}
+
+// FixtureAuditEntry is a single action taken by a moderator.
+type FixtureAuditEntry struct {
+	// Actor is the email of the moderator.
+	Actor   string                  `json:"actor" yaml:"actor"`
+	Action  domain.ModerationAction `json:"action" yaml:"action"`
+	Article string                  `json:"article,omitempty" yaml:"article,omitempty"`
+	Comment int                     `json:"comment,omitempty" yaml:"comment,omitempty"`
+	// User is the email of the user who was moderated.
+	User string `json:"user,omitempty" yaml:"user,omitempty"`
+	// Report is the id of the report in the fixture.
+	Report int    `json:"report,omitempty" yaml:"report,omitempty"`
+	Note   string `json:"note,omitempty" yaml:"note,omitempty"`
+	// CreatedAtUTC is only for reference, entries are recorded again on import.
+	CreatedAtUTC time.Time `json:"createdAt,omitempty" yaml:"createdAt,omitempty"`
+}
+
+// FixtureFormat is the encoding of a fixture.
+type FixtureFormat string
+
+const (
+	// FixtureJSON is a fixture encoded as JSON.
+	FixtureJSON FixtureFormat = "json"
+	// FixtureYAML is a fixture encoded as YAML.
+	FixtureYAML FixtureFormat = "yaml"
+)
+
+// FixtureFormatOf picks the format of the fixture file from its extension, JSON unless it's .yaml or .yml.
+func FixtureFormatOf(path string) FixtureFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FixtureYAML
+	default:
+		return FixtureJSON
+	}
+}
+
+// ReadFixture decodes a fixture in the format.
+func ReadFixture(r io.Reader, f FixtureFormat) (*Fixture, error) {
+	fx := new(Fixture)
+	var err error
+	if f == FixtureYAML {
+		err = yaml.NewDecoder(r).Decode(fx)
+	} else {
+		err = json.NewDecoder(r).Decode(fx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return fx, nil
+}
+
+// ReadFixtureFile decodes the fixture file in the format of its extension.
+func ReadFixtureFile(path string) (*Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadFixture(f, FixtureFormatOf(path))
+}
+
+// Write encodes the fixture in the format.
+func (fx *Fixture) Write(w io.Writer, f FixtureFormat) error {
+	if f == FixtureYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(fx); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fx)
+}
+
+// WriteFile encodes the fixture to the file in the format of its extension.
+func (fx *Fixture) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := fx.Write(f, FixtureFormatOf(path)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Import adds everything in the fixture to the store.
+// Nothing is added unless the users, articles and comments in the fixture are valid and don't conflict with what's already stored,
+// everything else is added afterwards like ImportTo, stopping at the first problem.
+func (r *implementation) Import(fx *Fixture) error {
+	if err := r.importRecords(fx); err != nil {
+		return err
+	}
+
+	// Comments keep their ids
+	return fx.importBelongings(context.Background(), r, func(id int) int {
+		return id
+	})
+}
+
+// importRecords adds the users, follows, favorites, articles and comments in the fixture to the store all at once.
+func (r *implementation) importRecords(fx *Fixture) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	users := make(map[string]*userRecord, len(fx.Users))
+	usernames := make(map[string]interface{}, len(r.users)+len(fx.Users))
+	for _, v := range r.users {
+		usernames[strings.ToLower(v.username)] = nil
+	}
+	for k := range r.previousUsernames {
+		usernames[k] = nil
+	}
+
+	for _, fu := range fx.Users {
+		u := &domain.User{
+			ID:       fu.ID,
+			Email:    fu.Email,
+			Username: fu.Username,
+			Bio:      fu.Bio,
+			Image:    fu.Image,
+			Password: domain.PasswordHash(fu.PasswordHash),
+			Role:     fu.Role,
+			Verified: fu.Verified,
+			Banned:   fu.Banned,
+		}
+		if u.ID == "" {
+			id, err := domain.NewID()
+			if err != nil {
+				return err
+			}
+			u.ID = id
+		}
+		if u.Role == "" {
+			u.Role = domain.RoleUser
+		}
+		if fu.Password != "" {
+			if err := u.RehashPassword(fu.Password); err != nil {
+				return fmt.Errorf("user %v: %w", fu.Email, err)
+			}
+		}
+		if _, err := u.Validate(); err != nil {
+			return fmt.Errorf("user %v: %w", fu.Email, err)
+		}
+
+		em := strings.ToLower(u.Email)
+		_, existing := r.users[em]
+		_, staged := users[em]
+		_, taken := usernames[strings.ToLower(u.Username)]
+		if existing || staged || taken {
+			return fmt.Errorf("user %v: %w", fu.Email, domain.ErrDuplicateUser)
+		}
+		usernames[strings.ToLower(u.Username)] = nil
+
+		users[em] = &userRecord{
+			u.ID,
+			u.Email,
+			u.Username,
+			u.Bio,
+			u.Image,
+			"",
+			"",
+			u.Password,
+			string(u.Role),
+			u.Banned,
+			"",
+			"",
+			u.Verified,
+			"",
+			false,
+			"",
+			0,
+			0,
+			time.Time{},
+		}
+	}
+
+	author := func(em string) (string, bool) {
+		if u, ok := users[strings.ToLower(em)]; ok {
+			return u.email, true
+		}
+		if u, ok := r.users[strings.ToLower(em)]; ok {
+			return u.email, true
+		}
+		return "", false
+	}
+
+	commentIDs := make(map[int]interface{})
+	lastCommentID := r.lastCommentID
+	for _, ar := range r.articles {
+		for _, c := range ar.comments {
+			commentIDs[c.id] = nil
+		}
+	}
+	for _, fa := range fx.Articles {
+		for _, fc := range fa.Comments {
+			if fc.ID <= 0 {
+				continue
+			}
+			if _, ok := commentIDs[fc.ID]; ok {
+				return fmt.Errorf("comment %v: duplicate id", fc.ID)
+			}
+			commentIDs[fc.ID] = nil
+			if fc.ID > lastCommentID {
+				lastCommentID = fc.ID
+			}
+		}
+	}
+
+	articles := make(map[string]*articleRecord, len(fx.Articles))
+	for _, fa := range fx.Articles {
+		a := &domain.Article{
+			ID:          fa.ID,
+			Slug:        fa.Slug,
+			Title:       fa.Title,
+			Description: fa.Description,
+			Body:        fa.Body,
+			TagList:     fa.TagList,
+			AuthorEmail: fa.Author,
+			Hidden:      fa.Hidden,
+		}
+		if a.ID == "" {
+			id, err := domain.NewID()
+			if err != nil {
+				return err
+			}
+			a.ID = id
+		}
+		if a.Slug == "" {
+			a.SetTitle(a.Title)
+		}
+		a.RenderBody()
+		if _, err := a.Validate(); err != nil {
+			return fmt.Errorf("article %v: %w", fa.Title, err)
+		}
+
+		ae, ok := author(a.AuthorEmail)
+		if !ok {
+			return fmt.Errorf("article %v: %w", a.Slug, domain.ErrNoAuthor)
+		}
+
+		s := strings.ToLower(a.Slug)
+		_, existing := r.articles[s]
+		_, staged := articles[s]
+		if existing || staged {
+			return fmt.Errorf("article %v: %w", a.Slug, domain.ErrDuplicateArticle)
+		}
+
+		created := fa.CreatedAtUTC.UTC()
+		if created.IsZero() {
+			created = now
+		}
+		updated := fa.UpdatedAtUTC.UTC()
+		if updated.IsZero() {
+			updated = created
+		}
+
+		cs := make([]commentRecord, 0, len(fa.Comments))
+		for _, fc := range fa.Comments {
+			c := &domain.Comment{
+				ID:          fc.ID,
+				UID:         fc.UID,
+				Body:        fc.Body,
+				AuthorEmail: fc.Author,
+				Hidden:      fc.Hidden,
+			}
+			if c.ID <= 0 {
+				// Comment ids are unique across all articles so they don't depend on the slug
+				lastCommentID++
+				c.ID = lastCommentID
+			}
+			if c.UID == "" {
+				uid, err := domain.NewID()
+				if err != nil {
+					return err
+				}
+				c.UID = uid
+			}
+			c.RenderBody()
+			if _, err := c.Validate(); err != nil {
+				return fmt.Errorf("article %v comment %v: %w", a.Slug, c.ID, err)
+			}
+
+			ce, ok := author(c.AuthorEmail)
+			if !ok {
+				return fmt.Errorf("article %v comment %v: %w", a.Slug, c.ID, domain.ErrUserNotFound)
+			}
+
+			cc := fc.CreatedAtUTC.UTC()
+			if cc.IsZero() {
+				cc = now
+			}
+			cs = append(cs, commentRecord{
+				id:           c.ID,
+				uid:          c.UID,
+				body:         c.Body,
+				bodyHTML:     c.BodyHTML,
+				createdAtUTC: cc,
+				author:       ce,
+				hidden:       c.Hidden,
+			})
+		}
+		sort.Slice(cs, func(i, j int) bool {
+			return cs[i].id < cs[j].id
+		})
+
+		articles[s] = &articleRecord{
+			a.ID,
+			a.Slug,
+			a.Title,
+			a.Description,
+			a.Body,
+			a.BodyHTML,
+			strings.Join(a.TagList, ","),
+			created,
+			updated,
+			ae,
+			cs,
+			a.Hidden,
+		}
+	}
+
+	for _, fu := range fx.Users {
+		following := make(map[string]interface{}, len(fu.Following))
+		for _, e := range fu.Following {
+			fr, ok := users[strings.ToLower(e)]
+			if !ok {
+				fr, ok = r.users[strings.ToLower(e)]
+			}
+			if !ok {
+				return fmt.Errorf("user %v following %v: %w", fu.Email, e, domain.ErrUserNotFound)
+			}
+			following[fr.id] = nil
+		}
+
+		favorites := make(map[string]interface{}, len(fu.Favorites))
+		for _, s := range fu.Favorites {
+			_, existing := r.articles[strings.ToLower(s)]
+			_, staged := articles[strings.ToLower(s)]
+			if !existing && !staged {
+				return fmt.Errorf("user %v favorite %v: %w", fu.Email, s, domain.ErrArticleNotFound)
+			}
+			favorites[strings.ToLower(s)] = nil
+		}
+
+		ur := users[strings.ToLower(fu.Email)]
+		ur.following = joinIDs(following)
+		ur.favorites = joinKeys(favorites)
+	}
+
+	// Everything checks out so it can all be added
+	for k, v := range users {
+		r.users[k] = v
+		r.userIDs[v.id] = k
+		r.indexFollowing(v.id, v.following)
+		r.indexFavorites(v.email, v.favorites)
+	}
+	for _, v := range articles {
+		r.indexArticle(v)
+	}
+	r.lastCommentID = lastCommentID
+
+	return nil
+}
+
+// Export dumps everything in the store as a fixture.
+// Users are ordered by email, articles by when they were created, reports by id and the audit trail oldest first.
+func (r *implementation) Export() *Fixture {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fx := &Fixture{
+		Users:    make([]FixtureUser, 0, len(r.users)),
+		Articles: make([]FixtureArticle, 0, len(r.articles)),
+	}
+
+	for _, u := range r.users {
+		following := make([]string, 0)
+		for k := range r.userEmailsOf(splitKeys(u.following)) {
+			following = append(following, r.users[k].email)
+		}
+		sort.Strings(following)
+
+		favorites := make([]string, 0)
+		for k := range splitKeys(u.favorites) {
+			if a, ok := r.articles[k]; ok {
+				favorites = append(favorites, a.slug)
+			}
+		}
+		sort.Strings(favorites)
+
+		fu := FixtureUser{
+			ID:           u.id,
+			Email:        u.email,
+			Username:     u.username,
+			Bio:          u.bio,
+			Image:        u.image,
+			PasswordHash: string(u.password),
+			Role:         domain.Role(u.role),
+			Verified:     u.verified,
+			Banned:       u.banned,
+			Following:    following,
+			Favorites:    favorites,
+		}
+		r.exportBelongings(u, &fu)
+		fx.Users = append(fx.Users, fu)
+	}
+	sort.Slice(fx.Users, func(i, j int) bool {
+		return strings.ToLower(fx.Users[i].Email) < strings.ToLower(fx.Users[j].Email)
+	})
+
+	for _, a := range r.articles {
+		cs := make([]FixtureComment, 0, len(a.comments))
+		for _, c := range a.comments {
+			cs = append(cs, FixtureComment{
+				ID:           c.id,
+				UID:          c.uid,
+				Body:         c.body,
+				Author:       c.author,
+				Hidden:       c.hidden,
+				CreatedAtUTC: c.createdAtUTC,
+			})
+		}
+
+		fx.Articles = append(fx.Articles, FixtureArticle{
+			ID:           a.id,
+			Slug:         a.slug,
+			Title:        a.title,
+			Description:  a.description,
+			Body:         a.body,
+			TagList:      splitList(a.tagList),
+			Author:       a.author,
+			Hidden:       a.hidden,
+			CreatedAtUTC: a.createdAtUTC,
+			UpdatedAtUTC: a.updatedAtUTC,
+			Comments:     cs,
+		})
+	}
+	sort.Slice(fx.Articles, func(i, j int) bool {
+		if fx.Articles[i].CreatedAtUTC.Equal(fx.Articles[j].CreatedAtUTC) {
+			return fx.Articles[i].Slug < fx.Articles[j].Slug
+		}
+		return fx.Articles[i].CreatedAtUTC.Before(fx.Articles[j].CreatedAtUTC)
+	})
+
+	for _, rr := range r.reports {
+		rep := rr.toDomain()
+		fx.Reports = append(fx.Reports, FixtureReport{
+			ID:            rep.ID,
+			Reporter:      rep.ReporterEmail,
+			Article:       rep.ArticleSlug,
+			Comment:       rep.CommentID,
+			Reason:        rep.Reason,
+			Status:        rep.Status,
+			Resolver:      rep.ResolverEmail,
+			Resolution:    rep.Resolution,
+			ResolvedAtUTC: rep.ResolvedAtUTC,
+			CreatedAtUTC:  rep.CreatedAtUTC,
+		})
+	}
+	sort.Slice(fx.Reports, func(i, j int) bool {
+		return fx.Reports[i].ID < fx.Reports[j].ID
+	})
+
+	for _, ar := range r.audit {
+		fx.Audit = append(fx.Audit, FixtureAuditEntry{
+			Actor:        ar.actor,
+			Action:       domain.ModerationAction(ar.action),
+			Article:      ar.slug,
+			Comment:      ar.commentID,
+			User:         ar.user,
+			Report:       ar.reportID,
+			Note:         ar.note,
+			CreatedAtUTC: ar.createdAtUTC,
+		})
+	}
+
+	return fx
+}
+
+// exportBelongings adds everything else that belongs to the user to their fixture.
+func (r *implementation) exportBelongings(u *userRecord, fu *FixtureUser) {
+	for k := range r.userEmailsOf(splitKeys(u.blocking)) {
+		fu.Blocking = append(fu.Blocking, r.users[k].email)
+	}
+	sort.Strings(fu.Blocking)
+	for k := range r.userEmailsOf(splitKeys(u.muting)) {
+		fu.Muting = append(fu.Muting, r.users[k].email)
+	}
+	sort.Strings(fu.Muting)
+
+	if u.totpSecret != "" {
+		fu.TwoFactor = &FixtureTwoFactor{
+			Secret:        u.totpSecret,
+			Enabled:       u.totpEnabled,
+			RecoveryCodes: splitList(u.recoveryCodes),
+			LastCounter:   u.totpLastCounter,
+		}
+	}
+
+	if usernames := r.previousUsernamesOf(u.id); len(usernames) > 0 {
+		fu.PreviousUsernames = usernames
+	}
+	for _, i := range r.identitiesOf(strings.ToLower(u.email)) {
+		fu.Identities = append(fu.Identities, FixtureIdentity{
+			Provider: i.Provider,
+			Subject:  i.Subject,
+		})
+	}
+
+	lists := make([]*domain.ReadingList, 0)
+	for _, v := range r.readingLists {
+		if strings.EqualFold(v.owner, u.email) {
+			lists = append(lists, v.toDomain())
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		return strings.ToLower(lists[i].Name) < strings.ToLower(lists[j].Name)
+	})
+	for _, l := range lists {
+		fl := FixtureReadingList{
+			Slug: l.Slug,
+			Name: l.Name,
+		}
+		if len(l.ArticleSlugs) > 0 {
+			fl.Articles = l.ArticleSlugs
+		}
+		fu.ReadingLists = append(fu.ReadingLists, fl)
+	}
+
+	tokens := make([]*domain.APIToken, 0)
+	for _, v := range r.apiTokens {
+		if strings.EqualFold(v.owner, u.email) {
+			tokens = append(tokens, v.toDomain())
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return strings.ToLower(tokens[i].Name) < strings.ToLower(tokens[j].Name)
+	})
+	for _, t := range tokens {
+		fu.APITokens = append(fu.APITokens, FixtureAPIToken{
+			Name:   t.Name,
+			Hash:   t.Hash,
+			Scopes: t.Scopes,
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package inmemory_test
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFixture = `
+users:
+  - email: writer@fixture.com
+    username: writer
+    password: fixture-password
+    bio: Writes things.
+    following: [reader@fixture.com]
+    muting: [reader@fixture.com]
+    previousUsernames: [scribe, wordsmith]
+    twoFactor:
+      secret: JBSWY3DPEHPK3PXP
+      enabled: true
+      recoveryCodes: [313a5fb6bc7995803b71fab763fa549f255e3befdce54a50394b061f86edc8ab]
+    identities:
+      - provider: fixture
+        subject: writer-subject
+    apiTokens:
+      - name: scripts
+        hash: not-a-real-token-hash
+        scopes: [read]
+    readingLists:
+      - slug: later
+        name: Later
+        articles: [fixture-title]
+  - email: reader@fixture.com
+    username: reader
+    passwordHash: not-a-real-hash
+    role: moderator
+    favorites: [fixture-title]
+    blocking: [writer@fixture.com]
+articles:
+  - title: Fixture Title
+    description: Fixture description
+    body: Fixture *body*
+    tagList: [fixture, demo]
+    author: writer@fixture.com
+    createdAt: 2023-04-05T06:07:08Z
+    comments:
+      - body: Fixture comment
+        author: reader@fixture.com
+      - id: 1000
+        body: Hidden fixture comment
+        author: writer@fixture.com
+        hidden: true
+reports:
+  - id: 7
+    reporter: reader@fixture.com
+    article: fixture-title
+    comment: 1000
+    reason: Rude
+    status: dismissed
+    resolver: reader@fixture.com
+    resolution: dismiss
+    resolvedAt: 2023-04-06T00:00:00Z
+audit:
+  - actor: reader@fixture.com
+    action: dismiss
+    article: fixture-title
+    comment: 1000
+    report: 7
+    note: Not that rude
+`
+
+// withoutCreated clears when the reports and audit entries were made, they're from when they're imported.
+func withoutCreated(fx *inmemory.Fixture) *inmemory.Fixture {
+	for i := range fx.Reports {
+		fx.Reports[i].CreatedAtUTC = time.Time{}
+	}
+	for i := range fx.Audit {
+		fx.Audit[i].CreatedAtUTC = time.Time{}
+	}
+	return fx
+}
+
+func Test_Fixtures(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	fx, err := inmemory.ReadFixture(strings.NewReader(testFixture), inmemory.FixtureYAML)
+	require.NoError(t, err)
+
+	r := inmemory.NewInstance()
+	require.NoError(t, r.Import(fx))
+
+	w, err := r.GetUserByEmail(ctx, "writer@fixture.com")
+	require.NoError(t, err)
+	assert.NotEmpty(t, w.ID)
+	assert.Equal(t, domain.RoleUser, w.Role)
+	assert.True(t, w.IsFollowing("reader@fixture.com"))
+	ok, err := w.HasPassword("fixture-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	rd, err := r.GetUserByEmail(ctx, "reader@fixture.com")
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleModerator, rd.Role)
+	assert.Equal(t, []byte("not-a-real-hash"), rd.Password)
+	assert.True(t, rd.IsBlocking("writer@fixture.com"))
+	assert.True(t, w.IsMuting("reader@fixture.com"))
+
+	assert.Equal(t, "writer", w.Username)
+	prev, err := r.GetUserByPreviousUsername(ctx, "scribe")
+	require.NoError(t, err)
+	assert.Equal(t, "writer@fixture.com", prev.Email)
+	assert.True(t, w.TwoFactor.Enabled)
+	assert.NoError(t, w.CheckSecondFactor("fixtr-ecovr", time.Now()))
+	linked, err := r.GetUserByIdentity(ctx, "fixture", "writer-subject")
+	require.NoError(t, err)
+	assert.Equal(t, "writer@fixture.com", linked.Email)
+	token, err := r.GetAPITokenByHash(ctx, "not-a-real-token-hash")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Scope{domain.ScopeRead}, token.Scopes)
+	l, err := r.GetReadingList(ctx, "writer@fixture.com", "later")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fixture-title"}, l.ArticleSlugs)
+
+	a, err := r.GetArticleBySlug(ctx, "fixture-title")
+	require.NoError(t, err)
+	assert.Equal(t, 1, a.FavoriteCount)
+	assert.Equal(t, "<p>Fixture <em>body</em></p>\n", a.BodyHTML)
+	assert.Equal(t, 2023, a.CreatedAtUTC.Year())
+	assert.Equal(t, a.CreatedAtUTC, a.UpdatedAtUTC)
+
+	ca, err := r.GetCommentsBySlug(ctx, "fixture-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 1)
+	assert.Equal(t, 1001, ca.Comments[0].ID, "comments without ids are numbered after the highest one")
+
+	rep, err := r.GetReportByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, rep.CommentID)
+	assert.Equal(t, domain.ReportDismissed, rep.Status)
+	assert.Equal(t, 2023, rep.ResolvedAtUTC.Year())
+	audit, err := r.LatestAuditEntries(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, audit, 1)
+	assert.Equal(t, rep.ID, audit[0].ReportID, "because reports get new ids")
+	assert.Equal(t, 1000, audit[0].CommentID)
+
+	// Importing the same thing again conflicts and adds nothing
+	err = r.Import(&inmemory.Fixture{
+		Users: []inmemory.FixtureUser{{
+			Email:        "new@fixture.com",
+			Username:     "new",
+			PasswordHash: "not-a-real-hash",
+		}},
+		Articles: []inmemory.FixtureArticle{{
+			Title:       "Fixture Title",
+			Description: "Fixture description",
+			Body:        "Fixture body",
+			Author:      "new@fixture.com",
+		}},
+	})
+	assert.ErrorIs(t, err, domain.ErrDuplicateArticle)
+	_, err = r.GetUserByEmail(ctx, "new@fixture.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	err = r.Import(&inmemory.Fixture{
+		Users: []inmemory.FixtureUser{{
+			Email:        "follower@fixture.com",
+			Username:     "follower",
+			PasswordHash: "not-a-real-hash",
+			Following:    []string{"nobody@fixture.com"},
+		}},
+	})
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	// Exports round trip through both formats
+	exported := r.Export()
+	for _, f := range []inmemory.FixtureFormat{inmemory.FixtureJSON, inmemory.FixtureYAML} {
+		var buf bytes.Buffer
+		require.NoError(t, exported.Write(&buf, f))
+
+		read, err := inmemory.ReadFixture(&buf, f)
+		require.NoError(t, err)
+
+		rt := inmemory.NewInstance()
+		require.NoError(t, rt.Import(read))
+		assert.Equal(t, withoutCreated(exported), withoutCreated(rt.Export()), f)
+	}
+}
+
+func Test_Seed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	scale := inmemory.SeedScale{
+		Users:              20,
+		Articles:           50,
+		CommentsPerArticle: 4,
+		FollowsPerUser:     5,
+		FavoritesPerUser:   5,
+	}
+	fx, err := inmemory.Seed(scale, "seed-password", rand.New(rand.NewSource(42)))
+	require.NoError(t, err)
+	assert.Len(t, fx.Users, scale.Users)
+	assert.Len(t, fx.Articles, scale.Articles)
+
+	again, err := inmemory.Seed(scale, "seed-password", rand.New(rand.NewSource(42)))
+	require.NoError(t, err)
+	assert.Equal(t, fx.Users[0].Email, again.Users[0].Email)
+	assert.Equal(t, fx.Articles[0].Slug, again.Articles[0].Slug)
+
+	r := inmemory.NewInstance()
+	require.NoError(t, r.Import(fx))
+
+	u, err := r.GetUserByEmail(ctx, fx.Users[0].Email)
+	require.NoError(t, err)
+	ok, err := u.HasPassword("seed-password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	latest, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Limit: scale.Articles})
+	require.NoError(t, err)
+	assert.Len(t, latest, scale.Articles)
+}
+
+func Test_Transfer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	r, _ := seeded(t, inmemory.SeedScale{
+		Users:              10,
+		Articles:           20,
+		CommentsPerArticle: 3,
+		FollowsPerUser:     3,
+		FavoritesPerUser:   3,
+	})
+	fx, err := inmemory.ReadFixture(strings.NewReader(testFixture), inmemory.FixtureYAML)
+	require.NoError(t, err)
+	require.NoError(t, r.Import(fx))
+	_, err = r.UpdateArticleBySlug(ctx, "fixture-title", func(a *domain.Article) (*domain.Article, error) {
+		a.Hidden = true
+		return a, nil
+	})
+	require.NoError(t, err)
+
+	// Going through the repository finds everything an export does, hidden or not
+	exported, err := inmemory.ExportFrom(ctx, r)
+	require.NoError(t, err)
+	assert.Equal(t, r.Export(), exported)
+
+	moved := inmemory.NewInstance()
+	require.NoError(t, exported.ImportTo(ctx, moved))
+	again, err := inmemory.ExportFrom(ctx, moved)
+	require.NoError(t, err)
+
+	assert.Equal(t, exported.Users, again.Users)
+	w, err := moved.GetUserByEmail(ctx, "writer@fixture.com")
+	require.NoError(t, err)
+	code, err := domain.TOTPCode("JBSWY3DPEHPK3PXP", time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, w.CheckSecondFactor(code, time.Now()), "because two-factor is still required")
+
+	require.Len(t, again.Reports, len(exported.Reports))
+	require.Len(t, again.Audit, len(exported.Audit))
+	for i, rep := range again.Reports {
+		e := exported.Reports[i]
+		assert.Equal(t, e.Reason, rep.Reason)
+		assert.Equal(t, e.Status, rep.Status)
+		assert.Equal(t, e.ResolvedAtUTC, rep.ResolvedAtUTC)
+		assert.Equal(t, rep.Comment, again.Audit[i].Comment, "because comments get new ids")
+		assert.Equal(t, rep.ID, again.Audit[i].Report, "because reports get new ids")
+	}
+	reported := ""
+	for _, a := range again.Articles {
+		for _, c := range a.Comments {
+			if c.ID == again.Reports[0].Comment {
+				reported = c.Body
+			}
+		}
+	}
+	assert.Equal(t, "Hidden fixture comment", reported, "because the report is still about the same comment")
+	require.Len(t, again.Articles, len(exported.Articles))
+	for i, a := range again.Articles {
+		e := exported.Articles[i]
+		assert.Equal(t, e.Slug, a.Slug, "because articles are imported oldest first")
+		assert.Equal(t, e.ID, a.ID)
+		assert.Equal(t, e.Hidden, a.Hidden)
+		assert.True(t, e.CreatedAtUTC.Equal(a.CreatedAtUTC), "because articles keep when they were created")
+		assert.True(t, e.UpdatedAtUTC.Equal(a.UpdatedAtUTC), "because articles keep when they were updated")
+		require.Len(t, a.Comments, len(e.Comments))
+		for j, c := range a.Comments {
+			assert.Equal(t, e.Comments[j].Body, c.Body)
+			assert.Equal(t, e.Comments[j].Author, c.Author)
+			assert.Equal(t, e.Comments[j].Hidden, c.Hidden)
+			assert.True(t, e.Comments[j].CreatedAtUTC.Equal(c.CreatedAtUTC), "because comments keep when they were created")
+		}
+	}
+
+	// Importing into a store that already has the users stops at the first one
+	err = exported.ImportTo(ctx, moved)
+	assert.ErrorIs(t, err, domain.ErrDuplicateUser)
+}
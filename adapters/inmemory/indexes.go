@@ -0,0 +1,124 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// articleIndex is a list of articles kept in the order they're listed in, most recently updated first,
+// so a page of them can be read off the front without sorting everything.
+type articleIndex []*articleRecord
+
+// listedBefore is the order articles are listed in, ties are broken by id so the order is stable.
+func listedBefore(a *articleRecord, b *articleRecord) bool {
+	if !a.updatedAtUTC.Equal(b.updatedAtUTC) {
+		return a.updatedAtUTC.After(b.updatedAtUTC)
+	}
+	return a.id > b.id
+}
+
+func (ix articleIndex) search(ar *articleRecord) int {
+	return sort.Search(len(ix), func(i int) bool {
+		return !listedBefore(ix[i], ar)
+	})
+}
+
+func (ix articleIndex) insert(ar *articleRecord) articleIndex {
+	i := ix.search(ar)
+	ix = append(ix, nil)
+	copy(ix[i+1:], ix[i:])
+	ix[i] = ar
+	return ix
+}
+
+func (ix articleIndex) remove(ar *articleRecord) articleIndex {
+	for i := ix.search(ar); i < len(ix) && !listedBefore(ar, ix[i]); i++ {
+		if ix[i] == ar {
+			copy(ix[i:], ix[i+1:])
+			ix[len(ix)-1] = nil
+			return ix[:len(ix)-1]
+		}
+	}
+	return ix
+}
+
+// indexArticle adds the article to the store and all of the indexes of it.
+func (r *implementation) indexArticle(ar *articleRecord) {
+	r.articles[strings.ToLower(ar.slug)] = ar
+	r.articleIDs[ar.id] = strings.ToLower(ar.slug)
+	r.latest = r.latest.insert(ar)
+	for t := range splitKeys(strings.ToLower(ar.tagList)) {
+		r.byTag[t] = r.byTag[t].insert(ar)
+	}
+	a := strings.ToLower(ar.author)
+	r.byAuthor[a] = r.byAuthor[a].insert(ar)
+}
+
+// unindexArticle removes the article from the store and all of the indexes of it.
+func (r *implementation) unindexArticle(ar *articleRecord) {
+	delete(r.articles, strings.ToLower(ar.slug))
+	delete(r.articleIDs, ar.id)
+	r.latest = r.latest.remove(ar)
+	for t := range splitKeys(strings.ToLower(ar.tagList)) {
+		if r.byTag[t] = r.byTag[t].remove(ar); len(r.byTag[t]) == 0 {
+			delete(r.byTag, t)
+		}
+	}
+	a := strings.ToLower(ar.author)
+	if r.byAuthor[a] = r.byAuthor[a].remove(ar); len(r.byAuthor[a]) == 0 {
+		delete(r.byAuthor, a)
+	}
+}
+
+// Reindex rebuilds the article indexes, the id lookups and the reverse indexes of follows and favorites from the records.
+func (r *implementation) Reindex(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.userIDs = make(map[string]string, len(r.users))
+	r.followers = make(map[string]map[string]interface{})
+	r.favoriters = make(map[string]map[string]interface{})
+	for k, v := range r.users {
+		r.userIDs[v.id] = k
+		r.indexFollowing(v.id, v.following)
+		r.indexFavorites(v.email, v.favorites)
+	}
+
+	r.articleIDs = make(map[string]string, len(r.articles))
+	r.latest = make(articleIndex, 0, len(r.articles))
+	r.byTag = make(map[string]articleIndex)
+	r.byAuthor = make(map[string]articleIndex)
+	for _, v := range r.articles {
+		r.indexArticle(v)
+	}
+
+	return nil
+}
+
+// merged reads the indexes as if they were one, in the order articles are listed in.
+// Each article is read once even if it's in more than one of them.
+func merged(ixs ...articleIndex) func() *articleRecord {
+	heads := make([]int, len(ixs))
+	var prev *articleRecord
+	return func() *articleRecord {
+		for {
+			next := -1
+			for i, ix := range ixs {
+				if heads[i] < len(ix) && (next < 0 || listedBefore(ix[heads[i]], ixs[next][heads[next]])) {
+					next = i
+				}
+			}
+			if next < 0 {
+				return nil
+			}
+
+			ar := ixs[next][heads[next]]
+			heads[next]++
+			if ar != prev {
+				prev = ar
+				return ar
+			}
+		}
+	}
+}
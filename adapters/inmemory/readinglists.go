@@ -0,0 +1,174 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+func readingListKey(owner string, slug string) string {
+	return strings.ToLower(owner) + "/" + strings.ToLower(slug)
+}
+
+// CreateReadingList creates a new reading list.
+func (r *implementation) CreateReadingList(_ context.Context, l *domain.ReadingList) (*domain.ReadingList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[strings.ToLower(l.OwnerEmail)]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	k := readingListKey(l.OwnerEmail, l.Slug)
+	if _, ok := r.readingLists[k]; ok {
+		return nil, domain.ErrDuplicateReadingList
+	}
+
+	now := time.Now().UTC()
+	r.readingLists[k] = &readingListRecord{
+		l.OwnerEmail,
+		l.Slug,
+		l.Name,
+		r.savedArticles(l.ArticleSlugs),
+		now,
+		now,
+	}
+
+	return r.readingLists[k].toDomain(), nil
+}
+
+// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+func (r *implementation) ReadingListsByOwner(_ context.Context, e string) ([]domain.ReadingList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.users[strings.ToLower(e)]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	results := make([]domain.ReadingList, 0)
+	for _, v := range r.readingLists {
+		if strings.ToLower(v.owner) == strings.ToLower(e) {
+			results = append(results, *v.toDomain())
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+
+	return results, nil
+}
+
+// GetReadingList gets a single reading list with the given owner email and slug.
+func (r *implementation) GetReadingList(_ context.Context, e string, s string) (*domain.ReadingList, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if l, ok := r.readingLists[readingListKey(e, s)]; ok {
+		return l.toDomain(), nil
+	}
+
+	return nil, domain.ErrReadingListNotFound
+}
+
+// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+// in the order of the list. Hidden articles are not included.
+func (r *implementation) ReadingListArticles(_ context.Context, e string, s string) ([]domain.AuthoredArticle, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.readingLists[readingListKey(e, s)]
+	if !ok {
+		return nil, domain.ErrReadingListNotFound
+	}
+
+	results := make([]domain.AuthoredArticle, 0)
+	for _, as := range l.toDomain().ArticleSlugs {
+		a, err := r.getArticleBySlug(as)
+		if err != nil || a.Hidden {
+			continue
+		}
+		results = append(results, *a)
+	}
+
+	return results, nil
+}
+
+// UpdateReadingList finds a single reading list based on its owner email and slug,
+// then applies the provide mutations.
+func (r *implementation) UpdateReadingList(_ context.Context, e string, s string, update func(*domain.ReadingList) (*domain.ReadingList, error)) (*domain.ReadingList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := readingListKey(e, s)
+	found, ok := r.readingLists[k]
+	if !ok {
+		return nil, domain.ErrReadingListNotFound
+	}
+
+	l, err := update(found.toDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	nk := readingListKey(found.owner, l.Slug)
+	if _, ok := r.readingLists[nk]; ok && nk != k {
+		return nil, domain.ErrDuplicateReadingList
+	}
+
+	delete(r.readingLists, k)
+	r.readingLists[nk] = &readingListRecord{
+		found.owner,
+		l.Slug,
+		l.Name,
+		r.savedArticles(l.ArticleSlugs),
+		found.createdAtUTC,
+		time.Now().UTC(),
+	}
+
+	return r.readingLists[nk].toDomain(), nil
+}
+
+// DeleteReadingList deletes the reading list if it exists.
+func (r *implementation) DeleteReadingList(_ context.Context, l *domain.ReadingList) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l == nil {
+		return nil
+	}
+
+	delete(r.readingLists, readingListKey(l.OwnerEmail, l.Slug))
+	return nil
+}
+
+// savedArticles joins the slugs of the articles that exist into the keys of a record, keeping their order.
+func (r *implementation) savedArticles(slugs []string) string {
+	ks := make([]string, 0, len(slugs))
+	for _, s := range slugs {
+		if _, ok := r.articles[strings.ToLower(s)]; ok {
+			ks = append(ks, strings.ToLower(s))
+		}
+	}
+	return strings.Join(ks, ",")
+}
+
+func (l *readingListRecord) toDomain() *domain.ReadingList {
+	slugs := make([]string, 0)
+	for _, s := range strings.Split(l.articles, ",") {
+		if s != "" {
+			slugs = append(slugs, s)
+		}
+	}
+
+	return &domain.ReadingList{
+		OwnerEmail:   l.owner,
+		Slug:         l.slug,
+		Name:         l.name,
+		ArticleSlugs: slugs,
+		CreatedAtUTC: l.createdAtUTC,
+		UpdatedAtUTC: l.updatedAtUTC,
+	}
+}
@@ -1,6 +1,8 @@
 package inmemory
 
 import (
+	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -8,22 +10,83 @@ import (
 )
 
 // NewInstance creates a new instance of the In-Memory store with the repository interface implementations
-func NewInstance() domain.Repository {
+func NewInstance() Instance {
 	i := &implementation{
-		&sync.Mutex{},
+		&sync.RWMutex{},
 		make(map[string]*userRecord),
 		make(map[string]*articleRecord),
+		make(map[string]string),
+		make(map[string]string),
+		make(articleIndex, 0),
+		make(map[string]articleIndex),
+		make(map[string]articleIndex),
+		make(map[int]*reportRecord),
+		make([]auditRecord, 0),
+		make(map[string]map[string]interface{}),
+		make(map[string]map[string]interface{}),
+		make(map[string]*readingListRecord),
+		make(map[string]string),
+		make(map[int]*apiTokenRecord),
+		make(map[string]*previousUsernameRecord),
+		0,
+		0,
 	}
 	return i
 }
 
+// Instance is the repository along with importing and exporting fixtures of what's in it.
+type Instance interface {
+	domain.Repository
+	// Import adds everything in the fixture to the store.
+	Import(*Fixture) error
+	// Export dumps the store as a fixture.
+	Export() *Fixture
+	// Reindex rebuilds the indexes kept alongside the records.
+	Reindex(context.Context) error
+}
+
 type implementation struct {
-	mu       *sync.Mutex
+	// mu guards everything else, the exported methods take it
+	// and the unexported ones they share expect it to already be held.
+	mu       *sync.RWMutex
 	users    map[string]*userRecord
 	articles map[string]*articleRecord
+	// userIDs are the lowercased emails of users keyed by their id.
+	userIDs map[string]string
+	// articleIDs are the lowercased slugs of articles keyed by their id.
+	articleIDs map[string]string
+	// latest, byTag and byAuthor index the articles in the order they're listed,
+	// byTag is keyed by the lowercased tag and byAuthor by the lowercased author email.
+	latest   articleIndex
+	byTag    map[string]articleIndex
+	byAuthor map[string]articleIndex
+	reports  map[int]*reportRecord
+	audit    []auditRecord
+	// followers is the reverse index of userRecord.following, keyed by user id like it.
+	followers map[string]map[string]interface{}
+	// favoriters is the reverse index of userRecord.favorites.
+	favoriters   map[string]map[string]interface{}
+	readingLists map[string]*readingListRecord
+	// identities are the emails of users keyed by the provider and subject of their linked external identities.
+	identities map[string]string
+	apiTokens  map[int]*apiTokenRecord
+	// previousUsernames are keyed by the lowercased username.
+	previousUsernames map[string]*previousUsernameRecord
+	// lastCommentID is the id of the newest comment on any article, ids are never reused.
+	lastCommentID int
+	// lastAPITokenID is the id of the newest API token, ids aren't reused after tokens are revoked.
+	lastAPITokenID int
+}
+
+// Ping always succeeds, everything is already in memory.
+func (r *implementation) Ping(context.Context) error {
+	return nil
 }
 
+// userRecord is a user, following, blocking and muting are the comma separated ids of the other users
+// so they don't change when the other users change their email.
 type userRecord struct {
+	id        string
 	email     string
 	username  string
 	bio       string
@@ -31,6 +94,18 @@ type userRecord struct {
 	following string
 	favorites string
 	password  []byte
+	role      string
+	banned    bool
+	blocking  string
+	muting    string
+	verified  bool
+	// totp* are the two-factor setup, recoveryCodes are comma separated hashes
+	totpSecret      string
+	totpEnabled     bool
+	recoveryCodes   string
+	totpLastCounter int64
+	totpFailures    int
+	totpLockedUntil time.Time
 }
 
 func (u userRecord) GetUsername() string {
@@ -46,25 +121,205 @@ func (u userRecord) GetImage() string {
 	return u.image
 }
 
+type previousUsernameRecord struct {
+	username     string
+	user         string
+	changedAtUTC time.Time
+}
+
 type articleRecord struct {
+	id           string
 	slug         string
 	title        string
 	description  string
 	body         string
+	bodyHTML     string
 	tagList      string
 	createdAtUTC time.Time
 	updatedAtUTC time.Time
 	author       string
 	comments     []commentRecord
+	hidden       bool
 }
 
 type commentRecord struct {
 	id           int
+	uid          string
 	body         string
+	bodyHTML     string
 	createdAtUTC time.Time
 	author       string
+	hidden       bool
+}
+
+type reportRecord struct {
+	id            int
+	reporter      string
+	slug          string
+	commentID     int
+	reason        string
+	status        string
+	createdAtUTC  time.Time
+	resolver      string
+	resolution    string
+	resolvedAtUTC time.Time
+}
+
+type readingListRecord struct {
+	owner        string
+	slug         string
+	name         string
+	articles     string
+	createdAtUTC time.Time
+	updatedAtUTC time.Time
+}
+
+type apiTokenRecord struct {
+	id           int
+	owner        string
+	name         string
+	hash         string
+	scopes       string
+	createdAtUTC time.Time
+}
+
+type auditRecord struct {
+	id           int
+	actor        string
+	action       string
+	slug         string
+	commentID    int
+	user         string
+	reportID     int
+	note         string
+	createdAtUTC time.Time
 }
 
 // articles is a (super inefficient) in-memory repository implementation for the articledomain.Repository.
 type articles struct {
 }
+
+// splitKeys splits the comma separated keys of a record into a set.
+func splitKeys(keys string) map[string]interface{} {
+	ks := strings.Split(keys, ",")
+	set := make(map[string]interface{}, len(ks))
+	for _, k := range ks {
+		if k != "" {
+			set[k] = nil
+		}
+	}
+	return set
+}
+
+// splitList splits the comma separated values of a record keeping their order.
+func splitList(values string) []string {
+	var vs []string
+	for _, v := range strings.Split(values, ",") {
+		if v != "" {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+// indexFollowing adds the follower's id to the reverse index for each of the users they follow.
+func (r *implementation) indexFollowing(follower string, following string) {
+	for k := range splitKeys(following) {
+		if _, ok := r.followers[k]; !ok {
+			r.followers[k] = make(map[string]interface{})
+		}
+		r.followers[k][follower] = nil
+	}
+}
+
+// unindexFollowing removes the follower's id from the reverse index for each of the users they follow.
+func (r *implementation) unindexFollowing(follower string, following string) {
+	for k := range splitKeys(following) {
+		delete(r.followers[k], follower)
+		if len(r.followers[k]) == 0 {
+			delete(r.followers, k)
+		}
+	}
+}
+
+// indexFavorites adds the user to the reverse index for each of the articles they favorited.
+func (r *implementation) indexFavorites(user string, favorites string) {
+	user = strings.ToLower(user)
+	for k := range splitKeys(favorites) {
+		if _, ok := r.favoriters[k]; !ok {
+			r.favoriters[k] = make(map[string]interface{})
+		}
+		r.favoriters[k][user] = nil
+	}
+}
+
+// unindexFavorites removes the user from the reverse index for each of the articles they favorited.
+func (r *implementation) unindexFavorites(user string, favorites string) {
+	user = strings.ToLower(user)
+	for k := range splitKeys(favorites) {
+		delete(r.favoriters[k], user)
+		if len(r.favoriters[k]) == 0 {
+			delete(r.favoriters, k)
+		}
+	}
+}
+
+// joinKeys joins a set of keys into the comma separated keys of a record.
+func joinKeys(set map[string]interface{}) string {
+	ks := make([]string, 0, len(set))
+	for k := range set {
+		if k != "" {
+			ks = append(ks, k)
+		}
+	}
+	return strings.ToLower(strings.Join(ks, ","))
+}
+
+// joinIDs joins a set of ids into the comma separated keys of a record,
+// unlike joinKeys it doesn't lowercase them.
+func joinIDs(set map[string]interface{}) string {
+	ks := make([]string, 0, len(set))
+	for k := range set {
+		if k != "" {
+			ks = append(ks, k)
+		}
+	}
+	return strings.Join(ks, ",")
+}
+
+// userIDsOf gets the comma separated ids of the users with the given emails, emails of nobody are left out.
+func (r *implementation) userIDsOf(emails map[string]interface{}) string {
+	ids := make(map[string]interface{}, len(emails))
+	for e := range emails {
+		if u, ok := r.users[strings.ToLower(e)]; ok {
+			ids[u.id] = nil
+		}
+	}
+	return joinIDs(ids)
+}
+
+// userEmailsOf gets the lowercased emails of the users with the given ids, ids of deleted users are left out.
+func (r *implementation) userEmailsOf(ids map[string]interface{}) map[string]interface{} {
+	emails := make(map[string]interface{}, len(ids))
+	for id := range ids {
+		if e, ok := r.userIDs[id]; ok {
+			emails[e] = nil
+		}
+	}
+	return emails
+}
+
+// replaceKey replaces a single key in the ordered comma separated keys of a record,
+// an empty replacement removes the key.
+func replaceKey(keys string, prev string, next string) string {
+	ks := make([]string, 0)
+	for _, k := range strings.Split(keys, ",") {
+		if k == prev {
+			k = next
+		}
+		if k != "" {
+			ks = append(ks, k)
+		}
+	}
+	return strings.Join(ks, ",")
+}
@@ -0,0 +1,162 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// CreateReport creates a new report.
+func (r *implementation) CreateReport(_ context.Context, rep *domain.Report) (*domain.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.articles[strings.ToLower(rep.ArticleSlug)]; !ok {
+		return nil, domain.ErrArticleNotFound
+	}
+	if _, ok := r.users[strings.ToLower(rep.ReporterEmail)]; !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	id := len(r.reports) + 1
+	r.reports[id] = &reportRecord{
+		id,
+		rep.ReporterEmail,
+		strings.ToLower(rep.ArticleSlug),
+		rep.CommentID,
+		rep.Reason,
+		string(domain.ReportOpen),
+		time.Now().UTC(),
+		"",
+		"",
+		time.Time{},
+	}
+
+	return r.reports[id].toDomain(), nil
+}
+
+// GetReportByID gets a single report with the given id.
+func (r *implementation) GetReportByID(_ context.Context, id int) (*domain.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rr, ok := r.reports[id]
+	if !ok {
+		return nil, domain.ErrReportNotFound
+	}
+
+	return rr.toDomain(), nil
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (r *implementation) ReportsByStatus(_ context.Context, s domain.ReportStatus, limit int, offset int) ([]domain.Report, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ordered := make([]*reportRecord, 0, len(r.reports))
+	for _, rr := range r.reports {
+		if rr.status == string(s) {
+			ordered = append(ordered, rr)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].id < ordered[j].id
+	})
+
+	results := []domain.Report{}
+	for i := max(offset, 0); i < len(ordered) && len(results) < limit; i++ {
+		results = append(results, *ordered[i].toDomain())
+	}
+
+	return results, nil
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (r *implementation) UpdateReportByID(_ context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (*domain.Report, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rr, ok := r.reports[id]
+	if !ok {
+		return nil, domain.ErrReportNotFound
+	}
+
+	rep, err := update(rr.toDomain())
+	if err != nil {
+		return nil, err
+	}
+
+	rr.status = string(rep.Status)
+	rr.resolver = rep.ResolverEmail
+	rr.resolution = string(rep.Resolution)
+	rr.resolvedAtUTC = rep.ResolvedAtUTC
+
+	return rr.toDomain(), nil
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (r *implementation) CreateAuditEntry(_ context.Context, e *domain.AuditEntry) (*domain.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ar := auditRecord{
+		len(r.audit) + 1,
+		e.ActorEmail,
+		string(e.Action),
+		e.ArticleSlug,
+		e.CommentID,
+		e.UserEmail,
+		e.ReportID,
+		e.Note,
+		time.Now().UTC(),
+	}
+	r.audit = append(r.audit, ar)
+
+	return ar.toDomain(), nil
+}
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (r *implementation) LatestAuditEntries(_ context.Context, limit int, offset int) ([]domain.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := []domain.AuditEntry{}
+	for i := len(r.audit) - 1 - max(offset, 0); i >= 0 && len(results) < limit; i-- {
+		results = append(results, *r.audit[i].toDomain())
+	}
+
+	return results, nil
+}
+
+func (rr *reportRecord) toDomain() *domain.Report {
+	return &domain.Report{
+		ID:            rr.id,
+		ReporterEmail: rr.reporter,
+		ArticleSlug:   rr.slug,
+		CommentID:     rr.commentID,
+		Reason:        rr.reason,
+		Status:        domain.ReportStatus(rr.status),
+		CreatedAtUTC:  rr.createdAtUTC,
+		ResolverEmail: rr.resolver,
+		Resolution:    domain.ModerationAction(rr.resolution),
+		ResolvedAtUTC: rr.resolvedAtUTC,
+	}
+}
+
+func (ar auditRecord) toDomain() *domain.AuditEntry {
+	return &domain.AuditEntry{
+		ID:           ar.id,
+		ActorEmail:   ar.actor,
+		Action:       domain.ModerationAction(ar.action),
+		ArticleSlug:  ar.slug,
+		CommentID:    ar.commentID,
+		UserEmail:    ar.user,
+		ReportID:     ar.reportID,
+		Note:         ar.note,
+		CreatedAtUTC: ar.createdAtUTC,
+	}
+}
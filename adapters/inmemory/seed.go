@@ -0,0 +1,189 @@
+package inmemory
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// SeedScale is how much fake data Seed generates.
+type SeedScale struct {
+	Users    int
+	Articles int
+	// The per user and per article amounts are the most there will be, each gets a random amount up to it.
+	CommentsPerArticle int
+	FollowsPerUser     int
+	FavoritesPerUser   int
+}
+
+// DefaultSeedScale is enough fake data for a demo to look lived in.
+var DefaultSeedScale = SeedScale{
+	Users:              50,
+	Articles:           200,
+	CommentsPerArticle: 8,
+	FollowsPerUser:     10,
+	FavoritesPerUser:   20,
+}
+
+// Seed generates a fixture of realistic looking fake data at the scale.
+// Every user has the same password (hashed once so large fixtures are quick to generate)
+// and the same source of randomness always generates the same users and articles.
+func Seed(scale SeedScale, password string, rnd *rand.Rand) (*Fixture, error) {
+	u := new(domain.User)
+	if err := u.RehashPassword(password); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	fx := &Fixture{
+		Users:    make([]FixtureUser, 0, scale.Users),
+		Articles: make([]FixtureArticle, 0, scale.Articles),
+	}
+
+	for i := 0; i < scale.Users; i++ {
+		first := pick(rnd, seedFirstNames)
+		last := pick(rnd, seedLastNames)
+		// The index keeps emails and usernames unique no matter how many there are
+		handle := fmt.Sprintf("%v%v%v", strings.ToLower(first), strings.ToLower(last), i+1)
+
+		fx.Users = append(fx.Users, FixtureUser{
+			Email:        handle + "@example.com",
+			Username:     handle,
+			Bio:          fmt.Sprintf("%v %v %v.", first, pick(rnd, seedBioVerbs), pick(rnd, seedTopics)),
+			PasswordHash: string(u.Password),
+			Verified:     rnd.Intn(10) > 0,
+		})
+	}
+	if len(fx.Users) == 0 {
+		return fx, nil
+	}
+
+	for i := 0; i < scale.Articles; i++ {
+		topic := pick(rnd, seedTopics)
+		title := strings.TrimSpace(fmt.Sprintf("%v %v %v", pick(rnd, seedTitleOpeners), topic, pick(rnd, seedTitleClosers)))
+		// A year of articles, the comments are sometime after the article
+		created := now.Add(-time.Duration(rnd.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+
+		tags := make([]string, 0, 3)
+		for _, t := range rnd.Perm(len(seedTags))[:1+rnd.Intn(3)] {
+			tags = append(tags, seedTags[t])
+		}
+
+		cs := make([]FixtureComment, 0)
+		if scale.CommentsPerArticle > 0 {
+			for c := rnd.Intn(scale.CommentsPerArticle + 1); c > 0; c-- {
+				cs = append(cs, FixtureComment{
+					Body:         pick(rnd, seedComments),
+					Author:       fx.Users[rnd.Intn(len(fx.Users))].Email,
+					CreatedAtUTC: created.Add(time.Duration(rnd.Int63n(int64(now.Sub(created)) + 1))).Truncate(time.Second),
+				})
+			}
+		}
+
+		fx.Articles = append(fx.Articles, FixtureArticle{
+			// The index keeps slugs unique when titles aren't
+			Slug:         fmt.Sprintf("%v-%v", domainSlug(title), i+1),
+			Title:        title,
+			Description:  fmt.Sprintf("%v about %v.", pick(rnd, seedDescriptions), topic),
+			Body:         seedBody(rnd, topic),
+			TagList:      tags,
+			Author:       fx.Users[rnd.Intn(len(fx.Users))].Email,
+			CreatedAtUTC: created,
+			UpdatedAtUTC: created,
+			Comments:     cs,
+		})
+	}
+
+	for i := range fx.Users {
+		if scale.FollowsPerUser > 0 {
+			for _, f := range rnd.Perm(len(fx.Users))[:min(rnd.Intn(scale.FollowsPerUser+1), len(fx.Users))] {
+				if f != i {
+					fx.Users[i].Following = append(fx.Users[i].Following, fx.Users[f].Email)
+				}
+			}
+		}
+		if scale.FavoritesPerUser > 0 && len(fx.Articles) > 0 {
+			for _, a := range rnd.Perm(len(fx.Articles))[:min(rnd.Intn(scale.FavoritesPerUser+1), len(fx.Articles))] {
+				fx.Users[i].Favorites = append(fx.Users[i].Favorites, fx.Articles[a].Slug)
+			}
+		}
+	}
+
+	return fx, nil
+}
+
+// domainSlug makes the same slug a new article with the title would have.
+func domainSlug(title string) string {
+	a := new(domain.Article)
+	a.SetTitle(title)
+	return a.Slug
+}
+
+func seedBody(rnd *rand.Rand, topic string) string {
+	paragraphs := make([]string, 0, 4)
+	paragraphs = append(paragraphs, fmt.Sprintf("## Why %v", topic))
+	for p := 2 + rnd.Intn(3); p > 0; p-- {
+		sentences := make([]string, 0, 5)
+		for s := 3 + rnd.Intn(3); s > 0; s-- {
+			sentences = append(sentences, fmt.Sprintf(pick(rnd, seedSentences), topic))
+		}
+		paragraphs = append(paragraphs, strings.Join(sentences, " "))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func pick(rnd *rand.Rand, from []string) string {
+	return from[rnd.Intn(len(from))]
+}
+
+var (
+	seedFirstNames = []string{
+		"Ada", "Alan", "Barbara", "Brian", "Carla", "Dennis", "Edsger", "Frances", "Grace", "Guido",
+		"Hedy", "Ivan", "Joan", "Ken", "Linus", "Margaret", "Niklaus", "Radia", "Rob", "Sophie",
+	}
+	seedLastNames = []string{
+		"Allen", "Backus", "Cerf", "Dijkstra", "Engelbart", "Floyd", "Goldberg", "Hamilton", "Hopper", "Johnson",
+		"Kernighan", "Lamport", "Liskov", "Perlman", "Pike", "Ritchie", "Shaw", "Thompson", "Wilson", "Wirth",
+	}
+	seedBioVerbs = []string{
+		"writes about", "is learning", "teaches", "has strong opinions on", "builds things with", "can't stop thinking about",
+	}
+	seedTopics = []string{
+		"Go", "databases", "distributed systems", "testing", "code review", "open source", "compilers",
+		"web performance", "accessibility", "observability", "type systems", "concurrency", "API design", "caching",
+	}
+	seedTags = []string{
+		"go", "programming", "databases", "devops", "testing", "career", "opensource", "web", "performance", "security",
+	}
+	seedTitleOpeners = []string{
+		"What I Learned About", "A Gentle Introduction to", "Ten Mistakes With", "Rethinking", "Notes on",
+		"The Hidden Cost of", "Getting Started With", "Lessons From a Year of",
+	}
+	seedTitleClosers = []string{
+		"in Production", "for Beginners", "the Hard Way", "at Scale", "in 2024", "Without the Hype", "",
+	}
+	seedDescriptions = []string{
+		"A short post", "Some hard-won lessons", "A practical guide", "A few opinions", "Everything I wish I knew",
+	}
+	seedSentences = []string{
+		"Most of what people say about %v is true, but not in the way they think.",
+		"The first time I used %v I got almost everything wrong.",
+		"It turns out %v is mostly about tradeoffs.",
+		"Nobody tells you how much of %v is just reading other people's code.",
+		"When %v goes well nobody notices, which is exactly the point.",
+		"I keep coming back to %v because the basics still matter.",
+		"There's a simpler way to think about %v than the docs suggest.",
+	}
+	seedComments = []string{
+		"Great post, thanks for writing this up!",
+		"I had the exact same experience last year.",
+		"Could you expand on the second point? I'm not sure I follow.",
+		"This is going straight into our team wiki.",
+		"I disagree a little, but it's a fair take.",
+		"Bookmarked. The examples really helped.",
+		"Has anyone tried this with a larger team?",
+	}
+)
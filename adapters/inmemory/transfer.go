@@ -0,0 +1,540 @@
+package inmemory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// transferPage is how many users or articles are read from a repository at a time.
+const transferPage = 100
+
+// ExportFrom dumps everything in any repository as a fixture,
+// going through domain.Repository so stores can be moved between adapters.
+// Users are ordered by email, articles by when they were created, reports by id and the audit trail oldest first.
+// It isn't a consistent snapshot, changes made while it's running may or may not be included.
+func ExportFrom(ctx context.Context, r domain.Repository) (*Fixture, error) {
+	fx := &Fixture{
+		Users:    make([]FixtureUser, 0),
+		Articles: make([]FixtureArticle, 0),
+	}
+
+	for offset := 0; ; offset += transferPage {
+		page, err := r.ListUsers(ctx, transferPage, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range page {
+			// Listed users don't have their password, follows or favorites
+			f, err := r.GetUserByEmail(ctx, u.Email)
+			if err != nil {
+				return nil, fmt.Errorf("user %v: %w", u.Email, err)
+			}
+
+			following := f.FollowingEmails()
+			sort.Strings(following)
+			favorites := f.FavoritedSlugs()
+			sort.Strings(favorites)
+
+			fu := FixtureUser{
+				ID:           f.ID,
+				Email:        f.Email,
+				Username:     f.Username,
+				Bio:          f.Bio,
+				Image:        f.Image,
+				PasswordHash: string(f.Password),
+				Role:         f.Role,
+				Verified:     f.Verified,
+				Banned:       f.Banned,
+				Following:    following,
+				Favorites:    favorites,
+			}
+			if err := exportBelongings(ctx, r, f, &fu); err != nil {
+				return nil, fmt.Errorf("user %v: %w", u.Email, err)
+			}
+			fx.Users = append(fx.Users, fu)
+		}
+		if len(page) < transferPage {
+			break
+		}
+	}
+	sort.Slice(fx.Users, func(i, j int) bool {
+		return strings.ToLower(fx.Users[i].Email) < strings.ToLower(fx.Users[j].Email)
+	})
+
+	for offset := 0; ; offset += transferPage {
+		page, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{
+			Limit:      transferPage,
+			Offset:     offset,
+			WithHidden: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range page {
+			ca, err := r.GetCommentsWithHiddenBySlug(ctx, a.Slug)
+			if err != nil {
+				return nil, fmt.Errorf("article %v: %w", a.Slug, err)
+			}
+
+			cs := make([]FixtureComment, 0, len(ca.Comments))
+			for _, c := range ca.Comments {
+				cs = append(cs, FixtureComment{
+					ID:           c.ID,
+					UID:          c.UID,
+					Body:         c.Body,
+					Author:       c.AuthorEmail,
+					Hidden:       c.Hidden,
+					CreatedAtUTC: c.CreatedAtUTC,
+				})
+			}
+
+			fx.Articles = append(fx.Articles, FixtureArticle{
+				ID:           a.ID,
+				Slug:         a.Slug,
+				Title:        a.Title,
+				Description:  a.Description,
+				Body:         a.Body,
+				TagList:      a.TagList,
+				Author:       a.AuthorEmail,
+				Hidden:       a.Hidden,
+				CreatedAtUTC: a.CreatedAtUTC,
+				UpdatedAtUTC: a.UpdatedAtUTC,
+				Comments:     cs,
+			})
+		}
+		if len(page) < transferPage {
+			break
+		}
+	}
+	sort.SliceStable(fx.Articles, func(i, j int) bool {
+		return fx.Articles[i].CreatedAtUTC.Before(fx.Articles[j].CreatedAtUTC)
+	})
+
+	for _, s := range []domain.ReportStatus{domain.ReportOpen, domain.ReportDismissed, domain.ReportActioned} {
+		for offset := 0; ; offset += transferPage {
+			page, err := r.ReportsByStatus(ctx, s, transferPage, offset)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, rep := range page {
+				fx.Reports = append(fx.Reports, FixtureReport{
+					ID:            rep.ID,
+					Reporter:      rep.ReporterEmail,
+					Article:       rep.ArticleSlug,
+					Comment:       rep.CommentID,
+					Reason:        rep.Reason,
+					Status:        rep.Status,
+					Resolver:      rep.ResolverEmail,
+					Resolution:    rep.Resolution,
+					ResolvedAtUTC: rep.ResolvedAtUTC,
+					CreatedAtUTC:  rep.CreatedAtUTC,
+				})
+			}
+			if len(page) < transferPage {
+				break
+			}
+		}
+	}
+	sort.Slice(fx.Reports, func(i, j int) bool {
+		return fx.Reports[i].ID < fx.Reports[j].ID
+	})
+
+	for offset := 0; ; offset += transferPage {
+		page, err := r.LatestAuditEntries(ctx, transferPage, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range page {
+			fx.Audit = append(fx.Audit, FixtureAuditEntry{
+				Actor:        e.ActorEmail,
+				Action:       e.Action,
+				Article:      e.ArticleSlug,
+				Comment:      e.CommentID,
+				User:         e.UserEmail,
+				Report:       e.ReportID,
+				Note:         e.Note,
+				CreatedAtUTC: e.CreatedAtUTC,
+			})
+		}
+		if len(page) < transferPage {
+			break
+		}
+	}
+	// The latest entries are listed first
+	for i, j := 0, len(fx.Audit)-1; i < j; i, j = i+1, j-1 {
+		fx.Audit[i], fx.Audit[j] = fx.Audit[j], fx.Audit[i]
+	}
+
+	return fx, nil
+}
+
+// exportBelongings adds everything else that belongs to the user to their fixture.
+func exportBelongings(ctx context.Context, r domain.Repository, f *domain.Fanboy, fu *FixtureUser) error {
+	if blocking := f.BlockingEmails(); len(blocking) > 0 {
+		sort.Strings(blocking)
+		fu.Blocking = blocking
+	}
+	if muting := f.MutingEmails(); len(muting) > 0 {
+		sort.Strings(muting)
+		fu.Muting = muting
+	}
+
+	if f.TwoFactor.Secret != "" {
+		fu.TwoFactor = &FixtureTwoFactor{
+			Secret:      f.TwoFactor.Secret,
+			Enabled:     f.TwoFactor.Enabled,
+			LastCounter: f.TwoFactor.LastCounter,
+		}
+		if len(f.TwoFactor.RecoveryCodes) > 0 {
+			fu.TwoFactor.RecoveryCodes = f.TwoFactor.RecoveryCodes
+		}
+	}
+
+	x, err := r.ExportUserByEmail(ctx, f.Email)
+	if err != nil {
+		return err
+	}
+	if len(x.PreviousUsernames) > 0 {
+		fu.PreviousUsernames = x.PreviousUsernames
+	}
+	for _, i := range x.Identities {
+		fu.Identities = append(fu.Identities, FixtureIdentity{
+			Provider: i.Provider,
+			Subject:  i.Subject,
+		})
+	}
+	for _, l := range x.ReadingLists {
+		fl := FixtureReadingList{
+			Slug: l.Slug,
+			Name: l.Name,
+		}
+		if len(l.ArticleSlugs) > 0 {
+			fl.Articles = l.ArticleSlugs
+		}
+		fu.ReadingLists = append(fu.ReadingLists, fl)
+	}
+
+	tokens, err := r.APITokensByOwner(ctx, f.Email)
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		fu.APITokens = append(fu.APITokens, FixtureAPIToken{
+			Name:   t.Name,
+			Hash:   t.Hash,
+			Scopes: t.Scopes,
+		})
+	}
+
+	return nil
+}
+
+// ImportTo adds everything in the fixture to any repository, going through domain.Repository.
+// Articles and comments keep their timestamps but comments get new ids,
+// reports and the audit trail refer to the new comment ids.
+// Unlike Import it stops at the first problem, leaving everything before it imported.
+func (fx *Fixture) ImportTo(ctx context.Context, r domain.Repository) error {
+	for _, fu := range fx.Users {
+		u := &domain.User{
+			ID:       fu.ID,
+			Email:    fu.Email,
+			Username: fu.Username,
+			Bio:      fu.Bio,
+			Image:    fu.Image,
+			Password: domain.PasswordHash(fu.PasswordHash),
+			Role:     fu.Role,
+			Verified: fu.Verified,
+			Banned:   fu.Banned,
+		}
+		if u.ID == "" {
+			id, err := domain.NewID()
+			if err != nil {
+				return err
+			}
+			u.ID = id
+		}
+		if u.Role == "" {
+			u.Role = domain.RoleUser
+		}
+		if fu.Password != "" {
+			if err := u.RehashPassword(fu.Password); err != nil {
+				return fmt.Errorf("user %v: %w", fu.Email, err)
+			}
+		}
+		if _, err := u.Validate(); err != nil {
+			return fmt.Errorf("user %v: %w", fu.Email, err)
+		}
+
+		if _, err := r.CreateUser(ctx, u); err != nil {
+			return fmt.Errorf("user %v: %w", fu.Email, err)
+		}
+	}
+
+	now := time.Now().UTC()
+	commentIDs := make(map[int]int)
+	articles := make([]FixtureArticle, len(fx.Articles))
+	copy(articles, fx.Articles)
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].CreatedAtUTC.Before(articles[j].CreatedAtUTC)
+	})
+
+	for _, fa := range articles {
+		a := &domain.Article{
+			ID:           fa.ID,
+			Slug:         fa.Slug,
+			Title:        fa.Title,
+			Description:  fa.Description,
+			Body:         fa.Body,
+			TagList:      fa.TagList,
+			AuthorEmail:  fa.Author,
+			Hidden:       fa.Hidden,
+			CreatedAtUTC: fa.CreatedAtUTC.UTC(),
+			UpdatedAtUTC: fa.UpdatedAtUTC.UTC(),
+		}
+		if a.CreatedAtUTC.IsZero() {
+			a.CreatedAtUTC = now
+		}
+		if a.UpdatedAtUTC.IsZero() {
+			a.UpdatedAtUTC = a.CreatedAtUTC
+		}
+		if a.ID == "" {
+			id, err := domain.NewID()
+			if err != nil {
+				return err
+			}
+			a.ID = id
+		}
+		if a.Slug == "" {
+			a.SetTitle(a.Title)
+		}
+		a.RenderBody()
+		if _, err := a.Validate(); err != nil {
+			return fmt.Errorf("article %v: %w", fa.Title, err)
+		}
+
+		comments := make([]FixtureComment, len(fa.Comments))
+		copy(comments, fa.Comments)
+		sort.SliceStable(comments, func(i, j int) bool {
+			return comments[i].ID < comments[j].ID
+		})
+		ca := &domain.CommentedArticle{
+			Article:  *a,
+			Comments: make([]domain.Comment, 0, len(comments)),
+		}
+		for _, fc := range comments {
+			c, err := domain.NewComment(fc.Body, fc.Author)
+			if err != nil {
+				return fmt.Errorf("article %v comment %v: %w", a.Slug, fc.ID, err)
+			}
+			c.Hidden = fc.Hidden
+			if fc.UID != "" {
+				c.UID = fc.UID
+			}
+			c.CreatedAtUTC = fc.CreatedAtUTC.UTC()
+			if c.CreatedAtUTC.IsZero() {
+				c.CreatedAtUTC = now
+			}
+			ca.Comments = append(ca.Comments, *c)
+		}
+
+		imported, err := r.ImportArticle(ctx, ca)
+		if err != nil {
+			return fmt.Errorf("article %v: %w", a.Slug, err)
+		}
+		// Comments are given ids in the order they're listed
+		for i, fc := range comments {
+			if fc.ID > 0 && i < len(imported.Comments) {
+				commentIDs[fc.ID] = imported.Comments[i].ID
+			}
+		}
+	}
+
+	// Everyone has to exist before they can be followed
+	for _, fu := range fx.Users {
+		if len(fu.Following) == 0 && len(fu.Favorites) == 0 {
+			continue
+		}
+
+		err := r.UpdateFanboyByEmail(ctx, fu.Email, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			for _, e := range fu.Following {
+				f.StartFollowing(e)
+			}
+			for _, s := range fu.Favorites {
+				f.Favorite(s)
+			}
+			return f, nil
+		})
+		if err != nil {
+			return fmt.Errorf("user %v: %w", fu.Email, err)
+		}
+	}
+
+	return fx.importBelongings(ctx, r, func(id int) int {
+		if c, ok := commentIDs[id]; ok {
+			return c
+		}
+		return id
+	})
+}
+
+// importBelongings adds everything else that belongs to the users, the reports and the audit trail to the repository.
+// commentID finds the stored id of a comment from its id in the fixture,
+// comments that have since been deleted (and reports that no longer exist) keep the ids from the fixture.
+func (fx *Fixture) importBelongings(ctx context.Context, r domain.Repository, commentID func(int) int) error {
+	for _, fu := range fx.Users {
+		if fu.TwoFactor != nil {
+			_, err := r.UpdateUserByEmail(ctx, fu.Email, func(u *domain.User) (*domain.User, error) {
+				u.TwoFactor = domain.TwoFactor{
+					Secret:        fu.TwoFactor.Secret,
+					Enabled:       fu.TwoFactor.Enabled,
+					RecoveryCodes: fu.TwoFactor.RecoveryCodes,
+					LastCounter:   fu.TwoFactor.LastCounter,
+				}
+				return u, nil
+			})
+			if err != nil {
+				return fmt.Errorf("user %v two-factor: %w", fu.Email, err)
+			}
+		}
+
+		// Renaming keeps the old username, so they're replayed oldest first before taking back the current one
+		if len(fu.PreviousUsernames) > 0 {
+			usernames := make([]string, 0, len(fu.PreviousUsernames)+1)
+			usernames = append(usernames, fu.PreviousUsernames...)
+			for _, un := range append(usernames, fu.Username) {
+				_, err := r.UpdateUserByEmail(ctx, fu.Email, func(u *domain.User) (*domain.User, error) {
+					u.Username = un
+					return u.Validate()
+				})
+				if err != nil {
+					return fmt.Errorf("user %v username %v: %w", fu.Email, un, err)
+				}
+			}
+		}
+
+		if len(fu.Blocking) > 0 || len(fu.Muting) > 0 {
+			err := r.UpdateFanboyByEmail(ctx, fu.Email, func(f *domain.Fanboy) (*domain.Fanboy, error) {
+				for _, e := range fu.Blocking {
+					f.Block(e)
+				}
+				for _, e := range fu.Muting {
+					f.Mute(e)
+				}
+				return f, nil
+			})
+			if err != nil {
+				return fmt.Errorf("user %v: %w", fu.Email, err)
+			}
+		}
+
+		for _, fi := range fu.Identities {
+			err := r.LinkIdentity(ctx, fu.Email, &domain.ExternalIdentity{
+				Provider: fi.Provider,
+				Subject:  fi.Subject,
+			})
+			if err != nil {
+				return fmt.Errorf("user %v identity %v: %w", fu.Email, fi.Provider, err)
+			}
+		}
+
+		for _, ft := range fu.APITokens {
+			t, err := (&domain.APIToken{
+				OwnerEmail: fu.Email,
+				Name:       ft.Name,
+				Hash:       ft.Hash,
+				Scopes:     ft.Scopes,
+			}).Validate()
+			if err != nil {
+				return fmt.Errorf("user %v token %v: %w", fu.Email, ft.Name, err)
+			}
+			if _, err := r.CreateAPIToken(ctx, t); err != nil {
+				return fmt.Errorf("user %v token %v: %w", fu.Email, ft.Name, err)
+			}
+		}
+
+		for _, fl := range fu.ReadingLists {
+			l, err := (&domain.ReadingList{
+				OwnerEmail:   fu.Email,
+				Slug:         fl.Slug,
+				Name:         fl.Name,
+				ArticleSlugs: make([]string, 0),
+			}).Validate()
+			if err != nil {
+				return fmt.Errorf("user %v reading list %v: %w", fu.Email, fl.Slug, err)
+			}
+			if _, err := r.CreateReadingList(ctx, l); err != nil {
+				return fmt.Errorf("user %v reading list %v: %w", fu.Email, fl.Slug, err)
+			}
+			if len(fl.Articles) == 0 {
+				continue
+			}
+
+			// Not every adapter saves the articles when the list is created
+			_, err = r.UpdateReadingList(ctx, fu.Email, fl.Slug, func(l *domain.ReadingList) (*domain.ReadingList, error) {
+				l.ArticleSlugs = fl.Articles
+				return l, nil
+			})
+			if err != nil {
+				return fmt.Errorf("user %v reading list %v: %w", fu.Email, fl.Slug, err)
+			}
+		}
+	}
+
+	reportIDs := make(map[int]int, len(fx.Reports))
+	for _, fr := range fx.Reports {
+		rep, err := domain.NewReport(fr.Reporter, fr.Article, commentID(fr.Comment), fr.Reason)
+		if err != nil {
+			return fmt.Errorf("report %v: %w", fr.ID, err)
+		}
+		rep, err = r.CreateReport(ctx, rep)
+		if err != nil {
+			return fmt.Errorf("report %v: %w", fr.ID, err)
+		}
+		if fr.ID > 0 {
+			reportIDs[fr.ID] = rep.ID
+		}
+
+		if fr.Status == "" || fr.Status == domain.ReportOpen {
+			continue
+		}
+		_, err = r.UpdateReportByID(ctx, rep.ID, func(rep *domain.Report) (*domain.Report, error) {
+			rep.Status = fr.Status
+			rep.ResolverEmail = fr.Resolver
+			rep.Resolution = fr.Resolution
+			rep.ResolvedAtUTC = fr.ResolvedAtUTC.UTC()
+			return rep.Validate()
+		})
+		if err != nil {
+			return fmt.Errorf("report %v: %w", fr.ID, err)
+		}
+	}
+
+	for i, fe := range fx.Audit {
+		e := domain.NewAuditEntry(fe.Actor, fe.Action)
+		e.ArticleSlug = fe.Article
+		e.CommentID = commentID(fe.Comment)
+		e.UserEmail = fe.User
+		e.ReportID = fe.Report
+		if id, ok := reportIDs[fe.Report]; ok {
+			e.ReportID = id
+		}
+		e.Note = fe.Note
+		if _, err := e.Validate(); err != nil {
+			return fmt.Errorf("audit entry %v: %w", i, err)
+		}
+		if _, err := r.CreateAuditEntry(ctx, e); err != nil {
+			return fmt.Errorf("audit entry %v: %w", i, err)
+		}
+	}
+
+	return nil
+}
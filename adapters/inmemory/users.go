@@ -3,7 +3,9 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/brycekbargar/realworld-backend/domain"
 )
@@ -19,8 +21,12 @@ func (r *implementation) CreateUser(ctx context.Context, u *domain.User) (*domai
 			return nil, domain.ErrDuplicateUser
 		}
 	}
+	if _, ok := r.previousUsernames[strings.ToLower(u.Username)]; ok {
+		return nil, domain.ErrDuplicateUser
+	}
 
 	r.users[strings.ToLower(u.Email)] = &userRecord{
+		u.ID,
 		u.Email,
 		u.Username,
 		u.Bio,
@@ -28,38 +34,61 @@ func (r *implementation) CreateUser(ctx context.Context, u *domain.User) (*domai
 		"",
 		"",
 		u.Password,
+		string(u.Role),
+		u.Banned,
+		"",
+		"",
+		u.Verified,
+		u.TwoFactor.Secret,
+		u.TwoFactor.Enabled,
+		strings.Join(u.TwoFactor.RecoveryCodes, ","),
+		u.TwoFactor.LastCounter,
+		u.TwoFactor.FailedAttempts,
+		u.TwoFactor.LockedUntilUTC,
 	}
+	r.userIDs[u.ID] = strings.ToLower(u.Email)
 
-	f, err := r.GetUserByEmail(ctx, u.Email)
+	f, err := r.getUserByEmail(u.Email)
 	return &f.User, err
 }
 
 // GetUserByEmail finds a single user based on their username.
 func (r *implementation) GetUserByEmail(_ context.Context, e string) (*domain.Fanboy, error) {
-	if u, ok := r.users[strings.ToLower(e)]; ok {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-		emails := strings.Split(u.following, ",")
-		follows := make(map[string]interface{}, len(emails))
-		for _, em := range emails {
-			follows[em] = nil
-		}
+	return r.getUserByEmail(e)
+}
 
-		slugs := strings.Split(u.favorites, ",")
-		favorites := make(map[string]interface{}, len(slugs))
-		for _, s := range slugs {
-			favorites[s] = nil
-		}
+func (r *implementation) getUserByEmail(e string) (*domain.Fanboy, error) {
+	if u, ok := r.users[strings.ToLower(e)]; ok {
+
+		favorites := splitKeys(u.favorites)
 
 		return &domain.Fanboy{
 			User: domain.User{
+				ID:       u.id,
 				Email:    u.email,
 				Username: u.username,
 				Bio:      u.bio,
 				Image:    u.image,
 				Password: u.password,
+				Role:     domain.Role(u.role),
+				Banned:   u.banned,
+				Verified: u.verified,
+				TwoFactor: domain.TwoFactor{
+					Secret:         u.totpSecret,
+					Enabled:        u.totpEnabled,
+					RecoveryCodes:  splitList(u.recoveryCodes),
+					LastCounter:    u.totpLastCounter,
+					FailedAttempts: u.totpFailures,
+					LockedUntilUTC: u.totpLockedUntil,
+				},
 			},
-			Following: follows,
+			Following: r.userEmailsOf(splitKeys(u.following)),
 			Favorites: favorites,
+			Blocking:  r.userEmailsOf(splitKeys(u.blocking)),
+			Muting:    r.userEmailsOf(splitKeys(u.muting)),
 		}, nil
 	}
 
@@ -67,10 +96,13 @@ func (r *implementation) GetUserByEmail(_ context.Context, e string) (*domain.Fa
 }
 
 // GetUserByUsername finds a single user based on their username.
-func (r *implementation) GetUserByUsername(ctx context.Context, un string) (*domain.User, error) {
+func (r *implementation) GetUserByUsername(_ context.Context, un string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for k, v := range r.users {
 		if strings.ToLower(v.username) == strings.ToLower(un) {
-			f, err := r.GetUserByEmail(ctx, k)
+			f, err := r.getUserByEmail(k)
 			return &f.User, err
 		}
 	}
@@ -78,17 +110,53 @@ func (r *implementation) GetUserByUsername(ctx context.Context, un string) (*dom
 	return nil, domain.ErrUserNotFound
 }
 
+// GetUserByID finds a single user based on their id.
+func (r *implementation) GetUserByID(_ context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if e, ok := r.userIDs[id]; ok {
+		f, err := r.getUserByEmail(e)
+		return &f.User, err
+	}
+
+	return nil, domain.ErrUserNotFound
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (r *implementation) GetUserByPreviousUsername(_ context.Context, un string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if pu, ok := r.previousUsernames[strings.ToLower(un)]; ok {
+		for k, v := range r.users {
+			if v.id == pu.user {
+				f, err := r.getUserByEmail(k)
+				return &f.User, err
+			}
+		}
+	}
+
+	return nil, domain.ErrUserNotFound
+}
+
 // UpdateUserByEmail finds a single user based on their email address,
 // then applies the provide mutations.
-func (r *implementation) UpdateUserByEmail(ctx context.Context, e string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
+// Usernames they stop using stay reserved for them.
+func (r *implementation) UpdateUserByEmail(_ context.Context, e string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	f, err := r.GetUserByEmail(ctx, e)
+	return r.updateUserByEmail(e, update)
+}
+
+func (r *implementation) updateUserByEmail(e string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
+	f, err := r.getUserByEmail(e)
 	if err != nil {
 		return nil, err
 	}
 	prevEm := strings.ToLower(f.Email)
+	prevUn := f.Username
 
 	u, err := update(&f.User)
 	if err != nil {
@@ -97,6 +165,8 @@ func (r *implementation) UpdateUserByEmail(ctx context.Context, e string, update
 
 	removed := r.users[strings.ToLower(e)]
 	delete(r.users, strings.ToLower(e))
+	r.unindexFollowing(removed.id, removed.following)
+	r.unindexFavorites(removed.email, removed.favorites)
 
 	for e, v := range r.users {
 		if e == strings.ToLower(u.Email) ||
@@ -104,28 +174,30 @@ func (r *implementation) UpdateUserByEmail(ctx context.Context, e string, update
 
 			// Add the deleted user back if they've become a duplicate
 			r.users[strings.ToLower(removed.email)] = removed
+			r.indexFollowing(removed.id, removed.following)
+			r.indexFavorites(removed.email, removed.favorites)
 			return nil, domain.ErrDuplicateUser
 		}
 	}
+	if pu, ok := r.previousUsernames[strings.ToLower(u.Username)]; ok && pu.user != removed.id {
+		r.users[strings.ToLower(removed.email)] = removed
+		r.indexFollowing(removed.id, removed.following)
+		r.indexFavorites(removed.email, removed.favorites)
+		return nil, domain.ErrDuplicateUser
+	}
 
-	if strings.ToLower(u.Email) != prevEm {
-		// TODO: Handle emails that contain other emails...
-
-		for _, v := range r.users {
-			// Make sure users following this one get an updated key
-			v.following = strings.ReplaceAll(v.following, prevEm, strings.ToLower(u.Email))
-		}
-		for _, v := range r.articles {
-			// Make sure articles this user authored get an updated key
-			if strings.ToLower(v.author) == prevEm {
-				v.author = u.Email
-			}
+	if !strings.EqualFold(u.Username, prevUn) {
+		// Users can take back their own previous usernames
+		delete(r.previousUsernames, strings.ToLower(u.Username))
+		r.previousUsernames[strings.ToLower(prevUn)] = &previousUsernameRecord{
+			prevUn,
+			removed.id,
+			time.Now().UTC(),
 		}
 	}
 
-	follows := make([]string, 0, len(f.Following))
-	for k := range f.Following {
-		follows = append(follows, k)
+	if strings.ToLower(u.Email) != prevEm {
+		r.rekeyUser(prevEm, u.Email)
 	}
 
 	favorites := make([]string, 0, len(f.Favorites))
@@ -133,66 +205,243 @@ func (r *implementation) UpdateUserByEmail(ctx context.Context, e string, update
 		favorites = append(favorites, k)
 	}
 
-	r.users[strings.ToLower(u.Email)] = &userRecord{
+	ur := &userRecord{
+		removed.id,
 		u.Email,
 		u.Username,
 		u.Bio,
 		u.Image,
-		strings.ToLower(strings.Join(follows, ",")),
+		removed.following,
 		strings.ToLower(strings.Join(favorites, ",")),
 		u.Password,
+		string(u.Role),
+		u.Banned,
+		removed.blocking,
+		removed.muting,
+		u.Verified,
+		u.TwoFactor.Secret,
+		u.TwoFactor.Enabled,
+		strings.Join(u.TwoFactor.RecoveryCodes, ","),
+		u.TwoFactor.LastCounter,
+		u.TwoFactor.FailedAttempts,
+		u.TwoFactor.LockedUntilUTC,
 	}
+	r.users[strings.ToLower(u.Email)] = ur
+	r.userIDs[ur.id] = strings.ToLower(u.Email)
+	r.indexFollowing(ur.id, ur.following)
+	r.indexFavorites(ur.email, ur.favorites)
 
-	f, err = r.GetUserByEmail(ctx, u.Email)
+	f, err = r.getUserByEmail(u.Email)
 	return &f.User, err
 }
 
-func (r *implementation) UpdateFanboyByEmail(ctx context.Context, e string, update func(*domain.Fanboy) (*domain.Fanboy, error)) error {
-	var uf *domain.Fanboy
-	err := func() error {
-		r.mu.Lock()
-		defer r.mu.Unlock()
+// rekeyUser updates everything keyed by the user's previous email to their next one.
+// Follows, blocks and mutes relate users by id so they're left alone,
+// the records of what the user wrote and owns still refer to them by email.
+func (r *implementation) rekeyUser(prevEm string, next string) {
+	nextKey := strings.ToLower(next)
 
-		f, err := r.GetUserByEmail(ctx, e)
-		if err != nil {
-			return err
-		}
-
-		uf, err = update(f)
-		if err != nil {
-			return err
+	if ix, ok := r.byAuthor[prevEm]; ok {
+		// Make sure the index of articles this user authored gets an updated key
+		delete(r.byAuthor, prevEm)
+		r.byAuthor[nextKey] = ix
+	}
+	for _, v := range r.articles {
+		// Make sure articles and comments this user authored get an updated key
+		if strings.ToLower(v.author) == prevEm {
+			v.author = next
 		}
-
-		follows := make([]string, 0, len(uf.Following))
-		for k := range uf.Following {
-			if k != "" {
-				follows = append(follows, k)
+		for i := range v.comments {
+			if strings.ToLower(v.comments[i].author) == prevEm {
+				v.comments[i].author = next
 			}
 		}
-
-		favorites := make([]string, 0, len(uf.Favorites))
-		for k := range uf.Favorites {
-			if k != "" {
-				favorites = append(favorites, k)
-			}
+	}
+	for k, v := range r.readingLists {
+		// Make sure reading lists this user owns get an updated key
+		if strings.ToLower(v.owner) == prevEm {
+			delete(r.readingLists, k)
+			v.owner = next
+			r.readingLists[readingListKey(v.owner, v.slug)] = v
 		}
-
-		fr, ok := r.users[strings.ToLower(e)]
-		if !ok {
-			return domain.ErrUserNotFound
+	}
+	for _, v := range r.apiTokens {
+		// Make sure api tokens this user owns get an updated key
+		if strings.ToLower(v.owner) == prevEm {
+			v.owner = next
+		}
+	}
+	for _, v := range r.reports {
+		// Make sure reports this user made or resolved get an updated key
+		if strings.ToLower(v.reporter) == prevEm {
+			v.reporter = next
+		}
+		if strings.ToLower(v.resolver) == prevEm {
+			v.resolver = next
+		}
+	}
+	for k, v := range r.identities {
+		// Make sure identities linked to this user get an updated key
+		if v == prevEm {
+			r.identities[k] = nextKey
 		}
-		fr.following = strings.ToLower(strings.Join(follows, ","))
-		fr.favorites = strings.ToLower(strings.Join(favorites, ","))
+	}
+}
+
+func (r *implementation) UpdateFanboyByEmail(_ context.Context, e string, update func(*domain.Fanboy) (*domain.Fanboy, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-		return nil
-	}()
+	f, err := r.getUserByEmail(e)
+	if err != nil {
+		return err
+	}
 
+	uf, err := update(f)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.UpdateUserByEmail(ctx, e, func(*domain.User) (*domain.User, error) {
+	favorites := make([]string, 0, len(uf.Favorites))
+	for k := range uf.Favorites {
+		if k != "" {
+			favorites = append(favorites, k)
+		}
+	}
+
+	fr, ok := r.users[strings.ToLower(e)]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	r.unindexFollowing(fr.id, fr.following)
+	fr.following = r.userIDsOf(uf.Following)
+	r.indexFollowing(fr.id, fr.following)
+	r.unindexFavorites(fr.email, fr.favorites)
+	fr.favorites = strings.ToLower(strings.Join(favorites, ","))
+	r.indexFavorites(fr.email, fr.favorites)
+	fr.blocking = r.userIDsOf(uf.Blocking)
+	fr.muting = r.userIDsOf(uf.Muting)
+
+	// The relations and the user are updated under the same lock so nobody sees one without the other
+	_, err = r.updateUserByEmail(e, func(*domain.User) (*domain.User, error) {
 		return &uf.User, nil
 	})
 	return err
 }
+
+// ListUsers lists every user, ordered by username.
+func (r *implementation) ListUsers(_ context.Context, limit int, offset int) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	emails := make(map[string]interface{}, len(r.users))
+	for e := range r.users {
+		emails[e] = nil
+	}
+	return r.pageUsers(emails, limit, offset)
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (r *implementation) FollowersByEmail(_ context.Context, e string, limit int, offset int) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[strings.ToLower(e)]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return r.pageUsers(r.userEmailsOf(r.followers[u.id]), limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (r *implementation) FollowingByEmail(_ context.Context, e string, limit int, offset int) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[strings.ToLower(e)]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return r.pageUsers(r.userEmailsOf(splitKeys(u.following)), limit, offset)
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (r *implementation) GetFollowCountsByEmail(_ context.Context, e string) (*domain.FollowCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[strings.ToLower(e)]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return &domain.FollowCounts{
+		Followers: len(r.followers[u.id]),
+		Following: len(splitKeys(u.following)),
+	}, nil
+}
+
+func (r *implementation) pageUsers(emails map[string]interface{}, limit int, offset int) ([]domain.User, error) {
+	users := make([]domain.User, 0, len(emails))
+	for e := range emails {
+		if f, err := r.getUserByEmail(e); err == nil {
+			users = append(users, f.User)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Username) < strings.ToLower(users[j].Username)
+	})
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(users) {
+		return []domain.User{}, nil
+	}
+	users = users[offset:]
+	if limit >= 0 && limit < len(users) {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+func identityKey(provider string, subject string) string {
+	return provider + "\x00" + subject
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (r *implementation) GetUserByIdentity(_ context.Context, provider string, subject string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	em, ok := r.identities[identityKey(provider, subject)]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+
+	f, err := r.getUserByEmail(em)
+	if err != nil {
+		return nil, err
+	}
+	return &f.User, nil
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (r *implementation) LinkIdentity(_ context.Context, e string, i *domain.ExternalIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[strings.ToLower(e)]; !ok {
+		return domain.ErrUserNotFound
+	}
+
+	k := identityKey(i.Provider, i.Subject)
+	if em, ok := r.identities[k]; ok && em != strings.ToLower(e) {
+		return domain.ErrDuplicateIdentity
+	}
+
+	r.identities[k] = strings.ToLower(e)
+	return nil
+}
@@ -0,0 +1,389 @@
+// Package metrics is a decorator for the repository adapters that times every operation for Prometheus.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/adapters/cache"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Repository is a domain.Repository that times the operations of another one,
+// it also counts the registrations, articles and comments created through it.
+type Repository struct {
+	domain.Repository
+	durations     *prometheus.HistogramVec
+	registrations prometheus.Counter
+	articles      prometheus.Counter
+	comments      prometheus.Counter
+}
+
+// NewInstance wraps the repository with metrics registered with the registerer.
+func NewInstance(r domain.Repository, reg prometheus.Registerer) *Repository {
+	m := &Repository{
+		r,
+		prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "conduit_repository_operation_duration_seconds",
+			Help:    "How long repository operations took, by operation and whether they succeeded.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation", "outcome"}),
+		prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "conduit_registrations_total",
+			Help: "How many users have registered.",
+		}),
+		prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "conduit_articles_created_total",
+			Help: "How many articles have been created.",
+		}),
+		prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "conduit_comments_created_total",
+			Help: "How many comments have been created.",
+		}),
+	}
+	reg.MustRegister(m.durations, m.registrations, m.articles, m.comments)
+	return m
+}
+
+// ObservePasswordHashing times hashing and verifying passwords with a histogram registered with the registerer.
+func ObservePasswordHashing(reg prometheus.Registerer) {
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "conduit_password_hashing_duration_seconds",
+		Help: "How long hashing and verifying passwords took, by algorithm and operation.",
+		// Hashing is slow on purpose
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"algorithm", "operation"})
+	reg.MustRegister(durations)
+
+	domain.ObservePasswordHashing(func(algorithm string, operation string, took time.Duration) {
+		durations.WithLabelValues(algorithm, operation).Observe(took.Seconds())
+	})
+}
+
+// ObserveCache exposes the hits, misses and backend errors of the cache as counters registered with the registerer.
+func ObserveCache(reg prometheus.Registerer, c *cache.Repository) {
+	kinds := map[string]func(cache.Stats) cache.Counts{
+		"users":    func(s cache.Stats) cache.Counts { return s.Users },
+		"articles": func(s cache.Stats) cache.Counts { return s.Articles },
+		"tags":     func(s cache.Stats) cache.Counts { return s.Tags },
+	}
+	for kind, counts := range kinds {
+		counts := counts
+		reg.MustRegister(
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "conduit_cache_hits_total",
+				Help:        "How many cached reads found the value in the cache, by kind of value.",
+				ConstLabels: prometheus.Labels{"kind": kind},
+			}, func() float64 { return float64(counts(c.Stats()).Hits) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name:        "conduit_cache_misses_total",
+				Help:        "How many cached reads went to the repository, by kind of value.",
+				ConstLabels: prometheus.Labels{"kind": kind},
+			}, func() float64 { return float64(counts(c.Stats()).Misses) }),
+		)
+	}
+	reg.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "conduit_cache_errors_total",
+		Help: "How many times the cache backend failed, the reads are treated as misses.",
+	}, func() float64 { return float64(c.Stats().Errors) }))
+}
+
+// observe records how long the operation took since it started.
+// Not finding what was asked for is an expected outcome rather than an error.
+func (m *Repository) observe(op string, start time.Time, err *error) {
+	outcome := "ok"
+	if err != nil && domain.IsNotFound(*err) {
+		outcome = "not_found"
+	} else if err != nil && *err != nil {
+		outcome = "error"
+	}
+	m.durations.WithLabelValues(op, outcome).Observe(time.Since(start).Seconds())
+}
+
+// CreateUser creates a new user.
+func (m *Repository) CreateUser(ctx context.Context, u *domain.User) (_ *domain.User, err error) {
+	defer m.observe("CreateUser", time.Now(), &err)
+	cu, err := m.Repository.CreateUser(ctx, u)
+	if err == nil {
+		m.registrations.Inc()
+	}
+	return cu, err
+}
+
+// GetUserByEmail finds a single user based on their email address.
+func (m *Repository) GetUserByEmail(ctx context.Context, e string) (_ *domain.Fanboy, err error) {
+	defer m.observe("GetUserByEmail", time.Now(), &err)
+	return m.Repository.GetUserByEmail(ctx, e)
+}
+
+// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
+func (m *Repository) GetAuthorByEmail(ctx context.Context, e string) domain.Author {
+	defer m.observe("GetAuthorByEmail", time.Now(), nil)
+	return m.Repository.GetAuthorByEmail(ctx, e)
+}
+
+// GetUserByID finds a single user based on their id.
+func (m *Repository) GetUserByID(ctx context.Context, id string) (_ *domain.User, err error) {
+	defer m.observe("GetUserByID", time.Now(), &err)
+	return m.Repository.GetUserByID(ctx, id)
+}
+
+// GetUserByUsername finds a single user based on their username.
+func (m *Repository) GetUserByUsername(ctx context.Context, un string) (_ *domain.User, err error) {
+	defer m.observe("GetUserByUsername", time.Now(), &err)
+	return m.Repository.GetUserByUsername(ctx, un)
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (m *Repository) GetUserByPreviousUsername(ctx context.Context, un string) (_ *domain.User, err error) {
+	defer m.observe("GetUserByPreviousUsername", time.Now(), &err)
+	return m.Repository.GetUserByPreviousUsername(ctx, un)
+}
+
+// UpdateUserByEmail finds a single user based on their email address,
+// then applies the provide mutations.
+// Usernames they stop using stay reserved for them.
+func (m *Repository) UpdateUserByEmail(ctx context.Context, e string, update func(*domain.User) (*domain.User, error)) (_ *domain.User, err error) {
+	defer m.observe("UpdateUserByEmail", time.Now(), &err)
+	return m.Repository.UpdateUserByEmail(ctx, e, update)
+}
+
+// UpdateFanboyByEmail finds a single user based on their email address,
+// then applies the provide mutations (probably to the follower list).
+func (m *Repository) UpdateFanboyByEmail(ctx context.Context, e string, update func(*domain.Fanboy) (*domain.Fanboy, error)) (err error) {
+	defer m.observe("UpdateFanboyByEmail", time.Now(), &err)
+	return m.Repository.UpdateFanboyByEmail(ctx, e, update)
+}
+
+// ListUsers lists every user, ordered by username.
+func (m *Repository) ListUsers(ctx context.Context, limit int, offset int) (_ []domain.User, err error) {
+	defer m.observe("ListUsers", time.Now(), &err)
+	return m.Repository.ListUsers(ctx, limit, offset)
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (m *Repository) FollowersByEmail(ctx context.Context, e string, limit int, offset int) (_ []domain.User, err error) {
+	defer m.observe("FollowersByEmail", time.Now(), &err)
+	return m.Repository.FollowersByEmail(ctx, e, limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (m *Repository) FollowingByEmail(ctx context.Context, e string, limit int, offset int) (_ []domain.User, err error) {
+	defer m.observe("FollowingByEmail", time.Now(), &err)
+	return m.Repository.FollowingByEmail(ctx, e, limit, offset)
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (m *Repository) GetFollowCountsByEmail(ctx context.Context, e string) (_ *domain.FollowCounts, err error) {
+	defer m.observe("GetFollowCountsByEmail", time.Now(), &err)
+	return m.Repository.GetFollowCountsByEmail(ctx, e)
+}
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (m *Repository) DeleteUser(ctx context.Context, e string, mode domain.DeletionMode) (err error) {
+	defer m.observe("DeleteUser", time.Now(), &err)
+	return m.Repository.DeleteUser(ctx, e, mode)
+}
+
+// ExportUserByEmail gathers everything kept about the user with the given email.
+func (m *Repository) ExportUserByEmail(ctx context.Context, e string) (_ *domain.UserExport, err error) {
+	defer m.observe("ExportUserByEmail", time.Now(), &err)
+	return m.Repository.ExportUserByEmail(ctx, e)
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (m *Repository) GetUserByIdentity(ctx context.Context, provider string, subject string) (_ *domain.User, err error) {
+	defer m.observe("GetUserByIdentity", time.Now(), &err)
+	return m.Repository.GetUserByIdentity(ctx, provider, subject)
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (m *Repository) LinkIdentity(ctx context.Context, e string, i *domain.ExternalIdentity) (err error) {
+	defer m.observe("LinkIdentity", time.Now(), &err)
+	return m.Repository.LinkIdentity(ctx, e, i)
+}
+
+// CreateAPIToken creates a new personal API token.
+func (m *Repository) CreateAPIToken(ctx context.Context, t *domain.APIToken) (_ *domain.APIToken, err error) {
+	defer m.observe("CreateAPIToken", time.Now(), &err)
+	return m.Repository.CreateAPIToken(ctx, t)
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (m *Repository) APITokensByOwner(ctx context.Context, e string) (_ []domain.APIToken, err error) {
+	defer m.observe("APITokensByOwner", time.Now(), &err)
+	return m.Repository.APITokensByOwner(ctx, e)
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (m *Repository) GetAPITokenByHash(ctx context.Context, h string) (_ *domain.APIToken, err error) {
+	defer m.observe("GetAPITokenByHash", time.Now(), &err)
+	return m.Repository.GetAPITokenByHash(ctx, h)
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (m *Repository) DeleteAPIToken(ctx context.Context, e string, id int) (err error) {
+	defer m.observe("DeleteAPIToken", time.Now(), &err)
+	return m.Repository.DeleteAPIToken(ctx, e, id)
+}
+
+// CreateArticle creates a new article.
+func (m *Repository) CreateArticle(ctx context.Context, a *domain.Article) (_ *domain.AuthoredArticle, err error) {
+	defer m.observe("CreateArticle", time.Now(), &err)
+	ca, err := m.Repository.CreateArticle(ctx, a)
+	if err == nil {
+		m.articles.Inc()
+	}
+	return ca, err
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (m *Repository) ImportArticle(ctx context.Context, a *domain.CommentedArticle) (_ *domain.CommentedArticle, err error) {
+	defer m.observe("ImportArticle", time.Now(), &err)
+	ca, err := m.Repository.ImportArticle(ctx, a)
+	if err == nil {
+		m.articles.Inc()
+		m.comments.Add(float64(len(ca.Comments)))
+	}
+	return ca, err
+}
+
+// LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
+// Hidden articles are never included.
+func (m *Repository) LatestArticlesByCriteria(ctx context.Context, query domain.ListCriteria) (_ []domain.AuthoredArticle, err error) {
+	defer m.observe("LatestArticlesByCriteria", time.Now(), &err)
+	return m.Repository.LatestArticlesByCriteria(ctx, query)
+}
+
+// GetArticleBySlug gets a single article with the given slug.
+func (m *Repository) GetArticleBySlug(ctx context.Context, s string) (_ *domain.AuthoredArticle, err error) {
+	defer m.observe("GetArticleBySlug", time.Now(), &err)
+	return m.Repository.GetArticleBySlug(ctx, s)
+}
+
+// GetArticleByID gets a single article with the given id.
+func (m *Repository) GetArticleByID(ctx context.Context, id string) (_ *domain.AuthoredArticle, err error) {
+	defer m.observe("GetArticleByID", time.Now(), &err)
+	return m.Repository.GetArticleByID(ctx, id)
+}
+
+// GetCommentsBySlug gets a single article and its comments with the given slug.
+// Hidden comments are not included.
+func (m *Repository) GetCommentsBySlug(ctx context.Context, s string) (_ *domain.CommentedArticle, err error) {
+	defer m.observe("GetCommentsBySlug", time.Now(), &err)
+	return m.Repository.GetCommentsBySlug(ctx, s)
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (m *Repository) GetCommentsWithHiddenBySlug(ctx context.Context, s string) (_ *domain.CommentedArticle, err error) {
+	defer m.observe("GetCommentsWithHiddenBySlug", time.Now(), &err)
+	return m.Repository.GetCommentsWithHiddenBySlug(ctx, s)
+}
+
+// UpdateArticleBySlug finds a single article based on its slug
+// then applies the provide mutations.
+func (m *Repository) UpdateArticleBySlug(ctx context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (_ *domain.AuthoredArticle, err error) {
+	defer m.observe("UpdateArticleBySlug", time.Now(), &err)
+	return m.Repository.UpdateArticleBySlug(ctx, s, update)
+}
+
+// UpdateCommentsBySlug finds a single article based on its slug
+// then applies the provide mutations to its comments (including the hidden ones).
+func (m *Repository) UpdateCommentsBySlug(ctx context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (_ *domain.Comment, err error) {
+	defer m.observe("UpdateCommentsBySlug", time.Now(), &err)
+	c, err := m.Repository.UpdateCommentsBySlug(ctx, s, update)
+	if err == nil && c != nil {
+		// The new comment is returned when there is one
+		m.comments.Inc()
+	}
+	return c, err
+}
+
+// DeleteArticle deletes the article if it exists.
+func (m *Repository) DeleteArticle(ctx context.Context, a *domain.Article) (err error) {
+	defer m.observe("DeleteArticle", time.Now(), &err)
+	return m.Repository.DeleteArticle(ctx, a)
+}
+
+// DistinctTags returns a distinct list of tags on all articles
+func (m *Repository) DistinctTags(ctx context.Context) (_ []string, err error) {
+	defer m.observe("DistinctTags", time.Now(), &err)
+	return m.Repository.DistinctTags(ctx)
+}
+
+// CreateReadingList creates a new reading list.
+func (m *Repository) CreateReadingList(ctx context.Context, l *domain.ReadingList) (_ *domain.ReadingList, err error) {
+	defer m.observe("CreateReadingList", time.Now(), &err)
+	return m.Repository.CreateReadingList(ctx, l)
+}
+
+// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+func (m *Repository) ReadingListsByOwner(ctx context.Context, e string) (_ []domain.ReadingList, err error) {
+	defer m.observe("ReadingListsByOwner", time.Now(), &err)
+	return m.Repository.ReadingListsByOwner(ctx, e)
+}
+
+// GetReadingList gets a single reading list with the given owner email and slug.
+func (m *Repository) GetReadingList(ctx context.Context, e string, s string) (_ *domain.ReadingList, err error) {
+	defer m.observe("GetReadingList", time.Now(), &err)
+	return m.Repository.GetReadingList(ctx, e, s)
+}
+
+// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+// in the order of the list. Hidden articles are not included.
+func (m *Repository) ReadingListArticles(ctx context.Context, e string, s string) (_ []domain.AuthoredArticle, err error) {
+	defer m.observe("ReadingListArticles", time.Now(), &err)
+	return m.Repository.ReadingListArticles(ctx, e, s)
+}
+
+// UpdateReadingList finds a single reading list based on its owner email and slug,
+// then applies the provide mutations.
+func (m *Repository) UpdateReadingList(ctx context.Context, e string, s string, update func(*domain.ReadingList) (*domain.ReadingList, error)) (_ *domain.ReadingList, err error) {
+	defer m.observe("UpdateReadingList", time.Now(), &err)
+	return m.Repository.UpdateReadingList(ctx, e, s, update)
+}
+
+// DeleteReadingList deletes the reading list if it exists.
+func (m *Repository) DeleteReadingList(ctx context.Context, l *domain.ReadingList) (err error) {
+	defer m.observe("DeleteReadingList", time.Now(), &err)
+	return m.Repository.DeleteReadingList(ctx, l)
+}
+
+// CreateReport creates a new report.
+func (m *Repository) CreateReport(ctx context.Context, rep *domain.Report) (_ *domain.Report, err error) {
+	defer m.observe("CreateReport", time.Now(), &err)
+	return m.Repository.CreateReport(ctx, rep)
+}
+
+// GetReportByID gets a single report with the given id.
+func (m *Repository) GetReportByID(ctx context.Context, id int) (_ *domain.Report, err error) {
+	defer m.observe("GetReportByID", time.Now(), &err)
+	return m.Repository.GetReportByID(ctx, id)
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (m *Repository) ReportsByStatus(ctx context.Context, s domain.ReportStatus, limit int, offset int) (_ []domain.Report, err error) {
+	defer m.observe("ReportsByStatus", time.Now(), &err)
+	return m.Repository.ReportsByStatus(ctx, s, limit, offset)
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (m *Repository) UpdateReportByID(ctx context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (_ *domain.Report, err error) {
+	defer m.observe("UpdateReportByID", time.Now(), &err)
+	return m.Repository.UpdateReportByID(ctx, id, update)
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (m *Repository) CreateAuditEntry(ctx context.Context, ae *domain.AuditEntry) (_ *domain.AuditEntry, err error) {
+	defer m.observe("CreateAuditEntry", time.Now(), &err)
+	return m.Repository.CreateAuditEntry(ctx, ae)
+}
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (m *Repository) LatestAuditEntries(ctx context.Context, limit int, offset int) (_ []domain.AuditEntry, err error) {
+	defer m.observe("LatestAuditEntries", time.Now(), &err)
+	return m.Repository.LatestAuditEntries(ctx, limit, offset)
+}
@@ -0,0 +1,115 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/cache"
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/metrics"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Repository(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	metrics.ObservePasswordHashing(reg)
+	r := metrics.NewInstance(inmemory.NewInstance(), reg)
+
+	u, err := domain.NewUserWithPassword("observed@user.com", "observed", "not a guessable password")
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.ErrorIs(t, err, domain.ErrDuplicateUser)
+	ok, err := u.HasPassword("not a guessable password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	a, err := domain.NewArticle("Observed Title", "Observed description", "Observed body", u.Email)
+	require.NoError(t, err)
+	_, err = r.CreateArticle(ctx, a)
+	require.NoError(t, err)
+	_, err = r.UpdateCommentsBySlug(ctx, a.Slug, func(ca *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+		return ca, ca.AddComment("Observed comment", u.Email)
+	})
+	require.NoError(t, err)
+	_, err = r.GetArticleBySlug(ctx, "unobserved")
+	require.ErrorIs(t, err, domain.ErrArticleNotFound)
+	_, err = inmemory.ExportFrom(ctx, r)
+	require.NoError(t, err)
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP conduit_registrations_total How many users have registered.
+# TYPE conduit_registrations_total counter
+conduit_registrations_total 1
+# HELP conduit_articles_created_total How many articles have been created.
+# TYPE conduit_articles_created_total counter
+conduit_articles_created_total 1
+# HELP conduit_comments_created_total How many comments have been created.
+# TYPE conduit_comments_created_total counter
+conduit_comments_created_total 1
+`), "conduit_registrations_total", "conduit_articles_created_total", "conduit_comments_created_total")
+	assert.NoError(t, err)
+
+	mfs, err := reg.Gather()
+	require.NoError(t, err)
+	outcomes := make(map[string]uint64)
+	hashing := make(map[string]uint64)
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			labels := make([]string, 0, 2)
+			for _, l := range m.GetLabel() {
+				labels = append(labels, l.GetValue())
+			}
+			switch mf.GetName() {
+			case "conduit_repository_operation_duration_seconds":
+				outcomes[strings.Join(labels, " ")] = m.GetHistogram().GetSampleCount()
+			case "conduit_password_hashing_duration_seconds":
+				hashing[strings.Join(labels, " ")] = m.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.Equal(t, uint64(1), outcomes["CreateUser ok"])
+	assert.Equal(t, uint64(1), outcomes["CreateUser error"])
+	assert.Equal(t, uint64(1), outcomes["GetArticleBySlug not_found"])
+	assert.Equal(t, uint64(1), outcomes["GetCommentsWithHiddenBySlug ok"])
+	assert.Equal(t, uint64(1), outcomes["UpdateCommentsBySlug ok"], "because exporting doesn't update")
+	assert.Zero(t, outcomes["UpdateCommentsBySlug error"])
+	assert.Equal(t, uint64(1), hashing["argon2id hash"])
+	assert.Equal(t, uint64(1), hashing["argon2id verify"])
+}
+
+func Test_ObserveCache(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewRegistry()
+	c := cache.NewInstance(inmemory.NewInstance(), cache.NewLRU(10), cache.DefaultTTLs)
+	metrics.ObserveCache(reg, c)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.DistinctTags(ctx)
+		require.NoError(t, err)
+	}
+
+	err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP conduit_cache_hits_total How many cached reads found the value in the cache, by kind of value.
+# TYPE conduit_cache_hits_total counter
+conduit_cache_hits_total{kind="articles"} 0
+conduit_cache_hits_total{kind="tags"} 2
+conduit_cache_hits_total{kind="users"} 0
+# HELP conduit_cache_misses_total How many cached reads went to the repository, by kind of value.
+# TYPE conduit_cache_misses_total counter
+conduit_cache_misses_total{kind="articles"} 0
+conduit_cache_misses_total{kind="tags"} 1
+conduit_cache_misses_total{kind="users"} 0
+# HELP conduit_cache_errors_total How many times the cache backend failed, the reads are treated as misses.
+# TYPE conduit_cache_errors_total counter
+conduit_cache_errors_total 0
+`), "conduit_cache_hits_total", "conduit_cache_misses_total", "conduit_cache_errors_total")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,161 @@
+// Package oidc is an OpenID Connect implementation of the identity provider adapter.
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// ErrInvalidIDToken indicates the provider's response didn't have a valid id token for the login.
+var ErrInvalidIDToken = errors.New("provider did not return a valid id token")
+
+// Config is the information necessary to discover and authenticate with the provider.
+type Config struct {
+	// Name identifies the provider in urls and linked identities, it shouldn't change once users have logged in.
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback for the provider, e.g. https://conduit.com/api/users/oidc/<name>/callback
+	RedirectURL string
+	// Scopes are requested in addition to openid, it defaults to email and profile.
+	Scopes []string
+}
+
+// ConfigFromEnv reads the configuration of the named provider from the environment using getenv (like os.Getenv).
+// The variables are prefixed by OIDC_ and the uppercased name, e.g. OIDC_GOOGLE_ISSUER,
+// followed by ISSUER, CLIENT_ID, CLIENT_SECRET, REDIRECT_URL and the optional space separated SCOPES.
+func ConfigFromEnv(name string, getenv func(string) string) (Config, error) {
+	prefix := "OIDC_" + strings.ToUpper(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)) + "_"
+
+	c := Config{
+		Name:         name,
+		Issuer:       getenv(prefix + "ISSUER"),
+		ClientID:     getenv(prefix + "CLIENT_ID"),
+		ClientSecret: getenv(prefix + "CLIENT_SECRET"),
+		RedirectURL:  getenv(prefix + "REDIRECT_URL"),
+		Scopes:       strings.Fields(getenv(prefix + "SCOPES")),
+	}
+
+	missing := make([]string, 0)
+	for v, val := range map[string]string{
+		"ISSUER":        c.Issuer,
+		"CLIENT_ID":     c.ClientID,
+		"CLIENT_SECRET": c.ClientSecret,
+		"REDIRECT_URL":  c.RedirectURL,
+	} {
+		if val == "" {
+			missing = append(missing, prefix+v)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return c, fmt.Errorf("%v provider is missing %v", name, strings.Join(missing, ", "))
+	}
+
+	return c, nil
+}
+
+// NewInstance creates a new instance of the provider using OpenID Connect discovery.
+// The context is also used to refresh the provider's signing keys so it shouldn't be canceled.
+func NewInstance(ctx context.Context, c Config) (domain.IdentityProvider, error) {
+	p, err := oidc.NewProvider(ctx, c.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"email", "profile"}
+	}
+
+	return &implementation{
+		c.Name,
+		&oauth2.Config{
+			ClientID:     c.ClientID,
+			ClientSecret: c.ClientSecret,
+			RedirectURL:  c.RedirectURL,
+			Endpoint:     p.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		},
+		p.Verifier(&oidc.Config{ClientID: c.ClientID}),
+	}, nil
+}
+
+type implementation struct {
+	name     string
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// Name uniquely identifies the provider.
+func (r *implementation) Name() string {
+	return r.name
+}
+
+// AuthCodeURL is where the User logs in with the provider.
+func (r *implementation) AuthCodeURL(state string, nonce string, verifier string) string {
+	return r.oauth.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier))
+}
+
+type claims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nickname          string `json:"nickname"`
+	Picture           string `json:"picture"`
+}
+
+// Exchange swaps the code from the provider and its PKCE verifier for the identity of the User.
+func (r *implementation) Exchange(ctx context.Context, code string, verifier string, nonce string) (*domain.ExternalIdentity, error) {
+	tok, err := r.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+	idt, err := r.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if idt.Nonce != nonce {
+		return nil, ErrInvalidIDToken
+	}
+
+	var c claims
+	if err := idt.Claims(&c); err != nil {
+		return nil, err
+	}
+
+	un := c.PreferredUsername
+	if un == "" {
+		un = c.Nickname
+	}
+
+	return &domain.ExternalIdentity{
+		Provider:      r.name,
+		Subject:       idt.Subject,
+		Email:         c.Email,
+		EmailVerified: c.EmailVerified,
+		Username:      un,
+		Image:         c.Picture,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/oidc"
+	"github.com/brycekbargar/realworld-backend/adapters/oidc/oidctest"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://conduit.test/api/users/oidc/test/callback"
+
+func newProvider(t *testing.T) (*oidctest.Server, domain.IdentityProvider) {
+	s := oidctest.NewServer("conduit", "conduit secret")
+	t.Cleanup(s.Close)
+
+	p, err := oidc.NewInstance(context.Background(), oidc.Config{
+		Name:         "test",
+		Issuer:       s.URL,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	require.NoError(t, err)
+
+	return s, p
+}
+
+// login follows the provider's auth code url and returns the code and state it redirects back with.
+func login(t *testing.T, p domain.IdentityProvider, state string, nonce string, verifier string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Get(p.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, redirectURL, loc.Scheme+"://"+loc.Host+loc.Path)
+
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
+
+func Test_OIDC(t *testing.T) {
+	t.Parallel()
+
+	verifier := "a-pkce-verifier-that-is-at-least-43-characters-long"
+
+	t.Run("Login", func(t *testing.T) {
+		t.Parallel()
+		s, p := newProvider(t)
+		s.SetIdentity(oidctest.Identity{
+			Subject:           "jubilant subject",
+			Email:             "user@jubilant.com",
+			EmailVerified:     true,
+			PreferredUsername: "jubilant",
+			Picture:           "http://jubilant.com/profile.png",
+		})
+		assert.Equal(t, "test", p.Name())
+
+		code, state := login(t, p, "jubilant state", "jubilant nonce", verifier)
+		assert.Equal(t, "jubilant state", state)
+
+		id, err := p.Exchange(context.Background(), code, verifier, "jubilant nonce")
+		require.NoError(t, err)
+		assert.Equal(t, &domain.ExternalIdentity{
+			Provider:      "test",
+			Subject:       "jubilant subject",
+			Email:         "user@jubilant.com",
+			EmailVerified: true,
+			Username:      "jubilant",
+			Image:         "http://jubilant.com/profile.png",
+		}, id)
+
+		_, err = p.Exchange(context.Background(), code, verifier, "jubilant nonce")
+		assert.Error(t, err, "codes are single use")
+	})
+
+	t.Run("PKCE Verifier", func(t *testing.T) {
+		t.Parallel()
+		_, p := newProvider(t)
+
+		code, _ := login(t, p, "state", "nonce", verifier)
+		_, err := p.Exchange(context.Background(), code, verifier+"-but-different", "nonce")
+		assert.Error(t, err)
+	})
+
+	t.Run("Nonce", func(t *testing.T) {
+		t.Parallel()
+		_, p := newProvider(t)
+
+		code, _ := login(t, p, "state", "nonce", verifier)
+		_, err := p.Exchange(context.Background(), code, verifier, "replayed nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func Test_ConfigFromEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"OIDC_CORP_SSO_ISSUER":        "https://sso.corp.test",
+		"OIDC_CORP_SSO_CLIENT_ID":     "conduit",
+		"OIDC_CORP_SSO_CLIENT_SECRET": "conduit secret",
+		"OIDC_CORP_SSO_REDIRECT_URL":  "https://conduit.test/api/users/oidc/corp-sso/callback",
+		"OIDC_CORP_SSO_SCOPES":        "email  groups",
+		"OIDC_PARTIAL_ISSUER":         "https://partial.test",
+	}
+	getenv := func(k string) string { return env[k] }
+
+	c, err := oidc.ConfigFromEnv("corp-sso", getenv)
+	require.NoError(t, err)
+	assert.Equal(t, oidc.Config{
+		Name:         "corp-sso",
+		Issuer:       "https://sso.corp.test",
+		ClientID:     "conduit",
+		ClientSecret: "conduit secret",
+		RedirectURL:  "https://conduit.test/api/users/oidc/corp-sso/callback",
+		Scopes:       []string{"email", "groups"},
+	}, c)
+
+	_, err = oidc.ConfigFromEnv("partial", getenv)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OIDC_PARTIAL_CLIENT_ID, OIDC_PARTIAL_CLIENT_SECRET, OIDC_PARTIAL_REDIRECT_URL")
+}
@@ -0,0 +1,78 @@
+// Package outbox is an implementation of the mailer adapter that keeps emails instead of delivering them.
+// It's useful for tests and for running the application locally.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// Outbox is a Mailer that keeps every sent Email so they can be read back.
+type Outbox struct {
+	mu   *sync.Mutex
+	sent []domain.Email
+	path string
+}
+
+// NewInstance creates a new instance of the outbox that keeps emails in memory.
+func NewInstance() *Outbox {
+	return &Outbox{
+		&sync.Mutex{},
+		make([]domain.Email, 0),
+		"",
+	}
+}
+
+// NewFileInstance creates a new instance of the outbox that also appends each email to the file as a line of json.
+func NewFileInstance(path string) *Outbox {
+	o := NewInstance()
+	o.path = path
+	return o
+}
+
+// SendMail keeps the Email in the outbox.
+func (o *Outbox) SendMail(_ context.Context, e *domain.Email) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.path != "" {
+		if err := o.write(e); err != nil {
+			return err
+		}
+	}
+
+	o.sent = append(o.sent, *e)
+	return nil
+}
+
+func (o *Outbox) write(e *domain.Email) error {
+	f, err := os.OpenFile(o.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Sent lists all the Emails sent to the address, oldest first.
+func (o *Outbox) Sent(to string) []domain.Email {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	es := make([]domain.Email, 0)
+	for _, e := range o.sent {
+		if strings.EqualFold(e.To, to) {
+			es = append(es, e)
+		}
+	}
+	return es
+}
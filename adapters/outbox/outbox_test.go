@@ -0,0 +1,60 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Outbox(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Sent Emails", func(t *testing.T) {
+		t.Parallel()
+		o := outbox.NewInstance()
+
+		require.NoError(t, o.SendMail(context.Background(), &domain.Email{To: "user@chatty.com", Subject: "first"}))
+		require.NoError(t, o.SendMail(context.Background(), &domain.Email{To: "user@quiet.com", Subject: "other"}))
+		require.NoError(t, o.SendMail(context.Background(), &domain.Email{To: "USER@chatty.com", Subject: "second"}))
+
+		es := o.Sent("user@chatty.com")
+		require.Len(t, es, 2)
+		assert.Equal(t, "first", es[0].Subject)
+		assert.Equal(t, "second", es[1].Subject)
+		assert.Empty(t, o.Sent("user@silent.com"))
+	})
+
+	t.Run("Sent Emails are written to the file", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "outbox.jsonl")
+		o := outbox.NewFileInstance(path)
+
+		require.NoError(t, o.SendMail(context.Background(), &domain.Email{To: "user@wordy.com", Subject: "first", Body: "hello\nthere"}))
+		require.NoError(t, o.SendMail(context.Background(), &domain.Email{To: "user@wordy.com", Subject: "second"}))
+		assert.Len(t, o.Sent("user@wordy.com"), 2)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		es := make([]domain.Email, 0)
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			var e domain.Email
+			require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+			es = append(es, e)
+		}
+		require.Len(t, es, 2)
+		assert.Equal(t, "hello\nthere", es[0].Body)
+		assert.Equal(t, "second", es[1].Subject)
+	})
+}
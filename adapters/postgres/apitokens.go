@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v4"
+)
+
+const selectAPITokens = `
+SELECT t.id, u.email AS owner_email, t.name, t.hash, t.scopes, t.created AS created_at_utc
+	FROM api_tokens t, users u
+	WHERE t.owner_id = u.id
+`
+
+// apiToken has the scopes as strings because pgx can't scan arrays into named types.
+type apiToken struct {
+	ID           int
+	OwnerEmail   string
+	Name         string
+	Hash         string
+	Scopes       []string
+	CreatedAtUTC time.Time
+}
+
+func (t *apiToken) toDomain() *domain.APIToken {
+	scopes := make([]domain.Scope, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return &domain.APIToken{
+		ID:           t.ID,
+		OwnerEmail:   t.OwnerEmail,
+		Name:         t.Name,
+		Hash:         t.Hash,
+		Scopes:       scopes,
+		CreatedAtUTC: t.CreatedAtUTC,
+	}
+}
+
+// CreateAPIToken creates a new personal API token.
+func (r *implementation) CreateAPIToken(ctx context.Context, t *domain.APIToken) (*domain.APIToken, error) {
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	var id int
+	err := r.db.QueryRow(ctx, `
+INSERT INTO api_tokens (owner_id, name, hash, scopes)
+	SELECT u.id, $2, $3, $4
+	FROM users u
+	WHERE u.email = $1
+	RETURNING id`,
+		t.OwnerEmail, t.Name, t.Hash, scopes).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, domain.ErrDuplicateAPIToken
+		}
+
+		return nil, err
+	}
+
+	found := new(apiToken)
+	if err := pgxscan.Get(ctx, r.db, found, selectAPITokens+`
+	AND t.id = $1
+`, id); err != nil {
+		return nil, err
+	}
+
+	return found.toDomain(), nil
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (r *implementation) APITokensByOwner(ctx context.Context, em string) ([]domain.APIToken, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit(ctx)
+
+	if _, err := getUserByEmail(ctx, tx, em); err != nil {
+		return nil, err
+	}
+
+	var found []apiToken
+	err = pgxscan.Select(ctx, tx, &found, selectAPITokens+`
+	AND u.email = $1
+	ORDER BY lower(t.name)
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.APIToken, 0, len(found))
+	for i := range found {
+		results = append(results, *found[i].toDomain())
+	}
+
+	return results, nil
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (r *implementation) GetAPITokenByHash(ctx context.Context, h string) (*domain.APIToken, error) {
+	found := new(apiToken)
+	err := pgxscan.Get(ctx, r.db, found, selectAPITokens+`
+	AND t.hash = $1
+`, h)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found.toDomain(), nil
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (r *implementation) DeleteAPIToken(ctx context.Context, em string, id int) error {
+	tag, err := r.db.Exec(ctx, `
+DELETE FROM api_tokens
+	USING users u
+	WHERE u.email = $1
+	AND owner_id = u.id
+	AND api_tokens.id = $2
+`, em, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrAPITokenNotFound
+	}
+
+	return nil
+}
@@ -19,11 +19,12 @@ func (r *implementation) CreateArticle(ctx context.Context, a *domain.Article) (
 		return nil, err
 	}
 
+	a.RenderBody()
 	res, err := tx.Exec(ctx, `
-INSERT INTO articles (slug, title, description, body, tags, author_id)
-	(SELECT $2, $3, $4, $5, $6, u.id
+INSERT INTO articles (uid, slug, title, description, body, body_html, tags, hidden, author_id)
+	(SELECT $9, $2, $3, $4, $5, $6, $7, $8, u.id
 	FROM users u WHERE u.email = $1)`,
-		a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.TagList)
+		a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, a.TagList, a.Hidden, a.ID)
 	if err != nil {
 		tx.Rollback(ctx)
 
@@ -49,8 +50,74 @@ INSERT INTO articles (slug, title, description, body, tags, author_id)
 	return r.GetArticleBySlug(ctx, a.Slug)
 }
 
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (r *implementation) ImportArticle(ctx context.Context, a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	a.RenderBody()
+	res, err := tx.Exec(ctx, `
+INSERT INTO articles (uid, slug, title, description, body, body_html, tags, hidden, created, updated, author_id)
+	(SELECT $9, $2, $3, $4, $5, $6, $7, $8, $10, $11, u.id
+	FROM users u WHERE u.email = $1)`,
+		a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, a.TagList, a.Hidden, a.ID,
+		a.CreatedAtUTC.UTC(), a.UpdatedAtUTC.UTC())
+	if err != nil {
+		tx.Rollback(ctx)
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, domain.ErrDuplicateArticle
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, domain.ErrNoAuthor
+		}
+
+		return nil, err
+	}
+	if res.RowsAffected() != 1 {
+		tx.Rollback(ctx)
+		return nil, domain.ErrNoAuthor
+	}
+
+	for _, c := range a.Comments {
+		res, err := tx.Exec(ctx, `
+INSERT INTO article_comments (uid, article_id, author_id, body, body_html, hidden, created)
+	(SELECT $7, a.id, u.id, $3, $4, $5, $6
+		FROM articles a, users u
+		WHERE a.slug = $1
+		AND u.email = $2)`,
+			a.Slug, c.AuthorEmail, c.Body, c.HTML(), c.Hidden, c.CreatedAtUTC.UTC(), c.UID)
+		if err != nil {
+			tx.Rollback(ctx)
+			return nil, err
+		}
+		if res.RowsAffected() != 1 {
+			tx.Rollback(ctx)
+			return nil, domain.ErrUserNotFound
+		}
+	}
+
+	imported, err := getCommentsBySlug(ctx, tx, a.Slug, true)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
 // LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
 func (r *implementation) LatestArticlesByCriteria(ctx context.Context, lc domain.ListCriteria) ([]domain.AuthoredArticle, error) {
+	if lc.Limit < 1 {
+		// a negative LIMIT is an error
+		return make([]domain.AuthoredArticle, 0), nil
+	}
+
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
 		return nil, err
@@ -74,15 +141,28 @@ WITH faves AS (
 		a.author_id = u.id
 	LEFT JOIN faves f ON
 		a.id = f.id
-	WHERE (length($3) = 0 OR $3 = ANY(a.tags))
+	WHERE ($7 OR NOT a.hidden)
+	AND (length($3) = 0 OR $3 = ANY(a.tags))
 	AND ($4::text[] IS NULL OR array_length($4::text[], 1) = 0 OR u.email = ANY($4))
 	AND (length($5) = 0 OR f.email = $5)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM users v, blocked_users b
+		WHERE v.email = $6
+		AND b.blocker_id = v.id
+		AND b.blocked_id = a.author_id)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM users v, muted_users m
+		WHERE v.email = $6
+		AND m.muter_id = v.id
+		AND m.muted_id = a.author_id)
 	ORDER BY a.updated DESC
 )
 SELECT slug FROM slugs
 LIMIT $1 OFFSET $2
 `,
-		lc.Limit, lc.Offset, lc.Tag, lc.AuthorEmails, lc.FavoritedByUserEmail)
+		lc.Limit, max(lc.Offset, 0), lc.Tag, lc.AuthorEmails, lc.FavoritedByUserEmail, lc.ViewerEmail, lc.WithHidden)
 	if err != nil {
 		return nil, err
 	}
@@ -127,14 +207,17 @@ WITH faves AS (
 	GROUP BY a.id
 )
 SELECT
-	a.slug
+	a.uid AS id
+	,a.slug
 	,a.title
 	,a.description
 	,a.body
+	,a.body_html
 	,a.tags as tag_list
 	,a.created AS created_at_utc
 	,a.updated AS updated_at_utc
 	,u.email AS author_email
+	,a.hidden
 	,f.count AS favorite_count
 FROM 
 	articles a
@@ -163,6 +246,34 @@ ORDER BY a.updated DESC
 	return found, nil
 }
 
+// GetArticleByID gets a single article with the given id.
+func (r *implementation) GetArticleByID(ctx context.Context, id string) (*domain.AuthoredArticle, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit(ctx)
+
+	var s string
+	err = tx.QueryRow(ctx, `
+SELECT slug
+	FROM articles
+	WHERE uid = $1`, id).Scan(&s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := getArticleBySlug(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &found[0], nil
+}
+
 // GetCommentsBySlug gets a single article and its comments with the given slug.
 func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*domain.CommentedArticle, error) {
 	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
@@ -171,19 +282,36 @@ func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*doma
 	}
 	defer tx.Commit(ctx)
 
-	found, err := getArticleBySlug(ctx, tx, s)
+	return getCommentsBySlug(ctx, tx, s, false)
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (r *implementation) GetCommentsWithHiddenBySlug(ctx context.Context, s string) (*domain.CommentedArticle, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit(ctx)
+
+	return getCommentsBySlug(ctx, tx, s, true)
+}
+
+func getCommentsBySlug(ctx context.Context, q pgxscan.Querier, s string, withHidden bool) (*domain.CommentedArticle, error) {
+	found, err := getArticleBySlug(ctx, q, s)
 	if err != nil {
 		return nil, err
 	}
 
 	var comments []domain.Comment
-	err = pgxscan.Select(ctx, tx, &comments, `
-SELECT c.id, c.body, c.created as created_at_utc, u.email as author_email
+	err = pgxscan.Select(ctx, q, &comments, `
+SELECT c.id, c.uid, c.body, c.body_html, c.created as created_at_utc, u.email as author_email, c.hidden
 	FROM articles a, article_comments c, users u
 	WHERE a.slug = $1
 	AND a.id = c.article_id
 	AND u.id = c.author_id
-`, s)
+	AND ($2 OR NOT c.hidden)
+	ORDER BY c.id
+`, s, withHidden)
 	if err != nil {
 		return nil, err
 	}
@@ -214,13 +342,14 @@ func (r *implementation) UpdateArticleBySlug(ctx context.Context, s string, upda
 		return nil, err
 	}
 
+	a.RenderBody()
 	res, err := tx.Exec(ctx, `
 UPDATE articles
-	SET slug = $3, title = $4, description = $5, body = $6, updated = now() at time zone 'utc', author_id = u.id
+	SET slug = $3, title = $4, description = $5, body = $6, body_html = $7, hidden = $8, updated = now() at time zone 'utc', author_id = u.id
  	FROM users u
 	WHERE slug = $1
 	AND u.email = $2
-	`, s, a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body)
+	`, s, a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, a.Hidden)
 
 	if err != nil {
 		tx.Rollback(ctx)
@@ -251,7 +380,7 @@ UPDATE articles
 // UpdateCommentsBySlug finds a single article based on its slug
 // then applies the provide mutations to its comments.
 func (r *implementation) UpdateCommentsBySlug(ctx context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (*domain.Comment, error) {
-	a, err := r.GetCommentsBySlug(ctx, s)
+	a, err := getCommentsBySlug(ctx, r.db, s, true)
 	if err != nil {
 		return nil, err
 	}
@@ -268,12 +397,17 @@ func (r *implementation) UpdateCommentsBySlug(ctx context.Context, s string, upd
 
 	var new *domain.Comment
 	ids := make([]int, 0, len(a.Comments))
+	hidden := make([]int, 0)
 	for _, c := range a.Comments {
+		c := c
 		if c.ID <= 0 {
 			new = &c
 		} else {
 			ids = append(ids, c.ID)
 		}
+		if c.ID > 0 && c.Hidden {
+			hidden = append(hidden, c.ID)
+		}
 	}
 
 	_, err = tx.Exec(ctx, `
@@ -281,7 +415,7 @@ DELETE FROM article_comments
 	USING articles a 
 	WHERE a.slug = $1
 	AND a.id = article_id
-	AND article_comments.id <> ANY($2)
+	AND article_comments.id <> ALL($2)
 `,
 		s, ids)
 	if err != nil {
@@ -289,17 +423,30 @@ DELETE FROM article_comments
 		return nil, err
 	}
 
+	_, err = tx.Exec(ctx, `
+UPDATE article_comments
+	SET hidden = article_comments.id = ANY($2)
+	FROM articles a
+	WHERE a.slug = $1
+	AND a.id = article_id
+`,
+		s, hidden)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
 	if new != nil {
 		var id int
 		var created time.Time
 		err = tx.QueryRow(ctx, `
-INSERT INTO article_comments (article_id, author_id, body)
-	(SELECT a.id, u.id, $3
+INSERT INTO article_comments (uid, article_id, author_id, body, body_html, hidden)
+	(SELECT $6, a.id, u.id, $3, $4, $5
 		FROM articles a, users u
 		WHERE a.slug = $1
 		AND u.email = $2)
 	RETURNING id, created`,
-			a.Slug, new.AuthorEmail, new.Body).Scan(&id, &created)
+			a.Slug, new.AuthorEmail, new.Body, new.HTML(), new.Hidden, new.UID).Scan(&id, &created)
 
 		if err != nil {
 			tx.Rollback(ctx)
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc(
+		"conduit_pgxpool_acquired_conns", "Connections currently in use.", nil, nil)
+	poolIdleConns = prometheus.NewDesc(
+		"conduit_pgxpool_idle_conns", "Connections currently idle.", nil, nil)
+	poolTotalConns = prometheus.NewDesc(
+		"conduit_pgxpool_total_conns", "Connections currently open, including ones being constructed.", nil, nil)
+	poolMaxConns = prometheus.NewDesc(
+		"conduit_pgxpool_max_conns", "Most connections the pool will open.", nil, nil)
+	poolAcquires = prometheus.NewDesc(
+		"conduit_pgxpool_acquires_total", "How many connections have been acquired from the pool.", nil, nil)
+	poolEmptyAcquires = prometheus.NewDesc(
+		"conduit_pgxpool_empty_acquires_total", "How many acquires had to wait for a connection because none were idle.", nil, nil)
+	poolCanceledAcquires = prometheus.NewDesc(
+		"conduit_pgxpool_canceled_acquires_total", "How many acquires were canceled before they got a connection.", nil, nil)
+	poolAcquireSeconds = prometheus.NewDesc(
+		"conduit_pgxpool_acquire_seconds_total", "How long has been spent acquiring connections.", nil, nil)
+)
+
+// Describe describes the pool statistics for Prometheus.
+func (r *implementation) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquires
+	ch <- poolEmptyAcquires
+	ch <- poolCanceledAcquires
+	ch <- poolAcquireSeconds
+}
+
+// Collect collects the pool statistics for Prometheus when they're scraped.
+func (r *implementation) Collect(ch chan<- prometheus.Metric) {
+	s := r.db.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquires, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSeconds, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
@@ -0,0 +1,203 @@
+package postgres
+
+// migrations are applied in order to bring the schema up to date.
+// Each one is applied exactly once and is tracked by its version in the schema_version table.
+var migrations = []struct {
+	version string
+	up      string
+}{
+	{"0.0.1.0", `
+CREATE TABLE users (
+	id 			serial PRIMARY KEY,
+	email		text NOT NULL UNIQUE,
+	username	text NOT NULL UNIQUE,
+	bio			text,
+	image		text
+);
+CREATE TABLE user_passwords (
+	id		integer PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+	hash	text NOT NULL
+);
+
+CREATE TABLE followed_users (
+	follower_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	followed_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (follower_id, followed_id)
+);
+
+CREATE TABLE articles (
+	id 			serial PRIMARY KEY,
+	slug		text NOT NULL UNIQUE,
+	title		text NOT NULL,
+	description	text,
+	body 		text,
+	tags 		text[],
+	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
+	updated	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
+	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE
+);
+
+CREATE TABLE favorited_articles (
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	UNIQUE (user_id, article_id)
+);
+
+CREATE TABLE article_comments (
+	id 			serial PRIMARY KEY,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	body		text,
+	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc')
+);
+`},
+	{"0.0.2.0", `
+ALTER TABLE users
+	ADD COLUMN role		text NOT NULL DEFAULT 'user',
+	ADD COLUMN banned	boolean NOT NULL DEFAULT false;
+
+ALTER TABLE articles
+	ADD COLUMN hidden	boolean NOT NULL DEFAULT false;
+`},
+	{"0.0.3.0", `
+ALTER TABLE article_comments
+	ADD COLUMN hidden	boolean NOT NULL DEFAULT false;
+
+CREATE TABLE reports (
+	id 				serial PRIMARY KEY,
+	reporter_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	article_id 		integer REFERENCES articles ON DELETE SET NULL,
+	article_slug	text NOT NULL,
+	comment_id		integer NOT NULL DEFAULT 0,
+	reason			text NOT NULL,
+	status			text NOT NULL DEFAULT 'open',
+	created	 		timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
+	resolver_id 	integer REFERENCES users ON DELETE SET NULL,
+	resolution		text,
+	resolved	 	timestamp WITHOUT TIME ZONE
+);
+CREATE INDEX reports_status_idx ON reports (status, id);
+
+CREATE TABLE moderation_audit (
+	id 				serial PRIMARY KEY,
+	actor_email		text NOT NULL,
+	action			text NOT NULL,
+	article_slug	text NOT NULL DEFAULT '',
+	comment_id		integer NOT NULL DEFAULT 0,
+	user_email		text NOT NULL DEFAULT '',
+	report_id		integer NOT NULL DEFAULT 0,
+	note			text NOT NULL DEFAULT '',
+	created	 		timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc')
+);
+`},
+	{"0.0.4.0", `
+CREATE TABLE blocked_users (
+	blocker_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	blocked_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (blocker_id, blocked_id)
+);
+
+CREATE TABLE muted_users (
+	muter_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	muted_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (muter_id, muted_id)
+);
+`},
+	{"0.0.5.0", `
+CREATE INDEX followed_users_followed_id_idx ON followed_users (followed_id);
+`},
+	{"0.0.6.0", `
+CREATE TABLE reading_lists (
+	id 			serial PRIMARY KEY,
+	owner_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	slug		text NOT NULL,
+	name		text NOT NULL,
+	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
+	updated	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
+	UNIQUE (owner_id, slug)
+);
+
+CREATE TABLE reading_list_articles (
+	list_id 	integer NOT NULL REFERENCES reading_lists ON DELETE CASCADE,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	position	integer NOT NULL,
+	UNIQUE (list_id, article_id)
+);
+`},
+	{"0.0.7.0", `
+ALTER TABLE articles ADD COLUMN body_html text NOT NULL DEFAULT '';
+ALTER TABLE article_comments ADD COLUMN body_html text NOT NULL DEFAULT '';
+`},
+	{"0.0.8.0", `
+ALTER TABLE users ADD COLUMN verified boolean NOT NULL DEFAULT false;
+`},
+	{"0.0.9.0", `
+CREATE TABLE user_identities (
+	provider	text NOT NULL,
+	subject		text NOT NULL,
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	PRIMARY KEY (provider, subject)
+);
+`},
+	{"0.0.10.0", `
+ALTER TABLE users
+	ADD COLUMN totp_secret text NOT NULL DEFAULT '',
+	ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false,
+	ADD COLUMN totp_recovery_codes text[] NOT NULL DEFAULT '{}',
+	ADD COLUMN totp_last_counter bigint NOT NULL DEFAULT 0,
+	ADD COLUMN totp_failures integer NOT NULL DEFAULT 0,
+	ADD COLUMN totp_locked_until timestamp WITHOUT TIME ZONE NOT NULL DEFAULT '0001-01-01 00:00:00';
+`},
+	{"0.0.11.0", `
+CREATE TABLE api_tokens (
+	id 			serial PRIMARY KEY,
+	owner_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	name		text NOT NULL,
+	hash		text NOT NULL UNIQUE,
+	scopes		text[] NOT NULL,
+	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc')
+);
+CREATE UNIQUE INDEX api_tokens_owner_name ON api_tokens (owner_id, lower(name));
+`},
+	{"0.0.12.0", `
+ALTER TABLE users ADD COLUMN uid text;
+UPDATE users SET uid = md5(random()::text || clock_timestamp()::text)::uuid::text;
+ALTER TABLE users ALTER COLUMN uid SET NOT NULL;
+CREATE UNIQUE INDEX users_uid ON users (uid);
+
+CREATE TABLE previous_usernames (
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	username	text NOT NULL,
+	changed	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc')
+);
+CREATE UNIQUE INDEX previous_usernames_username ON previous_usernames (lower(username));
+`},
+	{"0.0.13.0", `
+ALTER TABLE articles ADD COLUMN uid text;
+UPDATE articles SET uid = md5(random()::text || clock_timestamp()::text)::uuid::text;
+ALTER TABLE articles ALTER COLUMN uid SET NOT NULL;
+CREATE UNIQUE INDEX articles_uid ON articles (uid);
+`},
+	{"0.0.14.0", `
+ALTER TABLE article_comments ADD COLUMN uid text;
+UPDATE article_comments SET uid = md5(random()::text || clock_timestamp()::text)::uuid::text;
+ALTER TABLE article_comments ALTER COLUMN uid SET NOT NULL;
+CREATE UNIQUE INDEX article_comments_uid ON article_comments (uid);
+`},
+}
+
+// pending lists the versions of the migrations that haven't been applied yet.
+func pending(applied []string) []string {
+	done := make(map[string]interface{}, len(applied))
+	for _, v := range applied {
+		done[v] = nil
+	}
+
+	missing := make([]string, 0)
+	for _, m := range migrations {
+		if _, ok := done[m.version]; !ok {
+			missing = append(missing, m.version)
+		}
+	}
+	return missing
+}
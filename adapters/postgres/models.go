@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/pgxscan"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -13,7 +16,15 @@ import (
 func MustNewInstance(dsn string) Migrateable {
 	ctx := context.Background()
 
-	pool, err := pgxpool.Connect(ctx, dsn)
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		panic(err)
+	}
+	// Queries are only logged to trace them, see queryTracer
+	cfg.ConnConfig.Logger = queryTracer{}
+	cfg.ConnConfig.LogLevel = pgx.LogLevelInfo
+
+	pool, err := pgxpool.ConnectConfig(ctx, cfg)
 	if err != nil {
 		panic(err)
 	}
@@ -46,69 +57,42 @@ CREATE TABLE IF NOT EXISTS schema_version (
 		panic(err)
 	}
 
-	rows, err := tx.Query(
-		ctx,
-		"SELECT * FROM schema_version")
-	defer rows.Close()
+	// Only one instance should be migrating at a time
+	_, err = tx.Exec(ctx, `LOCK TABLE schema_version IN EXCLUSIVE MODE`)
 	if err != nil {
 		panic(err)
 	}
 
-	if !rows.Next() {
-		_, err = tx.Exec(ctx, `
-INSERT INTO schema_version VALUES 
-	('0.0.1.0');
-
-CREATE TABLE users (
-	id 			serial PRIMARY KEY,
-	email		text NOT NULL UNIQUE,
-	username	text NOT NULL UNIQUE,
-	bio			text,
-	image		text
-);
-CREATE TABLE user_passwords (
-	id		integer PRIMARY KEY REFERENCES users ON DELETE CASCADE,
-	hash	text NOT NULL
-);
-
-CREATE TABLE followed_users (
-	follower_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
-	followed_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
-	UNIQUE (follower_id, followed_id)
-);
-
-CREATE TABLE articles (
-	id 			serial PRIMARY KEY,
-	slug		text NOT NULL UNIQUE,
-	title		text NOT NULL,
-	description	text,
-	body 		text,
-	tags 		text[],
-	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
-	updated	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc'),
-	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE
-);
-
-CREATE TABLE favorited_articles (
-	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
-	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
-	UNIQUE (user_id, article_id)
-);
-
-CREATE TABLE article_comments (
-	id 			serial PRIMARY KEY,
-	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
-	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
-	body		text,
-	created	 	timestamp WITHOUT TIME ZONE DEFAULT (now() at time zone 'utc')
-);
-`)
-	}
+	var applied []string
+	err = pgxscan.Select(ctx, tx, &applied, `SELECT version FROM schema_version`)
 	if err != nil {
 		panic(err)
 	}
 
-	tx.Commit(ctx)
+	done := make(map[string]interface{}, len(applied))
+	for _, v := range applied {
+		done[v] = nil
+	}
+
+	for _, m := range migrations {
+		if _, ok := done[m.version]; ok {
+			continue
+		}
+
+		if _, err = tx.Exec(ctx, m.up); err != nil {
+			panic(err)
+		}
+		_, err = tx.Exec(ctx, `
+INSERT INTO schema_version (version)
+	VALUES ($1)`, m.version)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		panic(err)
+	}
 	return r
 }
 
@@ -116,6 +100,32 @@ type implementation struct {
 	db *pgxpool.Pool
 }
 
+// Ping checks the database can be reached and every migration has been applied.
+func (r *implementation) Ping(ctx context.Context) error {
+	if err := r.db.Ping(ctx); err != nil {
+		return err
+	}
+
+	var exists bool
+	err := r.db.QueryRow(ctx, `SELECT to_regclass('schema_version') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	applied := make([]string, 0)
+	if exists {
+		err = pgxscan.Select(ctx, r.db, &applied, `SELECT version FROM schema_version`)
+		if err != nil {
+			return err
+		}
+	}
+
+	if missing := pending(applied); len(missing) > 0 {
+		return fmt.Errorf("%w: %v", domain.ErrMigrationsPending, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 type queryer interface {
 	GetContext(context.Context, interface{}, string, ...interface{}) error
 }
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+const selectReports = `
+SELECT
+	r.id
+	,u.email AS reporter_email
+	,COALESCE(a.slug, r.article_slug) AS article_slug
+	,r.comment_id
+	,r.reason
+	,r.status
+	,r.created AS created_at_utc
+	,COALESCE(m.email, '') AS resolver_email
+	,COALESCE(r.resolution, '') AS resolution
+	,COALESCE(r.resolved, '0001-01-01 00:00:00') AS resolved_at_utc
+FROM reports r
+INNER JOIN users u ON
+	r.reporter_id = u.id
+LEFT JOIN articles a ON
+	r.article_id = a.id
+LEFT JOIN users m ON
+	r.resolver_id = m.id
+`
+
+// CreateReport creates a new report.
+func (r *implementation) CreateReport(ctx context.Context, rep *domain.Report) (*domain.Report, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `
+INSERT INTO reports (reporter_id, article_id, article_slug, comment_id, reason, status)
+	(SELECT u.id, a.id, a.slug, $3, $4, $5
+		FROM users u, articles a
+		WHERE u.email = $1
+		AND a.slug = $2)
+	RETURNING id`,
+		rep.ReporterEmail, rep.ArticleSlug, rep.CommentID, rep.Reason, string(domain.ReportOpen)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		tx.Rollback(ctx)
+		return nil, domain.ErrArticleNotFound
+	}
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return getReportByID(ctx, r.db, id)
+}
+
+// GetReportByID gets a single report with the given id.
+func (r *implementation) GetReportByID(ctx context.Context, id int) (*domain.Report, error) {
+	return getReportByID(ctx, r.db, id)
+}
+
+func getReportByID(ctx context.Context, q pgxscan.Querier, id int) (*domain.Report, error) {
+	found := new(domain.Report)
+	err := pgxscan.Get(ctx, q, found, selectReports+`
+WHERE r.id = $1`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (r *implementation) ReportsByStatus(ctx context.Context, s domain.ReportStatus, limit int, offset int) ([]domain.Report, error) {
+	found := []domain.Report{}
+	err := pgxscan.Select(ctx, r.db, &found, selectReports+`
+WHERE r.status = $1
+ORDER BY r.id
+LIMIT $2 OFFSET $3`, string(s), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (r *implementation) UpdateReportByID(ctx context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (*domain.Report, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Concurrent updates wait for each other so that only one of them sees the report while it's still open
+	if _, err = tx.Exec(ctx, `SELECT id FROM reports WHERE id = $1 FOR UPDATE`, id); err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	rep, err := getReportByID(ctx, tx, id)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	rep, err = update(rep)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	var resolved interface{}
+	if !rep.ResolvedAtUTC.IsZero() {
+		resolved = rep.ResolvedAtUTC
+	}
+
+	_, err = tx.Exec(ctx, `
+UPDATE reports
+	SET status = $2, resolution = $3, resolved = $4,
+		resolver_id = (SELECT u.id FROM users u WHERE u.email = $5)
+	WHERE id = $1`,
+		id, string(rep.Status), string(rep.Resolution), resolved, rep.ResolverEmail)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return getReportByID(ctx, r.db, id)
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (r *implementation) CreateAuditEntry(ctx context.Context, e *domain.AuditEntry) (*domain.AuditEntry, error) {
+	found := new(domain.AuditEntry)
+	err := pgxscan.Get(ctx, r.db, found, `
+INSERT INTO moderation_audit (actor_email, action, article_slug, comment_id, user_email, report_id, note)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING
+		id, actor_email, action, article_slug, comment_id,
+		user_email, report_id, note, created AS created_at_utc`,
+		e.ActorEmail, string(e.Action), e.ArticleSlug, e.CommentID, e.UserEmail, e.ReportID, e.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (r *implementation) LatestAuditEntries(ctx context.Context, limit int, offset int) ([]domain.AuditEntry, error) {
+	found := []domain.AuditEntry{}
+	err := pgxscan.Select(ctx, r.db, &found, `
+SELECT
+	id, actor_email, action, article_slug, comment_id,
+	user_email, report_id, note, created AS created_at_utc
+FROM moderation_audit
+ORDER BY id DESC
+LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/brycekbargar/realworld-backend/adapters/postgres"
+
+// queryTracer creates a span for every query pgx runs.
+// pgx v4 doesn't have hooks around queries so the spans are created after the fact from its logs,
+// which include how long the query took.
+type queryTracer struct{}
+
+// Log creates a span for the query being logged (if it's a query).
+func (queryTracer) Log(ctx context.Context, _ pgx.LogLevel, msg string, data map[string]interface{}) {
+	switch msg {
+	case "Query", "Exec", "CopyFrom",
+		"BatchResult.Exec", "BatchResult.Query", "BatchResult.QueryRow":
+	default:
+		return
+	}
+
+	end := time.Now()
+	start := end
+	if took, ok := data["time"].(time.Duration); ok {
+		start = end.Add(-took)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation.name", msg),
+	}
+	if sql, ok := data["sql"].(string); ok {
+		attrs = append(attrs, attribute.String("db.query.text", sql))
+	}
+	if table, ok := data["tableName"]; ok {
+		attrs = append(attrs, attribute.String("db.collection.name", fmt.Sprint(table)))
+	}
+	if rows, ok := data["rowCount"].(int); ok {
+		attrs = append(attrs, attribute.Int("db.response.returned_rows", rows))
+	}
+
+	_, span := otel.Tracer(tracerName).Start(ctx, "postgres."+msg,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...))
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 // CreateUser creates a new user.
@@ -19,12 +20,27 @@ func (r *implementation) CreateUser(ctx context.Context, u *domain.User) (*domai
 		return nil, err
 	}
 
+	var reserved bool
+	err = tx.QueryRow(ctx, `
+SELECT EXISTS (
+	SELECT 1 FROM previous_usernames
+		WHERE lower(username) = lower($1))`,
+		u.Username).Scan(&reserved)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	if reserved {
+		tx.Rollback(ctx)
+		return nil, domain.ErrDuplicateUser
+	}
+
 	var id int
 	err = tx.QueryRow(ctx, `
-INSERT INTO users (email, username, bio, image) 
-	VALUES ($1, $2, $3, $4)
+INSERT INTO users (uid, email, username, bio, image, role, banned, verified) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	RETURNING id`,
-		u.Email, u.Username, u.Bio, u.Image).Scan(&id)
+		u.ID, u.Email, u.Username, u.Bio, u.Image, u.Role, u.Banned, u.Verified).Scan(&id)
 
 	if err != nil {
 		tx.Rollback(ctx)
@@ -37,7 +53,7 @@ INSERT INTO users (email, username, bio, image)
 		return nil, err
 	}
 
-	// TODO: Use salts and pg stuff instead of the bcrypt server side implementation
+	// TODO: Use salts and pg stuff instead of the server side password hashers
 	_, err = tx.Exec(ctx, `
 INSERT INTO user_passwords (id, hash) 
 	VALUES ($1, $2)
@@ -101,17 +117,62 @@ SELECT a.slug
 		favorites[strings.ToLower(a)] = nil
 	}
 
+	var blocks []string
+	err = pgxscan.Select(ctx, tx, &blocks, `
+SELECT b.email
+	FROM users u, blocked_users bu, users b
+	WHERE u.email = $1
+	AND u.id = bu.blocker_id
+	AND b.id = bu.blocked_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking := make(map[string]interface{}, len(blocks))
+	for _, u := range blocks {
+		blocking[strings.ToLower(u)] = nil
+	}
+
+	var mutes []string
+	err = pgxscan.Select(ctx, tx, &mutes, `
+SELECT m.email
+	FROM users u, muted_users mu, users m
+	WHERE u.email = $1
+	AND u.id = mu.muter_id
+	AND m.id = mu.muted_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	muting := make(map[string]interface{}, len(mutes))
+	for _, u := range mutes {
+		muting[strings.ToLower(u)] = nil
+	}
+
 	return &domain.Fanboy{
 		User:      *found,
 		Following: following,
 		Favorites: favorites,
+		Blocking:  blocking,
+		Muting:    muting,
 	}, nil
 }
 
+// twoFactorColumns selects the TwoFactor of the users table aliased as u.
+const twoFactorColumns = `
+	,u.totp_secret AS "two_factor.secret"
+	,u.totp_enabled AS "two_factor.enabled"
+	,NULLIF(u.totp_recovery_codes, '{}') AS "two_factor.recovery_codes"
+	,u.totp_last_counter AS "two_factor.last_counter"
+	,u.totp_failures AS "two_factor.failed_attempts"
+	,u.totp_locked_until AS "two_factor.locked_until_utc"`
+
 func getUserByEmail(ctx context.Context, q pgxscan.Querier, em string) (*domain.User, error) {
 	found := new(domain.User)
 	err := pgxscan.Get(ctx, q, found, `
-SELECT u.email, u.username, u.bio, u.image, p.hash as password
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified`+twoFactorColumns+`
 	FROM users u, user_passwords p
 	WHERE u.email = $1 
 	AND u.id = p.id`, em)
@@ -134,11 +195,48 @@ func (r *implementation) GetAuthorByEmail(ctx context.Context, em string) domain
 	return auth
 }
 
+// GetUserByID finds a single user based on their id.
+func (r *implementation) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	found := new(domain.User)
+	err := pgxscan.Get(ctx, r.db, found, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified`+twoFactorColumns+`
+	FROM users u, user_passwords p
+	WHERE u.uid = $1 
+	AND u.id = p.id`, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (r *implementation) GetUserByPreviousUsername(ctx context.Context, un string) (*domain.User, error) {
+	found := new(domain.User)
+	err := pgxscan.Get(ctx, r.db, found, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified`+twoFactorColumns+`
+	FROM previous_usernames pu, users u, user_passwords p
+	WHERE lower(pu.username) = lower($1)
+	AND u.id = pu.user_id
+	AND u.id = p.id`, un)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
 // GetUserByUsername finds a single user based on their username.
 func (r *implementation) GetUserByUsername(ctx context.Context, un string) (*domain.User, error) {
 	found := new(domain.User)
 	err := pgxscan.Get(ctx, r.db, found, `
-SELECT u.email, u.username, u.bio, u.image, p.hash as password
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified`+twoFactorColumns+`
 	FROM users u, user_passwords p
 	WHERE u.username = $1 
 	AND u.id = p.id`, un)
@@ -166,19 +264,42 @@ func (r *implementation) UpdateUserByEmail(ctx context.Context, em string, updat
 		return nil, err
 	}
 
+	prevUn := u.Username
 	u, err = update(u)
 	if err != nil {
 		tx.Rollback(ctx)
 		return nil, err
 	}
 
+	var reserved bool
+	err = tx.QueryRow(ctx, `
+SELECT EXISTS (
+	SELECT 1 FROM previous_usernames pu, users u
+		WHERE lower(pu.username) = lower($2)
+		AND u.id = pu.user_id
+		AND u.email <> $1)`,
+		em, u.Username).Scan(&reserved)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, err
+	}
+	if reserved {
+		tx.Rollback(ctx)
+		return nil, domain.ErrDuplicateUser
+	}
+
 	var id int
 	err = tx.QueryRow(ctx, `
 UPDATE users 
-	SET email = $2, username = $3, bio = $4, image = $5
+	SET email = $2, username = $3, bio = $4, image = $5, role = $6, banned = $7, verified = $8,
+		totp_secret = $9, totp_enabled = $10, totp_recovery_codes = $11,
+		totp_last_counter = $12, totp_failures = $13, totp_locked_until = $14
 	WHERE email = $1
 	RETURNING id`,
-		em, u.Email, u.Username, u.Bio, u.Image).Scan(&id)
+		em, u.Email, u.Username, u.Bio, u.Image, u.Role, u.Banned, u.Verified,
+		// The column can't be null so no recovery codes has to be an empty array
+		u.TwoFactor.Secret, u.TwoFactor.Enabled, append([]string{}, u.TwoFactor.RecoveryCodes...),
+		u.TwoFactor.LastCounter, u.TwoFactor.FailedAttempts, u.TwoFactor.LockedUntilUTC).Scan(&id)
 
 	if err != nil {
 		tx.Rollback(ctx)
@@ -191,7 +312,7 @@ UPDATE users
 		return nil, err
 	}
 
-	// TODO: Use salts and pg stuff instead of the bcrypt server side implementation
+	// TODO: Use salts and pg stuff instead of the server side password hashers
 	_, err = tx.Exec(ctx, `
 UPDATE user_passwords
 	SET hash = $2
@@ -202,6 +323,28 @@ UPDATE user_passwords
 		return nil, err
 	}
 
+	if !strings.EqualFold(u.Username, prevUn) {
+		// Users can take back their own previous usernames
+		_, err = tx.Exec(ctx, `
+DELETE FROM previous_usernames
+	WHERE user_id = $1
+	AND lower(username) = lower($2)
+`, id, u.Username)
+		if err != nil {
+			tx.Rollback(ctx)
+			return nil, err
+		}
+
+		_, err = tx.Exec(ctx, `
+INSERT INTO previous_usernames (user_id, username)
+	VALUES ($1, $2)
+`, id, prevUn)
+		if err != nil {
+			tx.Rollback(ctx)
+			return nil, err
+		}
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return nil, err
 	}
@@ -251,6 +394,30 @@ DELETE FROM favorited_articles
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `
+DELETE FROM blocked_users
+	USING users u
+	WHERE u.email = $1
+	AND blocker_id = u.id
+`,
+		em)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+DELETE FROM muted_users
+	USING users u
+	WHERE u.email = $1
+	AND muter_id = u.id
+`,
+		em)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
 	follows := make([]string, 0, len(f.Following))
 	for k := range f.Following {
 		if k != "" {
@@ -291,6 +458,32 @@ INSERT INTO favorited_articles (user_id, article_id)
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `
+INSERT INTO blocked_users (blocker_id, blocked_id)
+	(SELECT u.id, b.id
+		FROM users u, users b
+		WHERE u.email = $1
+		AND b.email = ANY($2))
+`,
+		em, f.BlockingEmails())
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+INSERT INTO muted_users (muter_id, muted_id)
+	(SELECT u.id, m.id
+		FROM users u, users m
+		WHERE u.email = $1
+		AND m.email = ANY($2))
+`,
+		em, f.MutingEmails())
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		return err
 	}
@@ -298,3 +491,151 @@ INSERT INTO favorited_articles (user_id, article_id)
 	return nil
 
 }
+
+// ListUsers lists every user, ordered by username.
+func (r *implementation) ListUsers(ctx context.Context, limit int, offset int) ([]domain.User, error) {
+	users := []domain.User{}
+	err := pgxscan.Select(ctx, r.db, &users, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users u
+	ORDER BY lower(u.username)
+	LIMIT $1 OFFSET $2
+`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (r *implementation) FollowersByEmail(ctx context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return selectFollows(ctx, r.db, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users t, followed_users fu, users u
+	WHERE t.email = $1
+	AND t.id = fu.followed_id
+	AND u.id = fu.follower_id
+	ORDER BY u.username
+	LIMIT $2 OFFSET $3
+`, em, limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (r *implementation) FollowingByEmail(ctx context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return selectFollows(ctx, r.db, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users t, followed_users fu, users u
+	WHERE t.email = $1
+	AND t.id = fu.follower_id
+	AND u.id = fu.followed_id
+	ORDER BY u.username
+	LIMIT $2 OFFSET $3
+`, em, limit, offset)
+}
+
+func selectFollows(ctx context.Context, db *pgxpool.Pool, query string, em string, limit int, offset int) ([]domain.User, error) {
+	tx, err := db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit(ctx)
+
+	if _, err := getUserByEmail(ctx, tx, em); err != nil {
+		return nil, err
+	}
+
+	users := []domain.User{}
+	err = pgxscan.Select(ctx, tx, &users, query, em, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (r *implementation) GetFollowCountsByEmail(ctx context.Context, em string) (*domain.FollowCounts, error) {
+	counts := new(domain.FollowCounts)
+	err := pgxscan.Get(ctx, r.db, counts, `
+SELECT
+	(SELECT count(*) FROM followed_users fu WHERE fu.followed_id = u.id) as followers,
+	(SELECT count(*) FROM followed_users fu WHERE fu.follower_id = u.id) as following
+	FROM users u
+	WHERE u.email = $1
+`, em)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (r *implementation) GetUserByIdentity(ctx context.Context, provider string, subject string) (*domain.User, error) {
+	found := new(domain.User)
+	err := pgxscan.Get(ctx, r.db, found, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified`+twoFactorColumns+`
+	FROM user_identities i, users u, user_passwords p
+	WHERE i.provider = $1
+	AND i.subject = $2
+	AND u.id = i.user_id
+	AND u.id = p.id`, provider, subject)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (r *implementation) LinkIdentity(ctx context.Context, em string, i *domain.ExternalIdentity) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+INSERT INTO user_identities (provider, subject, user_id)
+	SELECT $2, $3, u.id
+	FROM users u
+	WHERE u.email = $1
+	ON CONFLICT (provider, subject) DO NOTHING
+`, em, i.Provider, i.Subject)
+	if err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		// Either the user doesn't exist or the identity is already linked
+		var linked string
+		err = tx.QueryRow(ctx, `
+SELECT u.email
+	FROM user_identities i, users u
+	WHERE i.provider = $1
+	AND i.subject = $2
+	AND u.id = i.user_id
+`, i.Provider, i.Subject).Scan(&linked)
+		tx.Rollback(ctx)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(linked, em) {
+			return domain.ErrDuplicateIdentity
+		}
+		return nil
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,119 @@
+// Package s3 is an S3 compatible object storage implementation of the blob storage adapter.
+// It only needs PutObject and GetObject so it signs requests itself instead of pulling in the AWS SDK.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// Config is the information necessary to reach and authenticate with the bucket.
+type Config struct {
+	// Endpoint is the scheme and host of the service, e.g. https://s3.us-east-1.amazonaws.com
+	// Buckets are always addressed by path so any S3 compatible service works.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// NewInstance creates a new instance of the S3 store for the configured bucket.
+func NewInstance(c Config) domain.BlobStore {
+	return &implementation{
+		c,
+		&http.Client{Timeout: 30 * time.Second},
+		time.Now,
+	}
+}
+
+type implementation struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// PutBlob stores the data with its content type under the key, replacing anything already there.
+func (r *implementation) PutBlob(ctx context.Context, key string, contentType string, data []byte) error {
+	if !domain.IsValidBlobKey(key) {
+		return domain.ErrInvalidBlobKey
+	}
+
+	req, err := r.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	r.sign(req, data)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+	return nil
+}
+
+// GetBlob gets the data and its content type stored under the key.
+func (r *implementation) GetBlob(ctx context.Context, key string) ([]byte, string, error) {
+	if !domain.IsValidBlobKey(key) {
+		return nil, "", domain.ErrBlobNotFound
+	}
+
+	req, err := r.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	r.sign(req, nil)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, "", domain.ErrBlobNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, "", responseError(res)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, res.Header.Get("Content-Type"), nil
+}
+
+func (r *implementation) request(ctx context.Context, method string, key string, data []byte) (*http.Request, error) {
+	url := fmt.Sprintf("%v/%v/%v",
+		strings.TrimSuffix(r.cfg.Endpoint, "/"),
+		uriEncode(r.cfg.Bucket),
+		uriEncode(key))
+
+	return http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+}
+
+func (r *implementation) sign(req *http.Request, data []byte) {
+	sum := sha256.Sum256(data)
+	signV4(req, hex.EncodeToString(sum[:]), r.now().UTC(), r.cfg.Region, r.cfg.AccessKeyID, r.cfg.SecretAccessKey)
+}
+
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("s3 %v %v: %v %s", res.Request.Method, res.Request.URL.Path, res.Status, body)
+}
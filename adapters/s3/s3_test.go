@@ -0,0 +1,95 @@
+package s3_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/s3"
+	"github.com/brycekbargar/realworld-backend/adapters/testcases"
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+var uut domain.BlobStore
+
+// standIn is just enough of S3 to store and retrieve objects from a single bucket.
+type standIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=standin-key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, "/standin-bucket/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.objects[r.URL.Path] = body
+		s.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", s.types[r.URL.Path])
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMain(m *testing.M) {
+	srv := httptest.NewServer(&standIn{
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	})
+
+	uut = s3.NewInstance(s3.Config{
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		Bucket:          "standin-bucket",
+		AccessKeyID:     "standin-key",
+		SecretAccessKey: "standin-secret",
+	})
+	res := m.Run()
+
+	srv.Close()
+	os.Exit(res)
+}
+
+func Test_Blobs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Put and Get Blob", func(t *testing.T) {
+		t.Parallel()
+		testcases.Blobs_PutBlob(t, uut)
+	})
+	t.Run("Invalid Keys", func(t *testing.T) {
+		t.Parallel()
+		testcases.Blobs_InvalidKeys(t, uut)
+	})
+}
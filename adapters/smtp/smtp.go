@@ -0,0 +1,123 @@
+// Package smtp is an SMTP relay implementation of the mailer adapter.
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// ErrInvalidHeader indicates an Email has a recipient or subject that would inject extra headers.
+var ErrInvalidHeader = errors.New("email headers can't contain line breaks")
+
+// Config is the information necessary to reach and authenticate with the relay.
+type Config struct {
+	// Addr is the host:port of the relay.
+	// STARTTLS is used whenever the relay supports it.
+	Addr string
+	// Username and Password are only used for PLAIN auth when the Username is set.
+	Username string
+	Password string
+	// From is the address emails are sent from.
+	From string
+}
+
+// NewInstance creates a new instance of the SMTP mailer for the configured relay.
+func NewInstance(c Config) domain.Mailer {
+	return &implementation{
+		c,
+		30 * time.Second,
+	}
+}
+
+type implementation struct {
+	cfg     Config
+	timeout time.Duration
+}
+
+// SendMail delivers the Email to the relay.
+func (r *implementation) SendMail(ctx context.Context, e *domain.Email) error {
+	if strings.ContainsAny(e.To+e.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", r.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+
+	host, _, err := net.SplitHostPort(r.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if r.cfg.Username != "" {
+		// PlainAuth refuses to send credentials unencrypted (except to localhost)
+		if err := c.Auth(smtp.PlainAuth("", r.cfg.Username, r.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(r.cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(e.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(r.message(e)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// message formats the Email as a plain-text RFC 5322 message.
+func (r *implementation) message(e *domain.Email) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", r.cfg.From)
+	fmt.Fprintf(&b, "To: %v\r\n", e.To)
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+
+	body := strings.ReplaceAll(e.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -0,0 +1,136 @@
+package smtp_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/smtp"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn is just enough of an SMTP relay to receive a message.
+type standIn struct {
+	net.Listener
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpt     string
+	received string
+}
+
+func newStandIn(t *testing.T) *standIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	s := &standIn{Listener: l}
+	go s.serve()
+	return s
+}
+
+func (s *standIn) serve() {
+	for {
+		conn, err := s.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(textproto.NewConn(conn))
+	}
+}
+
+func (s *standIn) handle(c *textproto.Conn) {
+	defer c.Close()
+
+	c.PrintfLine("220 stand-in ESMTP")
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO":
+			c.PrintfLine("250-stand-in")
+			c.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			s.auth = string(creds)
+			c.PrintfLine("235 ok")
+		case "MAIL":
+			s.from = line
+			c.PrintfLine("250 ok")
+		case "RCPT":
+			s.rcpt = line
+			c.PrintfLine("250 ok")
+		case "DATA":
+			c.PrintfLine("354 go ahead")
+			data, _ := c.ReadDotBytes()
+			s.received = string(data)
+			c.PrintfLine("250 ok")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			c.PrintfLine("502 not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func Test_SMTP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Send Mail", func(t *testing.T) {
+		t.Parallel()
+		s := newStandIn(t)
+		uut := smtp.NewInstance(smtp.Config{
+			Addr:     s.Addr().String(),
+			Username: "relay user",
+			Password: "relay password",
+			From:     "noreply@conduit.com",
+		})
+
+		err := uut.SendMail(context.Background(), &domain.Email{
+			To:      "user@talkative.com",
+			Subject: "Hello",
+			Body:    "line one\nline two\n",
+		})
+		require.NoError(t, err)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		assert.Equal(t, "\x00relay user\x00relay password", s.auth)
+		assert.Equal(t, "MAIL FROM:<noreply@conduit.com>", s.from)
+		assert.Equal(t, "RCPT TO:<user@talkative.com>", s.rcpt)
+		assert.Contains(t, s.received, "To: user@talkative.com\n")
+		assert.Contains(t, s.received, "Subject: Hello\n")
+		assert.Contains(t, s.received, "Content-Type: text/plain; charset=utf-8\n")
+		assert.True(t, strings.HasSuffix(s.received, "\nline one\nline two\n"), s.received)
+	})
+
+	t.Run("Header Injection", func(t *testing.T) {
+		t.Parallel()
+		s := newStandIn(t)
+		uut := smtp.NewInstance(smtp.Config{
+			Addr: s.Addr().String(),
+			From: "noreply@conduit.com",
+		})
+
+		err := uut.SendMail(context.Background(), &domain.Email{
+			To:      "user@sneaky.com",
+			Subject: "Hello\r\nBcc: everyone@sneaky.com",
+		})
+		assert.ErrorIs(t, err, smtp.ErrInvalidHeader)
+	})
+}
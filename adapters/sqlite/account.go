@@ -0,0 +1,194 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (r *implementation) DeleteUser(ctx context.Context, em string, m domain.DeletionMode) error {
+	if m == domain.DeleteContent {
+		// Everything else referencing the user is deleted in cascade
+		res, err := r.db.ExecContext(ctx, `
+DELETE FROM users
+	WHERE email = $1
+`, em)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return domain.ErrUserNotFound
+		}
+
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	u, err := getUserByEmail(ctx, tx, em)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := u.Anonymize(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+UPDATE users
+	SET uid = $9, email = $2, username = $3, bio = $4, image = $5, role = $6, banned = $7, verified = $8,
+		totp_secret = '', totp_enabled = false, totp_recovery_codes = '[]',
+		totp_last_counter = 0, totp_failures = 0, totp_locked_until = $10
+	WHERE email = $1
+	RETURNING id`,
+		em, u.Email, u.Username, u.Bio, u.Image, u.Role, u.Banned, u.Verified, u.ID, utc(time.Time{})).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+UPDATE user_passwords
+	SET hash = $2
+	WHERE id = $1`,
+		id, u.Password); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Everything only the user could see goes either way
+	for _, q := range []string{
+		`DELETE FROM followed_users WHERE follower_id = $1 OR followed_id = $1`,
+		`DELETE FROM blocked_users WHERE blocker_id = $1 OR blocked_id = $1`,
+		`DELETE FROM muted_users WHERE muter_id = $1 OR muted_id = $1`,
+		`DELETE FROM favorited_articles WHERE user_id = $1`,
+		`DELETE FROM reading_lists WHERE owner_id = $1`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM api_tokens WHERE owner_id = $1`,
+		`DELETE FROM previous_usernames WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ExportUserByEmail gathers everything kept about the user with the given email.
+func (r *implementation) ExportUserByEmail(ctx context.Context, em string) (*domain.UserExport, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	u, err := getUserByEmail(ctx, tx, em)
+	if err != nil {
+		return nil, err
+	}
+
+	x := &domain.UserExport{
+		User:         *u,
+		CreatedAtUTC: time.Now().UTC(),
+	}
+
+	err = sqlscan.Select(ctx, tx, &x.PreviousUsernames, `
+SELECT pu.username
+	FROM users u, previous_usernames pu
+	WHERE u.email = $1
+	AND u.id = pu.user_id
+	ORDER BY pu.changed
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlscan.Select(ctx, tx, &x.Identities, `
+SELECT i.provider, i.subject
+	FROM users u, user_identities i
+	WHERE u.email = $1
+	AND u.id = i.user_id
+	ORDER BY i.provider, i.subject
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlscan.Select(ctx, tx, &x.Following, `
+SELECT f.username
+	FROM users u, followed_users fu, users f
+	WHERE u.email = $1
+	AND u.id = fu.follower_id
+	AND f.id = fu.followed_id
+	ORDER BY f.username
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sqlscan.Select(ctx, tx, &x.Favorites, `
+SELECT a.slug
+	FROM users u, favorited_articles fa, articles a
+	WHERE u.email = $1
+	AND u.id = fa.user_id
+	AND a.id = fa.article_id
+	ORDER BY a.slug
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []article
+	err = sqlscan.Select(ctx, tx, &articles, selectArticles+`
+FROM articles a, users u
+WHERE u.email = $1
+AND a.author_id = u.id
+ORDER BY a.created
+`, em)
+	if err != nil {
+		return nil, err
+	}
+	for i := range articles {
+		x.Articles = append(x.Articles, articles[i].toDomain().Article)
+	}
+
+	err = sqlscan.Select(ctx, tx, &x.Comments, `
+SELECT a.slug AS article_slug, c.id, c.uid, c.body, c.body_html, c.created as created_at_utc, u.email as author_email, c.hidden
+	FROM articles a, article_comments c, users u
+	WHERE u.email = $1
+	AND a.id = c.article_id
+	AND u.id = c.author_id
+	ORDER BY c.created
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	var lists []readingList
+	err = sqlscan.Select(ctx, tx, &lists, selectReadingLists+`
+	AND u.email = $1
+	ORDER BY lower(l.name)
+`, em)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		if err := getReadingListArticles(ctx, tx, &lists[i]); err != nil {
+			return nil, err
+		}
+		x.ReadingLists = append(x.ReadingLists, lists[i].ReadingList)
+	}
+
+	return x, nil
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+const selectAPITokens = `
+SELECT t.id, u.email AS owner_email, t.name, t.hash, t.scopes, t.created AS created_at_utc
+	FROM api_tokens t, users u
+	WHERE t.owner_id = u.id
+`
+
+// apiToken has the scopes as a list because sqlite doesn't have arrays.
+type apiToken struct {
+	ID           int
+	OwnerEmail   string
+	Name         string
+	Hash         string
+	Scopes       list
+	CreatedAtUTC time.Time
+}
+
+func (t *apiToken) toDomain() *domain.APIToken {
+	scopes := make([]domain.Scope, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return &domain.APIToken{
+		ID:           t.ID,
+		OwnerEmail:   t.OwnerEmail,
+		Name:         t.Name,
+		Hash:         t.Hash,
+		Scopes:       scopes,
+		CreatedAtUTC: t.CreatedAtUTC,
+	}
+}
+
+// CreateAPIToken creates a new personal API token.
+func (r *implementation) CreateAPIToken(ctx context.Context, t *domain.APIToken) (*domain.APIToken, error) {
+	scopes := make(list, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO api_tokens (owner_id, name, hash, scopes, created)
+	SELECT u.id, $2, $3, $4, $5
+	FROM users u
+	WHERE u.email = $1
+	RETURNING id`,
+		t.OwnerEmail, t.Name, t.Hash, scopes, now()).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateAPIToken
+		}
+
+		return nil, err
+	}
+
+	found := new(apiToken)
+	if err := sqlscan.Get(ctx, r.db, found, selectAPITokens+`
+	AND t.id = $1
+`, id); err != nil {
+		return nil, err
+	}
+
+	return found.toDomain(), nil
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (r *implementation) APITokensByOwner(ctx context.Context, em string) ([]domain.APIToken, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	if _, err := getUserByEmail(ctx, tx, em); err != nil {
+		return nil, err
+	}
+
+	var found []apiToken
+	err = sqlscan.Select(ctx, tx, &found, selectAPITokens+`
+	AND u.email = $1
+	ORDER BY lower(t.name)
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.APIToken, 0, len(found))
+	for i := range found {
+		results = append(results, *found[i].toDomain())
+	}
+
+	return results, nil
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (r *implementation) GetAPITokenByHash(ctx context.Context, h string) (*domain.APIToken, error) {
+	found := new(apiToken)
+	err := sqlscan.Get(ctx, r.db, found, selectAPITokens+`
+	AND t.hash = $1
+`, h)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found.toDomain(), nil
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (r *implementation) DeleteAPIToken(ctx context.Context, em string, id int) error {
+	res, err := r.db.ExecContext(ctx, `
+DELETE FROM api_tokens
+	WHERE owner_id = (SELECT u.id FROM users u WHERE u.email = $1)
+	AND id = $2
+`, em, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return domain.ErrAPITokenNotFound
+	}
+
+	return nil
+}
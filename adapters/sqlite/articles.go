@@ -0,0 +1,475 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+// article has the tags as a list because sqlite doesn't have arrays.
+type article struct {
+	domain.AuthoredArticle
+	TagList list
+}
+
+func (a *article) toDomain() domain.AuthoredArticle {
+	a.AuthoredArticle.TagList = a.TagList
+	return a.AuthoredArticle
+}
+
+// selectArticles selects the articles table aliased as a with their authors aliased as u.
+const selectArticles = `
+SELECT
+	a.uid AS id
+	,a.slug
+	,a.title
+	,a.description
+	,a.body
+	,a.body_html
+	,a.tags as tag_list
+	,a.created AS created_at_utc
+	,a.updated AS updated_at_utc
+	,u.email AS author_email
+	,a.hidden`
+
+// CreateArticle creates a new article.
+func (r *implementation) CreateArticle(ctx context.Context, a *domain.Article) (*domain.AuthoredArticle, error) {
+	a.RenderBody()
+	res, err := r.db.ExecContext(ctx, `
+INSERT INTO articles (uid, slug, title, description, body, body_html, tags, hidden, created, updated, author_id)
+	SELECT $9, $2, $3, $4, $5, $6, $7, $8, $10, $10, u.id
+	FROM users u WHERE u.email = $1`,
+		a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, list(a.TagList), a.Hidden, a.ID, now())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateArticle
+		}
+		if isForeignKeyViolation(err) {
+			return nil, domain.ErrNoAuthor
+		}
+
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, domain.ErrNoAuthor
+	}
+
+	return r.GetArticleBySlug(ctx, a.Slug)
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (r *implementation) ImportArticle(ctx context.Context, a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a.RenderBody()
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO articles (uid, slug, title, description, body, body_html, tags, hidden, created, updated, author_id)
+	SELECT $9, $2, $3, $4, $5, $6, $7, $8, $10, $11, u.id
+	FROM users u WHERE u.email = $1`,
+		a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, list(a.TagList), a.Hidden, a.ID,
+		utc(a.CreatedAtUTC), utc(a.UpdatedAtUTC))
+	if err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateArticle
+		}
+		if isForeignKeyViolation(err) {
+			return nil, domain.ErrNoAuthor
+		}
+
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		tx.Rollback()
+		return nil, domain.ErrNoAuthor
+	}
+
+	for _, c := range a.Comments {
+		res, err := tx.ExecContext(ctx, `
+INSERT INTO article_comments (uid, article_id, author_id, body, body_html, hidden, created)
+	SELECT $7, a.id, u.id, $3, $4, $5, $6
+		FROM articles a, users u
+		WHERE a.slug = $1
+		AND u.email = $2`,
+			a.Slug, c.AuthorEmail, c.Body, c.HTML(), c.Hidden, utc(c.CreatedAtUTC), c.UID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 1 {
+			tx.Rollback()
+			return nil, domain.ErrUserNotFound
+		}
+	}
+
+	imported, err := getCommentsBySlug(ctx, tx, a.Slug, true)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+// LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
+func (r *implementation) LatestArticlesByCriteria(ctx context.Context, lc domain.ListCriteria) ([]domain.AuthoredArticle, error) {
+	if lc.Limit < 1 {
+		// LIMIT -1 is unlimited
+		return make([]domain.AuthoredArticle, 0), nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	var slugs []string
+	err = sqlscan.Select(ctx, tx, &slugs, `
+SELECT a.slug
+	FROM articles a
+	INNER JOIN users u ON
+		a.author_id = u.id
+	WHERE ($7 OR NOT a.hidden)
+	AND (length($3) = 0 OR EXISTS (
+		SELECT 1
+		FROM json_each(a.tags) t
+		WHERE t.value = $3))
+	AND (json_array_length($4) = 0 OR u.email IN (SELECT value FROM json_each($4)))
+	AND (length($5) = 0 OR EXISTS (
+		SELECT 1
+		FROM favorited_articles fa, users fu
+		WHERE fa.article_id = a.id
+		AND fa.user_id = fu.id
+		AND fu.email = $5))
+	AND NOT EXISTS (
+		SELECT 1
+		FROM users v, blocked_users b
+		WHERE v.email = $6
+		AND b.blocker_id = v.id
+		AND b.blocked_id = a.author_id)
+	AND NOT EXISTS (
+		SELECT 1
+		FROM users v, muted_users m
+		WHERE v.email = $6
+		AND m.muter_id = v.id
+		AND m.muted_id = a.author_id)
+	ORDER BY a.updated DESC, a.id DESC
+	LIMIT $1 OFFSET $2
+`,
+		lc.Limit, max(lc.Offset, 0), lc.Tag, list(lc.AuthorEmails), lc.FavoritedByUserEmail, lc.ViewerEmail, lc.WithHidden)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := getArticleBySlug(ctx, tx, slugs...)
+	if err == domain.ErrArticleNotFound {
+		return make([]domain.AuthoredArticle, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+// GetArticleBySlug gets a single article with the given slug.
+func (r *implementation) GetArticleBySlug(ctx context.Context, s string) (*domain.AuthoredArticle, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	found, err := getArticleBySlug(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &found[0], nil
+}
+
+func getArticleBySlug(ctx context.Context, q sqlscan.Querier, s ...string) ([]domain.AuthoredArticle, error) {
+	var found []article
+	err := sqlscan.Select(ctx, q, &found, selectArticles+`
+	,(SELECT count(*) FROM favorited_articles fa WHERE fa.article_id = a.id) AS favorite_count
+FROM articles a, users u
+WHERE a.slug IN (SELECT value FROM json_each($1))
+AND a.author_id = u.id
+ORDER BY a.updated DESC, a.id DESC
+`,
+		list(s))
+	if err == nil && len(found) == 0 {
+		return nil, domain.ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.AuthoredArticle, 0, len(found))
+	for i := range found {
+		a := found[i].toDomain()
+		a.Author, _ = getUserByEmail(ctx, q, a.AuthorEmail)
+		results = append(results, a)
+	}
+
+	return results, nil
+}
+
+// GetArticleByID gets a single article with the given id.
+func (r *implementation) GetArticleByID(ctx context.Context, id string) (*domain.AuthoredArticle, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	var s string
+	err = tx.QueryRowContext(ctx, `
+SELECT slug
+	FROM articles
+	WHERE uid = $1`, id).Scan(&s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := getArticleBySlug(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &found[0], nil
+}
+
+// GetCommentsBySlug gets a single article and its comments with the given slug.
+func (r *implementation) GetCommentsBySlug(ctx context.Context, s string) (*domain.CommentedArticle, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	return getCommentsBySlug(ctx, tx, s, false)
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (r *implementation) GetCommentsWithHiddenBySlug(ctx context.Context, s string) (*domain.CommentedArticle, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	return getCommentsBySlug(ctx, tx, s, true)
+}
+
+func getCommentsBySlug(ctx context.Context, q sqlscan.Querier, s string, withHidden bool) (*domain.CommentedArticle, error) {
+	found, err := getArticleBySlug(ctx, q, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []domain.Comment
+	err = sqlscan.Select(ctx, q, &comments, `
+SELECT c.id, c.uid, c.body, c.body_html, c.created as created_at_utc, u.email as author_email, c.hidden
+	FROM articles a, article_comments c, users u
+	WHERE a.slug = $1
+	AND a.id = c.article_id
+	AND u.id = c.author_id
+	AND ($2 OR NOT c.hidden)
+	ORDER BY c.id
+`, s, withHidden)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CommentedArticle{
+		Article:  found[0].Article,
+		Comments: comments,
+	}, nil
+}
+
+// UpdateArticleBySlug finds a single article based on its slug
+// then applies the provide mutations.
+func (r *implementation) UpdateArticleBySlug(ctx context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (*domain.AuthoredArticle, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	as, err := getArticleBySlug(ctx, tx, s)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	a, err := update(&as[0].Article)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	a.RenderBody()
+	res, err := tx.ExecContext(ctx, `
+UPDATE articles
+	SET slug = $3, title = $4, description = $5, body = $6, body_html = $7, hidden = $8, updated = $9,
+		author_id = (SELECT u.id FROM users u WHERE u.email = $2)
+	WHERE slug = $1
+	AND EXISTS (SELECT 1 FROM users u WHERE u.email = $2)
+	`, s, a.AuthorEmail, a.Slug, a.Title, a.Description, a.Body, a.BodyHTML, a.Hidden, now())
+	if err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateArticle
+		}
+		if isForeignKeyViolation(err) {
+			return nil, domain.ErrNoAuthor
+		}
+
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		tx.Rollback()
+		return nil, domain.ErrNoAuthor
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetArticleBySlug(ctx, a.Slug)
+}
+
+// UpdateCommentsBySlug finds a single article based on its slug
+// then applies the provide mutations to its comments.
+func (r *implementation) UpdateCommentsBySlug(ctx context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (*domain.Comment, error) {
+	a, err := getCommentsBySlug(ctx, r.db, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err = update(a)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var new *domain.Comment
+	kept := make(ids, 0, len(a.Comments))
+	hidden := make(ids, 0)
+	for _, c := range a.Comments {
+		c := c
+		if c.ID <= 0 {
+			new = &c
+		} else {
+			kept = append(kept, c.ID)
+		}
+		if c.ID > 0 && c.Hidden {
+			hidden = append(hidden, c.ID)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM article_comments
+	WHERE article_id = (SELECT a.id FROM articles a WHERE a.slug = $1)
+	AND id NOT IN (SELECT value FROM json_each($2))
+`,
+		s, kept)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+UPDATE article_comments
+	SET hidden = id IN (SELECT value FROM json_each($2))
+	WHERE article_id = (SELECT a.id FROM articles a WHERE a.slug = $1)
+`,
+		s, hidden)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if new != nil {
+		created := time.Now().UTC()
+		var id int
+		err = tx.QueryRowContext(ctx, `
+INSERT INTO article_comments (uid, article_id, author_id, body, body_html, hidden, created)
+	SELECT $7, a.id, u.id, $3, $4, $5, $6
+		FROM articles a, users u
+		WHERE a.slug = $1
+		AND u.email = $2
+	RETURNING id`,
+			a.Slug, new.AuthorEmail, new.Body, new.HTML(), new.Hidden, utc(created), new.UID).Scan(&id)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		new.ID = id
+		new.CreatedAtUTC = created
+
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return new, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// DeleteArticle deletes the article if it exists.
+func (r *implementation) DeleteArticle(ctx context.Context, a *domain.Article) error {
+	if a == nil {
+		return nil
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+DELETE FROM articles
+	WHERE slug = $1`, a.Slug)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return domain.ErrArticleNotFound
+	}
+
+	return nil
+}
+
+// DistinctTags returns a distinct list of tags on all articles
+func (r *implementation) DistinctTags(ctx context.Context) ([]string, error) {
+	var tags []string
+	err := sqlscan.Select(ctx, r.db, &tags, `
+SELECT DISTINCT t.value
+	FROM articles a, json_each(a.tags) t
+`)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
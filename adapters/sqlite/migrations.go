@@ -0,0 +1,173 @@
+package sqlite
+
+// migrations are applied in order to bring the schema up to date.
+// Each one is applied exactly once and is tracked by its version in the schema_version table.
+// Times are text in the timeFormat and lists are json arrays.
+var migrations = []struct {
+	version string
+	up      string
+}{
+	{"0.0.1.0", `
+CREATE TABLE users (
+	id 					integer PRIMARY KEY,
+	uid					text NOT NULL UNIQUE,
+	email				text NOT NULL UNIQUE,
+	username			text NOT NULL UNIQUE,
+	bio					text NOT NULL DEFAULT '',
+	image				text NOT NULL DEFAULT '',
+	role				text NOT NULL DEFAULT 'user',
+	banned				boolean NOT NULL DEFAULT false,
+	verified			boolean NOT NULL DEFAULT false,
+	totp_secret			text NOT NULL DEFAULT '',
+	totp_enabled		boolean NOT NULL DEFAULT false,
+	totp_recovery_codes	text NOT NULL DEFAULT '[]',
+	totp_last_counter	integer NOT NULL DEFAULT 0,
+	totp_failures		integer NOT NULL DEFAULT 0,
+	totp_locked_until	timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000000'
+);
+CREATE TABLE user_passwords (
+	id		integer PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+	hash	text NOT NULL
+);
+
+CREATE TABLE previous_usernames (
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	username	text NOT NULL,
+	changed	 	timestamp NOT NULL
+);
+CREATE UNIQUE INDEX previous_usernames_username ON previous_usernames (lower(username));
+
+CREATE TABLE user_identities (
+	provider	text NOT NULL,
+	subject		text NOT NULL,
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	PRIMARY KEY (provider, subject)
+);
+
+CREATE TABLE followed_users (
+	follower_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	followed_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (follower_id, followed_id)
+);
+CREATE INDEX followed_users_followed_id_idx ON followed_users (followed_id);
+
+CREATE TABLE blocked_users (
+	blocker_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	blocked_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (blocker_id, blocked_id)
+);
+
+CREATE TABLE muted_users (
+	muter_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	muted_id integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	UNIQUE (muter_id, muted_id)
+);
+
+CREATE TABLE api_tokens (
+	id 			integer PRIMARY KEY AUTOINCREMENT,
+	owner_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	name		text NOT NULL,
+	hash		text NOT NULL UNIQUE,
+	scopes		text NOT NULL,
+	created	 	timestamp NOT NULL
+);
+CREATE UNIQUE INDEX api_tokens_owner_name ON api_tokens (owner_id, lower(name));
+
+CREATE TABLE articles (
+	id 			integer PRIMARY KEY,
+	uid			text NOT NULL UNIQUE,
+	slug		text NOT NULL UNIQUE,
+	title		text NOT NULL,
+	description	text NOT NULL DEFAULT '',
+	body 		text NOT NULL DEFAULT '',
+	body_html	text NOT NULL DEFAULT '',
+	tags 		text NOT NULL DEFAULT '[]',
+	hidden		boolean NOT NULL DEFAULT false,
+	created	 	timestamp NOT NULL,
+	updated	 	timestamp NOT NULL,
+	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE
+);
+CREATE INDEX articles_updated_idx ON articles (updated);
+
+CREATE TABLE favorited_articles (
+	user_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	UNIQUE (user_id, article_id)
+);
+
+CREATE TABLE article_comments (
+	id 			integer PRIMARY KEY AUTOINCREMENT,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	author_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	body		text NOT NULL DEFAULT '',
+	body_html	text NOT NULL DEFAULT '',
+	hidden		boolean NOT NULL DEFAULT false,
+	created	 	timestamp NOT NULL
+);
+
+CREATE TABLE reading_lists (
+	id 			integer PRIMARY KEY,
+	owner_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	slug		text NOT NULL,
+	name		text NOT NULL,
+	created	 	timestamp NOT NULL,
+	updated	 	timestamp NOT NULL,
+	UNIQUE (owner_id, slug)
+);
+
+CREATE TABLE reading_list_articles (
+	list_id 	integer NOT NULL REFERENCES reading_lists ON DELETE CASCADE,
+	article_id 	integer NOT NULL REFERENCES articles ON DELETE CASCADE,
+	position	integer NOT NULL,
+	UNIQUE (list_id, article_id)
+);
+
+CREATE TABLE reports (
+	id 				integer PRIMARY KEY AUTOINCREMENT,
+	reporter_id 	integer NOT NULL REFERENCES users ON DELETE CASCADE,
+	article_id 		integer REFERENCES articles ON DELETE SET NULL,
+	article_slug	text NOT NULL,
+	comment_id		integer NOT NULL DEFAULT 0,
+	reason			text NOT NULL,
+	status			text NOT NULL DEFAULT 'open',
+	created	 		timestamp NOT NULL,
+	resolver_id 	integer REFERENCES users ON DELETE SET NULL,
+	resolution		text NOT NULL DEFAULT '',
+	resolved	 	timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000000'
+);
+CREATE INDEX reports_status_idx ON reports (status, id);
+
+CREATE TABLE moderation_audit (
+	id 				integer PRIMARY KEY AUTOINCREMENT,
+	actor_email		text NOT NULL,
+	action			text NOT NULL,
+	article_slug	text NOT NULL DEFAULT '',
+	comment_id		integer NOT NULL DEFAULT 0,
+	user_email		text NOT NULL DEFAULT '',
+	report_id		integer NOT NULL DEFAULT 0,
+	note			text NOT NULL DEFAULT '',
+	created	 		timestamp NOT NULL
+);
+`},
+	{"0.0.2.0", `
+ALTER TABLE article_comments ADD COLUMN uid text NOT NULL DEFAULT '';
+UPDATE article_comments SET uid = lower(hex(randomblob(16)));
+CREATE UNIQUE INDEX article_comments_uid ON article_comments (uid);
+`},
+}
+
+// pending lists the versions of the migrations that haven't been applied yet.
+func pending(applied []string) []string {
+	done := make(map[string]interface{}, len(applied))
+	for _, v := range applied {
+		done[v] = nil
+	}
+
+	missing := make([]string, 0)
+	for _, m := range migrations {
+		if _, ok := done[m.version]; !ok {
+			missing = append(missing, m.version)
+		}
+	}
+	return missing
+}
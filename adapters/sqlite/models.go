@@ -0,0 +1,215 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// MustNewInstance creates a new instance of the sqlite store with the repository interface implementations. Panics on error.
+// The database is created at path if it doesn't exist.
+func MustNewInstance(path string) Migrateable {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "journal_mode(wal)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	// Writes take the lock when they start instead of failing when a read turns into a write
+	q.Add("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?%s", path, q.Encode()))
+	if err != nil {
+		panic(err)
+	}
+	if err = db.Ping(); err != nil {
+		panic(err)
+	}
+
+	return &implementation{db}
+}
+
+type Migrateable interface {
+	MustMigrate() domain.Repository
+}
+
+func (r *implementation) MustMigrate() domain.Repository {
+	ctx := context.Background()
+
+	// Only one instance can be migrating at a time because this takes the write lock
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+CREATE TABLE IF NOT EXISTS schema_version (
+	version varchar(40) NOT NULL,
+	applied timestamp NOT NULL
+)`)
+	if err != nil {
+		panic(err)
+	}
+
+	var applied []string
+	err = sqlscan.Select(ctx, tx, &applied, `SELECT version FROM schema_version`)
+	if err != nil {
+		panic(err)
+	}
+
+	done := make(map[string]interface{}, len(applied))
+	for _, v := range applied {
+		done[v] = nil
+	}
+
+	for _, m := range migrations {
+		if _, ok := done[m.version]; ok {
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, m.up); err != nil {
+			panic(err)
+		}
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO schema_version (version, applied)
+	VALUES ($1, $2)`, m.version, now())
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+type implementation struct {
+	db *sql.DB
+}
+
+// Ping checks the database can be reached and every migration has been applied.
+func (r *implementation) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return err
+	}
+
+	var tables int
+	err := r.db.QueryRowContext(ctx, `
+SELECT COUNT(*)
+	FROM sqlite_master
+	WHERE type = 'table' AND name = 'schema_version'`).Scan(&tables)
+	if err != nil {
+		return err
+	}
+
+	applied := make([]string, 0)
+	if tables > 0 {
+		err = sqlscan.Select(ctx, r.db, &applied, `SELECT version FROM schema_version`)
+		if err != nil {
+			return err
+		}
+	}
+
+	if missing := pending(applied); len(missing) > 0 {
+		return fmt.Errorf("%w: %v", domain.ErrMigrationsPending, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// readOnly is used for transactions that only read so they don't wait for writes.
+var readOnly = &sql.TxOptions{ReadOnly: true}
+
+// isConstraint checks if the error is sqlite rejecting a statement for violating the (extended) constraint code.
+func isConstraint(err error, code int) bool {
+	var sqlErr *sqlite.Error
+	return errors.As(err, &sqlErr) && sqlErr.Code() == code
+}
+
+func isUniqueViolation(err error) bool {
+	return isConstraint(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) ||
+		isConstraint(err, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+func isForeignKeyViolation(err error) bool {
+	return isConstraint(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY)
+}
+
+// timeFormat is how times are kept. It is fixed width so times sort correctly as text
+// and has no zone so the driver reads them back as UTC.
+const timeFormat = "2006-01-02 15:04:05.000000000"
+
+// utc is a time that is written in the timeFormat.
+type utc time.Time
+
+// Value implements driver.Valuer.
+func (t utc) Value() (driver.Value, error) {
+	return time.Time(t).UTC().Format(timeFormat), nil
+}
+
+// now is the current time to write, sqlite's own current time is only precise to the millisecond.
+func now() utc {
+	return utc(time.Now())
+}
+
+// list is a list of strings kept as a json array because sqlite doesn't have arrays.
+// Empty lists are read as nil.
+type list []string
+
+// Scan implements sql.Scanner.
+func (l *list) Scan(src interface{}) error {
+	var raw []byte
+	switch s := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		raw = []byte(s)
+	case []byte:
+		raw = s
+	default:
+		return fmt.Errorf("can't scan %T into a list", src)
+	}
+
+	var found []string
+	if err := json.Unmarshal(raw, &found); err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		found = nil
+	}
+
+	*l = found
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (l list) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+// ids is a list of ids written as a json array because sqlite doesn't have arrays.
+type ids []int
+
+// Value implements driver.Valuer.
+func (l ids) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]int(l))
+	return string(b), err
+}
@@ -0,0 +1,259 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+const selectReadingLists = `
+SELECT l.id, u.email AS owner_email, l.slug, l.name, l.created AS created_at_utc, l.updated AS updated_at_utc
+	FROM reading_lists l, users u
+	WHERE l.owner_id = u.id
+`
+
+type readingList struct {
+	ID int
+	domain.ReadingList
+}
+
+// CreateReadingList creates a new reading list.
+func (r *implementation) CreateReadingList(ctx context.Context, l *domain.ReadingList) (*domain.ReadingList, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+INSERT INTO reading_lists (owner_id, slug, name, created, updated)
+	SELECT u.id, $2, $3, $4, $4
+	FROM users u
+	WHERE u.email = $1
+	RETURNING id`,
+		l.OwnerEmail, l.Slug, l.Name, now()).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateReadingList
+		}
+
+		return nil, err
+	}
+
+	if err = insertReadingListArticles(ctx, tx, id, l.ArticleSlugs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReadingList(ctx, l.OwnerEmail, l.Slug)
+}
+
+// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+func (r *implementation) ReadingListsByOwner(ctx context.Context, em string) ([]domain.ReadingList, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	if _, err := getUserByEmail(ctx, tx, em); err != nil {
+		return nil, err
+	}
+
+	var found []readingList
+	err = sqlscan.Select(ctx, tx, &found, selectReadingLists+`
+	AND u.email = $1
+	ORDER BY lower(l.name)
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.ReadingList, 0, len(found))
+	for i := range found {
+		if err := getReadingListArticles(ctx, tx, &found[i]); err != nil {
+			return nil, err
+		}
+		results = append(results, found[i].ReadingList)
+	}
+
+	return results, nil
+}
+
+// GetReadingList gets a single reading list with the given owner email and slug.
+func (r *implementation) GetReadingList(ctx context.Context, em string, s string) (*domain.ReadingList, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	found, err := getReadingList(ctx, tx, em, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &found.ReadingList, nil
+}
+
+func getReadingList(ctx context.Context, q sqlscan.Querier, em string, s string) (*readingList, error) {
+	found := new(readingList)
+	err := sqlscan.Get(ctx, q, found, selectReadingLists+`
+	AND u.email = $1
+	AND l.slug = $2
+`, em, s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrReadingListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := getReadingListArticles(ctx, q, found); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+func getReadingListArticles(ctx context.Context, q sqlscan.Querier, l *readingList) error {
+	l.ArticleSlugs = make([]string, 0)
+	return sqlscan.Select(ctx, q, &l.ArticleSlugs, `
+SELECT a.slug
+	FROM reading_list_articles la, articles a
+	WHERE la.list_id = $1
+	AND la.article_id = a.id
+	ORDER BY la.position
+`, l.ID)
+}
+
+func insertReadingListArticles(ctx context.Context, tx *sql.Tx, id int, slugs []string) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO reading_list_articles (list_id, article_id, position)
+	SELECT $1, a.id, s.key
+		FROM json_each($2) s, articles a
+		WHERE a.slug = s.value
+`,
+		id, list(slugs))
+	return err
+}
+
+// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+// in the order of the list. Hidden articles are not included.
+func (r *implementation) ReadingListArticles(ctx context.Context, em string, s string) ([]domain.AuthoredArticle, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	l, err := getReadingList(ctx, tx, em, s)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.AuthoredArticle, 0, len(l.ArticleSlugs))
+	if len(l.ArticleSlugs) == 0 {
+		return results, nil
+	}
+
+	found, err := getArticleBySlug(ctx, tx, l.ArticleSlugs...)
+	if err != nil {
+		return nil, err
+	}
+
+	// getArticleBySlug has its own order so put them back in the list's
+	bySlug := make(map[string]domain.AuthoredArticle, len(found))
+	for _, a := range found {
+		bySlug[a.Slug] = a
+	}
+	for _, as := range l.ArticleSlugs {
+		if a, ok := bySlug[as]; ok && !a.Hidden {
+			results = append(results, a)
+		}
+	}
+
+	return results, nil
+}
+
+// UpdateReadingList finds a single reading list based on its owner email and slug,
+// then applies the provide mutations.
+func (r *implementation) UpdateReadingList(ctx context.Context, em string, s string, update func(*domain.ReadingList) (*domain.ReadingList, error)) (*domain.ReadingList, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := getReadingList(ctx, tx, em, s)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	l, err := update(&found.ReadingList)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+UPDATE reading_lists
+	SET slug = $2, name = $3, updated = $4
+	WHERE id = $1`,
+		found.ID, l.Slug, l.Name, now())
+	if err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateReadingList
+		}
+
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+DELETE FROM reading_list_articles
+	WHERE list_id = $1`,
+		found.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = insertReadingListArticles(ctx, tx, found.ID, l.ArticleSlugs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetReadingList(ctx, em, l.Slug)
+}
+
+// DeleteReadingList deletes the reading list if it exists.
+func (r *implementation) DeleteReadingList(ctx context.Context, l *domain.ReadingList) error {
+	if l == nil {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+DELETE FROM reading_lists
+	WHERE owner_id = (SELECT u.id FROM users u WHERE u.email = $1)
+	AND slug = $2
+`, l.OwnerEmail, l.Slug)
+	return err
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+const selectReports = `
+SELECT
+	r.id
+	,u.email AS reporter_email
+	,COALESCE(a.slug, r.article_slug) AS article_slug
+	,r.comment_id
+	,r.reason
+	,r.status
+	,r.created AS created_at_utc
+	,COALESCE(m.email, '') AS resolver_email
+	,r.resolution
+	,r.resolved AS resolved_at_utc
+FROM reports r
+INNER JOIN users u ON
+	r.reporter_id = u.id
+LEFT JOIN articles a ON
+	r.article_id = a.id
+LEFT JOIN users m ON
+	r.resolver_id = m.id
+`
+
+// CreateReport creates a new report.
+func (r *implementation) CreateReport(ctx context.Context, rep *domain.Report) (*domain.Report, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO reports (reporter_id, article_id, article_slug, comment_id, reason, status, created)
+	SELECT u.id, a.id, a.slug, $3, $4, $5, $6
+		FROM users u, articles a
+		WHERE u.email = $1
+		AND a.slug = $2
+	RETURNING id`,
+		rep.ReporterEmail, rep.ArticleSlug, rep.CommentID, rep.Reason, string(domain.ReportOpen), now()).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return getReportByID(ctx, r.db, id)
+}
+
+// GetReportByID gets a single report with the given id.
+func (r *implementation) GetReportByID(ctx context.Context, id int) (*domain.Report, error) {
+	return getReportByID(ctx, r.db, id)
+}
+
+func getReportByID(ctx context.Context, q sqlscan.Querier, id int) (*domain.Report, error) {
+	found := new(domain.Report)
+	err := sqlscan.Get(ctx, q, found, selectReports+`
+WHERE r.id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (r *implementation) ReportsByStatus(ctx context.Context, s domain.ReportStatus, limit int, offset int) ([]domain.Report, error) {
+	found := []domain.Report{}
+	err := sqlscan.Select(ctx, r.db, &found, selectReports+`
+WHERE r.status = $1
+ORDER BY r.id
+LIMIT $2 OFFSET $3`, string(s), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (r *implementation) UpdateReportByID(ctx context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (*domain.Report, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rep, err := getReportByID(ctx, tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	rep, err = update(rep)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+UPDATE reports
+	SET status = $2, resolution = $3, resolved = $4,
+		resolver_id = (SELECT u.id FROM users u WHERE u.email = $5)
+	WHERE id = $1`,
+		id, string(rep.Status), string(rep.Resolution), utc(rep.ResolvedAtUTC), rep.ResolverEmail)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getReportByID(ctx, r.db, id)
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (r *implementation) CreateAuditEntry(ctx context.Context, e *domain.AuditEntry) (*domain.AuditEntry, error) {
+	var id int
+	err := r.db.QueryRowContext(ctx, `
+INSERT INTO moderation_audit (actor_email, action, article_slug, comment_id, user_email, report_id, note, created)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id`,
+		e.ActorEmail, string(e.Action), e.ArticleSlug, e.CommentID, e.UserEmail, e.ReportID, e.Note, now()).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	found := new(domain.AuditEntry)
+	err = sqlscan.Get(ctx, r.db, found, selectAuditEntries+`
+WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+const selectAuditEntries = `
+SELECT
+	id, actor_email, action, article_slug, comment_id,
+	user_email, report_id, note, created AS created_at_utc
+FROM moderation_audit
+`
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (r *implementation) LatestAuditEntries(ctx context.Context, limit int, offset int) ([]domain.AuditEntry, error) {
+	found := []domain.AuditEntry{}
+	err := sqlscan.Select(ctx, r.db, &found, selectAuditEntries+`
+ORDER BY id DESC
+LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
@@ -0,0 +1,213 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/sqlite"
+	"github.com/brycekbargar/realworld-backend/adapters/testcases"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var uut domain.Repository
+var dsn string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "realworld_backend_test_")
+	if err != nil {
+		panic(err)
+	}
+
+	dsn = filepath.Join(dir, "conduit.db")
+	uut = sqlite.
+		MustNewInstance(dsn).
+		MustMigrate()
+	res := m.Run()
+
+	if res != 0 {
+		// Save the test database when tests fail
+		fmt.Print(dsn)
+	} else if err := os.RemoveAll(dir); err != nil {
+		fmt.Print(err)
+	}
+	os.Exit(res)
+}
+
+func Test_RepositoryMustMigrate(t *testing.T) {
+	sqlite.MustNewInstance(dsn).MustMigrate()
+	sqlite.MustNewInstance(dsn).MustMigrate()
+	r := sqlite.MustNewInstance(dsn)
+	r.MustMigrate()
+	sqlite.MustNewInstance(dsn).MustMigrate()
+	r.MustMigrate()
+}
+
+func Test_RepositoryPing(t *testing.T) {
+	assert.NoError(t, uut.Ping(context.Background()))
+
+	unmigrated := sqlite.MustNewInstance(filepath.Join(t.TempDir(), "unmigrated.db"))
+	assert.ErrorIs(t, unmigrated.(domain.Repository).Ping(context.Background()), domain.ErrMigrationsPending)
+	assert.NoError(t, unmigrated.MustMigrate().Ping(context.Background()))
+}
+
+func Test_Users(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update User", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_CreateUser(t, uut)
+	})
+	t.Run("Get and Update User By Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByEmail(t, uut)
+	})
+	t.Run("Get and Update User By Username", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByUsername(t, uut)
+	})
+	t.Run("Get User By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_GetUserByID(t, uut)
+	})
+	t.Run("Username History", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_UsernameHistory(t, uut)
+	})
+	t.Run("Fanboy Following Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Following(t, uut)
+	})
+	t.Run("Fanboy Favoriting Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Favorites(t, uut)
+	})
+	t.Run("Listing Followers", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_FollowersByEmail(t, uut)
+	})
+	t.Run("Listing Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ListUsers(t, uut)
+	})
+	t.Run("Fanboy Blocking and Muting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateFanboyByEmail_Blocking(t, uut)
+	})
+	t.Run("Changing An Email Inside Another", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_EmailInsideAnother(t, uut)
+	})
+	t.Run("Roles and Bans", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Roles(t, uut)
+	})
+	t.Run("Verifying Email", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_Verified(t, uut)
+	})
+	t.Run("Two-Factor Authentication", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_UpdateUserByEmail_TwoFactor(t, uut)
+	})
+	t.Run("Deleting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_DeleteUser_Anonymize(t, uut)
+		testcases.Users_DeleteUser_Delete(t, uut)
+	})
+	t.Run("Exporting Users", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_ExportUserByEmail(t, uut)
+	})
+	t.Run("Linking External Identities", func(t *testing.T) {
+		t.Parallel()
+		testcases.Users_LinkIdentity(t, uut)
+	})
+}
+
+func Test_Articles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_CreateArticle(t, uut)
+	})
+	t.Run("Get and Update Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleBySlug(t, uut)
+	})
+	t.Run("Get Article By ID", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_GetArticleByID(t, uut)
+	})
+	t.Run("Delete Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DeleteArticle(t, uut)
+	})
+	t.Run("Hide Article", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateArticleBySlug_Hidden(t, uut)
+	})
+	t.Run("Hidden Content", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_HiddenContent(t, uut)
+	})
+	t.Run("Query Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria(t, uut)
+	})
+	t.Run("Rendered Article Bodies", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_BodyHTML(t, uut)
+	})
+	t.Run("Query Articles As Viewer", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_LatestArticlesByCriteria_Viewer(t, uut)
+	})
+	t.Run("Create and Delete Comments", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_UpdateCommentsBySlug(t, uut)
+	})
+	t.Run("Import Articles", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_ImportArticle(t, uut)
+	})
+	t.Run("Query Tags", func(t *testing.T) {
+		t.Parallel()
+		testcases.Articles_DistinctTags(t, uut)
+	})
+}
+
+func Test_Reports(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Resolve Report", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateReport(t, uut)
+	})
+	t.Run("Audit Trail", func(t *testing.T) {
+		t.Parallel()
+		testcases.Reports_CreateAuditEntry(t, uut)
+	})
+}
+
+func Test_ReadingLists(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Update Reading Lists", func(t *testing.T) {
+		t.Parallel()
+		testcases.ReadingLists_CreateReadingList(t, uut)
+	})
+}
+
+func Test_APITokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Create and Revoke API Tokens", func(t *testing.T) {
+		t.Parallel()
+		testcases.APITokens_CreateAPIToken(t, uut)
+	})
+}
@@ -0,0 +1,555 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+// user has the recovery codes as a list because sqlite doesn't have arrays.
+type user struct {
+	domain.User
+	RecoveryCodes list `db:"two_factor.recovery_codes"`
+}
+
+func (u *user) toDomain() *domain.User {
+	u.User.TwoFactor.RecoveryCodes = u.RecoveryCodes
+	return &u.User
+}
+
+// selectUsers selects the users table aliased as u with their passwords aliased as p.
+const selectUsers = `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, p.hash as password, u.role, u.banned, u.verified
+	,u.totp_secret AS "two_factor.secret"
+	,u.totp_enabled AS "two_factor.enabled"
+	,u.totp_recovery_codes AS "two_factor.recovery_codes"
+	,u.totp_last_counter AS "two_factor.last_counter"
+	,u.totp_failures AS "two_factor.failed_attempts"
+	,u.totp_locked_until AS "two_factor.locked_until_utc"`
+
+func getUser(ctx context.Context, q sqlscan.Querier, query string, args ...interface{}) (*domain.User, error) {
+	found := new(user)
+	err := sqlscan.Get(ctx, q, found, selectUsers+query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return found.toDomain(), nil
+}
+
+// CreateUser creates a new user.
+func (r *implementation) CreateUser(ctx context.Context, u *domain.User) (*domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var reserved bool
+	err = tx.QueryRowContext(ctx, `
+SELECT EXISTS (
+	SELECT 1 FROM previous_usernames
+		WHERE lower(username) = lower($1))`,
+		u.Username).Scan(&reserved)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if reserved {
+		tx.Rollback()
+		return nil, domain.ErrDuplicateUser
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+INSERT INTO users (uid, email, username, bio, image, role, banned, verified)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id`,
+		u.ID, u.Email, u.Username, u.Bio, u.Image, u.Role, u.Banned, u.Verified).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateUser
+		}
+
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO user_passwords (id, hash)
+	VALUES ($1, $2)
+`, id, u.Password)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getUserByEmail(ctx, r.db, u.Email)
+}
+
+// GetUserByEmail finds a single user based on their email address.
+func (r *implementation) GetUserByEmail(ctx context.Context, em string) (*domain.Fanboy, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	found, err := getUserByEmail(ctx, tx, em)
+	if err != nil {
+		return nil, err
+	}
+
+	following, err := selectKeys(ctx, tx, `
+SELECT f.email
+	FROM users u, followed_users fu, users f
+	WHERE u.email = $1
+	AND u.id = fu.follower_id
+	AND f.id = fu.followed_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites, err := selectKeys(ctx, tx, `
+SELECT a.slug
+	FROM users u, favorited_articles fa, articles a
+	WHERE u.email = $1
+	AND u.id = fa.user_id
+	AND a.id = fa.article_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking, err := selectKeys(ctx, tx, `
+SELECT b.email
+	FROM users u, blocked_users bu, users b
+	WHERE u.email = $1
+	AND u.id = bu.blocker_id
+	AND b.id = bu.blocked_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	muting, err := selectKeys(ctx, tx, `
+SELECT m.email
+	FROM users u, muted_users mu, users m
+	WHERE u.email = $1
+	AND u.id = mu.muter_id
+	AND m.id = mu.muted_id
+`, em)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Fanboy{
+		User:      *found,
+		Following: following,
+		Favorites: favorites,
+		Blocking:  blocking,
+		Muting:    muting,
+	}, nil
+}
+
+// selectKeys selects a single column into a set of lowercase keys.
+func selectKeys(ctx context.Context, q sqlscan.Querier, query string, args ...interface{}) (map[string]interface{}, error) {
+	var found []string
+	if err := sqlscan.Select(ctx, q, &found, query, args...); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(found))
+	for _, k := range found {
+		keys[strings.ToLower(k)] = nil
+	}
+	return keys, nil
+}
+
+func getUserByEmail(ctx context.Context, q sqlscan.Querier, em string) (*domain.User, error) {
+	return getUser(ctx, q, `
+	FROM users u, user_passwords p
+	WHERE u.email = $1
+	AND u.id = p.id`, em)
+}
+
+// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
+func (r *implementation) GetAuthorByEmail(ctx context.Context, em string) domain.Author {
+	auth, err := getUserByEmail(ctx, r.db, em)
+	if err != nil {
+		return nil
+	}
+	return auth
+}
+
+// GetUserByID finds a single user based on their id.
+func (r *implementation) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return getUser(ctx, r.db, `
+	FROM users u, user_passwords p
+	WHERE u.uid = $1
+	AND u.id = p.id`, id)
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (r *implementation) GetUserByPreviousUsername(ctx context.Context, un string) (*domain.User, error) {
+	return getUser(ctx, r.db, `
+	FROM previous_usernames pu, users u, user_passwords p
+	WHERE lower(pu.username) = lower($1)
+	AND u.id = pu.user_id
+	AND u.id = p.id`, un)
+}
+
+// GetUserByUsername finds a single user based on their username.
+func (r *implementation) GetUserByUsername(ctx context.Context, un string) (*domain.User, error) {
+	return getUser(ctx, r.db, `
+	FROM users u, user_passwords p
+	WHERE u.username = $1
+	AND u.id = p.id`, un)
+}
+
+// UpdateUserByEmail finds a single user based on their email address,
+// then applies the provide mutations.
+func (r *implementation) UpdateUserByEmail(ctx context.Context, em string, update func(*domain.User) (*domain.User, error)) (*domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := getUserByEmail(ctx, tx, em)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	prevUn := u.Username
+	u, err = update(u)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var reserved bool
+	err = tx.QueryRowContext(ctx, `
+SELECT EXISTS (
+	SELECT 1 FROM previous_usernames pu, users u
+		WHERE lower(pu.username) = lower($2)
+		AND u.id = pu.user_id
+		AND u.email <> $1)`,
+		em, u.Username).Scan(&reserved)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if reserved {
+		tx.Rollback()
+		return nil, domain.ErrDuplicateUser
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+UPDATE users
+	SET email = $2, username = $3, bio = $4, image = $5, role = $6, banned = $7, verified = $8,
+		totp_secret = $9, totp_enabled = $10, totp_recovery_codes = $11,
+		totp_last_counter = $12, totp_failures = $13, totp_locked_until = $14
+	WHERE email = $1
+	RETURNING id`,
+		em, u.Email, u.Username, u.Bio, u.Image, u.Role, u.Banned, u.Verified,
+		u.TwoFactor.Secret, u.TwoFactor.Enabled, list(u.TwoFactor.RecoveryCodes),
+		u.TwoFactor.LastCounter, u.TwoFactor.FailedAttempts, utc(u.TwoFactor.LockedUntilUTC)).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+
+		if isUniqueViolation(err) {
+			return nil, domain.ErrDuplicateUser
+		}
+
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+UPDATE user_passwords
+	SET hash = $2
+	WHERE id = $1
+`, id, u.Password)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if !strings.EqualFold(u.Username, prevUn) {
+		// Users can take back their own previous usernames
+		_, err = tx.ExecContext(ctx, `
+DELETE FROM previous_usernames
+	WHERE user_id = $1
+	AND lower(username) = lower($2)
+`, id, u.Username)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO previous_usernames (user_id, username, changed)
+	VALUES ($1, $2, $3)
+`, id, prevUn, now())
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getUserByEmail(ctx, r.db, u.Email)
+}
+
+// UpdateFanboyByEmail finds a single user based on their email address,
+// then applies the provide mutations (probably to the follower list).
+func (r *implementation) UpdateFanboyByEmail(ctx context.Context, em string, update func(*domain.Fanboy) (*domain.Fanboy, error)) error {
+	f, err := r.GetUserByEmail(ctx, em)
+	if err != nil {
+		return err
+	}
+
+	f, err = update(f)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range []string{
+		`DELETE FROM followed_users WHERE follower_id = (SELECT id FROM users WHERE email = $1)`,
+		`DELETE FROM favorited_articles WHERE user_id = (SELECT id FROM users WHERE email = $1)`,
+		`DELETE FROM blocked_users WHERE blocker_id = (SELECT id FROM users WHERE email = $1)`,
+		`DELETE FROM muted_users WHERE muter_id = (SELECT id FROM users WHERE email = $1)`,
+	} {
+		if _, err = tx.ExecContext(ctx, q, em); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	follows := make(list, 0, len(f.Following))
+	for k := range f.Following {
+		if k != "" {
+			follows = append(follows, k)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO followed_users (follower_id, followed_id)
+	SELECT u.id, f.id
+		FROM users u, users f
+		WHERE u.email = $1
+		AND f.email IN (SELECT value FROM json_each($2))
+`,
+		em, follows)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	favors := make(list, 0, len(f.Favorites))
+	for k := range f.Favorites {
+		if k != "" {
+			favors = append(favors, k)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO favorited_articles (user_id, article_id)
+	SELECT u.id, a.id
+		FROM users u, articles a
+		WHERE u.email = $1
+		AND a.slug IN (SELECT value FROM json_each($2))
+`,
+		em, favors)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO blocked_users (blocker_id, blocked_id)
+	SELECT u.id, b.id
+		FROM users u, users b
+		WHERE u.email = $1
+		AND b.email IN (SELECT value FROM json_each($2))
+`,
+		em, list(f.BlockingEmails()))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO muted_users (muter_id, muted_id)
+	SELECT u.id, m.id
+		FROM users u, users m
+		WHERE u.email = $1
+		AND m.email IN (SELECT value FROM json_each($2))
+`,
+		em, list(f.MutingEmails()))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListUsers lists every user, ordered by username.
+func (r *implementation) ListUsers(ctx context.Context, limit int, offset int) ([]domain.User, error) {
+	users := []domain.User{}
+	err := sqlscan.Select(ctx, r.db, &users, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users u
+	ORDER BY lower(u.username)
+	LIMIT $1 OFFSET $2
+`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (r *implementation) FollowersByEmail(ctx context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return r.selectFollows(ctx, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users t, followed_users fu, users u
+	WHERE t.email = $1
+	AND t.id = fu.followed_id
+	AND u.id = fu.follower_id
+	ORDER BY u.username
+	LIMIT $2 OFFSET $3
+`, em, limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (r *implementation) FollowingByEmail(ctx context.Context, em string, limit int, offset int) ([]domain.User, error) {
+	return r.selectFollows(ctx, `
+SELECT u.uid AS id, u.email, u.username, u.bio, u.image, u.role, u.banned, u.verified
+	FROM users t, followed_users fu, users u
+	WHERE t.email = $1
+	AND t.id = fu.follower_id
+	AND u.id = fu.followed_id
+	ORDER BY u.username
+	LIMIT $2 OFFSET $3
+`, em, limit, offset)
+}
+
+func (r *implementation) selectFollows(ctx context.Context, query string, em string, limit int, offset int) ([]domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, readOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Commit()
+
+	if _, err := getUserByEmail(ctx, tx, em); err != nil {
+		return nil, err
+	}
+
+	users := []domain.User{}
+	err = sqlscan.Select(ctx, tx, &users, query, em, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (r *implementation) GetFollowCountsByEmail(ctx context.Context, em string) (*domain.FollowCounts, error) {
+	counts := new(domain.FollowCounts)
+	err := sqlscan.Get(ctx, r.db, counts, `
+SELECT
+	(SELECT count(*) FROM followed_users fu WHERE fu.followed_id = u.id) as followers,
+	(SELECT count(*) FROM followed_users fu WHERE fu.follower_id = u.id) as following
+	FROM users u
+	WHERE u.email = $1
+`, em)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (r *implementation) GetUserByIdentity(ctx context.Context, provider string, subject string) (*domain.User, error) {
+	return getUser(ctx, r.db, `
+	FROM user_identities i, users u, user_passwords p
+	WHERE i.provider = $1
+	AND i.subject = $2
+	AND u.id = i.user_id
+	AND u.id = p.id`, provider, subject)
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (r *implementation) LinkIdentity(ctx context.Context, em string, i *domain.ExternalIdentity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO user_identities (provider, subject, user_id)
+	SELECT $2, $3, u.id
+	FROM users u
+	WHERE u.email = $1
+	ON CONFLICT (provider, subject) DO NOTHING
+`, em, i.Provider, i.Subject)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		// Either the user doesn't exist or the identity is already linked
+		var linked string
+		err = tx.QueryRowContext(ctx, `
+SELECT u.email
+	FROM user_identities i, users u
+	WHERE i.provider = $1
+	AND i.subject = $2
+	AND u.id = i.user_id
+`, i.Provider, i.Subject).Scan(&linked)
+		tx.Rollback()
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(linked, em) {
+			return domain.ErrDuplicateIdentity
+		}
+		return nil
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,96 @@
+package testcases
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func APITokens_CreateAPIToken(
+	t *testing.T,
+	r domain.Repository,
+) {
+	_, err := r.CreateUser(ctx, testUser("automated"))
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, testUser("manual"))
+	require.NoError(t, err)
+
+	at, _, err := domain.NewAPIToken("user@unautomated.com", "Deploy Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = r.CreateAPIToken(ctx, at)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	at, raw, err := domain.NewAPIToken("user@automated.com", "Deploy Script", []domain.Scope{
+		domain.ScopeRead,
+		domain.ScopeWriteArticles,
+	})
+	require.NoError(t, err)
+	ct, err := r.CreateAPIToken(ctx, at)
+	require.NoError(t, err)
+	assert.Positive(t, ct.ID)
+	assert.Equal(t, "Deploy Script", ct.Name)
+	assert.Equal(t, []domain.Scope{domain.ScopeRead, domain.ScopeWriteArticles}, ct.Scopes)
+	assert.False(t, ct.CreatedAtUTC.IsZero())
+
+	_, err = r.CreateAPIToken(ctx, at)
+	assert.ErrorIs(t, err, domain.ErrDuplicateAPIToken)
+
+	ot, _, err := domain.NewAPIToken("user@automated.com", "Backup Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = r.CreateAPIToken(ctx, ot)
+	require.NoError(t, err)
+	mt, _, err := domain.NewAPIToken("user@manual.com", "Deploy Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = r.CreateAPIToken(ctx, mt)
+	require.NoError(t, err, "names only need to be unique per user")
+
+	ts, err := r.APITokensByOwner(ctx, "user@automated.com")
+	require.NoError(t, err)
+	require.Len(t, ts, 2)
+	assert.Equal(t, "Backup Script", ts[0].Name)
+	assert.Equal(t, "Deploy Script", ts[1].Name)
+	_, err = r.APITokensByOwner(ctx, "user@unautomated.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	ft, err := r.GetAPITokenByHash(ctx, domain.HashAPIToken(raw))
+	require.NoError(t, err)
+	assert.Equal(t, ct.ID, ft.ID)
+	assert.Equal(t, "user@automated.com", ft.OwnerEmail)
+	_, err = r.GetAPITokenByHash(ctx, domain.HashAPIToken(raw+"but different"))
+	assert.ErrorIs(t, err, domain.ErrAPITokenNotFound)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@automated.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.ChangeEmail("user@robotic.com")
+			return u, nil
+		})
+	require.NoError(t, err)
+	ft, err = r.GetAPITokenByHash(ctx, domain.HashAPIToken(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "user@robotic.com", ft.OwnerEmail)
+
+	assert.ErrorIs(t, r.DeleteAPIToken(ctx, "user@manual.com", ct.ID), domain.ErrAPITokenNotFound)
+	require.NoError(t, r.DeleteAPIToken(ctx, "user@robotic.com", ct.ID))
+	assert.ErrorIs(t, r.DeleteAPIToken(ctx, "user@robotic.com", ct.ID), domain.ErrAPITokenNotFound)
+	_, err = r.GetAPITokenByHash(ctx, domain.HashAPIToken(raw))
+	assert.ErrorIs(t, err, domain.ErrAPITokenNotFound)
+
+	ts, err = r.APITokensByOwner(ctx, "user@robotic.com")
+	require.NoError(t, err)
+	require.Len(t, ts, 1)
+	assert.Equal(t, "Backup Script", ts[0].Name)
+
+	rt, _, err := domain.NewAPIToken("user@robotic.com", "Revoked Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	rt, err = r.CreateAPIToken(ctx, rt)
+	require.NoError(t, err)
+	require.NoError(t, r.DeleteAPIToken(ctx, "user@robotic.com", rt.ID))
+	nt, _, err := domain.NewAPIToken("user@robotic.com", "Replacement Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	nt, err = r.CreateAPIToken(ctx, nt)
+	require.NoError(t, err)
+	assert.Greater(t, nt.ID, rt.ID, "because ids of revoked tokens aren't reused")
+}
@@ -129,6 +129,58 @@ func Articles_GetArticleBySlug(
 	fa, err = r.GetArticleBySlug(ctx, "silent-title")
 	assert.NoError(t, err)
 }
+
+func Articles_GetArticleByID(
+	t *testing.T,
+	r domain.Repository,
+) {
+	r.CreateUser(ctx, testAuthor("enduring"))
+	a := testArticle("enduring")
+	require.NotEmpty(t, a.ID)
+	_, err := r.CreateArticle(ctx, a)
+	require.NoError(t, err)
+
+	fa, err := r.GetArticleByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, fa.ID)
+	assert.Equal(t, "enduring-title", fa.Slug)
+
+	_, err = r.GetArticleByID(ctx, "00000000-0000-4000-8000-000000000000")
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+
+	_, err = r.UpdateArticleBySlug(ctx,
+		"enduring-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.SetTitle("fleeting title")
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	fa, err = r.GetArticleByID(ctx, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "fleeting-title", fa.Slug, "because the id doesn't change with the slug")
+
+	r.CreateUser(ctx, testAuthor("chatty"))
+	r.CreateArticle(ctx, testArticle("chatty"))
+	c1, err := r.UpdateCommentsBySlug(ctx,
+		"fleeting-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("chatty comment", "author@chatty.com")
+		})
+	require.NoError(t, err)
+	c2, err := r.UpdateCommentsBySlug(ctx,
+		"chatty-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("chatty comment", "author@chatty.com")
+		})
+	require.NoError(t, err)
+	assert.NotEqual(t, c1.ID, c2.ID, "because comment ids are unique across articles")
+
+	require.NoError(t, r.DeleteArticle(ctx, &fa.Article))
+	_, err = r.GetArticleByID(ctx, a.ID)
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+}
+
 func Articles_DeleteArticle(
 	t *testing.T,
 	r domain.Repository,
@@ -147,6 +199,150 @@ func Articles_DeleteArticle(
 	assert.NoError(t, err)
 }
 
+func Articles_UpdateArticleBySlug_Hidden(
+	t *testing.T,
+	r domain.Repository,
+) {
+	r.CreateUser(ctx, testAuthor("shady"))
+	r.CreateUser(ctx, testUser("sneaky"))
+
+	a := testArticle("shady")
+	_, err := r.CreateArticle(ctx, a)
+	require.NoError(t, err)
+
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"shady-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("shady body", "user@sneaky.com")
+		})
+	require.NoError(t, err)
+
+	fa, err := r.GetArticleBySlug(ctx, "shady-title")
+	require.NoError(t, err)
+	assert.False(t, fa.Hidden)
+
+	_, err = r.UpdateArticleBySlug(ctx,
+		"shady-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.Hidden = true
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	fa, err = r.GetArticleBySlug(ctx, "shady-title")
+	require.NoError(t, err)
+	assert.True(t, fa.Hidden)
+
+	ca, err := r.GetCommentsBySlug(ctx, "shady-title")
+	require.NoError(t, err)
+	assert.Len(t, ca.Comments, 1, "because updating an article keeps its comments")
+}
+
+func Articles_HiddenContent(
+	t *testing.T,
+	r domain.Repository,
+) {
+	tt := "Articles_HiddenContent"
+
+	r.CreateUser(ctx, testAuthor("furtive"))
+	r.CreateUser(ctx, testUser("stealthy"))
+	for _, adj := range []string{
+		"cagey",
+		"sly",
+		"wily",
+	} {
+		a := testArticle(adj)
+		a.AuthorEmail = "author@furtive.com"
+		a.TagList = append(a.TagList, tt)
+
+		_, err := r.CreateArticle(ctx, a)
+		require.NoError(t, err)
+	}
+
+	_, err := r.UpdateArticleBySlug(ctx,
+		"sly-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.Hidden = true
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	all, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: tt, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	for _, a := range all {
+		assert.NotEqual(t, "sly-title", a.Slug)
+	}
+
+	some, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: tt, Limit: 10, Offset: 1})
+	require.NoError(t, err)
+	assert.Len(t, some, 1, "because hidden articles don't count towards the offset")
+
+	all, err = r.LatestArticlesByCriteria(ctx, domain.ListCriteria{Tag: tt, Limit: 10, WithHidden: true})
+	require.NoError(t, err)
+	require.Len(t, all, 3, "because operators can ask for the hidden articles")
+	assert.Equal(t, "sly-title", all[0].Slug, "because hiding it updated it")
+	assert.True(t, all[0].Hidden)
+
+	for _, b := range []string{"first furtive body", "second furtive body"} {
+		b := b
+		_, err = r.UpdateCommentsBySlug(ctx,
+			"wily-title",
+			func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+				return a, a.AddComment(b, "user@stealthy.com")
+			})
+		require.NoError(t, err)
+	}
+
+	ca, err := r.GetCommentsBySlug(ctx, "wily-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 2)
+	hid := ca.Comments[0].ID
+
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"wily-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			a.HideComment(hid, true)
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	ca, err = r.GetCommentsBySlug(ctx, "wily-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 1)
+	assert.NotEqual(t, hid, ca.Comments[0].ID)
+
+	ca, err = r.GetCommentsWithHiddenBySlug(ctx, "wily-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 2, "because operators can ask for the hidden comments")
+	assert.Equal(t, hid, ca.Comments[0].ID)
+	assert.True(t, ca.Comments[0].Hidden)
+	_, err = r.GetCommentsWithHiddenBySlug(ctx, "missing-title")
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"wily-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			assert.Len(t, a.Comments, 2, "because updates can see hidden comments")
+			return a, a.AddComment("third furtive body", "user@stealthy.com")
+		})
+	require.NoError(t, err)
+
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"wily-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			require.NotNil(t, a.FindComment(hid))
+			assert.True(t, a.FindComment(hid).Hidden)
+			a.HideComment(hid, false)
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	ca, err = r.GetCommentsBySlug(ctx, "wily-title")
+	require.NoError(t, err)
+	assert.Len(t, ca.Comments, 3)
+}
+
 func Articles_LatestArticlesByCriteria(
 	t *testing.T,
 	r domain.Repository,
@@ -228,6 +424,50 @@ func Articles_LatestArticlesByCriteria(
 				}
 			},
 		},
+		{
+			"No Limit",
+			domain.ListCriteria{
+				Tag:   tt,
+				Limit: 0,
+			},
+			func(t *testing.T) func([]domain.AuthoredArticle, error) {
+				return func(none []domain.AuthoredArticle, err error) {
+					require.NoError(t, err)
+
+					assert.Empty(t, none)
+				}
+			},
+		},
+		{
+			"Negative Limit",
+			domain.ListCriteria{
+				Tag:   tt,
+				Limit: -1,
+			},
+			func(t *testing.T) func([]domain.AuthoredArticle, error) {
+				return func(none []domain.AuthoredArticle, err error) {
+					require.NoError(t, err)
+
+					assert.Empty(t, none)
+				}
+			},
+		},
+		{
+			"Negative Offset",
+			domain.ListCriteria{
+				Tag:    tt,
+				Offset: -3,
+				Limit:  2,
+			},
+			func(t *testing.T) func([]domain.AuthoredArticle, error) {
+				return func(some []domain.AuthoredArticle, err error) {
+					require.NoError(t, err)
+
+					require.Len(t, some, 2)
+					assert.Equal(t, "waggish-title", some[0].Slug)
+				}
+			},
+		},
 		{
 			"Authored By",
 			domain.ListCriteria{
@@ -258,6 +498,104 @@ func Articles_LatestArticlesByCriteria(
 	}
 }
 
+func Articles_LatestArticlesByCriteria_Viewer(
+	t *testing.T,
+	r domain.Repository,
+) {
+	tt := "Articles_LatestArticlesByCriteria_Viewer"
+
+	for _, adj := range []string{
+		"loud",
+		"quiet",
+		"bland",
+	} {
+		_, err := r.CreateUser(ctx, testAuthor(adj))
+		require.NoError(t, err)
+
+		a := testArticle(adj)
+		a.TagList = append(a.TagList, tt)
+		_, err = r.CreateArticle(ctx, a)
+		require.NoError(t, err)
+	}
+
+	_, err := r.CreateUser(ctx, testUser("picky"))
+	require.NoError(t, err)
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@picky.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.Block("author@loud.com")
+			f.Mute("author@quiet.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	all, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{
+		Tag:   tt,
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	viewed, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{
+		Tag:         tt,
+		Limit:       10,
+		ViewerEmail: "user@picky.com",
+	})
+	require.NoError(t, err)
+	require.Len(t, viewed, 1)
+	assert.Equal(t, "bland-title", viewed[0].Slug)
+
+	anon, err := r.LatestArticlesByCriteria(ctx, domain.ListCriteria{
+		Tag:         tt,
+		Limit:       10,
+		ViewerEmail: "user@nobody.com",
+	})
+	require.NoError(t, err)
+	assert.Len(t, anon, 3)
+}
+
+func Articles_BodyHTML(
+	t *testing.T,
+	r domain.Repository,
+) {
+	_, err := r.CreateUser(ctx, testAuthor("marked"))
+	require.NoError(t, err)
+
+	a := testArticle("marked")
+	a.Body = "*marked* body"
+	a.BodyHTML = ""
+	_, err = r.CreateArticle(ctx, a)
+	require.NoError(t, err)
+
+	ca, err := r.GetArticleBySlug(ctx, "marked-title")
+	require.NoError(t, err)
+	assert.Equal(t, "<p><em>marked</em> body</p>\n", ca.BodyHTML)
+
+	_, err = r.UpdateArticleBySlug(ctx,
+		"marked-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.Body = "**remarked** body<script>alert(1)</script>"
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	ua, err := r.GetArticleBySlug(ctx, "marked-title")
+	require.NoError(t, err)
+	assert.Equal(t, "<p><strong>remarked</strong> bodyalert(1)</p>\n", ua.BodyHTML)
+
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"marked-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("`marked` comment", "author@marked.com")
+		})
+	require.NoError(t, err)
+
+	cs, err := r.GetCommentsBySlug(ctx, "marked-title")
+	require.NoError(t, err)
+	require.Len(t, cs.Comments, 1)
+	assert.Equal(t, "<p><code>marked</code> comment</p>\n", cs.Comments[0].BodyHTML)
+}
+
 func Articles_UpdateCommentsBySlug(
 	t *testing.T,
 	r domain.Repository,
@@ -323,11 +661,65 @@ func Articles_UpdateCommentsBySlug(
 
 	require.Len(t, a.Comments, 1)
 	assert.Positive(t, a.Comments[0].ID)
+	assert.NotEmpty(t, a.Comments[0].UID)
+	assert.NotEqual(t, c.UID, a.Comments[0].UID)
 	assert.Equal(t, "quirky body", a.Comments[0].Body)
 	assert.Equal(t, "user@simplistic.com", a.Comments[0].AuthorEmail)
 	assert.True(t, now.Before(a.Comments[0].CreatedAtUTC))
 }
 
+func Articles_ImportArticle(
+	t *testing.T,
+	r domain.Repository,
+) {
+	r.CreateUser(ctx, testUser("sentimental"))
+	r.CreateUser(ctx, testAuthor("weathered"))
+
+	created := time.Date(2019, time.March, 4, 5, 6, 7, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+	a := testArticle("weathered")
+	a.CreatedAtUTC = created
+	a.UpdatedAtUTC = updated
+	ca := &domain.CommentedArticle{Article: *a}
+	for i, b := range []string{"first body", "hidden body"} {
+		c, err := domain.NewComment(b, "user@sentimental.com")
+		require.NoError(t, err)
+		c.CreatedAtUTC = created.Add(time.Duration(i+1) * time.Hour)
+		c.Hidden = i == 1
+		ca.Comments = append(ca.Comments, *c)
+	}
+
+	imported, err := r.ImportArticle(ctx, ca)
+	require.NoError(t, err)
+	require.Len(t, imported.Comments, 2)
+	for i, c := range imported.Comments {
+		assert.Positive(t, c.ID)
+		assert.Equal(t, ca.Comments[i].UID, c.UID)
+		assert.Equal(t, ca.Comments[i].Body, c.Body)
+		assert.Equal(t, ca.Comments[i].Hidden, c.Hidden)
+		assert.True(t, ca.Comments[i].CreatedAtUTC.Equal(c.CreatedAtUTC), "because comments keep when they were created")
+	}
+	assert.Less(t, imported.Comments[0].ID, imported.Comments[1].ID)
+
+	fa, err := r.GetArticleBySlug(ctx, "weathered-title")
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, fa.ID)
+	assert.True(t, created.Equal(fa.CreatedAtUTC), "because articles keep when they were created")
+	assert.True(t, updated.Equal(fa.UpdatedAtUTC), "because articles keep when they were updated")
+
+	visible, err := r.GetCommentsBySlug(ctx, "weathered-title")
+	require.NoError(t, err)
+	require.Len(t, visible.Comments, 1)
+	assert.Equal(t, imported.Comments[0].ID, visible.Comments[0].ID)
+
+	_, err = r.ImportArticle(ctx, ca)
+	assert.ErrorIs(t, err, domain.ErrDuplicateArticle)
+
+	orphan := &domain.CommentedArticle{Article: *testArticle("orphaned")}
+	_, err = r.ImportArticle(ctx, orphan)
+	assert.ErrorIs(t, err, domain.ErrNoAuthor)
+}
+
 func Articles_DistinctTags(
 	t *testing.T,
 	r domain.Repository,
@@ -0,0 +1,43 @@
+package testcases
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Blobs_PutBlob(
+	t *testing.T,
+	s domain.BlobStore,
+) {
+	_, _, err := s.GetBlob(ctx, "absent.png")
+	assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+
+	require.NoError(t, s.PutBlob(ctx, "present.png", "image/png", []byte("present data")))
+
+	data, ct, err := s.GetBlob(ctx, "present.png")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("present data"), data)
+	assert.Equal(t, "image/png", ct)
+
+	require.NoError(t, s.PutBlob(ctx, "present.png", "image/gif", []byte("replaced data")))
+
+	data, ct, err = s.GetBlob(ctx, "present.png")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("replaced data"), data)
+	assert.Equal(t, "image/gif", ct)
+}
+
+func Blobs_InvalidKeys(
+	t *testing.T,
+	s domain.BlobStore,
+) {
+	for _, k := range []string{"", "../escaped.png", "nested/key.png", ".hidden", "spaced key.png"} {
+		assert.ErrorIs(t, s.PutBlob(ctx, k, "image/png", []byte("invalid data")), domain.ErrInvalidBlobKey, k)
+
+		_, _, err := s.GetBlob(ctx, k)
+		assert.ErrorIs(t, err, domain.ErrBlobNotFound, k)
+	}
+}
@@ -0,0 +1,128 @@
+package testcases
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ReadingLists_CreateReadingList(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, adj := range []string{"bookish", "studious", "literate"} {
+		_, err := r.CreateUser(ctx, testAuthor(adj))
+		require.NoError(t, err)
+		_, err = r.CreateArticle(ctx, testArticle(adj))
+		require.NoError(t, err)
+	}
+	_, err := r.CreateUser(ctx, testUser("curious"))
+	require.NoError(t, err)
+
+	l, err := domain.NewReadingList("user@incurious.com", "Curious Reads")
+	require.NoError(t, err)
+	_, err = r.CreateReadingList(ctx, l)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	l, err = domain.NewReadingList("user@curious.com", "Curious Reads")
+	require.NoError(t, err)
+	l.Add("bookish-title")
+	l.Add("nonexistent-title")
+	cl, err := r.CreateReadingList(ctx, l)
+	require.NoError(t, err)
+	assert.Equal(t, "curious-reads", cl.Slug)
+	assert.Equal(t, "Curious Reads", cl.Name)
+	assert.Equal(t, []string{"bookish-title"}, cl.ArticleSlugs)
+	assert.False(t, cl.CreatedAtUTC.IsZero())
+
+	_, err = r.CreateReadingList(ctx, l)
+	assert.ErrorIs(t, err, domain.ErrDuplicateReadingList)
+
+	o, err := domain.NewReadingList("user@curious.com", "Another Read")
+	require.NoError(t, err)
+	_, err = r.CreateReadingList(ctx, o)
+	require.NoError(t, err)
+
+	ls, err := r.ReadingListsByOwner(ctx, "user@curious.com")
+	require.NoError(t, err)
+	require.Len(t, ls, 2)
+	assert.Equal(t, "another-read", ls[0].Slug)
+	assert.Empty(t, ls[0].ArticleSlugs)
+	assert.Equal(t, "curious-reads", ls[1].Slug)
+
+	_, err = r.GetReadingList(ctx, "user@curious.com", "incurious-reads")
+	assert.ErrorIs(t, err, domain.ErrReadingListNotFound)
+	_, err = r.GetReadingList(ctx, "user@bookish.com", "curious-reads")
+	assert.ErrorIs(t, err, domain.ErrReadingListNotFound)
+
+	ul, err := r.UpdateReadingList(ctx,
+		"user@curious.com",
+		"curious-reads",
+		func(l *domain.ReadingList) (*domain.ReadingList, error) {
+			l.Add("studious-title")
+			l.Add("literate-title")
+			return l, l.Reorder([]string{"literate-title", "bookish-title", "studious-title"})
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"literate-title", "bookish-title", "studious-title"}, ul.ArticleSlugs)
+
+	_, err = r.UpdateReadingList(ctx,
+		"user@curious.com",
+		"curious-reads",
+		func(l *domain.ReadingList) (*domain.ReadingList, error) {
+			l.SetName("Another Read")
+			return l, nil
+		})
+	assert.ErrorIs(t, err, domain.ErrDuplicateReadingList)
+
+	_, err = r.UpdateArticleBySlug(ctx,
+		"studious-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.SetTitle("diligent title")
+			return a, nil
+		})
+	require.NoError(t, err)
+	_, err = r.UpdateArticleBySlug(ctx,
+		"literate-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.Hidden = true
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	as, err := r.ReadingListArticles(ctx, "user@curious.com", "curious-reads")
+	require.NoError(t, err)
+	require.Len(t, as, 2)
+	assert.Equal(t, "bookish-title", as[0].Slug)
+	assert.Equal(t, "author@bookish.com", as[0].Author.GetEmail())
+	assert.Equal(t, "diligent-title", as[1].Slug)
+
+	a, err := r.GetArticleBySlug(ctx, "bookish-title")
+	require.NoError(t, err)
+	require.NoError(t, r.DeleteArticle(ctx, &a.Article))
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@curious.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "user@inquisitive.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	gl, err := r.GetReadingList(ctx, "user@inquisitive.com", "curious-reads")
+	require.NoError(t, err)
+	assert.Equal(t, "user@inquisitive.com", gl.OwnerEmail)
+	assert.Equal(t, []string{"literate-title", "diligent-title"}, gl.ArticleSlugs)
+
+	require.NoError(t, r.DeleteReadingList(ctx, gl))
+	_, err = r.GetReadingList(ctx, "user@inquisitive.com", "curious-reads")
+	assert.ErrorIs(t, err, domain.ErrReadingListNotFound)
+	_, err = r.ReadingListArticles(ctx, "user@inquisitive.com", "curious-reads")
+	assert.ErrorIs(t, err, domain.ErrReadingListNotFound)
+
+	ls, err = r.ReadingListsByOwner(ctx, "user@inquisitive.com")
+	require.NoError(t, err)
+	assert.Len(t, ls, 1)
+}
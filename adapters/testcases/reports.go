@@ -0,0 +1,168 @@
+package testcases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Reports_CreateReport(
+	t *testing.T,
+	r domain.Repository,
+) {
+	r.CreateUser(ctx, testAuthor("vulgar"))
+	r.CreateUser(ctx, testUser("crass"))
+	r.CreateUser(ctx, testUser("prudish"))
+	_, err := r.CreateArticle(ctx, testArticle("vulgar"))
+	require.NoError(t, err)
+
+	rep, err := domain.NewReport("user@prudish.com", "nonexistent-vulgar-title", 0, "prudish reason")
+	require.NoError(t, err)
+	_, err = r.CreateReport(ctx, rep)
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+
+	now := time.Now().UTC()
+	rep, err = domain.NewReport("user@prudish.com", "vulgar-title", 0, "prudish reason")
+	require.NoError(t, err)
+	ar, err := r.CreateReport(ctx, rep)
+	require.NoError(t, err)
+	assert.Positive(t, ar.ID)
+	assert.Equal(t, "user@prudish.com", ar.ReporterEmail)
+	assert.Equal(t, "vulgar-title", ar.ArticleSlug)
+	assert.Zero(t, ar.CommentID)
+	assert.Equal(t, "prudish reason", ar.Reason)
+	assert.Equal(t, domain.ReportOpen, ar.Status)
+	assert.True(t, now.Before(ar.CreatedAtUTC))
+	assert.True(t, ar.ResolvedAtUTC.IsZero())
+
+	rep, err = domain.NewReport("user@crass.com", "vulgar-title", 12, "crass reason")
+	require.NoError(t, err)
+	cr, err := r.CreateReport(ctx, rep)
+	require.NoError(t, err)
+	assert.Equal(t, 12, cr.CommentID)
+
+	fr, err := r.GetReportByID(ctx, cr.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cr, fr)
+
+	_, err = r.GetReportByID(ctx, -1)
+	assert.ErrorIs(t, err, domain.ErrReportNotFound)
+
+	open, err := r.ReportsByStatus(ctx, domain.ReportOpen, 1000, 0)
+	require.NoError(t, err)
+	assert.True(t, containsReport(open, ar.ID))
+	assert.True(t, containsReport(open, cr.ID))
+
+	_, err = r.UpdateArticleBySlug(ctx,
+		"vulgar-title",
+		func(a *domain.Article) (*domain.Article, error) {
+			a.SetTitle("refined title")
+			return a, nil
+		})
+	require.NoError(t, err)
+
+	_, err = r.UpdateReportByID(ctx, -1, func(rep *domain.Report) (*domain.Report, error) {
+		return rep, nil
+	})
+	assert.ErrorIs(t, err, domain.ErrReportNotFound)
+
+	ur, err := r.UpdateReportByID(ctx,
+		ar.ID,
+		func(rep *domain.Report) (*domain.Report, error) {
+			return rep, rep.Resolve("user@crass.com", domain.ActionHide)
+		})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReportActioned, ur.Status)
+	assert.Equal(t, domain.ActionHide, ur.Resolution)
+	assert.Equal(t, "user@crass.com", ur.ResolverEmail)
+	assert.Equal(t, "refined-title", ur.ArticleSlug,
+		"because reports follow the article when it changes")
+	assert.False(t, ur.ResolvedAtUTC.IsZero())
+
+	open, err = r.ReportsByStatus(ctx, domain.ReportOpen, 1000, 0)
+	require.NoError(t, err)
+	assert.False(t, containsReport(open, ar.ID))
+	assert.True(t, containsReport(open, cr.ID))
+
+	actioned, err := r.ReportsByStatus(ctx, domain.ReportActioned, 1000, 0)
+	require.NoError(t, err)
+	assert.True(t, containsReport(actioned, ar.ID))
+
+	claims := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := r.UpdateReportByID(ctx,
+				cr.ID,
+				func(rep *domain.Report) (*domain.Report, error) {
+					return rep, rep.Resolve("user@crass.com", domain.ActionDismiss)
+				})
+			claims <- err
+		}()
+	}
+	first, second := <-claims, <-claims
+	assert.True(t, (first == nil) != (second == nil),
+		"because only one moderator can claim an open report")
+	if first != nil {
+		assert.ErrorIs(t, first, domain.ErrReportResolved)
+	} else {
+		assert.ErrorIs(t, second, domain.ErrReportResolved)
+	}
+}
+
+func Reports_CreateAuditEntry(
+	t *testing.T,
+	r domain.Repository,
+) {
+	now := time.Now().UTC()
+
+	e := domain.NewAuditEntry("moderator@unruly.com", domain.ActionSuspend)
+	e.UserEmail = "user@unruly.com"
+	e.Note = "unruly note"
+	ce, err := r.CreateAuditEntry(ctx, e)
+	require.NoError(t, err)
+	assert.Positive(t, ce.ID)
+	assert.Equal(t, domain.ActionSuspend, ce.Action)
+	assert.Equal(t, "user@unruly.com", ce.UserEmail)
+	assert.Equal(t, "unruly note", ce.Note)
+	assert.True(t, now.Before(ce.CreatedAtUTC))
+
+	e = domain.NewAuditEntry("moderator@unruly.com", domain.ActionHide)
+	e.ArticleSlug = "unruly-title"
+	e.CommentID = 4
+	ce2, err := r.CreateAuditEntry(ctx, e)
+	require.NoError(t, err)
+
+	latest, err := r.LatestAuditEntries(ctx, 1000, 0)
+	require.NoError(t, err)
+
+	first, second := -1, -1
+	for i, le := range latest {
+		if le.ID == ce.ID {
+			first = i
+		}
+		if le.ID == ce2.ID {
+			second = i
+		}
+	}
+	require.NotEqual(t, -1, first)
+	require.NotEqual(t, -1, second)
+	assert.Less(t, second, first, "because the latest entries are first")
+	assert.Equal(t, "unruly-title", latest[second].ArticleSlug)
+	assert.Equal(t, 4, latest[second].CommentID)
+
+	paged, err := r.LatestAuditEntries(ctx, 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, paged, 1)
+}
+
+func containsReport(rs []domain.Report, id int) bool {
+	for _, r := range rs {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
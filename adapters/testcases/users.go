@@ -2,7 +2,9 @@ package testcases
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/brycekbargar/realworld-backend/domain"
 	"github.com/stretchr/testify/assert"
@@ -122,6 +124,99 @@ func Users_GetUserByUsername(
 	assert.NoError(t, err)
 }
 
+func Users_GetUserByID(
+	t *testing.T,
+	r domain.Repository,
+) {
+	u := testUser("steadfast")
+	_, err := r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	require.NotEmpty(t, u.ID)
+
+	fu, err := r.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u, fu)
+
+	_, err = r.GetUserByID(ctx, "00000000-0000-4000-8000-000000000000")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@steadfast.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.ChangeEmail("user@unwavering.com")
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "user@unwavering.com", fu.Email)
+
+	require.NoError(t, r.DeleteUser(ctx, "user@unwavering.com", domain.DeleteContent))
+	_, err = r.GetUserByID(ctx, u.ID)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+}
+
+func Users_UpdateUserByEmail_UsernameHistory(
+	t *testing.T,
+	r domain.Repository,
+) {
+	u := testUser("wistful")
+	_, err := r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, testUser("grudging"))
+	require.NoError(t, err)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@wistful.com",
+		func(u *domain.User) (*domain.User, error) {
+			return u, u.ChangeUsername("nostalgic username")
+		})
+	require.NoError(t, err)
+
+	_, err = r.GetUserByUsername(ctx, "wistful username")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	pu, err := r.GetUserByPreviousUsername(ctx, "Wistful Username")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, pu.ID)
+	assert.Equal(t, "nostalgic username", pu.Username)
+	_, err = r.GetUserByPreviousUsername(ctx, "grudging username")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@grudging.com",
+		func(u *domain.User) (*domain.User, error) {
+			return u, u.ChangeUsername("wistful username")
+		})
+	assert.ErrorIs(t, err, domain.ErrDuplicateUser, "because previous usernames stay reserved")
+	taker := testUser("covetous")
+	taker.Username = "wistful username"
+	_, err = r.CreateUser(ctx, taker)
+	assert.ErrorIs(t, err, domain.ErrDuplicateUser, "because previous usernames stay reserved")
+
+	x, err := r.ExportUserByEmail(ctx, "user@wistful.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wistful username"}, x.PreviousUsernames)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@wistful.com",
+		func(u *domain.User) (*domain.User, error) {
+			return u, u.ChangeUsername("wistful username")
+		})
+	require.NoError(t, err, "because users can take back their previous usernames")
+	_, err = r.GetUserByPreviousUsername(ctx, "wistful username")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	pu, err = r.GetUserByPreviousUsername(ctx, "nostalgic username")
+	require.NoError(t, err)
+	assert.Equal(t, "wistful username", pu.Username)
+
+	require.NoError(t, r.DeleteUser(ctx, "user@wistful.com", domain.AnonymizeContent))
+	_, err = r.GetUserByPreviousUsername(ctx, "nostalgic username")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetUserByID(ctx, u.ID)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound, "because anonymized users get a new id")
+}
+
 func Users_UpdateFanboyByEmail_Following(
 	t *testing.T,
 	r domain.Repository,
@@ -272,6 +367,624 @@ func Users_UpdateFanboyByEmail_Favorites(
 	assert.False(t, fu.Favors("aware-title"))
 }
 
+func Users_UpdateFanboyByEmail_Blocking(
+	t *testing.T,
+	r domain.Repository,
+) {
+	u := testUser("petty")
+	_, err := r.CreateUser(ctx, u)
+	require.NoError(t, err)
+
+	fu, err := r.GetUserByEmail(ctx, u.Email)
+	require.NoError(t, err)
+	assert.Empty(t, fu.BlockingEmails())
+	assert.Empty(t, fu.MutingEmails())
+
+	for _, a := range []string{"brash", "meek", "grumpy"} {
+		_, err := r.CreateUser(ctx, testUser(a))
+		require.NoError(t, err)
+	}
+
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@petty.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.StartFollowing("user@meek.com")
+			f.Block("user@brash.com")
+			f.Block("user@grumpy.com")
+			f.Mute("user@meek.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByEmail(ctx, "user@petty.com")
+	require.NoError(t, err)
+	assert.Len(t, fu.BlockingEmails(), 2)
+	assert.True(t, fu.IsBlocking("user@brash.com"))
+	assert.True(t, fu.IsMuting("user@meek.com"))
+	assert.True(t, fu.IsFollowing("user@meek.com"))
+	assert.False(t, fu.IsMuting("user@brash.com"))
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@brash.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "user@bold.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByEmail(ctx, "user@petty.com")
+	require.NoError(t, err)
+	assert.True(t, fu.IsBlocking("user@bold.com"))
+	assert.False(t, fu.IsBlocking("user@brash.com"))
+
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@petty.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.Unblock("user@grumpy.com")
+			f.Unmute("user@meek.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByEmail(ctx, "user@petty.com")
+	require.NoError(t, err)
+	assert.Len(t, fu.BlockingEmails(), 1)
+	assert.Empty(t, fu.MutingEmails())
+	assert.True(t, fu.IsFollowing("user@meek.com"))
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@meek.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "user@timid.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@petty.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.StartFollowing("user@nobody.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByEmail(ctx, "user@petty.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user@timid.com"}, fu.FollowingEmails(), "because nobody can't be followed")
+	followers, err := r.FollowersByEmail(ctx, "user@timid.com", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, followers, 1)
+	assert.Equal(t, "user@petty.com", followers[0].Email)
+}
+
+func Users_UpdateUserByEmail_EmailInsideAnother(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, a := range []string{"sly", "coy"} {
+		_, err := r.CreateUser(ctx, testUser(a))
+		require.NoError(t, err)
+	}
+	// ser@sly.com is the end of user@sly.com
+	inside, _ := domain.NewUserWithPassword("ser@sly.com", "ser username", "Test1234!")
+	_, err := r.CreateUser(ctx, inside)
+	require.NoError(t, err)
+
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@coy.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.StartFollowing("user@sly.com")
+			f.StartFollowing("ser@sly.com")
+			f.Mute("user@sly.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"ser@sly.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "ser@wily.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err := r.GetUserByEmail(ctx, "user@coy.com")
+	require.NoError(t, err)
+	assert.Len(t, fu.FollowingEmails(), 2)
+	assert.True(t, fu.IsFollowing("ser@wily.com"))
+	assert.True(t, fu.IsFollowing("user@sly.com"),
+		"because only the whole email is changed")
+	assert.True(t, fu.IsMuting("user@sly.com"))
+	assert.False(t, fu.IsFollowing("user@wily.com"))
+}
+
+func Users_FollowersByEmail(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, a := range []string{"popular", "ardent", "eager", "zealous"} {
+		_, err := r.CreateUser(ctx, testUser(a))
+		require.NoError(t, err)
+	}
+
+	_, err := r.FollowersByEmail(ctx, "user@unpopular.com", 10, 0)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.FollowingByEmail(ctx, "user@unpopular.com", 10, 0)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetFollowCountsByEmail(ctx, "user@unpopular.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	c, err := r.GetFollowCountsByEmail(ctx, "user@popular.com")
+	require.NoError(t, err)
+	assert.Equal(t, domain.FollowCounts{}, *c)
+
+	for _, a := range []string{"zealous", "ardent", "eager"} {
+		err = r.UpdateFanboyByEmail(ctx,
+			fmt.Sprintf("user@%v.com", a),
+			func(f *domain.Fanboy) (*domain.Fanboy, error) {
+				f.StartFollowing("user@popular.com")
+				return f, nil
+			})
+		require.NoError(t, err)
+	}
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@popular.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.StartFollowing("user@eager.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	c, err = r.GetFollowCountsByEmail(ctx, "user@popular.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, c.Followers)
+	assert.Equal(t, 1, c.Following)
+
+	fs, err := r.FollowersByEmail(ctx, "user@popular.com", 2, 0)
+	require.NoError(t, err)
+	require.Len(t, fs, 2)
+	assert.Equal(t, "ardent username", fs[0].Username)
+	assert.Equal(t, "eager username", fs[1].Username)
+
+	fs, err = r.FollowersByEmail(ctx, "user@popular.com", 2, 2)
+	require.NoError(t, err)
+	require.Len(t, fs, 1)
+	assert.Equal(t, "zealous username", fs[0].Username)
+
+	fs, err = r.FollowingByEmail(ctx, "user@popular.com", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, fs, 1)
+	assert.Equal(t, "user@eager.com", fs[0].Email)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@ardent.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "user@fervent.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+	err = r.UpdateFanboyByEmail(ctx,
+		"user@zealous.com",
+		func(f *domain.Fanboy) (*domain.Fanboy, error) {
+			f.StopFollowing("user@popular.com")
+			return f, nil
+		})
+	require.NoError(t, err)
+
+	fs, err = r.FollowersByEmail(ctx, "user@popular.com", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, fs, 2)
+	assert.Equal(t, "user@fervent.com", fs[0].Email)
+	assert.Equal(t, "user@eager.com", fs[1].Email)
+
+	fs, err = r.FollowersByEmail(ctx, "user@eager.com", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, fs, 1)
+	assert.Equal(t, "user@popular.com", fs[0].Email)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@popular.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Email = "user@famous.com"
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	c, err = r.GetFollowCountsByEmail(ctx, "user@famous.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Followers)
+	assert.Equal(t, 1, c.Following)
+}
+
+func Users_ListUsers(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, a := range []string{"listed", "enumerated", "catalogued"} {
+		_, err := r.CreateUser(ctx, testUser(a))
+		require.NoError(t, err)
+	}
+
+	// Other tests are creating users at the same time so only these ones are checked for
+	found := make(map[string]domain.User)
+	for offset := 0; ; offset += 2 {
+		page, err := r.ListUsers(ctx, 2, offset)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(page), 2)
+		if len(page) == 2 {
+			assert.LessOrEqual(t,
+				strings.ToLower(page[0].Username),
+				strings.ToLower(page[1].Username),
+				"because users are listed by username")
+		}
+		for _, u := range page {
+			found[u.Email] = u
+		}
+		if len(page) < 2 {
+			break
+		}
+	}
+
+	for _, a := range []string{"listed", "enumerated", "catalogued"} {
+		u, ok := found[fmt.Sprintf("user@%v.com", a)]
+		if assert.True(t, ok, a) {
+			assert.Equal(t, fmt.Sprintf("%v bio", a), u.Bio)
+		}
+	}
+}
+
+func Users_UpdateUserByEmail_Roles(
+	t *testing.T,
+	r domain.Repository,
+) {
+	u := testUser("jolly")
+	u.Role = domain.RoleModerator
+	cu, err := r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleModerator, cu.Role)
+	assert.False(t, cu.Banned)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@jolly.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Role = domain.RoleAdmin
+			u.Banned = true
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err := r.GetUserByEmail(ctx, "user@jolly.com")
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleAdmin, fu.Role)
+	assert.True(t, fu.Banned)
+
+	uu, err := r.GetUserByUsername(ctx, "jolly username")
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleAdmin, uu.Role)
+	assert.True(t, uu.Banned)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@jolly.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Banned = false
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByEmail(ctx, "user@jolly.com")
+	require.NoError(t, err)
+	assert.False(t, fu.Banned)
+}
+
+func Users_UpdateUserByEmail_Verified(
+	t *testing.T,
+	r domain.Repository,
+) {
+	cu, err := r.CreateUser(ctx, testUser("earnest"))
+	require.NoError(t, err)
+	assert.False(t, cu.Verified)
+
+	uu, err := r.UpdateUserByEmail(ctx,
+		"user@earnest.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.Verified = true
+			return u, nil
+		})
+	require.NoError(t, err)
+	assert.True(t, uu.Verified)
+
+	fu, err := r.GetUserByEmail(ctx, "user@earnest.com")
+	require.NoError(t, err)
+	assert.True(t, fu.Verified)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@earnest.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.ChangeEmail("user@sincere.com")
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	un, err := r.GetUserByUsername(ctx, "earnest username")
+	require.NoError(t, err)
+	assert.Equal(t, "user@sincere.com", un.Email)
+	assert.False(t, un.Verified)
+}
+
+func Users_UpdateUserByEmail_TwoFactor(
+	t *testing.T,
+	r domain.Repository,
+) {
+	cu, err := r.CreateUser(ctx, testUser("vigilant"))
+	require.NoError(t, err)
+	assert.False(t, cu.TwoFactor.Enabled)
+
+	tf := domain.TwoFactor{
+		Secret:         "JBSWY3DPEHPK3PXP",
+		Enabled:        true,
+		RecoveryCodes:  []string{"first hash", "second hash"},
+		LastCounter:    56789,
+		FailedAttempts: 3,
+		LockedUntilUTC: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@vigilant.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.TwoFactor = tf
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err := r.GetUserByEmail(ctx, "user@vigilant.com")
+	require.NoError(t, err)
+	assert.Equal(t, tf, fu.TwoFactor)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@vigilant.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.DisableTOTP()
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	un, err := r.GetUserByUsername(ctx, "vigilant username")
+	require.NoError(t, err)
+	assert.False(t, un.TwoFactor.Enabled)
+	assert.Empty(t, un.TwoFactor.Secret)
+	assert.Empty(t, un.TwoFactor.RecoveryCodes)
+}
+
+func Users_LinkIdentity(
+	t *testing.T,
+	r domain.Repository,
+) {
+	_, err := r.CreateUser(ctx, testUser("social"))
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, testUser("reclusive"))
+	require.NoError(t, err)
+
+	id := &domain.ExternalIdentity{
+		Provider: "social provider",
+		Subject:  "social subject",
+		Email:    "user@social.com",
+	}
+
+	_, err = r.GetUserByIdentity(ctx, id.Provider, id.Subject)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	require.NoError(t, r.LinkIdentity(ctx, "user@social.com", id))
+	require.NoError(t, r.LinkIdentity(ctx, "user@social.com", id), "linking again is fine")
+	assert.ErrorIs(t, r.LinkIdentity(ctx, "user@reclusive.com", id), domain.ErrDuplicateIdentity)
+	assert.ErrorIs(t, r.LinkIdentity(ctx, "user@missing.com", &domain.ExternalIdentity{
+		Provider: "social provider",
+		Subject:  "missing subject",
+	}), domain.ErrUserNotFound)
+
+	fu, err := r.GetUserByIdentity(ctx, id.Provider, id.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, "social username", fu.Username)
+
+	_, err = r.GetUserByIdentity(ctx, "other provider", id.Subject)
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	_, err = r.UpdateUserByEmail(ctx,
+		"user@social.com",
+		func(u *domain.User) (*domain.User, error) {
+			u.ChangeEmail("user@sociable.com")
+			return u, nil
+		})
+	require.NoError(t, err)
+
+	fu, err = r.GetUserByIdentity(ctx, id.Provider, id.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, "user@sociable.com", fu.Email)
+
+	require.NoError(t, r.LinkIdentity(ctx, "user@sociable.com", &domain.ExternalIdentity{
+		Provider: "another provider",
+		Subject:  "social subject",
+	}))
+	x, err := r.ExportUserByEmail(ctx, "user@sociable.com")
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ExternalIdentity{
+		{Provider: "another provider", Subject: "social subject"},
+		{Provider: "social provider", Subject: "social subject"},
+	}, x.Identities)
+	x, err = r.ExportUserByEmail(ctx, "user@reclusive.com")
+	require.NoError(t, err)
+	assert.Empty(t, x.Identities)
+}
+
+func Users_DeleteUser_Anonymize(
+	t *testing.T,
+	r domain.Repository,
+) {
+	_, err := r.CreateUser(ctx, testAuthor("forgotten"))
+	require.NoError(t, err)
+	_, err = r.CreateArticle(ctx, testArticle("forgotten"))
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, testUser("lingering"))
+	require.NoError(t, err)
+
+	for _, em := range []string{"author@forgotten.com", "user@lingering.com"} {
+		em := em
+		_, err = r.UpdateCommentsBySlug(ctx,
+			"forgotten-title",
+			func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+				return a, a.AddComment("forgotten comment", em)
+			})
+		require.NoError(t, err)
+	}
+	require.NoError(t, r.UpdateFanboyByEmail(ctx,
+		"user@lingering.com",
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			u.StartFollowing("author@forgotten.com")
+			u.Favorite("forgotten-title")
+			return u, nil
+		}))
+	require.NoError(t, r.UpdateFanboyByEmail(ctx,
+		"author@forgotten.com",
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			u.StartFollowing("user@lingering.com")
+			return u, nil
+		}))
+
+	l, err := domain.NewReadingList("author@forgotten.com", "Forgotten Reads")
+	require.NoError(t, err)
+	_, err = r.CreateReadingList(ctx, l)
+	require.NoError(t, err)
+	at, raw, err := domain.NewAPIToken("author@forgotten.com", "Forgotten Script", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = r.CreateAPIToken(ctx, at)
+	require.NoError(t, err)
+	require.NoError(t, r.LinkIdentity(ctx, "author@forgotten.com", &domain.ExternalIdentity{
+		Provider: "forgotten provider",
+		Subject:  "forgotten subject",
+	}))
+
+	require.NoError(t, r.DeleteUser(ctx, "author@forgotten.com", domain.AnonymizeContent))
+	assert.ErrorIs(t, r.DeleteUser(ctx, "author@forgotten.com", domain.AnonymizeContent), domain.ErrUserNotFound)
+
+	_, err = r.GetUserByEmail(ctx, "author@forgotten.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetUserByUsername(ctx, "forgotten username")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetUserByIdentity(ctx, "forgotten provider", "forgotten subject")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetAPITokenByHash(ctx, domain.HashAPIToken(raw))
+	assert.ErrorIs(t, err, domain.ErrAPITokenNotFound)
+
+	fa, err := r.GetArticleBySlug(ctx, "forgotten-title")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(fa.AuthorEmail, "@"+domain.AnonymousDomain))
+	assert.True(t, strings.HasPrefix(fa.Author.GetUsername(), "deleted-"))
+	assert.Empty(t, fa.Author.GetBio())
+	assert.Equal(t, 1, fa.FavoriteCount)
+
+	ca, err := r.GetCommentsBySlug(ctx, "forgotten-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 2)
+	assert.Equal(t, fa.AuthorEmail, ca.Comments[0].AuthorEmail)
+	assert.Equal(t, "user@lingering.com", ca.Comments[1].AuthorEmail)
+
+	fu, err := r.GetUserByEmail(ctx, "user@lingering.com")
+	require.NoError(t, err)
+	assert.Empty(t, fu.Following)
+	counts, err := r.GetFollowCountsByEmail(ctx, "user@lingering.com")
+	require.NoError(t, err)
+	assert.Equal(t, &domain.FollowCounts{}, counts)
+}
+
+func Users_DeleteUser_Delete(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, adj := range []string{"obliterated", "bystanding"} {
+		_, err := r.CreateUser(ctx, testAuthor(adj))
+		require.NoError(t, err)
+		_, err = r.CreateArticle(ctx, testArticle(adj))
+		require.NoError(t, err)
+	}
+	_, err := r.UpdateCommentsBySlug(ctx,
+		"bystanding-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("obliterated comment", "author@obliterated.com")
+		})
+	require.NoError(t, err)
+	_, err = r.UpdateCommentsBySlug(ctx,
+		"bystanding-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("bystanding comment", "author@bystanding.com")
+		})
+	require.NoError(t, err)
+	require.NoError(t, r.UpdateFanboyByEmail(ctx,
+		"author@bystanding.com",
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			u.StartFollowing("author@obliterated.com")
+			return u, nil
+		}))
+
+	require.NoError(t, r.DeleteUser(ctx, "author@obliterated.com", domain.DeleteContent))
+
+	_, err = r.GetUserByEmail(ctx, "author@obliterated.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	_, err = r.GetArticleBySlug(ctx, "obliterated-title")
+	assert.ErrorIs(t, err, domain.ErrArticleNotFound)
+
+	ca, err := r.GetCommentsBySlug(ctx, "bystanding-title")
+	require.NoError(t, err)
+	require.Len(t, ca.Comments, 1)
+	assert.Equal(t, "bystanding comment", ca.Comments[0].Body)
+
+	fu, err := r.GetUserByEmail(ctx, "author@bystanding.com")
+	require.NoError(t, err)
+	assert.Empty(t, fu.Following)
+}
+
+func Users_ExportUserByEmail(
+	t *testing.T,
+	r domain.Repository,
+) {
+	for _, adj := range []string{"prolific", "admired"} {
+		_, err := r.CreateUser(ctx, testAuthor(adj))
+		require.NoError(t, err)
+		_, err = r.CreateArticle(ctx, testArticle(adj))
+		require.NoError(t, err)
+	}
+	_, err := r.UpdateCommentsBySlug(ctx,
+		"admired-title",
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			return a, a.AddComment("prolific comment", "author@prolific.com")
+		})
+	require.NoError(t, err)
+	require.NoError(t, r.UpdateFanboyByEmail(ctx,
+		"author@prolific.com",
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			u.StartFollowing("author@admired.com")
+			u.Favorite("admired-title")
+			return u, nil
+		}))
+	l, err := domain.NewReadingList("author@prolific.com", "Prolific Reads")
+	require.NoError(t, err)
+	_, err = r.CreateReadingList(ctx, l)
+	require.NoError(t, err)
+
+	_, err = r.ExportUserByEmail(ctx, "author@unprolific.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+
+	x, err := r.ExportUserByEmail(ctx, "author@prolific.com")
+	require.NoError(t, err)
+	assert.Equal(t, "prolific username", x.User.Username)
+	assert.Equal(t, []string{"admired username"}, x.Following)
+	assert.Equal(t, []string{"admired-title"}, x.Favorites)
+	require.Len(t, x.Articles, 1)
+	assert.Equal(t, "prolific-title", x.Articles[0].Slug)
+	require.Len(t, x.Comments, 1)
+	assert.Equal(t, "admired-title", x.Comments[0].ArticleSlug)
+	assert.Equal(t, "prolific comment", x.Comments[0].Body)
+	require.Len(t, x.ReadingLists, 1)
+	assert.Equal(t, "prolific-reads", x.ReadingLists[0].Slug)
+	assert.False(t, x.CreatedAtUTC.IsZero())
+}
+
 func testUser(adj string) *domain.User {
 	u, _ := domain.NewUserWithPassword(
 		fmt.Sprintf("user@%v.com", adj),
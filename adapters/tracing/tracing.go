@@ -0,0 +1,358 @@
+// Package tracing is a decorator for the repository adapters that creates an OpenTelemetry span for every operation.
+package tracing
+
+import (
+	"context"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Repository is a domain.Repository that traces the operations of another one,
+// the spans are children of whatever span is in the context they're called with.
+type Repository struct {
+	domain.Repository
+	tracer trace.Tracer
+}
+
+// NewInstance wraps the repository with spans from the provider.
+func NewInstance(r domain.Repository, tp trace.TracerProvider) *Repository {
+	return &Repository{
+		r,
+		tp.Tracer("github.com/brycekbargar/realworld-backend/adapters/tracing"),
+	}
+}
+
+func (r *Repository) start(ctx context.Context, op string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "Repository."+op,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("conduit.repository.operation", op)))
+}
+
+// end ends the span, recording the error if the operation failed.
+// Not finding what was asked for is an expected outcome rather than an error.
+func end(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		if domain.IsNotFound(*err) {
+			span.SetAttributes(attribute.Bool("conduit.repository.not_found", true))
+		} else {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+	span.End()
+}
+
+// CreateUser creates a new user.
+func (r *Repository) CreateUser(ctx context.Context, u *domain.User) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "CreateUser")
+	defer end(span, &err)
+	return r.Repository.CreateUser(ctx, u)
+}
+
+// GetUserByEmail finds a single user based on their email address.
+func (r *Repository) GetUserByEmail(ctx context.Context, e string) (_ *domain.Fanboy, err error) {
+	ctx, span := r.start(ctx, "GetUserByEmail")
+	defer end(span, &err)
+	return r.Repository.GetUserByEmail(ctx, e)
+}
+
+// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
+func (r *Repository) GetAuthorByEmail(ctx context.Context, e string) domain.Author {
+	ctx, span := r.start(ctx, "GetAuthorByEmail")
+	defer end(span, nil)
+	return r.Repository.GetAuthorByEmail(ctx, e)
+}
+
+// GetUserByID finds a single user based on their id.
+func (r *Repository) GetUserByID(ctx context.Context, id string) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByID")
+	defer end(span, &err)
+	return r.Repository.GetUserByID(ctx, id)
+}
+
+// GetUserByUsername finds a single user based on their username.
+func (r *Repository) GetUserByUsername(ctx context.Context, un string) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByUsername")
+	defer end(span, &err)
+	return r.Repository.GetUserByUsername(ctx, un)
+}
+
+// GetUserByPreviousUsername finds a single user based on a username they used to have.
+func (r *Repository) GetUserByPreviousUsername(ctx context.Context, un string) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByPreviousUsername")
+	defer end(span, &err)
+	return r.Repository.GetUserByPreviousUsername(ctx, un)
+}
+
+// UpdateUserByEmail finds a single user based on their email address,
+// then applies the provide mutations.
+// Usernames they stop using stay reserved for them.
+func (r *Repository) UpdateUserByEmail(ctx context.Context, e string, update func(*domain.User) (*domain.User, error)) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "UpdateUserByEmail")
+	defer end(span, &err)
+	return r.Repository.UpdateUserByEmail(ctx, e, update)
+}
+
+// UpdateFanboyByEmail finds a single user based on their email address,
+// then applies the provide mutations (probably to the follower list).
+func (r *Repository) UpdateFanboyByEmail(ctx context.Context, e string, update func(*domain.Fanboy) (*domain.Fanboy, error)) (err error) {
+	ctx, span := r.start(ctx, "UpdateFanboyByEmail")
+	defer end(span, &err)
+	return r.Repository.UpdateFanboyByEmail(ctx, e, update)
+}
+
+// ListUsers lists every user, ordered by username.
+func (r *Repository) ListUsers(ctx context.Context, limit int, offset int) (_ []domain.User, err error) {
+	ctx, span := r.start(ctx, "ListUsers")
+	defer end(span, &err)
+	return r.Repository.ListUsers(ctx, limit, offset)
+}
+
+// FollowersByEmail lists the users following the user with the given email, ordered by username.
+func (r *Repository) FollowersByEmail(ctx context.Context, e string, limit int, offset int) (_ []domain.User, err error) {
+	ctx, span := r.start(ctx, "FollowersByEmail")
+	defer end(span, &err)
+	return r.Repository.FollowersByEmail(ctx, e, limit, offset)
+}
+
+// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+func (r *Repository) FollowingByEmail(ctx context.Context, e string, limit int, offset int) (_ []domain.User, err error) {
+	ctx, span := r.start(ctx, "FollowingByEmail")
+	defer end(span, &err)
+	return r.Repository.FollowingByEmail(ctx, e, limit, offset)
+}
+
+// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+func (r *Repository) GetFollowCountsByEmail(ctx context.Context, e string) (_ *domain.FollowCounts, err error) {
+	ctx, span := r.start(ctx, "GetFollowCountsByEmail")
+	defer end(span, &err)
+	return r.Repository.GetFollowCountsByEmail(ctx, e)
+}
+
+// DeleteUser deletes the user with the given email,
+// their articles and comments are either anonymized or deleted along with them.
+func (r *Repository) DeleteUser(ctx context.Context, e string, mode domain.DeletionMode) (err error) {
+	ctx, span := r.start(ctx, "DeleteUser")
+	defer end(span, &err)
+	return r.Repository.DeleteUser(ctx, e, mode)
+}
+
+// ExportUserByEmail gathers everything kept about the user with the given email.
+func (r *Repository) ExportUserByEmail(ctx context.Context, e string) (_ *domain.UserExport, err error) {
+	ctx, span := r.start(ctx, "ExportUserByEmail")
+	defer end(span, &err)
+	return r.Repository.ExportUserByEmail(ctx, e)
+}
+
+// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+func (r *Repository) GetUserByIdentity(ctx context.Context, provider string, subject string) (_ *domain.User, err error) {
+	ctx, span := r.start(ctx, "GetUserByIdentity")
+	defer end(span, &err)
+	return r.Repository.GetUserByIdentity(ctx, provider, subject)
+}
+
+// LinkIdentity links the external identity to the user with the given email so they can login with it.
+func (r *Repository) LinkIdentity(ctx context.Context, e string, i *domain.ExternalIdentity) (err error) {
+	ctx, span := r.start(ctx, "LinkIdentity")
+	defer end(span, &err)
+	return r.Repository.LinkIdentity(ctx, e, i)
+}
+
+// CreateAPIToken creates a new personal API token.
+func (r *Repository) CreateAPIToken(ctx context.Context, t *domain.APIToken) (_ *domain.APIToken, err error) {
+	ctx, span := r.start(ctx, "CreateAPIToken")
+	defer end(span, &err)
+	return r.Repository.CreateAPIToken(ctx, t)
+}
+
+// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+func (r *Repository) APITokensByOwner(ctx context.Context, e string) (_ []domain.APIToken, err error) {
+	ctx, span := r.start(ctx, "APITokensByOwner")
+	defer end(span, &err)
+	return r.Repository.APITokensByOwner(ctx, e)
+}
+
+// GetAPITokenByHash gets a single API token with the given hash.
+func (r *Repository) GetAPITokenByHash(ctx context.Context, h string) (_ *domain.APIToken, err error) {
+	ctx, span := r.start(ctx, "GetAPITokenByHash")
+	defer end(span, &err)
+	return r.Repository.GetAPITokenByHash(ctx, h)
+}
+
+// DeleteAPIToken revokes the API token with the given owner email and id.
+func (r *Repository) DeleteAPIToken(ctx context.Context, e string, id int) (err error) {
+	ctx, span := r.start(ctx, "DeleteAPIToken")
+	defer end(span, &err)
+	return r.Repository.DeleteAPIToken(ctx, e, id)
+}
+
+// CreateArticle creates a new article.
+func (r *Repository) CreateArticle(ctx context.Context, a *domain.Article) (_ *domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "CreateArticle")
+	defer end(span, &err)
+	return r.Repository.CreateArticle(ctx, a)
+}
+
+// ImportArticle creates an article along with its comments keeping when they were created and updated.
+func (r *Repository) ImportArticle(ctx context.Context, a *domain.CommentedArticle) (_ *domain.CommentedArticle, err error) {
+	ctx, span := r.start(ctx, "ImportArticle")
+	defer end(span, &err)
+	return r.Repository.ImportArticle(ctx, a)
+}
+
+// LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
+// Hidden articles are never included.
+func (r *Repository) LatestArticlesByCriteria(ctx context.Context, query domain.ListCriteria) (_ []domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "LatestArticlesByCriteria")
+	defer end(span, &err)
+	return r.Repository.LatestArticlesByCriteria(ctx, query)
+}
+
+// GetArticleBySlug gets a single article with the given slug.
+func (r *Repository) GetArticleBySlug(ctx context.Context, s string) (_ *domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "GetArticleBySlug")
+	defer end(span, &err)
+	return r.Repository.GetArticleBySlug(ctx, s)
+}
+
+// GetArticleByID gets a single article with the given id.
+func (r *Repository) GetArticleByID(ctx context.Context, id string) (_ *domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "GetArticleByID")
+	defer end(span, &err)
+	return r.Repository.GetArticleByID(ctx, id)
+}
+
+// GetCommentsBySlug gets a single article and its comments with the given slug.
+// Hidden comments are not included.
+func (r *Repository) GetCommentsBySlug(ctx context.Context, s string) (_ *domain.CommentedArticle, err error) {
+	ctx, span := r.start(ctx, "GetCommentsBySlug")
+	defer end(span, &err)
+	return r.Repository.GetCommentsBySlug(ctx, s)
+}
+
+// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug.
+func (r *Repository) GetCommentsWithHiddenBySlug(ctx context.Context, s string) (_ *domain.CommentedArticle, err error) {
+	ctx, span := r.start(ctx, "GetCommentsWithHiddenBySlug")
+	defer end(span, &err)
+	return r.Repository.GetCommentsWithHiddenBySlug(ctx, s)
+}
+
+// UpdateArticleBySlug finds a single article based on its slug
+// then applies the provide mutations.
+func (r *Repository) UpdateArticleBySlug(ctx context.Context, s string, update func(*domain.Article) (*domain.Article, error)) (_ *domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "UpdateArticleBySlug")
+	defer end(span, &err)
+	return r.Repository.UpdateArticleBySlug(ctx, s, update)
+}
+
+// UpdateCommentsBySlug finds a single article based on its slug
+// then applies the provide mutations to its comments (including the hidden ones).
+func (r *Repository) UpdateCommentsBySlug(ctx context.Context, s string, update func(*domain.CommentedArticle) (*domain.CommentedArticle, error)) (_ *domain.Comment, err error) {
+	ctx, span := r.start(ctx, "UpdateCommentsBySlug")
+	defer end(span, &err)
+	return r.Repository.UpdateCommentsBySlug(ctx, s, update)
+}
+
+// DeleteArticle deletes the article if it exists.
+func (r *Repository) DeleteArticle(ctx context.Context, a *domain.Article) (err error) {
+	ctx, span := r.start(ctx, "DeleteArticle")
+	defer end(span, &err)
+	return r.Repository.DeleteArticle(ctx, a)
+}
+
+// DistinctTags returns a distinct list of tags on all articles
+func (r *Repository) DistinctTags(ctx context.Context) (_ []string, err error) {
+	ctx, span := r.start(ctx, "DistinctTags")
+	defer end(span, &err)
+	return r.Repository.DistinctTags(ctx)
+}
+
+// CreateReadingList creates a new reading list.
+func (r *Repository) CreateReadingList(ctx context.Context, l *domain.ReadingList) (_ *domain.ReadingList, err error) {
+	ctx, span := r.start(ctx, "CreateReadingList")
+	defer end(span, &err)
+	return r.Repository.CreateReadingList(ctx, l)
+}
+
+// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+func (r *Repository) ReadingListsByOwner(ctx context.Context, e string) (_ []domain.ReadingList, err error) {
+	ctx, span := r.start(ctx, "ReadingListsByOwner")
+	defer end(span, &err)
+	return r.Repository.ReadingListsByOwner(ctx, e)
+}
+
+// GetReadingList gets a single reading list with the given owner email and slug.
+func (r *Repository) GetReadingList(ctx context.Context, e string, s string) (_ *domain.ReadingList, err error) {
+	ctx, span := r.start(ctx, "GetReadingList")
+	defer end(span, &err)
+	return r.Repository.GetReadingList(ctx, e, s)
+}
+
+// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+// in the order of the list. Hidden articles are not included.
+func (r *Repository) ReadingListArticles(ctx context.Context, e string, s string) (_ []domain.AuthoredArticle, err error) {
+	ctx, span := r.start(ctx, "ReadingListArticles")
+	defer end(span, &err)
+	return r.Repository.ReadingListArticles(ctx, e, s)
+}
+
+// UpdateReadingList finds a single reading list based on its owner email and slug,
+// then applies the provide mutations.
+func (r *Repository) UpdateReadingList(ctx context.Context, e string, s string, update func(*domain.ReadingList) (*domain.ReadingList, error)) (_ *domain.ReadingList, err error) {
+	ctx, span := r.start(ctx, "UpdateReadingList")
+	defer end(span, &err)
+	return r.Repository.UpdateReadingList(ctx, e, s, update)
+}
+
+// DeleteReadingList deletes the reading list if it exists.
+func (r *Repository) DeleteReadingList(ctx context.Context, l *domain.ReadingList) (err error) {
+	ctx, span := r.start(ctx, "DeleteReadingList")
+	defer end(span, &err)
+	return r.Repository.DeleteReadingList(ctx, l)
+}
+
+// CreateReport creates a new report.
+func (r *Repository) CreateReport(ctx context.Context, rep *domain.Report) (_ *domain.Report, err error) {
+	ctx, span := r.start(ctx, "CreateReport")
+	defer end(span, &err)
+	return r.Repository.CreateReport(ctx, rep)
+}
+
+// GetReportByID gets a single report with the given id.
+func (r *Repository) GetReportByID(ctx context.Context, id int) (_ *domain.Report, err error) {
+	ctx, span := r.start(ctx, "GetReportByID")
+	defer end(span, &err)
+	return r.Repository.GetReportByID(ctx, id)
+}
+
+// ReportsByStatus lists the oldest reports with the given status first.
+func (r *Repository) ReportsByStatus(ctx context.Context, s domain.ReportStatus, limit int, offset int) (_ []domain.Report, err error) {
+	ctx, span := r.start(ctx, "ReportsByStatus")
+	defer end(span, &err)
+	return r.Repository.ReportsByStatus(ctx, s, limit, offset)
+}
+
+// UpdateReportByID finds a single report based on its id,
+// then applies the provide mutations.
+func (r *Repository) UpdateReportByID(ctx context.Context, id int, update func(*domain.Report) (*domain.Report, error)) (_ *domain.Report, err error) {
+	ctx, span := r.start(ctx, "UpdateReportByID")
+	defer end(span, &err)
+	return r.Repository.UpdateReportByID(ctx, id, update)
+}
+
+// CreateAuditEntry records a new entry in the moderation audit trail.
+func (r *Repository) CreateAuditEntry(ctx context.Context, ae *domain.AuditEntry) (_ *domain.AuditEntry, err error) {
+	ctx, span := r.start(ctx, "CreateAuditEntry")
+	defer end(span, &err)
+	return r.Repository.CreateAuditEntry(ctx, ae)
+}
+
+// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+func (r *Repository) LatestAuditEntries(ctx context.Context, limit int, offset int) (_ []domain.AuditEntry, err error) {
+	ctx, span := r.start(ctx, "LatestAuditEntries")
+	defer end(span, &err)
+	return r.Repository.LatestAuditEntries(ctx, limit, offset)
+}
@@ -0,0 +1,58 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/tracing"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Repository(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	r := tracing.NewInstance(inmemory.NewInstance(), tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	u, err := domain.NewUserWithPassword("traced@user.com", "traced", "not a guessable password")
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.NoError(t, err)
+	_, err = r.CreateUser(ctx, u)
+	require.ErrorIs(t, err, domain.ErrDuplicateUser)
+	_, err = r.GetArticleBySlug(ctx, "untraced")
+	require.ErrorIs(t, err, domain.ErrArticleNotFound)
+	parent.End()
+
+	spans := exp.GetSpans().Snapshots()
+	require.Len(t, spans, 4)
+
+	for _, s := range spans[:3] {
+		assert.Equal(t, parent.SpanContext().TraceID(), s.SpanContext().TraceID())
+		assert.Equal(t, parent.SpanContext().SpanID(), s.Parent().SpanID())
+	}
+
+	assert.Equal(t, "Repository.CreateUser", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("conduit.repository.operation", "CreateUser"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "Repository.CreateUser", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	if assert.Len(t, spans[1].Events(), 1) {
+		assert.Equal(t, "exception", spans[1].Events()[0].Name)
+	}
+
+	assert.Equal(t, "Repository.GetArticleBySlug", spans[2].Name())
+	assert.Equal(t, codes.Unset, spans[2].Status().Code, "not finding something isn't an error")
+	assert.Contains(t, spans[2].Attributes(), attribute.Bool("conduit.repository.not_found", true))
+	assert.Empty(t, spans[2].Events())
+}
@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/brycekbargar/realworld-backend/adapters/boltdb"
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/postgres"
+	"github.com/brycekbargar/realworld-backend/adapters/sqlite"
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// store is which repository adapter the flags configured.
+type store struct {
+	postgres string
+	bolt     string
+	sqlite   string
+	fixture  string
+}
+
+// admin is an open store for the admin commands.
+type admin struct {
+	repo domain.Repository
+	// migrate brings the schema up to date, it's nil when the store doesn't have one.
+	migrate func() domain.Repository
+	// close releases the store, it's nil when there's nothing to release.
+	close func() error
+	// reindex rebuilds the indexes the adapter keeps itself, it's nil when the database keeps them.
+	reindex func(context.Context) error
+	// bolt is the store when it's a bolt database, it's the only one that can be snapshotted.
+	bolt boltdb.Instance
+	in   io.Reader
+	out  io.Writer
+}
+
+// open opens the store without migrating it, commands read from in and write to out.
+func (s store) open(in io.Reader, out io.Writer) *admin {
+	a := &admin{in: in, out: out}
+	switch {
+	case s.postgres != "":
+		m := postgres.MustNewInstance(s.postgres)
+		a.repo, a.migrate = m.(domain.Repository), m.MustMigrate
+	case s.bolt != "":
+		b := boltdb.MustNewInstance(s.bolt)
+		a.repo, a.close, a.reindex, a.bolt = b, b.Close, b.Reindex, b
+	case s.sqlite != "":
+		m := sqlite.MustNewInstance(s.sqlite)
+		a.repo, a.migrate = m.(domain.Repository), m.MustMigrate
+	default:
+		// Changes to the in memory store are lost when the command finishes,
+		// it's still useful for exporting and getting the stats of a fixture.
+		mem := inmemory.NewInstance()
+		if s.fixture != "" {
+			fx, err := inmemory.ReadFixtureFile(s.fixture)
+			if err != nil {
+				panic(err)
+			}
+			if err := mem.Import(fx); err != nil {
+				panic(err)
+			}
+		}
+		a.repo, a.reindex = mem, mem.Reindex
+	}
+	return a
+}
+
+type command struct {
+	usage string
+	// migrated commands only run when the store is up to date.
+	migrated bool
+	run      func(*admin, context.Context, *flag.FlagSet, []string) error
+}
+
+// commands are run instead of the server when they're given after the flags,
+// e.g. conduit -sqlite conduit.db create-user -email ... -username ...
+var commands = map[string]command{
+	"migrate": {
+		"brings the schema of the store up to date",
+		false,
+		(*admin).migrateStore,
+	},
+	"create-user": {
+		"creates a user, their password is read from stdin",
+		true,
+		(*admin).createUser,
+	},
+	"promote": {
+		"changes the role of a user",
+		true,
+		(*admin).promote,
+	},
+	"ban": {
+		"bans (or with -lift unbans) a user",
+		true,
+		(*admin).ban,
+	},
+	"reset-password": {
+		"sets the password of a user, it's read from stdin",
+		true,
+		(*admin).resetPassword,
+	},
+	"reindex": {
+		"rebuilds the indexes the -bolt and in memory stores keep alongside their records, the server has to be stopped first",
+		true,
+		(*admin).reindexStore,
+	},
+	"snapshot": {
+		"writes a copy of the -bolt database as it is now, the server has to be stopped first",
+		false,
+		(*admin).snapshot,
+	},
+	"restore": {
+		"replaces everything in the -bolt database with a snapshot, the server has to be stopped first",
+		false,
+		(*admin).restore,
+	},
+	"export": {
+		"writes everything in the store to a fixture file, including credentials like password hashes and two-factor secrets",
+		true,
+		(*admin).export,
+	},
+	"import": {
+		"adds everything in a fixture file to the store",
+		true,
+		(*admin).importFixture,
+	},
+	"stats": {
+		"prints how many users, articles, comments, tags and open reports there are",
+		true,
+		(*admin).stats,
+	},
+}
+
+// commandsUsage lists the commands for the -help output.
+func commandsUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "\nCommands (run instead of the server, see <command> -help for their flags):")
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-16v%v\n", n, commands[n].usage)
+	}
+}
+
+// runCommand runs the command in args against the store and returns the exit code.
+// Passwords are read from stdin, output goes to stdout and errors (and -help) to stderr.
+func runCommand(ctx context.Context, s store, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		commandsUsage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := func() (err error) {
+		// Opening and migrating the stores panics
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%v", p)
+			}
+		}()
+
+		a := s.open(stdin, stdout)
+		if a.close != nil {
+			defer a.close()
+		}
+		if c.migrated {
+			if err := a.repo.Ping(ctx); errors.Is(err, domain.ErrMigrationsPending) {
+				return fmt.Errorf("%w, run the migrate command first", err)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		return c.run(a, ctx, fs, args[1:])
+	}()
+
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%v: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func (a *admin) migrateStore(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if a.migrate == nil {
+		fmt.Fprintln(a.out, "the store doesn't have a schema to migrate")
+		return nil
+	}
+	a.migrate()
+	if err := a.repo.Ping(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "the schema is up to date")
+	return nil
+}
+
+// password reads the first line of stdin so it isn't in the shell history or the process list.
+func (a *admin) password() (string, error) {
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	pw := strings.TrimRight(line, "\r\n")
+	if pw == "" {
+		return "", errors.New("no password was given on stdin")
+	}
+	return pw, nil
+}
+
+func (a *admin) createUser(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	email := fs.String("email", "", "email address of the user")
+	username := fs.String("username", "", "username of the user")
+	role := fs.String("role", string(domain.RoleUser), "role of the user (user, moderator or admin)")
+	verified := fs.Bool("verified", true, "whether their email address is already verified")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !domain.Role(*role).IsValid() {
+		return fmt.Errorf("%q isn't a role", *role)
+	}
+
+	pw, err := a.password()
+	if err != nil {
+		return err
+	}
+	u, err := domain.NewUserWithPassword(*email, *username, pw)
+	if err != nil {
+		return err
+	}
+	u.Role = domain.Role(*role)
+	u.Verified = *verified
+
+	created, err := a.repo.CreateUser(ctx, u)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "created %v (%v) with the %v role\n", created.Username, created.Email, created.Role)
+	return nil
+}
+
+// moderate changes a user the way a moderator would, recording it in the audit trail as the operator.
+func (a *admin) moderate(
+	ctx context.Context,
+	username string,
+	operator string,
+	e *domain.AuditEntry,
+	update func(*domain.User) (*domain.User, error),
+) (*domain.User, error) {
+	found, err := a.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	e.ActorEmail = operator
+	e.UserEmail = found.Email
+	if _, err := e.Validate(); err != nil {
+		return nil, fmt.Errorf("-as has to be the email address of whoever is running the command: %w", err)
+	}
+
+	updated, err := a.repo.UpdateUserByEmail(ctx, found.Email, update)
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.repo.CreateAuditEntry(ctx, e)
+	return updated, err
+}
+
+func (a *admin) promote(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	username := fs.String("username", "", "username of the user")
+	role := fs.String("role", string(domain.RoleModerator), "new role of the user (user, moderator or admin)")
+	operator := fs.String("as", "", "email address of whoever is running the command, for the audit trail")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	r := domain.Role(*role)
+	if !r.IsValid() {
+		return fmt.Errorf("%q isn't a role", *role)
+	}
+
+	e := domain.NewAuditEntry(*operator, domain.ActionChangeRole)
+	e.Note = string(r)
+	updated, err := a.moderate(ctx, *username, *operator, e, func(u *domain.User) (*domain.User, error) {
+		u.Role = r
+		return u.Validate()
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "%v now has the %v role\n", updated.Username, updated.Role)
+	return nil
+}
+
+func (a *admin) ban(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	username := fs.String("username", "", "username of the user")
+	lift := fs.Bool("lift", false, "unban the user instead")
+	operator := fs.String("as", "", "email address of whoever is running the command, for the audit trail")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	action := domain.ActionSuspend
+	if *lift {
+		action = domain.ActionUnsuspend
+	}
+	updated, err := a.moderate(ctx, *username, *operator, domain.NewAuditEntry(*operator, action), func(u *domain.User) (*domain.User, error) {
+		u.Banned = !*lift
+		return u, nil
+	})
+	if err != nil {
+		return err
+	}
+	if updated.Banned {
+		fmt.Fprintf(a.out, "%v is banned\n", updated.Username)
+	} else {
+		fmt.Fprintf(a.out, "%v isn't banned\n", updated.Username)
+	}
+	return nil
+}
+
+func (a *admin) resetPassword(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	email := fs.String("email", "", "email address of the user")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pw, err := a.password()
+	if err != nil {
+		return err
+	}
+	updated, err := a.repo.UpdateUserByEmail(ctx, *email, func(u *domain.User) (*domain.User, error) {
+		return u, u.SetPassword(pw)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "the password of %v has been reset\n", updated.Username)
+	return nil
+}
+
+// reindexStore rebuilds the lookups by id, email, username, slug, tag and author that the adapter keeps itself.
+// There's no full-text search index, and the SQL stores' indexes are kept by the database so it fails for them.
+func (a *admin) reindexStore(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.reindex == nil {
+		return errors.New("the database keeps the indexes of the sqlite and postgres stores, use its REINDEX instead")
+	}
+
+	if err := a.reindex(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, "the indexes have been rebuilt")
+	return nil
+}
+
+// errNotBolt indicates a command only works with the bolt store.
+var errNotBolt = errors.New("only a -bolt store can be snapshotted and restored")
+
+func (a *admin) snapshot(_ context.Context, fs *flag.FlagSet, args []string) (err error) {
+	out := fs.String("o", "snapshot.db", "file to write the snapshot to, it's a bolt database itself")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.bolt == nil {
+		return errNotBolt
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			// Half written snapshots can't be restored
+			os.Remove(*out)
+		}
+	}()
+	if err := a.bolt.Snapshot(f); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "wrote a snapshot to %v\n", *out)
+	return nil
+}
+
+func (a *admin) restore(_ context.Context, fs *flag.FlagSet, args []string) error {
+	from := fs.String("from", "", "snapshot file to restore (like one written by snapshot)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if a.bolt == nil {
+		return errNotBolt
+	}
+
+	f, err := os.Open(*from)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := a.bolt.Restore(f); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "restored the snapshot from %v\n", *from)
+	return nil
+}
+
+func (a *admin) export(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	out := fs.String("o", "export.json", "fixture file to write, YAML when it ends in .yaml or .yml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fx, err := inmemory.ExportFrom(ctx, a.repo)
+	if err != nil {
+		return err
+	}
+	if err := fx.WriteFile(*out); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "exported %v users and %v articles to %v\n", len(fx.Users), len(fx.Articles), *out)
+	return nil
+}
+
+func (a *admin) importFixture(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	from := fs.String("from", "", "fixture file to read (like one written by export or the -dump flag), YAML when it ends in .yaml or .yml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fx, err := inmemory.ReadFixtureFile(*from)
+	if err != nil {
+		return err
+	}
+	if err := fx.ImportTo(ctx, a.repo); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "imported %v users and %v articles from %v\n", len(fx.Users), len(fx.Articles), *from)
+	return nil
+}
+
+func (a *admin) stats(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Reading everything is slow for big stores but it's the only way to see all of it through the repository
+	fx, err := inmemory.ExportFrom(ctx, a.repo)
+	if err != nil {
+		return err
+	}
+	tags, err := a.repo.DistinctTags(ctx)
+	if err != nil {
+		return err
+	}
+	reports := 0
+	for {
+		page, err := a.repo.ReportsByStatus(ctx, domain.ReportOpen, 100, reports)
+		if err != nil {
+			return err
+		}
+		reports += len(page)
+		if len(page) < 100 {
+			break
+		}
+	}
+
+	roles := make(map[domain.Role]int)
+	banned := 0
+	for _, u := range fx.Users {
+		roles[u.Role]++
+		if u.Banned {
+			banned++
+		}
+	}
+	hidden, comments, hiddenComments := 0, 0, 0
+	for _, fa := range fx.Articles {
+		if fa.Hidden {
+			hidden++
+		}
+		for _, fc := range fa.Comments {
+			comments++
+			if fc.Hidden {
+				hiddenComments++
+			}
+		}
+	}
+
+	for _, s := range []struct {
+		name  string
+		count int
+	}{
+		{"users", len(fx.Users)},
+		{"admins", roles[domain.RoleAdmin]},
+		{"moderators", roles[domain.RoleModerator]},
+		{"banned users", banned},
+		{"articles", len(fx.Articles)},
+		{"hidden articles", hidden},
+		{"comments", comments},
+		{"hidden comments", hiddenComments},
+		{"tags", len(tags)},
+		{"open reports", reports},
+	} {
+		fmt.Fprintf(a.out, "%-16v%v\n", s.name, s.count)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/adapters/boltdb"
+	"github.com/brycekbargar/realworld-backend/adapters/sqlite"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The default parameters are much slower than tests need
+	cheap := domain.DefaultArgon2idParams
+	cheap.Memory = 1024
+	cheap.Iterations = 1
+	domain.SetPasswordHasher(domain.NewArgon2idHasher(cheap))
+	os.Exit(m.Run())
+}
+
+// result is what a command wrote and how it exited.
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// run runs the command against the store with stdin as its input.
+func run(t *testing.T, s store, stdin string, args ...string) result {
+	var stdout, stderr bytes.Buffer
+	code := runCommand(context.Background(), s, args, strings.NewReader(stdin), &stdout, &stderr)
+	return result{code, stdout.String(), stderr.String()}
+}
+
+// ok runs the command and fails the test unless it succeeds.
+func ok(t *testing.T, s store, stdin string, args ...string) string {
+	r := run(t, s, stdin, args...)
+	require.Equal(t, 0, r.code, "%v: %v", args[0], r.stderr)
+	return r.stdout
+}
+
+// repo opens the sqlite store to check what the commands did.
+func repo(s store) domain.Repository {
+	return sqlite.MustNewInstance(s.sqlite).MustMigrate()
+}
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	s := store{sqlite: filepath.Join(t.TempDir(), "conduit.db")}
+	const pw = "quizzical-ostrich-9417\n"
+
+	r := run(t, s, pw, "create-user", "-email", "admin@conduit.com", "-username", "admin")
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "run the migrate command first")
+
+	assert.Contains(t, ok(t, s, "", "migrate"), "the schema is up to date")
+
+	assert.Contains(t,
+		ok(t, s, pw, "create-user", "-email", "boss@conduit.com", "-username", "boss", "-role", "admin"),
+		"created boss (boss@conduit.com) with the admin role")
+	ok(t, s, pw, "create-user", "-email", "user@conduit.com", "-username", "unruly", "-verified=false")
+
+	r = run(t, s, "", "create-user", "-email", "other@conduit.com", "-username", "other")
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "no password was given on stdin")
+	r = run(t, s, pw, "create-user", "-email", "other@conduit.com", "-username", "other", "-role", "owner")
+	assert.Equal(t, 1, r.code)
+
+	r = run(t, s, "", "promote", "-username", "unruly")
+	assert.Equal(t, 1, r.code, "because the audit trail needs to know who ran it")
+	assert.Contains(t,
+		ok(t, s, "", "promote", "-username", "unruly", "-as", "operator@conduit.com"),
+		"unruly now has the moderator role")
+
+	assert.Contains(t,
+		ok(t, s, "", "ban", "-username", "unruly", "-as", "operator@conduit.com"),
+		"unruly is banned")
+	u, err := repo(s).GetUserByUsername(ctx, "unruly")
+	require.NoError(t, err)
+	assert.True(t, u.Banned)
+	assert.Equal(t, domain.RoleModerator, u.Role)
+	assert.False(t, u.Verified)
+
+	audit, err := repo(s).LatestAuditEntries(ctx, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, audit, 2)
+	assert.Equal(t, domain.ActionSuspend, audit[0].Action)
+	assert.Equal(t, "operator@conduit.com", audit[0].ActorEmail)
+	assert.Equal(t, "user@conduit.com", audit[0].UserEmail)
+	assert.Equal(t, domain.ActionChangeRole, audit[1].Action)
+
+	assert.Contains(t,
+		ok(t, s, "", "ban", "-username", "unruly", "-lift", "-as", "operator@conduit.com"),
+		"unruly isn't banned")
+
+	assert.Contains(t,
+		ok(t, s, "another-quizzical-ostrich\n", "reset-password", "-email", "user@conduit.com"),
+		"the password of unruly has been reset")
+	f, err := repo(s).GetUserByEmail(ctx, "user@conduit.com")
+	require.NoError(t, err)
+	matches, err := f.HasPassword("another-quizzical-ostrich")
+	require.NoError(t, err)
+	assert.True(t, matches)
+
+	r = run(t, s, "", "reindex")
+	assert.Equal(t, 1, r.code)
+	assert.Contains(t, r.stderr, "use its REINDEX instead")
+	assert.Contains(t, ok(t, store{}, "", "reindex"), "the indexes have been rebuilt")
+
+	stats := ok(t, s, "", "stats")
+	assert.Regexp(t, `users\s+2\n`, stats)
+	assert.Regexp(t, `admins\s+1\n`, stats)
+	assert.Regexp(t, `moderators\s+1\n`, stats)
+	assert.Regexp(t, `banned users\s+0\n`, stats)
+
+	// Everything that belongs to users moves too
+	_, err = repo(s).UpdateUserByEmail(ctx, "user@conduit.com", func(u *domain.User) (*domain.User, error) {
+		if _, err := u.EnrollTOTP(); err != nil {
+			return nil, err
+		}
+		code, err := domain.TOTPCode(u.TwoFactor.Secret, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		_, err = u.ConfirmTOTP(code, time.Now())
+		return u, err
+	})
+	require.NoError(t, err)
+	_, err = repo(s).UpdateUserByEmail(ctx, "user@conduit.com", func(u *domain.User) (*domain.User, error) {
+		u.Username = "reformed"
+		return u, nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, repo(s).UpdateFanboyByEmail(ctx, "user@conduit.com", func(f *domain.Fanboy) (*domain.Fanboy, error) {
+		f.Block("boss@conduit.com")
+		return f, nil
+	}))
+	require.NoError(t, repo(s).LinkIdentity(ctx, "user@conduit.com", &domain.ExternalIdentity{
+		Provider: "conduit",
+		Subject:  "user-subject",
+	}))
+	token, _, err := domain.NewAPIToken("user@conduit.com", "scripts", []domain.Scope{domain.ScopeRead})
+	require.NoError(t, err)
+	_, err = repo(s).CreateAPIToken(ctx, token)
+	require.NoError(t, err)
+
+	fixture := filepath.Join(t.TempDir(), "export.yaml")
+	assert.Contains(t, ok(t, s, "", "export", "-o", fixture), "exported 2 users and 0 articles")
+
+	moved := store{sqlite: filepath.Join(t.TempDir(), "moved.db")}
+	ok(t, moved, "", "migrate")
+	assert.Contains(t, ok(t, moved, "", "import", "-from", fixture), "imported 2 users and 0 articles")
+	assert.Equal(t, stats, ok(t, moved, "", "stats"))
+	f, err = repo(moved).GetUserByEmail(ctx, "user@conduit.com")
+	require.NoError(t, err)
+	matches, err = f.HasPassword("another-quizzical-ostrich")
+	require.NoError(t, err)
+	assert.True(t, matches, "because the password hash is moved too")
+	assert.Equal(t, "reformed", f.Username)
+	assert.True(t, f.IsBlocking("boss@conduit.com"))
+	code, err := domain.TOTPCode(f.TwoFactor.Secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	assert.NoError(t, f.CheckSecondFactor(code, time.Now().Add(30*time.Second)),
+		"because two-factor is still required")
+	prev, err := repo(moved).GetUserByPreviousUsername(ctx, "unruly")
+	require.NoError(t, err)
+	assert.Equal(t, "user@conduit.com", prev.Email)
+	linked, err := repo(moved).GetUserByIdentity(ctx, "conduit", "user-subject")
+	require.NoError(t, err)
+	assert.Equal(t, "user@conduit.com", linked.Email)
+	_, err = repo(moved).GetAPITokenByHash(ctx, token.Hash)
+	assert.NoError(t, err)
+	audit, err = repo(moved).LatestAuditEntries(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, audit, 3, "because the audit trail is moved too")
+
+	r = run(t, moved, "", "import", "-from", fixture)
+	assert.Equal(t, 1, r.code, "because the users already exist")
+
+	assert.Contains(t,
+		ok(t, store{fixture: fixture}, "", "stats"),
+		stats,
+		"because the in memory store is started from the fixture")
+}
+
+func TestSnapshotCommands(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	s := store{bolt: filepath.Join(t.TempDir(), "conduit.db")}
+	const pw = "quizzical-ostrich-9417\n"
+	ok(t, s, pw, "create-user", "-email", "kept@conduit.com", "-username", "kept")
+
+	snap := filepath.Join(t.TempDir(), "snapshot.db")
+	assert.Contains(t, ok(t, s, "", "snapshot", "-o", snap), "wrote a snapshot to "+snap)
+	ok(t, s, pw, "create-user", "-email", "lost@conduit.com", "-username", "lost")
+
+	assert.Contains(t, ok(t, s, "", "restore", "-from", snap), "restored the snapshot from "+snap)
+	assert.Contains(t, ok(t, s, "", "reindex"), "the indexes have been rebuilt")
+	func() {
+		b := boltdb.MustNewInstance(s.bolt)
+		defer b.Close()
+		_, err := b.GetUserByEmail(ctx, "kept@conduit.com")
+		assert.NoError(t, err)
+		_, err = b.GetUserByEmail(ctx, "lost@conduit.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound, "because it was created after the snapshot")
+	}()
+
+	r := run(t, s, "", "restore", "-from", filepath.Join(t.TempDir(), "missing.db"))
+	assert.Equal(t, 1, r.code)
+
+	other := store{sqlite: filepath.Join(t.TempDir(), "conduit.db")}
+	for _, args := range [][]string{{"snapshot", "-o", snap}, {"restore", "-from", snap}} {
+		r := run(t, other, "", args...)
+		assert.Equal(t, 1, r.code)
+		assert.Contains(t, r.stderr, "only a -bolt store can be snapshotted and restored")
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	t.Parallel()
+
+	r := run(t, store{}, "", "unknown")
+	assert.Equal(t, 2, r.code)
+	assert.Contains(t, r.stderr, `unknown command "unknown"`)
+	for n := range commands {
+		assert.Contains(t, r.stderr, n)
+	}
+
+	r = run(t, store{}, "", "create-user", "-help")
+	assert.Equal(t, 0, r.code)
+	assert.Contains(t, r.stderr, "-username")
+
+	r = run(t, store{}, "", "migrate")
+	assert.Equal(t, 0, r.code)
+	assert.Contains(t, r.stdout, "the store doesn't have a schema to migrate")
+}
@@ -0,0 +1,38 @@
+// Command seed generates a fixture of fake users, follows, favorites, articles and comments
+// for starting the in-memory store with (see the -fixture flag of the server).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+func main() {
+	scale := inmemory.DefaultSeedScale
+	flag.IntVar(&scale.Users, "users", scale.Users, "number of users")
+	flag.IntVar(&scale.Articles, "articles", scale.Articles, "number of articles")
+	flag.IntVar(&scale.CommentsPerArticle, "comments", scale.CommentsPerArticle, "most comments on each article")
+	flag.IntVar(&scale.FollowsPerUser, "follows", scale.FollowsPerUser, "most users each user follows")
+	flag.IntVar(&scale.FavoritesPerUser, "favorites", scale.FavoritesPerUser, "most articles each user favorites")
+	password := flag.String("password", "conduit-seed-password", "password of every user")
+	seed := flag.Int64("seed", 1, "seed for the fake data, the same seed always generates the same data")
+	out := flag.String("o", "seed.json", "fixture file to write, YAML when it ends in .yaml or .yml")
+	flag.Parse()
+
+	domain.SetPasswordHasher(domain.NewArgon2idHasher(domain.DefaultArgon2idParams))
+	fx, err := inmemory.Seed(scale, *password, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := fx.WriteFile(*out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrInvalidDeletionMode indicates a User asked for their content to be something other than anonymized or deleted.
+var ErrInvalidDeletionMode = errors.New("content must be either anonymize or delete")
+
+// DeletionMode is what happens to a deleted User's articles and comments.
+type DeletionMode string
+
+const (
+	// AnonymizeContent keeps the User's articles and comments but attributes them to an anonymous placeholder.
+	AnonymizeContent DeletionMode = "anonymize"
+	// DeleteContent deletes the User's articles and comments along with them.
+	DeleteContent DeletionMode = "delete"
+)
+
+// IsValid checks if the mode is one of the known modes.
+func (m DeletionMode) IsValid() bool {
+	return m == AnonymizeContent || m == DeleteContent
+}
+
+// AnonymousDomain is the (reserved, RFC 2606) email domain of anonymized Users.
+const AnonymousDomain = "deleted.invalid"
+
+// anonymousPrefix starts the username of anonymized Users, nobody else can have it.
+const anonymousPrefix = "deleted-"
+
+// Anonymize replaces everything that identifies the User with a random placeholder
+// so their articles and comments can be kept after they're deleted.
+// Nobody can login as the placeholder.
+func (u *User) Anonymize() error {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(b)
+
+	pw, err := unusablePassword()
+	if err != nil {
+		return err
+	}
+
+	uid, err := NewID()
+	if err != nil {
+		return err
+	}
+
+	// The placeholder gets a new ID too so existing sessions can't be used as it
+	*u = User{
+		ID:       uid,
+		Email:    anonymousPrefix + id + "@" + AnonymousDomain,
+		Username: anonymousPrefix + id,
+		Password: pw,
+		Role:     RoleUser,
+	}
+	return nil
+}
+
+// UserExport is everything kept about a User, for them to download.
+type UserExport struct {
+	User User
+	// PreviousUsernames are the usernames they used to have, oldest first.
+	PreviousUsernames []string
+	// Identities are the external identities they can login with, only the Provider and Subject are kept.
+	Identities []ExternalIdentity
+	// Following are the usernames of the users they follow.
+	Following []string
+	// Favorites are the slugs of the articles they favorited.
+	Favorites []string
+	// Articles are all the articles they authored, including hidden ones.
+	Articles []Article
+	// Comments are all the comments they made, including hidden ones.
+	Comments     []ExportedComment
+	ReadingLists []ReadingList
+	CreatedAtUTC time.Time
+}
+
+// ExportedComment is a comment with the article it was made on.
+type ExportedComment struct {
+	ArticleSlug string
+	Comment
+}
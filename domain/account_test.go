@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_Anonymize(t *testing.T) {
+	t.Parallel()
+
+	u, err := domain.NewUserWithPassword("user@departing.com", "departing", "a farewell passphrase")
+	require.NoError(t, err)
+	u.Bio = "departing bio"
+	u.Image = "http://departing.com/profile.png"
+	u.Role = domain.RoleModerator
+	u.Verified = true
+	_, err = u.EnrollTOTP()
+	require.NoError(t, err)
+
+	id := u.ID
+	require.NoError(t, u.Anonymize())
+	assert.NotEqual(t, id, u.ID)
+	assert.True(t, strings.HasSuffix(u.Email, "@"+domain.AnonymousDomain))
+	assert.True(t, strings.HasPrefix(u.Username, "deleted-"))
+	assert.Empty(t, u.Bio)
+	assert.Empty(t, u.Image)
+	assert.Equal(t, domain.RoleUser, u.Role)
+	assert.False(t, u.Verified)
+	assert.Empty(t, u.TwoFactor.Secret)
+
+	hp, err := u.HasPassword("a farewell passphrase")
+	require.NoError(t, err)
+	assert.False(t, hp)
+
+	_, err = u.Validate()
+	assert.NoError(t, err)
+
+	o, err := domain.NewUserWithPassword("user@leaving.com", "leaving", "a goodbye passphrase")
+	require.NoError(t, err)
+	require.NoError(t, o.Anonymize())
+	assert.NotEqual(t, u.Email, o.Email)
+	assert.NotEqual(t, u.Username, o.Username)
+}
+
+func TestDeletionMode_IsValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, domain.AnonymizeContent.IsValid())
+	assert.True(t, domain.DeleteContent.IsValid())
+	assert.False(t, domain.DeletionMode("archive").IsValid())
+}
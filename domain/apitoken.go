@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// ErrInvalidScope indicates an API token was requested without scopes or with a scope that doesn't exist.
+var ErrInvalidScope = errors.New("scopes must be one or more of read, write:articles and write:comments")
+
+// Scope is what a personal API token is allowed to do.
+type Scope string
+
+const (
+	// ScopeRead allows reading anything the User can see.
+	ScopeRead Scope = "read"
+	// ScopeWriteArticles allows creating, updating, deleting and favoriting articles.
+	ScopeWriteArticles Scope = "write:articles"
+	// ScopeWriteComments allows adding and deleting comments.
+	ScopeWriteComments Scope = "write:comments"
+)
+
+// IsValid checks if the scope is one of the known scopes.
+func (s Scope) IsValid() bool {
+	return s == ScopeRead || s == ScopeWriteArticles || s == ScopeWriteComments
+}
+
+// APITokenPrefix starts every personal API token so they can be told apart from session jwts (and found by secret scanners).
+const APITokenPrefix = "conduit_"
+
+// APIToken is a long-lived personal token scripts can use to act as a User, limited to its Scopes.
+// Only the hash of the token is kept, the token itself is shown once when it is created.
+type APIToken struct {
+	ID           int    `valid:"-"`
+	OwnerEmail   string `valid:"required,email"`
+	Name         string `valid:"required,stringlength(1|100)"`
+	Hash         string `valid:"required"`
+	Scopes       []Scope
+	CreatedAtUTC time.Time
+}
+
+// NewAPIToken creates a new APIToken with the provided information.
+// It returns the token to give to the User along with the APIToken to save.
+func NewAPIToken(ownerEmail string, name string, scopes []Scope) (*APIToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	raw := APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	t, err := (&APIToken{
+		OwnerEmail: ownerEmail,
+		Name:       strings.TrimSpace(name),
+		Hash:       HashAPIToken(raw),
+		Scopes:     scopes,
+	}).Validate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return t, raw, nil
+}
+
+// Validate returns the provided APIToken if it is valid, otherwise error will contain validation errors.
+func (t *APIToken) Validate() (*APIToken, error) {
+	if v, err := govalidator.ValidateStruct(t); !v {
+		return nil, err
+	}
+
+	if len(t.Scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, s := range t.Scopes {
+		if !s.IsValid() {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	return t, nil
+}
+
+// HasScope checks if the APIToken was granted the scope.
+func (t *APIToken) HasScope(s Scope) bool {
+	for _, ts := range t.Scopes {
+		if ts == s {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIToken checks if the raw token looks like a personal API token rather than a session jwt.
+func IsAPIToken(raw string) bool {
+	return strings.HasPrefix(raw, APITokenPrefix)
+}
+
+// HashAPIToken hashes the raw token for storing and looking it up.
+// Tokens are random enough that a fast hash is fine.
+func HashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,68 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Only the hash is kept", func(t *testing.T) {
+		t.Parallel()
+
+		at, raw, err := domain.NewAPIToken("user@scripted.com", " Scripted ", []domain.Scope{domain.ScopeRead})
+		require.NoError(t, err)
+		assert.Equal(t, "Scripted", at.Name)
+		assert.True(t, strings.HasPrefix(raw, domain.APITokenPrefix))
+		assert.True(t, domain.IsAPIToken(raw))
+		assert.Equal(t, domain.HashAPIToken(raw), at.Hash)
+		assert.NotContains(t, at.Hash, raw)
+
+		_, other, err := domain.NewAPIToken("user@scripted.com", "Scripted", []domain.Scope{domain.ScopeRead})
+		require.NoError(t, err)
+		assert.NotEqual(t, raw, other)
+	})
+
+	t.Run("Validation happens", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := domain.NewAPIToken("user@automated.com", "", []domain.Scope{domain.ScopeRead})
+		assert.Error(t, err)
+
+		_, _, err = domain.NewAPIToken("not an automated email", "Automated", []domain.Scope{domain.ScopeRead})
+		assert.Error(t, err)
+
+		_, _, err = domain.NewAPIToken("user@automated.com", "Automated", nil)
+		assert.ErrorIs(t, err, domain.ErrInvalidScope)
+
+		_, _, err = domain.NewAPIToken("user@automated.com", "Automated", []domain.Scope{"write:everything"})
+		assert.ErrorIs(t, err, domain.ErrInvalidScope)
+	})
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	t.Parallel()
+
+	at, _, err := domain.NewAPIToken("user@limited.com", "Limited", []domain.Scope{
+		domain.ScopeRead,
+		domain.ScopeWriteComments,
+	})
+	require.NoError(t, err)
+
+	assert.True(t, at.HasScope(domain.ScopeRead))
+	assert.True(t, at.HasScope(domain.ScopeWriteComments))
+	assert.False(t, at.HasScope(domain.ScopeWriteArticles))
+}
+
+func TestIsAPIToken(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, domain.IsAPIToken("eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.e30.sig"))
+	assert.False(t, domain.IsAPIToken(""))
+}
@@ -15,14 +15,18 @@ func init() {
 
 // Article is an individual post in the application.
 type Article struct {
+	// ID never changes, unlike the slug which changes with the title.
+	ID           string `valid:"-"`
 	Slug         string `valid:"required,slug"`
 	Title        string `valid:"required"`
 	Description  string `valid:"required"`
 	Body         string `valid:"required"`
+	BodyHTML     string
 	TagList      []string
 	CreatedAtUTC time.Time
 	UpdatedAtUTC time.Time
 	AuthorEmail  string `valid:"required,email"`
+	Hidden       bool
 }
 
 // CommentedArticle is an individual post in the application with its comment information included.
@@ -61,14 +65,22 @@ func (u User) GetImage() string {
 
 // NewArticle creates a new Article with the provided information and defaults for the rest.
 func NewArticle(title string, description string, body string, authorEmail string, tags ...string) (*Article, error) {
-	return (&Article{
+	id, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Article{
+		ID:          id,
 		Slug:        slug.Make(title),
 		Title:       title,
 		Description: description,
 		Body:        body,
 		TagList:     tags,
 		AuthorEmail: authorEmail,
-	}).Validate()
+	}
+	a.RenderBody()
+	return a.Validate()
 }
 
 // Validate returns the provided Article if it is valid, otherwise error will contain validation errors.
@@ -86,6 +98,19 @@ func (a *Article) SetTitle(title string) {
 	a.Title = title
 }
 
+// RenderBody renders the Markdown body to sanitized HTML and caches it.
+func (a *Article) RenderBody() {
+	a.BodyHTML = RenderMarkdown(a.Body)
+}
+
+// HTML is the body as sanitized HTML, rendering it when it hasn't been cached yet.
+func (a *Article) HTML() string {
+	if a.BodyHTML == "" && a.Body != "" {
+		return RenderMarkdown(a.Body)
+	}
+	return a.BodyHTML
+}
+
 // AddComment creates a new comment and adds it to this Article.
 func (a *CommentedArticle) AddComment(body string, authorEmail string) error {
 	c, err := NewComment(body, authorEmail)
@@ -97,6 +122,36 @@ func (a *CommentedArticle) AddComment(body string, authorEmail string) error {
 	return nil
 }
 
+// HideComment hides (or unhides) the comment (if it exists by id) on this Article.
+func (a *CommentedArticle) HideComment(id int, hidden bool) {
+	for i := range a.Comments {
+		if a.Comments[i].ID == id {
+			a.Comments[i].Hidden = hidden
+			return
+		}
+	}
+}
+
+// FindComment finds the comment by id on this Article, returning nil if it doesn't exist.
+func (a *CommentedArticle) FindComment(id int) *Comment {
+	for i := range a.Comments {
+		if a.Comments[i].ID == id {
+			return &a.Comments[i]
+		}
+	}
+	return nil
+}
+
+// FindCommentByUID finds the comment by uid on this Article, returning nil if it doesn't exist.
+func (a *CommentedArticle) FindCommentByUID(uid string) *Comment {
+	for i := range a.Comments {
+		if a.Comments[i].UID == uid {
+			return &a.Comments[i]
+		}
+	}
+	return nil
+}
+
 // RemoveComment removes the comment (if it exists by id) from this Article.
 func (a *CommentedArticle) RemoveComment(id int) {
 	for i, c := range a.Comments {
@@ -27,6 +27,21 @@ func TestNewArticle(t *testing.T) {
 
 	})
 
+	t.Run("IDs don't depend on the title", func(t *testing.T) {
+		t.Parallel()
+
+		a1, err := domain.NewArticle("twin title", "twin description", "twin body", "author@twin.com")
+		require.NoError(t, err)
+		a2, err := domain.NewArticle("twin title", "twin description", "twin body", "author@twin.com")
+		require.NoError(t, err)
+		assert.Equal(t, a1.Slug, a2.Slug)
+		assert.NotEqual(t, a1.ID, a2.ID)
+
+		id := a1.ID
+		a1.SetTitle("renamed title")
+		assert.Equal(t, id, a1.ID)
+	})
+
 	t.Run("Validation happens", func(t *testing.T) {
 		t.Parallel()
 
@@ -176,6 +191,10 @@ func TestArticle_Comments(t *testing.T) {
 	err := ca.AddComment("mysterious title", "author@mysterious.com")
 	require.NoError(t, err)
 	assert.Len(t, ca.Comments, 5)
+	added := ca.Comments[4]
+	assert.NotEmpty(t, added.UID)
+	assert.Equal(t, &added, ca.FindCommentByUID(added.UID))
+	assert.Nil(t, ca.FindCommentByUID("mysterious uid"))
 
 	err = ca.AddComment("", "")
 	assert.Error(t, err)
@@ -186,3 +205,26 @@ func TestArticle_Comments(t *testing.T) {
 	ca.RemoveComment(8)
 	assert.Len(t, ca.Comments, 4)
 }
+
+func TestArticle_HideComment(t *testing.T) {
+	t.Parallel()
+
+	ca := domain.CommentedArticle{
+		Article: domain.Article{},
+		Comments: []domain.Comment{
+			{ID: 3},
+			{ID: 7},
+		},
+	}
+
+	ca.HideComment(7, true)
+	require.NotNil(t, ca.FindComment(7))
+	assert.True(t, ca.FindComment(7).Hidden)
+	assert.False(t, ca.FindComment(3).Hidden)
+
+	ca.HideComment(7, false)
+	assert.False(t, ca.FindComment(7).Hidden)
+
+	ca.HideComment(11, true)
+	assert.Nil(t, ca.FindComment(11))
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrBlobNotFound indicates the requested blob was not found.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// ErrInvalidBlobKey indicates a key has characters that aren't safe to use in paths and urls.
+var ErrInvalidBlobKey = errors.New("blob keys can only contain letters, numbers, dots, dashes and underscores")
+
+var blobKey = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// IsValidBlobKey checks if the key is safe to use in paths and urls.
+func IsValidBlobKey(key string) bool {
+	return len(key) <= 255 && blobKey.MatchString(key)
+}
+
+// BlobStore allows performing abstracted I/O operations on uploaded files.
+type BlobStore interface {
+	// PutBlob stores the data with its content type under the key, replacing anything already there.
+	PutBlob(context.Context, string, string, []byte) error
+	// GetBlob gets the data and its content type stored under the key.
+	GetBlob(context.Context, string) ([]byte, string, error)
+}
@@ -20,18 +20,30 @@ func init() {
 
 // Comment is an individual comment associated with a single Article.
 type Comment struct {
-	ID           int    `valid:"positive"`
+	ID int `valid:"positive"`
+	// UID is opaque and never changes, ID stays numeric because it's part of the comment routes.
+	UID          string `valid:"-"`
 	Body         string `valid:"required"`
+	BodyHTML     string
 	CreatedAtUTC time.Time
 	AuthorEmail  string `valid:"required,email"`
+	Hidden       bool
 }
 
 // NewComment creates a new comment with the provided information and defaults for the rest
 func NewComment(body string, author string) (*Comment, error) {
-	return (&Comment{
+	uid, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Comment{
+		UID:         uid,
 		Body:        body,
 		AuthorEmail: author,
-	}).Validate()
+	}
+	c.RenderBody()
+	return c.Validate()
 }
 
 // Validate returns the provided Article if it is valid, otherwise error will contain validation errors.
@@ -42,3 +54,16 @@ func (c *Comment) Validate() (*Comment, error) {
 
 	return c, nil
 }
+
+// RenderBody renders the Markdown body to sanitized HTML and caches it.
+func (c *Comment) RenderBody() {
+	c.BodyHTML = RenderMarkdown(c.Body)
+}
+
+// HTML is the body as sanitized HTML, rendering it when it hasn't been cached yet.
+func (c *Comment) HTML() string {
+	if c.BodyHTML == "" && c.Body != "" {
+		return RenderMarkdown(c.Body)
+	}
+	return c.BodyHTML
+}
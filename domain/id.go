@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewID creates a random (version 4) UUID.
+// IDs never change, unlike emails and slugs, so they're safe to refer to Users and Articles by.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ExternalIdentity is who a User is according to an external identity provider.
+type ExternalIdentity struct {
+	// Provider is the name of the identity provider.
+	Provider string
+	// Subject uniquely identifies the User within the provider, unlike the email it never changes.
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Username is only a suggestion for new Users.
+	Username string
+	Image    string
+}
+
+// IdentityProvider allows Users to login with an external service using the authorization code flow.
+type IdentityProvider interface {
+	// Name uniquely identifies the provider.
+	Name() string
+	// AuthCodeURL is where the User logs in with the provider,
+	// given the state and nonce to protect the login and the PKCE verifier for the code.
+	AuthCodeURL(string, string, string) string
+	// Exchange swaps the code from the provider and its PKCE verifier for the identity of the User,
+	// making sure the identity was issued with the nonce.
+	Exchange(context.Context, string, string, string) (*ExternalIdentity, error)
+}
+
+var notUsername = regexp.MustCompile(`[^\pL\pN_.-]+`)
+
+// NewUserFromIdentity creates a new partially-hydrated User for someone logging in with an external identity.
+// The User has a random unusable password, they can set one with a password reset.
+func NewUserFromIdentity(i *ExternalIdentity) (*User, error) {
+	if i.Email == "" {
+		return nil, errors.New("identity provider did not share an email address")
+	}
+
+	un := notUsername.ReplaceAllString(i.Username, "")
+	if un == "" || IsReservedUsername(un) {
+		un = notUsername.ReplaceAllString(strings.SplitN(i.Email, "@", 2)[0], "")
+	}
+	if IsReservedUsername(un) {
+		return nil, ErrReservedUsername
+	}
+
+	pw, err := unusablePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	return (&User{
+		ID:       id,
+		Email:    i.Email,
+		Username: un,
+		Image:    i.Image,
+		Password: pw,
+		Role:     RoleUser,
+		Verified: i.EmailVerified,
+	}).Validate()
+}
+
+// unusablePassword hashes a random secret nobody knows,
+// the User can only login another way (or after resetting their password).
+func unusablePassword() (PasswordHash, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return hashPassword(base64.RawURLEncoding.EncodeToString(secret))
+}
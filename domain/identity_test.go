@@ -0,0 +1,74 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUserFromIdentity(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Users are created from the identity", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := domain.NewUserFromIdentity(&domain.ExternalIdentity{
+			Provider:      "provider",
+			Subject:       "subject",
+			Email:         "user@outgoing.com",
+			EmailVerified: true,
+			Username:      "outgoing user!",
+			Image:         "http://outgoing.com/profile.png",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "user@outgoing.com", u.Email)
+		assert.Equal(t, "outgoinguser", u.Username)
+		assert.Equal(t, "http://outgoing.com/profile.png", u.Image)
+		assert.Equal(t, domain.RoleUser, u.Role)
+		assert.True(t, u.Verified)
+
+		hp, err := u.HasPassword("")
+		require.NoError(t, err)
+		assert.False(t, hp)
+	})
+
+	t.Run("Usernames default to the email", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := domain.NewUserFromIdentity(&domain.ExternalIdentity{
+			Email: "shy.user+conduit@bashful.com",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "shy.userconduit", u.Username)
+		assert.False(t, u.Verified)
+	})
+
+	t.Run("Reserved usernames fall back to the email", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := domain.NewUserFromIdentity(&domain.ExternalIdentity{
+			Email:    "user@pretentious.com",
+			Username: "root",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "user", u.Username)
+
+		_, err = domain.NewUserFromIdentity(&domain.ExternalIdentity{
+			Email:    "admin@pretentious.com",
+			Username: "root",
+		})
+		assert.ErrorIs(t, err, domain.ErrReservedUsername)
+	})
+
+	t.Run("Emails are required", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := domain.NewUserFromIdentity(&domain.ExternalIdentity{
+			Username: "secretive",
+		})
+		assert.Error(t, err)
+	})
+}
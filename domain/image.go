@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the gif decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrUnsupportedImage indicates an upload isn't an image in one of the supported formats.
+var ErrUnsupportedImage = errors.New("image must be a png, jpeg or gif")
+
+// ErrImageTooLarge indicates an upload is bigger than MaxImageBytes or MaxImagePixels.
+var ErrImageTooLarge = errors.New("image is too large")
+
+// MaxImageBytes is the largest upload accepted as an Image.
+const MaxImageBytes = 5 << 20
+
+// MaxImagePixels is the largest width or height accepted for an Image,
+// it keeps decoding (small but) maliciously crafted uploads from using huge amounts of memory.
+const MaxImagePixels = 8000
+
+// AvatarThumbnailSize is the width and height of the square thumbnails made for avatars.
+const AvatarThumbnailSize = 128
+
+// imageTypes are the supported content types and the extension for their keys.
+var imageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// Image is an uploaded picture that can be referenced by users and articles.
+type Image struct {
+	// Key uniquely identifies the image by its content.
+	Key         string
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+// NewImage validates the uploaded data is a supported image and creates an Image for it.
+// The content type is sniffed from the data rather than trusted from the client.
+func NewImage(data []byte) (*Image, error) {
+	if len(data) > MaxImageBytes {
+		return nil, ErrImageTooLarge
+	}
+
+	ct := http.DetectContentType(data)
+	ext, ok := imageTypes[ct]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if cfg.Width > MaxImagePixels || cfg.Height > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	sum := sha256.Sum256(data)
+	return &Image{
+		Key:         hex.EncodeToString(sum[:]) + ext,
+		ContentType: ct,
+		Data:        data,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
+
+// Thumbnail creates a square image of the given size from the center of this Image.
+// Thumbnails of gifs are only of the first frame and are encoded as pngs.
+func (i *Image) Thumbnail(size int) (*Image, error) {
+	src, _, err := image.Decode(bytes.NewReader(i.Data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+
+	// Crop the largest centered square so the thumbnail isn't stretched
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side).
+		Add(b.Min).
+		Add(image.Pt((b.Dx()-side)/2, (b.Dy()-side)/2))
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+
+	var buf bytes.Buffer
+	ct := i.ContentType
+	switch ct {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	default:
+		ct = "image/png"
+		err = png.Encode(&buf, dst)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := i.Key[:len(i.Key)-len(imageTypes[i.ContentType])]
+	return &Image{
+		Key:         fmt.Sprintf("%v-%vx%v%v", base, size, size, imageTypes[ct]),
+		ContentType: ct,
+		Data:        buf.Bytes(),
+		Width:       size,
+		Height:      size,
+	}, nil
+}
@@ -0,0 +1,129 @@
+package domain_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// striped makes an image with red, green and blue vertical stripes of equal width.
+func striped(w int, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		c := color.RGBA{0, 0, 0, 255}
+		switch x * 3 / w {
+		case 0:
+			c.R = 255
+		case 1:
+			c.G = 255
+		default:
+			c.B = 255
+		}
+		for y := 0; y < h; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encoded(t *testing.T, enc func(*bytes.Buffer, image.Image) error, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, enc(&buf, img))
+	return buf.Bytes()
+}
+
+func TestNewImage(t *testing.T) {
+	t.Parallel()
+
+	pngEnc := func(b *bytes.Buffer, i image.Image) error { return png.Encode(b, i) }
+	jpegEnc := func(b *bytes.Buffer, i image.Image) error { return jpeg.Encode(b, i, nil) }
+	gifEnc := func(b *bytes.Buffer, i image.Image) error { return gif.Encode(b, i, nil) }
+
+	tcs := []struct {
+		name string
+		enc  func(*bytes.Buffer, image.Image) error
+		ct   string
+		ext  string
+	}{
+		{"Pngs", pngEnc, "image/png", ".png"},
+		{"Jpegs", jpegEnc, "image/jpeg", ".jpg"},
+		{"Gifs", gifEnc, "image/gif", ".gif"},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name+" are supported", func(t *testing.T) {
+			t.Parallel()
+
+			data := encoded(t, tc.enc, striped(30, 20))
+			i, err := domain.NewImage(data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.ct, i.ContentType)
+			assert.True(t, strings.HasSuffix(i.Key, tc.ext))
+			assert.True(t, domain.IsValidBlobKey(i.Key))
+			assert.Equal(t, 30, i.Width)
+			assert.Equal(t, 20, i.Height)
+
+			again, err := domain.NewImage(data)
+			require.NoError(t, err)
+			assert.Equal(t, i.Key, again.Key)
+		})
+	}
+
+	t.Run("Other content is unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := domain.NewImage([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		assert.ErrorIs(t, err, domain.ErrUnsupportedImage)
+
+		// A png signature with nothing after it
+		_, err = domain.NewImage([]byte("\x89PNG\x0D\x0A\x1A\x0A"))
+		assert.ErrorIs(t, err, domain.ErrUnsupportedImage)
+	})
+
+	t.Run("Large images are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := domain.NewImage(make([]byte, domain.MaxImageBytes+1))
+		assert.ErrorIs(t, err, domain.ErrImageTooLarge)
+
+		// Mostly empty images compress really well
+		_, err = domain.NewImage(encoded(t, pngEnc,
+			image.NewGray(image.Rect(0, 0, domain.MaxImagePixels+1, 1))))
+		assert.ErrorIs(t, err, domain.ErrImageTooLarge)
+	})
+}
+
+func TestImage_Thumbnail(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, striped(300, 100)))
+	i, err := domain.NewImage(buf.Bytes())
+	require.NoError(t, err)
+
+	th, err := i.Thumbnail(domain.AvatarThumbnailSize)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", th.ContentType)
+	assert.Equal(t, strings.TrimSuffix(i.Key, ".png")+"-128x128.png", th.Key)
+	assert.True(t, domain.IsValidBlobKey(th.Key))
+
+	decoded, err := png.Decode(bytes.NewReader(th.Data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 128, 128), decoded.Bounds())
+
+	// The middle stripe is the center square of the original
+	r, g, b, _ := decoded.At(64, 64).RGBA()
+	assert.Zero(t, r)
+	assert.NotZero(t, g)
+	assert.Zero(t, b)
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Email is a plain-text message sent to a User.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// NewVerificationEmail creates the Email asking the User to verify their address with the token.
+func NewVerificationEmail(u *User, token string) *Email {
+	return &Email{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(`Hi %v,
+
+Please verify this is your email address by submitting the following token to POST /api/users/verify.
+
+%v
+
+The token expires in %v.
+`, u.Username, token, hours(PurposeVerifyEmail.TTL())),
+	}
+}
+
+// NewPasswordResetEmail creates the Email letting the User choose a new password with the token.
+func NewPasswordResetEmail(u *User, token string) *Email {
+	return &Email{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(`Hi %v,
+
+Someone asked to reset your password, you can choose a new one by submitting the following token to PUT /api/users/password-reset.
+
+%v
+
+The token expires in %v. If you didn't ask to reset your password you can ignore this email.
+`, u.Username, token, hours(PurposeResetPassword.TTL())),
+	}
+}
+
+// NewDeleteAccountEmail creates the Email letting the User confirm deleting their account with the token.
+func NewDeleteAccountEmail(u *User, token string) *Email {
+	return &Email{
+		To:      u.Email,
+		Subject: "Confirm deleting your account",
+		Body: fmt.Sprintf(`Hi %v,
+
+Someone asked to delete your account, you can confirm by submitting the following token to DELETE /api/user.
+
+%v
+
+The token expires in %v. If you didn't ask to delete your account you should contact an administrator.
+`, u.Username, token, hours(PurposeDeleteAccount.TTL())),
+	}
+}
+
+// NewEmailChangedEmail creates the Email letting the User know their email address was changed,
+// it's sent to the previous address in case someone else changed it.
+func NewEmailChangedEmail(u *User, previous string) *Email {
+	return &Email{
+		To:      previous,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf(`Hi %v,
+
+The email address of your account was changed to %v.
+
+If you didn't change it you should reset your password and contact an administrator.
+`, u.Username, u.Email),
+	}
+}
+
+// hours formats the duration for people to read.
+func hours(d time.Duration) string {
+	if h := int(d.Hours()); h != 1 {
+		return fmt.Sprintf("%v hours", h)
+	}
+	return "1 hour"
+}
+
+// Mailer allows sending Emails to users.
+type Mailer interface {
+	// SendMail sends the Email, it returns once the message has been accepted for delivery.
+	SendMail(context.Context, *Email) error
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// markdown renders CommonMark, raw HTML in the source is omitted by default.
+var markdown = goldmark.New()
+
+// sanitizer is the allowlist of HTML that's safe to show for user generated content.
+var sanitizer = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w-]+$`)).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+	return p
+}()
+
+// RenderMarkdown renders CommonMark source to sanitized HTML.
+func RenderMarkdown(src string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		// goldmark only fails when writing fails which a buffer doesn't
+		return sanitizer.Sanitize(src)
+	}
+	return sanitizer.Sanitize(buf.String())
+}
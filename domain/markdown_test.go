@@ -0,0 +1,67 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		name     string
+		markdown string
+		html     string
+	}{
+		{"Headings and emphasis",
+			"# Title\n\nSome *emphasis* and **strong** text",
+			"<h1>Title</h1>\n<p>Some <em>emphasis</em> and <strong>strong</strong> text</p>\n"},
+		{"Fenced code keeps its language",
+			"```go\nfmt.Println(\"hi\")\n```",
+			"<pre><code class=\"language-go\">fmt.Println(&#34;hi&#34;)\n</code></pre>\n"},
+		{"Links are nofollow",
+			"[site](https://example.com)",
+			"<p><a href=\"https://example.com\" rel=\"nofollow\">site</a></p>\n"},
+		{"Raw HTML is dropped",
+			"<script>alert(1)</script>\n\nok",
+			"\n<p>ok</p>\n"},
+		{"Script links are dropped",
+			"[click](javascript:alert(1))",
+			"<p>click</p>\n"},
+		{"Images are allowed",
+			"![img](https://example.com/a.png \"t\")",
+			"<p><img src=\"https://example.com/a.png\" alt=\"img\" title=\"t\"></p>\n"},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.html, domain.RenderMarkdown(tc.markdown))
+		})
+	}
+}
+
+func TestArticle_HTML(t *testing.T) {
+	t.Parallel()
+
+	a, err := domain.NewArticle("zesty title", "zesty description", "*zesty* body", "author@zesty.com")
+	require.NoError(t, err)
+	assert.Equal(t, "<p><em>zesty</em> body</p>\n", a.BodyHTML)
+
+	a.Body = "**zestier** body"
+	assert.Equal(t, "<p><em>zesty</em> body</p>\n", a.HTML())
+	a.RenderBody()
+	assert.Equal(t, "<p><strong>zestier</strong> body</p>\n", a.HTML())
+
+	a.BodyHTML = ""
+	assert.Equal(t, "<p><strong>zestier</strong> body</p>\n", a.HTML())
+
+	c, err := domain.NewComment("`zesty` comment", "user@zesty.com")
+	require.NoError(t, err)
+	assert.Equal(t, "<p><code>zesty</code> comment</p>\n", c.HTML())
+}
@@ -0,0 +1,327 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed" // Embeds the list of breached passwords
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownPasswordHash indicates a password hash wasn't created by any of the known hashers.
+var ErrUnknownPasswordHash = errors.New("password hash is not in a known format")
+
+// ErrPasswordTooShort indicates a password has fewer than MinPasswordLength characters.
+var ErrPasswordTooShort = fmt.Errorf("password must be at least %v characters", MinPasswordLength)
+
+// ErrPasswordTooLong indicates a password has more than MaxPasswordBytes bytes.
+var ErrPasswordTooLong = fmt.Errorf("password must be at most %v bytes", MaxPasswordBytes)
+
+// ErrPasswordBreached indicates a password is too common or has appeared in a data breach.
+var ErrPasswordBreached = errors.New("password is too common, it has appeared in a data breach")
+
+// ErrPasswordGuessable indicates a password contains the user's email or username.
+var ErrPasswordGuessable = errors.New("password can't contain your email or username")
+
+// MinPasswordLength is the fewest characters a new password can have.
+const MinPasswordLength = 8
+
+// MaxPasswordBytes is the most bytes a new password can have,
+// bcrypt ignores anything after 72 bytes so longer passwords aren't as strong as they look.
+const MaxPasswordBytes = 72
+
+// PasswordHasher creates and verifies password hashes with one algorithm and set of parameters.
+type PasswordHasher interface {
+	// Hash creates a PHC formatted hash of the password with a random salt.
+	Hash(string) (PasswordHash, error)
+	// Identifies checks if the hash was created by this algorithm (with any parameters).
+	Identifies(PasswordHash) bool
+	// Verify checks if the password matches a hash this hasher Identifies.
+	Verify(PasswordHash, string) (bool, error)
+	// NeedsRehash checks if the hash wasn't created with this algorithm and its current parameters.
+	NeedsRehash(PasswordHash) bool
+}
+
+// passwordHasher is used for all new password hashes.
+var passwordHasher PasswordHasher = NewArgon2idHasher(DefaultArgon2idParams)
+
+// DefaultBcryptCost is the cost of bcrypt hashes when bcrypt is configured instead of argon2id.
+const DefaultBcryptCost = 14
+
+// legacyHashers can still verify hashes created before the current hasher was configured,
+// argon2id reads its parameters from the hash so it verifies hashes made with any of them.
+var legacyHashers = []PasswordHasher{
+	NewArgon2idHasher(DefaultArgon2idParams),
+	NewBcryptHasher(DefaultBcryptCost),
+}
+
+// SetPasswordHasher changes the hasher used for new password hashes,
+// existing hashes from any of the built in algorithms can still be verified.
+// It isn't safe to call while passwords are being hashed so it should only be called at startup.
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasher = h
+}
+
+// passwordHashObserver is told how long each password hash and verification took.
+var passwordHashObserver = func(string, string, time.Duration) {}
+
+// ObservePasswordHashing sets a function that's told the algorithm ("argon2id" or "bcrypt"),
+// the operation ("hash" or "verify") and how long it took every time a password is hashed or verified.
+// Like SetPasswordHasher it should only be called at startup.
+func ObservePasswordHashing(f func(string, string, time.Duration)) {
+	passwordHashObserver = f
+}
+
+// hashPassword hashes the password with the current hasher.
+func hashPassword(password string) (PasswordHash, error) {
+	start := time.Now()
+	pw, err := passwordHasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHashObserver(passwordAlgorithm(pw), "hash", time.Since(start))
+	return pw, nil
+}
+
+// verifyPassword checks the password against a hash from any of the built in hashers.
+func verifyPassword(hash PasswordHash, password string) (bool, error) {
+	h, err := verifyingHasher(hash)
+	if err != nil {
+		return false, err
+	}
+
+	start := time.Now()
+	ok, err := h.Verify(hash, password)
+	passwordHashObserver(passwordAlgorithm(hash), "verify", time.Since(start))
+	return ok, err
+}
+
+// passwordAlgorithm names the algorithm of the hash for observing.
+func passwordAlgorithm(hash PasswordHash) string {
+	switch {
+	case new(Argon2idHasher).Identifies(hash):
+		return "argon2id"
+	case new(BcryptHasher).Identifies(hash):
+		return "bcrypt"
+	}
+	return "unknown"
+}
+
+// verifyingHasher finds the hasher that can verify the hash.
+func verifyingHasher(hash PasswordHash) (PasswordHasher, error) {
+	if passwordHasher.Identifies(hash) {
+		return passwordHasher, nil
+	}
+	for _, h := range legacyHashers {
+		if h.Identifies(hash) {
+			return h, nil
+		}
+	}
+	return nil, ErrUnknownPasswordHash
+}
+
+// CheckPasswordStrength checks if the password is acceptable as a new password for a user with the email and username.
+func CheckPasswordStrength(password string, email string, username string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+
+	lower := strings.ToLower(password)
+	for _, id := range []string{
+		strings.ToLower(email),
+		strings.ToLower(strings.SplitN(email, "@", 2)[0]),
+		strings.ToLower(username),
+	} {
+		if len(id) >= 3 && strings.Contains(lower, id) {
+			return ErrPasswordGuessable
+		}
+	}
+
+	if _, ok := breachedPasswords[lower]; ok {
+		return ErrPasswordBreached
+	}
+
+	return nil
+}
+
+//go:embed breachedpasswords.txt
+var breachedPasswordList []byte
+
+// breachedPasswords is the set of (lowercased) passwords that are too common to be used.
+var breachedPasswords = func() map[string]interface{} {
+	lines := bytes.Split(breachedPasswordList, []byte("\n"))
+	set := make(map[string]interface{}, len(lines))
+	for _, l := range lines {
+		if p := strings.ToLower(strings.TrimSpace(string(l))); p != "" {
+			set[p] = nil
+		}
+	}
+	return set
+}()
+
+// BcryptHasher hashes passwords with bcrypt in its standard $2a$ format.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a new BcryptHasher with the cost (between 4 and 31).
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost}
+}
+
+// Hash creates a bcrypt hash of the password with a random salt.
+func (h *BcryptHasher) Hash(password string) (PasswordHash, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), h.cost)
+}
+
+// Identifies checks if the hash is a bcrypt hash.
+func (h *BcryptHasher) Identifies(hash PasswordHash) bool {
+	return bytes.HasPrefix(hash, []byte("$2a$")) ||
+		bytes.HasPrefix(hash, []byte("$2b$")) ||
+		bytes.HasPrefix(hash, []byte("$2y$"))
+}
+
+// Verify checks if the password matches the bcrypt hash.
+func (h *BcryptHasher) Verify(hash PasswordHash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// NeedsRehash checks if the hash isn't a bcrypt hash with this cost.
+func (h *BcryptHasher) NeedsRehash(hash PasswordHash) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != h.cost
+}
+
+// Argon2idParams are the tuneable costs of an argon2id hash.
+type Argon2idParams struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams are the minimums recommended by OWASP.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// MaxArgon2idMemory is the most KiB of memory (1 GiB) an argon2id hash can use,
+// hashes asking for more are rejected so a stored hash can't force a huge allocation.
+const MaxArgon2idMemory = 1024 * 1024
+
+// Argon2idHasher hashes passwords with argon2id in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher with the params.
+func NewArgon2idHasher(p Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{p}
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// Hash creates an argon2id hash of the password with a random salt.
+func (h *Argon2idHasher) Hash(password string) (PasswordHash, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(password), salt,
+		h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return PasswordHash(fmt.Sprintf("%vv=%v$m=%v,t=%v,p=%v$%v$%v",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))), nil
+}
+
+// Identifies checks if the hash is an argon2id hash.
+func (h *Argon2idHasher) Identifies(hash PasswordHash) bool {
+	return bytes.HasPrefix(hash, []byte(argon2idPrefix))
+}
+
+// Verify checks if the password matches the argon2id hash, using the parameters in the hash.
+func (h *Argon2idHasher) Verify(hash PasswordHash, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt,
+		p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash checks if the hash isn't an argon2id hash with these params.
+func (h *Argon2idHasher) NeedsRehash(hash PasswordHash) bool {
+	p, _, _, err := decodeArgon2id(hash)
+	return err != nil || p != h.params
+}
+
+func decodeArgon2id(hash PasswordHash) (p Argon2idParams, salt []byte, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	// argon2 panics on zero iterations or threads and needs at least 8KiB of memory for each thread
+	if p.Iterations < 1 || p.Parallelism < 1 ||
+		p.Memory < 8*uint32(p.Parallelism) || p.Memory > MaxArgon2idMemory {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if len(salt) == 0 || len(key) == 0 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,178 @@
+package domain_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgon2idHasher(t *testing.T) {
+	t.Parallel()
+
+	cheap := domain.Argon2idParams{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+
+	t.Run("Hashes are PHC formatted", func(t *testing.T) {
+		t.Parallel()
+		h := domain.NewArgon2idHasher(cheap)
+
+		hash, err := h.Hash("Test1234!")
+		require.NoError(t, err)
+		assert.Regexp(t,
+			regexp.MustCompile(`^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`),
+			string(hash))
+		assert.True(t, h.Identifies(hash))
+
+		other, err := h.Hash("Test1234!")
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other, "salts are random")
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		t.Parallel()
+		h := domain.NewArgon2idHasher(cheap)
+
+		hash, err := h.Hash("Test1234!")
+		require.NoError(t, err)
+
+		ok, err := h.Verify(hash, "Test1234!")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = h.Verify(hash, "test1234!")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		_, err = h.Verify([]byte("$argon2id$v=19$m=1024$nope"), "Test1234!")
+		assert.ErrorIs(t, err, domain.ErrUnknownPasswordHash)
+	})
+
+	t.Run("Invalid params aren't verified", func(t *testing.T) {
+		t.Parallel()
+		h := domain.NewArgon2idHasher(cheap)
+
+		for _, hash := range []string{
+			"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$",
+			"$argon2id$v=19$m=64,t=1,p=1$$a2V5a2V5a2V5",
+			"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5",
+			"$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHQ$a2V5a2V5a2V5",
+			"$argon2id$v=19$m=4,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5",
+			"$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5",
+		} {
+			_, err := h.Verify([]byte(hash), "Test1234!")
+			assert.ErrorIs(t, err, domain.ErrUnknownPasswordHash, hash)
+			assert.True(t, h.NeedsRehash([]byte(hash)), hash)
+		}
+	})
+
+	t.Run("Changed params need rehashing", func(t *testing.T) {
+		t.Parallel()
+		h := domain.NewArgon2idHasher(cheap)
+
+		hash, err := h.Hash("Test1234!")
+		require.NoError(t, err)
+		assert.False(t, h.NeedsRehash(hash))
+
+		tuned := cheap
+		tuned.Iterations = 2
+		th := domain.NewArgon2idHasher(tuned)
+		assert.True(t, th.NeedsRehash(hash))
+
+		// Old hashes still verify with their own params
+		ok, err := th.Verify(hash, "Test1234!")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+}
+
+func TestBcryptHasher(t *testing.T) {
+	t.Parallel()
+
+	h := domain.NewBcryptHasher(4)
+	hash, err := h.Hash("Test1234!")
+	require.NoError(t, err)
+	assert.True(t, h.Identifies(hash))
+	assert.False(t, domain.NewArgon2idHasher(domain.DefaultArgon2idParams).Identifies(hash))
+
+	ok, err := h.Verify(hash, "Test1234!")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.False(t, h.NeedsRehash(hash))
+	assert.True(t, domain.NewBcryptHasher(5).NeedsRehash(hash))
+}
+
+func TestRehashPassword(t *testing.T) {
+	t.Parallel()
+
+	legacy, err := domain.NewBcryptHasher(4).Hash("Test1234!")
+	require.NoError(t, err)
+	u := &domain.User{
+		Email:    "user@antique.com",
+		Username: "antique user",
+		Password: legacy,
+	}
+
+	ok, err := u.HasPassword("Test1234!")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, u.NeedsRehash())
+
+	require.NoError(t, u.RehashPassword("Test1234!"))
+	assert.False(t, u.NeedsRehash())
+	assert.True(t, strings.HasPrefix(string(u.Password), "$argon2id$"))
+
+	ok, err = u.HasPassword("Test1234!")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCheckPasswordStrength(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		password string
+		err      error
+	}{
+		{"Strong", "correct horse battery staple", nil},
+		{"Short", "Ab1!", domain.ErrPasswordTooShort},
+		{"Short multibyte", "ünïcødé", domain.ErrPasswordTooShort},
+		{"Multibyte", "ünïcødé!", nil},
+		{"Long", strings.Repeat("a", domain.MaxPasswordBytes+1), domain.ErrPasswordTooLong},
+		{"Breached", "password123", domain.ErrPasswordBreached},
+		{"Breached any case", "PassWord123", domain.ErrPasswordBreached},
+		{"Username", "xxMarvelous99", domain.ErrPasswordGuessable},
+		{"Email", "Gleeful-User@gleeful.com!", domain.ErrPasswordGuessable},
+		{"Email local part", "gleeful-user!", domain.ErrPasswordGuessable},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			err := domain.CheckPasswordStrength(c.password, "gleeful-user@gleeful.com", "marvelous")
+			assert.Equal(t, c.err, err)
+		})
+	}
+
+	t.Run("New users are checked", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := domain.NewUserWithPassword("user@lazy.com", "lazy user", "password1")
+		assert.Equal(t, domain.ErrPasswordBreached, err)
+
+		u, err := domain.NewUserWithPassword("user@careful.com", "careful user", "Test1234!")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ErrPasswordTooShort, u.SetPassword("short"))
+	})
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrForbidden indicates the user is not allowed to perform the requested action.
+var ErrForbidden = errors.New("user is not allowed to perform this action")
+
+// Policy decides which users are allowed to perform which actions.
+type Policy interface {
+	// CanPublish checks if the user can create new articles and comments.
+	CanPublish(*User) bool
+	// CanEditArticle checks if the user can change the contents of the article.
+	CanEditArticle(*User, *Article) bool
+	// CanDeleteArticle checks if the user can delete the article.
+	CanDeleteArticle(*User, *Article) bool
+	// CanDeleteComment checks if the user can delete the comment.
+	CanDeleteComment(*User, *Comment) bool
+	// CanHideArticle checks if the user can hide (or unhide) the article from other users.
+	CanHideArticle(*User, *Article) bool
+	// CanHideComment checks if the user can hide (or unhide) the comment from other users.
+	CanHideComment(*User, *Comment) bool
+	// CanViewHidden checks if the user can see content that has been hidden.
+	CanViewHidden(*User) bool
+	// CanBanUser checks if the user can ban (or unban) the other user.
+	CanBanUser(*User, *User) bool
+	// CanChangeRole checks if the user can give the other user the provided role.
+	CanChangeRole(*User, *User, Role) bool
+}
+
+// NewRolePolicy creates a Policy which allows actions based on authorship and the Role of the user.
+func NewRolePolicy() Policy {
+	return rolePolicy{}
+}
+
+type rolePolicy struct{}
+
+func (rolePolicy) CanPublish(u *User) bool {
+	return u != nil && !u.Banned
+}
+
+func (p rolePolicy) CanEditArticle(u *User, a *Article) bool {
+	return p.CanPublish(u) && isSameEmail(u.Email, a.AuthorEmail)
+}
+
+func (p rolePolicy) CanDeleteArticle(u *User, a *Article) bool {
+	return p.CanPublish(u) &&
+		(isSameEmail(u.Email, a.AuthorEmail) || u.HasRole(RoleModerator))
+}
+
+func (p rolePolicy) CanDeleteComment(u *User, c *Comment) bool {
+	return p.CanPublish(u) &&
+		(isSameEmail(u.Email, c.AuthorEmail) || u.HasRole(RoleModerator))
+}
+
+func (p rolePolicy) CanHideArticle(u *User, _ *Article) bool {
+	return p.CanPublish(u) && u.HasRole(RoleModerator)
+}
+
+func (p rolePolicy) CanHideComment(u *User, _ *Comment) bool {
+	return p.CanPublish(u) && u.HasRole(RoleModerator)
+}
+
+func (p rolePolicy) CanViewHidden(u *User) bool {
+	return p.CanPublish(u) && u.HasRole(RoleModerator)
+}
+
+func (p rolePolicy) CanBanUser(u *User, o *User) bool {
+	// Users can only be banned by someone with more access than them
+	return p.CanPublish(u) && o != nil &&
+		u.HasRole(RoleModerator) &&
+		u.Role.rank() > o.Role.rank()
+}
+
+func (p rolePolicy) CanChangeRole(u *User, o *User, r Role) bool {
+	return p.CanPublish(u) && o != nil &&
+		u.HasRole(RoleAdmin) &&
+		r.IsValid() &&
+		!isSameEmail(u.Email, o.Email)
+}
+
+func isSameEmail(a string, b string) bool {
+	return a != "" && strings.ToLower(a) == strings.ToLower(b)
+}
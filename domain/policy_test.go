@@ -0,0 +1,90 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePolicy(t *testing.T) {
+	t.Parallel()
+
+	p := domain.NewRolePolicy()
+
+	author := &domain.User{Email: "author@jittery.com", Role: domain.RoleUser}
+	user := &domain.User{Email: "user@jittery.com", Role: domain.RoleUser}
+	banned := &domain.User{Email: "banned@jittery.com", Role: domain.RoleUser, Banned: true}
+	moderator := &domain.User{Email: "moderator@jittery.com", Role: domain.RoleModerator}
+	admin := &domain.User{Email: "admin@jittery.com", Role: domain.RoleAdmin}
+	legacy := &domain.User{Email: "legacy@jittery.com"}
+
+	a := &domain.Article{AuthorEmail: "AUTHOR@jittery.com"}
+	c := &domain.Comment{AuthorEmail: "author@jittery.com"}
+
+	t.Run("Publishing", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, p.CanPublish(user))
+		assert.True(t, p.CanPublish(legacy))
+		assert.False(t, p.CanPublish(banned))
+		assert.False(t, p.CanPublish(nil))
+	})
+
+	t.Run("Articles", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, p.CanEditArticle(author, a))
+		assert.False(t, p.CanEditArticle(user, a))
+		assert.False(t, p.CanEditArticle(moderator, a),
+			"because only the author can change what they wrote")
+
+		assert.True(t, p.CanDeleteArticle(author, a))
+		assert.False(t, p.CanDeleteArticle(user, a))
+		assert.True(t, p.CanDeleteArticle(moderator, a))
+		assert.True(t, p.CanDeleteArticle(admin, a))
+
+		assert.False(t, p.CanHideArticle(author, a))
+		assert.True(t, p.CanHideArticle(moderator, a))
+		assert.True(t, p.CanHideArticle(admin, a))
+
+		assert.False(t, p.CanViewHidden(user))
+		assert.True(t, p.CanViewHidden(moderator))
+
+		author.Banned = true
+		defer func() { author.Banned = false }()
+		assert.False(t, p.CanEditArticle(author, a),
+			"because banned users can't change anything")
+	})
+
+	t.Run("Comments", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, p.CanDeleteComment(&domain.User{Email: "author@jittery.com"}, c))
+		assert.False(t, p.CanDeleteComment(user, c))
+		assert.True(t, p.CanDeleteComment(moderator, c))
+		assert.True(t, p.CanDeleteComment(admin, c))
+
+		assert.False(t, p.CanHideComment(author, c))
+		assert.True(t, p.CanHideComment(moderator, c))
+	})
+
+	t.Run("Users", func(t *testing.T) {
+		t.Parallel()
+
+		assert.False(t, p.CanBanUser(user, legacy))
+		assert.True(t, p.CanBanUser(moderator, user))
+		assert.True(t, p.CanBanUser(moderator, legacy))
+		assert.False(t, p.CanBanUser(moderator, admin))
+		assert.False(t, p.CanBanUser(moderator, moderator))
+		assert.True(t, p.CanBanUser(admin, moderator))
+		assert.False(t, p.CanBanUser(admin, admin))
+
+		assert.False(t, p.CanChangeRole(moderator, user, domain.RoleModerator))
+		assert.True(t, p.CanChangeRole(admin, user, domain.RoleModerator))
+		assert.False(t, p.CanChangeRole(admin, user, domain.Role("superuser")))
+		assert.False(t, p.CanChangeRole(admin, admin, domain.RoleUser),
+			"because admins can't lock themselves out")
+	})
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/gosimple/slug"
+)
+
+// ErrInvalidOrder indicates a reordering of a reading list doesn't contain exactly the articles already in it.
+var ErrInvalidOrder = errors.New("reading list order must contain each of its articles exactly once")
+
+// ReadingList is a private, named and ordered collection of articles saved by a user to read later.
+type ReadingList struct {
+	OwnerEmail   string `valid:"required,email"`
+	Slug         string `valid:"required,slug"`
+	Name         string `valid:"required"`
+	ArticleSlugs []string
+	CreatedAtUTC time.Time
+	UpdatedAtUTC time.Time
+}
+
+// NewReadingList creates a new empty ReadingList with the provided information.
+func NewReadingList(ownerEmail string, name string) (*ReadingList, error) {
+	return (&ReadingList{
+		OwnerEmail:   ownerEmail,
+		Slug:         slug.Make(name),
+		Name:         name,
+		ArticleSlugs: make([]string, 0),
+	}).Validate()
+}
+
+// Validate returns the provided ReadingList if it is valid, otherwise error will contain validation errors.
+func (l *ReadingList) Validate() (*ReadingList, error) {
+	if v, err := govalidator.ValidateStruct(l); !v {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// SetName sets the name and slugifies it too.
+func (l *ReadingList) SetName(name string) {
+	l.Slug = slug.Make(name)
+	l.Name = name
+}
+
+// Contains checks if the article with the given slug is in the reading list.
+func (l *ReadingList) Contains(s string) bool {
+	return l.indexOf(s) >= 0
+}
+
+// Add puts the article with the given slug at the end of the reading list if it isn't already in it.
+func (l *ReadingList) Add(s string) {
+	if !l.Contains(s) {
+		l.ArticleSlugs = append(l.ArticleSlugs, strings.ToLower(s))
+	}
+}
+
+// Remove takes the article with the given slug out of the reading list.
+func (l *ReadingList) Remove(s string) {
+	if i := l.indexOf(s); i >= 0 {
+		l.ArticleSlugs = append(l.ArticleSlugs[:i], l.ArticleSlugs[i+1:]...)
+	}
+}
+
+// Reorder puts the articles in the reading list into the given order.
+// The order must contain every article in the list exactly once.
+func (l *ReadingList) Reorder(slugs []string) error {
+	if len(slugs) != len(l.ArticleSlugs) {
+		return ErrInvalidOrder
+	}
+
+	seen := make(map[string]interface{}, len(slugs))
+	ordered := make([]string, 0, len(slugs))
+	for _, s := range slugs {
+		s = strings.ToLower(s)
+		if _, ok := seen[s]; ok || !l.Contains(s) {
+			return ErrInvalidOrder
+		}
+		seen[s] = nil
+		ordered = append(ordered, s)
+	}
+
+	l.ArticleSlugs = ordered
+	return nil
+}
+
+func (l *ReadingList) indexOf(s string) int {
+	for i, as := range l.ArticleSlugs {
+		if strings.EqualFold(as, s) {
+			return i
+		}
+	}
+	return -1
+}
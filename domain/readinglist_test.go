@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReadingList(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reading lists start empty", func(t *testing.T) {
+		t.Parallel()
+
+		l, err := domain.NewReadingList("user@idle.com", "Idle Reads")
+		require.NoError(t, err)
+		assert.Equal(t, "idle-reads", l.Slug)
+		assert.Equal(t, "Idle Reads", l.Name)
+		assert.Empty(t, l.ArticleSlugs)
+	})
+
+	t.Run("Validation happens", func(t *testing.T) {
+		t.Parallel()
+
+		l, err := domain.NewReadingList("user@lazy.com", "")
+		assert.Error(t, err)
+		assert.Nil(t, l)
+
+		l, err = domain.NewReadingList("not a lazy email", "Lazy Reads")
+		assert.Error(t, err)
+		assert.Nil(t, l)
+	})
+}
+
+func TestReadingList_Articles(t *testing.T) {
+	t.Parallel()
+
+	l, err := domain.NewReadingList("user@avid.com", "Avid Reads")
+	require.NoError(t, err)
+
+	l.Add("avid-one")
+	l.Add("Avid-Two")
+	l.Add("avid-three")
+	l.Add("avid-one")
+	assert.Equal(t, []string{"avid-one", "avid-two", "avid-three"}, l.ArticleSlugs)
+	assert.True(t, l.Contains("AVID-TWO"))
+
+	l.Remove("avid-two")
+	l.Remove("avid-four")
+	assert.Equal(t, []string{"avid-one", "avid-three"}, l.ArticleSlugs)
+	assert.False(t, l.Contains("avid-two"))
+
+	require.NoError(t, l.Reorder([]string{"avid-three", "avid-one"}))
+	assert.Equal(t, []string{"avid-three", "avid-one"}, l.ArticleSlugs)
+
+	assert.ErrorIs(t, l.Reorder([]string{"avid-three"}), domain.ErrInvalidOrder)
+	assert.ErrorIs(t, l.Reorder([]string{"avid-three", "avid-three"}), domain.ErrInvalidOrder)
+	assert.ErrorIs(t, l.Reorder([]string{"avid-three", "avid-two"}), domain.ErrInvalidOrder)
+	assert.Equal(t, []string{"avid-three", "avid-one"}, l.ArticleSlugs)
+
+	l.SetName("Avid Rereads")
+	assert.Equal(t, "avid-rereads", l.Slug)
+}
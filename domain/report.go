@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// ErrReportResolved indicates the report has already been resolved by a moderator.
+var ErrReportResolved = errors.New("report has already been resolved")
+
+// ErrInvalidAction indicates the moderation action can't be used for the requested purpose.
+var ErrInvalidAction = errors.New("moderation action is not valid")
+
+// ReportStatus is where a Report is in the moderation workflow.
+type ReportStatus string
+
+const (
+	// ReportOpen reports are waiting in the moderation queue.
+	ReportOpen ReportStatus = "open"
+	// ReportDismissed reports were reviewed and no action was taken.
+	ReportDismissed ReportStatus = "dismissed"
+	// ReportActioned reports were reviewed and the content or its author was moderated.
+	ReportActioned ReportStatus = "actioned"
+)
+
+// ModerationAction is something a moderator does to content or users.
+type ModerationAction string
+
+const (
+	// ActionDismiss closes a report without changing anything.
+	ActionDismiss ModerationAction = "dismiss"
+	// ActionHide hides the content from other users.
+	ActionHide ModerationAction = "hide"
+	// ActionUnhide makes hidden content visible again.
+	ActionUnhide ModerationAction = "unhide"
+	// ActionDelete deletes the content.
+	ActionDelete ModerationAction = "delete"
+	// ActionSuspend bans the user (or the author of the content).
+	ActionSuspend ModerationAction = "suspend"
+	// ActionUnsuspend lifts a ban on the user.
+	ActionUnsuspend ModerationAction = "unsuspend"
+	// ActionChangeRole changes the role of the user.
+	ActionChangeRole ModerationAction = "role"
+)
+
+// IsReportResolution checks if the action can be used to resolve a report.
+func (a ModerationAction) IsReportResolution() bool {
+	return a == ActionDismiss || a == ActionHide || a == ActionDelete || a == ActionSuspend
+}
+
+// Report is a user flagging an article (or one of its comments) as abusive.
+type Report struct {
+	ID            int    `valid:"positive"`
+	ReporterEmail string `valid:"required,email"`
+	ArticleSlug   string `valid:"required"`
+	CommentID     int    `valid:"positive"`
+	Reason        string `valid:"required"`
+	Status        ReportStatus
+	CreatedAtUTC  time.Time
+	ResolverEmail string `valid:"email,optional"`
+	Resolution    ModerationAction
+	ResolvedAtUTC time.Time
+}
+
+// NewReport creates a new open report with the provided information.
+// A zero comment id reports the article itself.
+func NewReport(reporterEmail string, slug string, commentID int, reason string) (*Report, error) {
+	return (&Report{
+		ReporterEmail: reporterEmail,
+		ArticleSlug:   slug,
+		CommentID:     commentID,
+		Reason:        strings.TrimSpace(reason),
+		Status:        ReportOpen,
+	}).Validate()
+}
+
+// Validate returns the provided Report if it is valid, otherwise error will contain validation errors.
+func (r *Report) Validate() (*Report, error) {
+	if v, err := govalidator.ValidateStruct(r); !v {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// IsComment checks if the report is about a comment rather than the article.
+func (r *Report) IsComment() bool {
+	return r.CommentID > 0
+}
+
+// Resolve closes the report using the provided action.
+func (r *Report) Resolve(moderatorEmail string, action ModerationAction) error {
+	if r.Status != ReportOpen {
+		return ErrReportResolved
+	}
+	if !action.IsReportResolution() {
+		return ErrInvalidAction
+	}
+
+	r.Status = ReportActioned
+	if action == ActionDismiss {
+		r.Status = ReportDismissed
+	}
+	r.ResolverEmail = moderatorEmail
+	r.Resolution = action
+	r.ResolvedAtUTC = time.Now().UTC()
+
+	return nil
+}
+
+// Reopen puts a resolved report back in the moderation queue.
+func (r *Report) Reopen() {
+	r.Status = ReportOpen
+	r.ResolverEmail = ""
+	r.Resolution = ""
+	r.ResolvedAtUTC = time.Time{}
+}
+
+// AuditEntry is the record of a single action taken by a moderator.
+type AuditEntry struct {
+	ID           int              `valid:"positive"`
+	ActorEmail   string           `valid:"required,email"`
+	Action       ModerationAction `valid:"required"`
+	ArticleSlug  string
+	CommentID    int `valid:"positive"`
+	UserEmail    string
+	ReportID     int `valid:"positive"`
+	Note         string
+	CreatedAtUTC time.Time
+}
+
+// NewAuditEntry creates a new audit entry for the action.
+// The targets (article, comment, user, report) are filled in by the caller.
+func NewAuditEntry(actorEmail string, action ModerationAction) *AuditEntry {
+	return &AuditEntry{
+		ActorEmail: actorEmail,
+		Action:     action,
+	}
+}
+
+// Validate returns the provided AuditEntry if it is valid, otherwise error will contain validation errors.
+func (e *AuditEntry) Validate() (*AuditEntry, error) {
+	if v, err := govalidator.ValidateStruct(e); !v {
+		return nil, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reports start open", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := domain.NewReport("user@woozy.com", "woozy-title", 0, " woozy reason ")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ReportOpen, r.Status)
+		assert.Equal(t, "woozy reason", r.Reason)
+		assert.False(t, r.IsComment())
+	})
+
+	t.Run("Validation happens", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := domain.NewReport("user@abrupt.com", "abrupt-title", 5, "   ")
+		assert.Error(t, err)
+		assert.Nil(t, r)
+
+		r, err = domain.NewReport("not an abrupt email", "abrupt-title", 5, "abrupt reason")
+		assert.Error(t, err)
+		assert.Nil(t, r)
+
+		r, err = domain.NewReport("user@abrupt.com", "abrupt-title", -5, "abrupt reason")
+		assert.Error(t, err)
+		assert.Nil(t, r)
+	})
+}
+
+func TestReport_Resolve(t *testing.T) {
+	t.Parallel()
+
+	r, err := domain.NewReport("user@wary.com", "wary-title", 8, "wary reason")
+	require.NoError(t, err)
+	assert.True(t, r.IsComment())
+
+	assert.ErrorIs(t, r.Resolve("moderator@wary.com", domain.ActionUnhide), domain.ErrInvalidAction)
+	assert.Equal(t, domain.ReportOpen, r.Status)
+
+	require.NoError(t, r.Resolve("moderator@wary.com", domain.ActionHide))
+	assert.Equal(t, domain.ReportActioned, r.Status)
+	assert.Equal(t, domain.ActionHide, r.Resolution)
+	assert.Equal(t, "moderator@wary.com", r.ResolverEmail)
+	assert.False(t, r.ResolvedAtUTC.IsZero())
+
+	assert.ErrorIs(t, r.Resolve("moderator@wary.com", domain.ActionDismiss), domain.ErrReportResolved)
+
+	r.Reopen()
+	assert.Equal(t, domain.ReportOpen, r.Status)
+	assert.Empty(t, r.ResolverEmail)
+	assert.True(t, r.ResolvedAtUTC.IsZero())
+	require.NoError(t, r.Resolve("moderator@wary.com", domain.ActionDismiss))
+
+	d, err := domain.NewReport("user@wary.com", "wary-title", 0, "wary reason")
+	require.NoError(t, err)
+	require.NoError(t, d.Resolve("moderator@wary.com", domain.ActionDismiss))
+	assert.Equal(t, domain.ReportDismissed, d.Status)
+}
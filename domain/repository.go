@@ -8,9 +8,13 @@ import (
 // ErrUserNotFound indicates the requested user was not found.
 var ErrUserNotFound = errors.New("user not found")
 
-// ErrDuplicateUser indicates the requested user could not be created because they already exist.
+// ErrDuplicateUser indicates the requested user could not be created because they already exist
+// (or someone else used to have their username).
 var ErrDuplicateUser = errors.New("user has a duplicate username or email address")
 
+// ErrDuplicateIdentity indicates an external identity could not be linked because it's linked to another user.
+var ErrDuplicateIdentity = errors.New("identity is already linked to another user")
+
 // ErrNoAuthor indicates when the author of an Article can't be found.
 var ErrNoAuthor = errors.New("author not found")
 
@@ -20,13 +24,58 @@ var ErrArticleNotFound = errors.New("article not found")
 // ErrDuplicateArticle indicates the requested article could not be created because another article has the same slug.
 var ErrDuplicateArticle = errors.New("article has a duplicate slug")
 
+// ErrCommentNotFound indicates the requested comment was not found on the article.
+var ErrCommentNotFound = errors.New("comment not found")
+
+// ErrReportNotFound indicates the requested report was not found.
+var ErrReportNotFound = errors.New("report not found")
+
+// ErrReadingListNotFound indicates the requested reading list was not found.
+var ErrReadingListNotFound = errors.New("reading list not found")
+
+// ErrDuplicateReadingList indicates the requested reading list could not be created because the owner has another list with the same slug.
+var ErrDuplicateReadingList = errors.New("reading list has a duplicate slug")
+
+// ErrAPITokenNotFound indicates the requested API token was not found (or has been revoked).
+var ErrAPITokenNotFound = errors.New("api token not found")
+
+// ErrDuplicateAPIToken indicates the requested API token could not be created because the owner has another token with the same name.
+var ErrDuplicateAPIToken = errors.New("api token has a duplicate name")
+
+// ErrMigrationsPending indicates the repository's schema is behind and has to be migrated before it can be used.
+var ErrMigrationsPending = errors.New("schema has migrations that have not been applied")
+
+// IsNotFound checks if the error is from the repository not finding what was asked for.
+func IsNotFound(err error) bool {
+	for _, nf := range []error{
+		ErrUserNotFound,
+		ErrArticleNotFound,
+		ErrCommentNotFound,
+		ErrReportNotFound,
+		ErrReadingListNotFound,
+		ErrAPITokenNotFound,
+	} {
+		if errors.Is(err, nf) {
+			return true
+		}
+	}
+	return false
+}
+
 // ListCriteria is the set of optional parameters to page/filter the Articles.
 type ListCriteria struct {
 	Tag                  string
 	AuthorEmails         []string
 	FavoritedByUserEmail string
-	Limit                int
-	Offset               int
+	// Limit is the most articles listed, none are listed when it isn't positive.
+	Limit int
+	// Offset is how many articles are skipped, a negative offset skips none.
+	Offset int
+	// ViewerEmail is the user doing the listing,
+	// articles by authors they have blocked or muted are excluded.
+	ViewerEmail string
+	// WithHidden includes hidden articles, it's for operators (like exporting everything) and never set for users.
+	WithHidden bool
 }
 
 // Repository allows performing abstracted I/O operations on users.
@@ -37,31 +86,106 @@ type Repository interface {
 	GetUserByEmail(context.Context, string) (*Fanboy, error)
 	// GetAuthorByEmail finds a single author based on their email address or nil if they don't exist.
 	GetAuthorByEmail(context.Context, string) Author
+	// GetUserByID finds a single user based on their id.
+	GetUserByID(context.Context, string) (*User, error)
 	// GetUserByUsername finds a single user based on their username.
 	GetUserByUsername(context.Context, string) (*User, error)
+	// GetUserByPreviousUsername finds a single user based on a username they used to have.
+	GetUserByPreviousUsername(context.Context, string) (*User, error)
 	// UpdateUserByEmail finds a single user based on their email address,
 	// then applies the provide mutations.
+	// Usernames they stop using stay reserved for them.
 	UpdateUserByEmail(context.Context, string, func(*User) (*User, error)) (*User, error)
 	// UpdateFanboyByEmail finds a single user based on their email address,
 	// then applies the provide mutations (probably to the follower list).
 	UpdateFanboyByEmail(context.Context, string, func(*Fanboy) (*Fanboy, error)) error
+	// ListUsers lists every user, ordered by username.
+	ListUsers(context.Context, int, int) ([]User, error)
+	// FollowersByEmail lists the users following the user with the given email, ordered by username.
+	FollowersByEmail(context.Context, string, int, int) ([]User, error)
+	// FollowingByEmail lists the users followed by the user with the given email, ordered by username.
+	FollowingByEmail(context.Context, string, int, int) ([]User, error)
+	// GetFollowCountsByEmail counts the followers and followed users of the user with the given email.
+	GetFollowCountsByEmail(context.Context, string) (*FollowCounts, error)
+	// DeleteUser deletes the user with the given email,
+	// their articles and comments are either anonymized or deleted along with them.
+	DeleteUser(context.Context, string, DeletionMode) error
+	// ExportUserByEmail gathers everything kept about the user with the given email.
+	ExportUserByEmail(context.Context, string) (*UserExport, error)
+	// GetUserByIdentity finds a single user based on the provider and subject of an external identity linked to them.
+	GetUserByIdentity(context.Context, string, string) (*User, error)
+	// LinkIdentity links the external identity to the user with the given email so they can login with it.
+	LinkIdentity(context.Context, string, *ExternalIdentity) error
+
+	// CreateAPIToken creates a new personal API token.
+	CreateAPIToken(context.Context, *APIToken) (*APIToken, error)
+	// APITokensByOwner lists all the API tokens of the user with the given email, ordered by name.
+	APITokensByOwner(context.Context, string) ([]APIToken, error)
+	// GetAPITokenByHash gets a single API token with the given hash.
+	GetAPITokenByHash(context.Context, string) (*APIToken, error)
+	// DeleteAPIToken revokes the API token with the given owner email and id.
+	DeleteAPIToken(context.Context, string, int) error
 
 	// CreateArticle creates a new article.
 	CreateArticle(context.Context, *Article) (*AuthoredArticle, error)
+	// ImportArticle creates an article along with its comments (including the hidden ones)
+	// keeping when they were created and updated, it's for moving everything between repositories.
+	// The comments are given new ids in the order they're listed.
+	ImportArticle(context.Context, *CommentedArticle) (*CommentedArticle, error)
 	// LatestArticlesByCriteria lists articles paged/filtered by the given criteria.
+	// Hidden articles are only included when the criteria asks for them.
 	LatestArticlesByCriteria(context.Context, ListCriteria) ([]AuthoredArticle, error)
 	// GetArticleBySlug gets a single article with the given slug.
 	GetArticleBySlug(context.Context, string) (*AuthoredArticle, error)
+	// GetArticleByID gets a single article with the given id.
+	GetArticleByID(context.Context, string) (*AuthoredArticle, error)
 	// GetCommentsBySlug gets a single article and its comments with the given slug.
+	// Hidden comments are not included.
 	GetCommentsBySlug(context.Context, string) (*CommentedArticle, error)
+	// GetCommentsWithHiddenBySlug gets a single article and all of its comments (including the hidden ones) with the given slug,
+	// it's for operators (like exporting everything) and never used for users.
+	GetCommentsWithHiddenBySlug(context.Context, string) (*CommentedArticle, error)
 	// UpdateArticleBySlug finds a single article based on its slug
 	// then applies the provide mutations.
 	UpdateArticleBySlug(context.Context, string, func(*Article) (*Article, error)) (*AuthoredArticle, error)
 	// UpdateCommentsBySlug finds a single article based on its slug
-	// then applies the provide mutations to its comments.
+	// then applies the provide mutations to its comments (including the hidden ones).
 	UpdateCommentsBySlug(context.Context, string, func(*CommentedArticle) (*CommentedArticle, error)) (*Comment, error)
 	// DeleteArticle deletes the article if it exists.
 	DeleteArticle(context.Context, *Article) error
 	// DistinctTags returns a distinct list of tags on all articles
 	DistinctTags(context.Context) ([]string, error)
+
+	// CreateReadingList creates a new reading list.
+	CreateReadingList(context.Context, *ReadingList) (*ReadingList, error)
+	// ReadingListsByOwner lists all the reading lists of the user with the given email, ordered by name.
+	ReadingListsByOwner(context.Context, string) ([]ReadingList, error)
+	// GetReadingList gets a single reading list with the given owner email and slug.
+	GetReadingList(context.Context, string, string) (*ReadingList, error)
+	// ReadingListArticles gets the articles in the reading list with the given owner email and slug
+	// in the order of the list. Hidden articles are not included.
+	ReadingListArticles(context.Context, string, string) ([]AuthoredArticle, error)
+	// UpdateReadingList finds a single reading list based on its owner email and slug,
+	// then applies the provide mutations.
+	UpdateReadingList(context.Context, string, string, func(*ReadingList) (*ReadingList, error)) (*ReadingList, error)
+	// DeleteReadingList deletes the reading list if it exists.
+	DeleteReadingList(context.Context, *ReadingList) error
+
+	// CreateReport creates a new report.
+	CreateReport(context.Context, *Report) (*Report, error)
+	// GetReportByID gets a single report with the given id.
+	GetReportByID(context.Context, int) (*Report, error)
+	// ReportsByStatus lists the oldest reports with the given status first.
+	ReportsByStatus(context.Context, ReportStatus, int, int) ([]Report, error)
+	// UpdateReportByID finds a single report based on its id,
+	// then applies the provide mutations.
+	UpdateReportByID(context.Context, int, func(*Report) (*Report, error)) (*Report, error)
+	// CreateAuditEntry records a new entry in the moderation audit trail.
+	CreateAuditEntry(context.Context, *AuditEntry) (*AuditEntry, error)
+	// LatestAuditEntries lists the most recent entries in the moderation audit trail.
+	LatestAuditEntries(context.Context, int, int) ([]AuditEntry, error)
+
+	// Ping checks the repository can be reached and is ready to be used,
+	// ErrMigrationsPending is returned when its schema is behind.
+	Ping(context.Context) error
 }
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken indicates a single-use token has expired, has already been used or was never issued.
+var ErrInvalidToken = errors.New("token is invalid or has expired")
+
+// TokenPurpose is the single thing a token emailed to a User allows them to do.
+type TokenPurpose string
+
+const (
+	// PurposeVerifyEmail tokens prove the User owns their email address.
+	PurposeVerifyEmail TokenPurpose = "verify-email"
+	// PurposeResetPassword tokens let the User choose a new password without knowing the old one.
+	PurposeResetPassword TokenPurpose = "reset-password"
+	// PurposeSecondFactor tokens are issued instead of a login for Users with two-factor authentication,
+	// they let the User finish logging in with a two-factor code.
+	PurposeSecondFactor TokenPurpose = "second-factor"
+	// PurposeDeleteAccount tokens confirm the User wants to delete their account,
+	// they're for Users who can't confirm with a password because they login with an identity provider.
+	PurposeDeleteAccount TokenPurpose = "delete-account"
+)
+
+// IsValid checks if the purpose is one of the known purposes.
+func (p TokenPurpose) IsValid() bool {
+	return p == PurposeVerifyEmail ||
+		p == PurposeResetPassword ||
+		p == PurposeSecondFactor ||
+		p == PurposeDeleteAccount
+}
+
+// TTL is how long a token for the purpose can be used after it is issued.
+func (p TokenPurpose) TTL() time.Duration {
+	switch p {
+	case PurposeResetPassword, PurposeDeleteAccount:
+		return time.Hour
+	case PurposeSecondFactor:
+		return 5 * time.Minute
+	}
+	return 48 * time.Hour
+}
+
+// TokenFingerprint summarizes the parts of the User that using a token for the purpose changes.
+// Tokens carry the fingerprint of when they were issued so once one is used
+// (or the User changes in a way that makes it moot) the fingerprint won't match and the token can't be used again.
+func (u *User) TokenFingerprint(p TokenPurpose) string {
+	h := sha256.New()
+	h.Write([]byte(string(p) + "\x00" + strings.ToLower(u.Email) + "\x00"))
+	switch p {
+	case PurposeVerifyEmail:
+		h.Write([]byte(strconv.FormatBool(u.Verified)))
+	case PurposeResetPassword, PurposeDeleteAccount:
+		h.Write(u.Password)
+	case PurposeSecondFactor:
+		h.Write(u.Password)
+		h.Write([]byte("\x00" + u.TwoFactor.Secret + "\x00" + strconv.FormatInt(u.TwoFactor.LastCounter, 10)))
+		h.Write([]byte("\x00" + strings.Join(u.TwoFactor.RecoveryCodes, ",")))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
@@ -0,0 +1,80 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenFingerprint(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Fingerprints are per purpose", func(t *testing.T) {
+		t.Parallel()
+
+		u := &domain.User{Email: "user@fickle.com", Password: []byte("hash")}
+		assert.NotEqual(t,
+			u.TokenFingerprint(domain.PurposeVerifyEmail),
+			u.TokenFingerprint(domain.PurposeResetPassword))
+		assert.Equal(t,
+			u.TokenFingerprint(domain.PurposeVerifyEmail),
+			(&domain.User{Email: "USER@fickle.com", Password: []byte("hash")}).TokenFingerprint(domain.PurposeVerifyEmail))
+	})
+
+	t.Run("Verifying uses the token", func(t *testing.T) {
+		t.Parallel()
+
+		u := &domain.User{Email: "user@wary.com", Password: []byte("hash")}
+		vfp := u.TokenFingerprint(domain.PurposeVerifyEmail)
+		rfp := u.TokenFingerprint(domain.PurposeResetPassword)
+
+		u.Verified = true
+		assert.NotEqual(t, vfp, u.TokenFingerprint(domain.PurposeVerifyEmail))
+		assert.Equal(t, rfp, u.TokenFingerprint(domain.PurposeResetPassword))
+	})
+
+	t.Run("Resetting the password uses the token", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := domain.NewUserWithPassword("user@forgetful.com", "forgetful user", "Test1234!")
+		require.NoError(t, err)
+		vfp := u.TokenFingerprint(domain.PurposeVerifyEmail)
+		rfp := u.TokenFingerprint(domain.PurposeResetPassword)
+
+		require.NoError(t, u.SetPassword("Test1234!"))
+		assert.NotEqual(t, rfp, u.TokenFingerprint(domain.PurposeResetPassword))
+		assert.Equal(t, vfp, u.TokenFingerprint(domain.PurposeVerifyEmail))
+	})
+
+	t.Run("Changing email invalidates tokens", func(t *testing.T) {
+		t.Parallel()
+
+		u := &domain.User{Email: "user@restless.com", Password: []byte("hash"), Verified: true}
+		vfp := u.TokenFingerprint(domain.PurposeVerifyEmail)
+		rfp := u.TokenFingerprint(domain.PurposeResetPassword)
+
+		u.ChangeEmail("USER@restless.com")
+		assert.True(t, u.Verified)
+
+		u.ChangeEmail("user@settled.com")
+		assert.False(t, u.Verified)
+		assert.NotEqual(t, vfp, u.TokenFingerprint(domain.PurposeVerifyEmail))
+		assert.NotEqual(t, rfp, u.TokenFingerprint(domain.PurposeResetPassword))
+	})
+
+	t.Run("Deleting uses the password", func(t *testing.T) {
+		t.Parallel()
+
+		u, err := domain.NewUserWithPassword("user@departing.com", "departing user", "Test1234!")
+		require.NoError(t, err)
+		dfp := u.TokenFingerprint(domain.PurposeDeleteAccount)
+		assert.NotEqual(t, dfp, u.TokenFingerprint(domain.PurposeResetPassword))
+
+		require.NoError(t, u.SetPassword("Test1234!"))
+		assert.NotEqual(t, dfp, u.TokenFingerprint(domain.PurposeDeleteAccount),
+			"because changing the password should cancel the deletion")
+	})
+}
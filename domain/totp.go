@@ -0,0 +1,238 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrTwoFactorEnabled indicates a User tried to enroll in two-factor authentication again.
+var ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+// ErrTwoFactorNotEnrolled indicates a User tried to confirm or disable two-factor authentication before enrolling.
+var ErrTwoFactorNotEnrolled = errors.New("two-factor authentication has not been enrolled")
+
+// ErrInvalidSecondFactor indicates a code was neither the current TOTP code nor an unused recovery code.
+var ErrInvalidSecondFactor = errors.New("two-factor code is invalid")
+
+// ErrSecondFactorLocked indicates there have been too many invalid codes and the User has to wait before trying again.
+var ErrSecondFactorLocked = errors.New("too many invalid two-factor codes, try again later")
+
+const (
+	// TOTPIssuer is the name authenticator apps show for the account.
+	TOTPIssuer = "Conduit"
+	// totpPeriod is how long each TOTP code is valid.
+	totpPeriod = 30 * time.Second
+	// totpSkew is how many periods before/after the current one are accepted, to allow for clock drift.
+	totpSkew   = 1
+	totpDigits = 6
+	// RecoveryCodeCount is how many recovery codes are made when two-factor authentication is enabled.
+	RecoveryCodeCount = 10
+	// secondFactorAttempts is how many invalid codes are allowed before locking.
+	secondFactorAttempts = 5
+	// secondFactorLockout is how long a User has to wait after too many invalid codes.
+	secondFactorLockout = 5 * time.Minute
+)
+
+// TwoFactor is a User's time-based one-time password (RFC 6238) setup.
+type TwoFactor struct {
+	// Secret is the base32 encoded TOTP key shared with the User's authenticator app.
+	Secret string
+	// Enabled is only set once the User has proven their app has the Secret.
+	Enabled bool
+	// RecoveryCodes are the sha256 hashes of the unused single-use codes.
+	RecoveryCodes []string
+	// LastCounter is the period of the last accepted TOTP code, so codes can't be replayed.
+	LastCounter int64
+	// FailedAttempts counts the invalid codes since the last valid one.
+	FailedAttempts int
+	LockedUntilUTC time.Time
+}
+
+// EnrollTOTP creates a new secret for the User and returns the otpauth:// provisioning uri for authenticator apps (usually shown as a QR code).
+// Two-factor authentication isn't required until the User confirms with a code from their app.
+func (u *User) EnrollTOTP() (string, error) {
+	if u.TwoFactor.Enabled {
+		return "", ErrTwoFactorEnabled
+	}
+
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	u.TwoFactor = TwoFactor{
+		Secret: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key),
+	}
+	return u.ProvisioningURI(), nil
+}
+
+// ProvisioningURI is the otpauth:// uri for authenticator apps to add the User's secret.
+func (u *User) ProvisioningURI() string {
+	q := url.Values{}
+	q.Set("secret", u.TwoFactor.Secret)
+	q.Set("issuer", TOTPIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + TOTPIssuer + ":" + u.Email,
+		RawQuery: q.Encode(),
+	}).String()
+}
+
+// ConfirmTOTP enables two-factor authentication when the code matches the enrolled secret.
+// It returns the plain-text recovery codes, they can't be retrieved later.
+func (u *User) ConfirmTOTP(code string, now time.Time) ([]string, error) {
+	if u.TwoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if u.TwoFactor.Secret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err := u.checkTOTP(code, now); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	u.TwoFactor.Enabled = true
+	u.TwoFactor.RecoveryCodes = hashes
+	return codes, nil
+}
+
+// DisableTOTP turns off two-factor authentication and forgets the secret and recovery codes.
+func (u *User) DisableTOTP() {
+	u.TwoFactor = TwoFactor{}
+}
+
+// CheckSecondFactor checks the code is either the current TOTP code or an unused recovery code.
+// Valid codes are used up and invalid codes count towards a lockout so the User always needs to be saved afterwards.
+func (u *User) CheckSecondFactor(code string, now time.Time) error {
+	if !u.TwoFactor.Enabled {
+		return ErrTwoFactorNotEnrolled
+	}
+	if now.Before(u.TwoFactor.LockedUntilUTC) {
+		return ErrSecondFactorLocked
+	}
+
+	err := u.checkTOTP(code, now)
+	if err == ErrInvalidSecondFactor {
+		err = u.useRecoveryCode(code)
+	}
+	if err == ErrInvalidSecondFactor {
+		u.TwoFactor.FailedAttempts++
+		if u.TwoFactor.FailedAttempts%secondFactorAttempts == 0 {
+			u.TwoFactor.LockedUntilUTC = now.Add(secondFactorLockout).UTC()
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	u.TwoFactor.FailedAttempts = 0
+	return nil
+}
+
+// checkTOTP checks the code against the periods around now, accepted periods can't be used again.
+func (u *User) checkTOTP(code string, now time.Time) error {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(u.TwoFactor.Secret)
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	counter := now.Unix() / int64(totpPeriod.Seconds())
+	for c := counter - totpSkew; c <= counter+totpSkew; c++ {
+		if c <= u.TwoFactor.LastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, c)), []byte(code)) == 1 {
+			u.TwoFactor.LastCounter = c
+			return nil
+		}
+	}
+
+	return ErrInvalidSecondFactor
+}
+
+// totpCode is the HOTP (RFC 4226) code for the counter.
+func totpCode(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, bin%1000000)
+}
+
+// TOTPCode is the code an authenticator app would show for the secret at the time.
+func TOTPCode(secret string, at time.Time) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, at.Unix()/int64(totpPeriod.Seconds())), nil
+}
+
+func (u *User) useRecoveryCode(code string) error {
+	hash := hashRecoveryCode(code)
+	for i, h := range u.TwoFactor.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			u.TwoFactor.RecoveryCodes = append(
+				append([]string{}, u.TwoFactor.RecoveryCodes[:i]...),
+				u.TwoFactor.RecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrInvalidSecondFactor
+}
+
+// newRecoveryCodes makes plain-text recovery codes like abcde-23456 and their hashes.
+// The codes are random enough that a fast hash is fine.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		c := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+		c = c[:5] + "-" + c[5:]
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes the normalized code so it can be typed without the dash or in any case.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTPCode(t *testing.T) {
+	t.Parallel()
+
+	// RFC 6238 Appendix B with the last 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := []struct {
+		at   int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, c := range cases {
+		code, err := domain.TOTPCode(secret, time.Unix(c.at, 0))
+		require.NoError(t, err)
+		assert.Equal(t, c.code, code, c.at)
+	}
+}
+
+// enrolled creates a User with two-factor authentication enabled and returns their recovery codes.
+func enrolled(t *testing.T, now time.Time) (*domain.User, []string) {
+	u := &domain.User{Email: "user@cautious.com", Username: "cautious user", Password: []byte("hash")}
+
+	uri, err := u.EnrollTOTP()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Conduit:user@cautious.com?"))
+	assert.Contains(t, uri, "secret="+u.TwoFactor.Secret)
+	assert.False(t, u.TwoFactor.Enabled)
+
+	code, err := domain.TOTPCode(u.TwoFactor.Secret, now)
+	require.NoError(t, err)
+	rcs, err := u.ConfirmTOTP(code, now)
+	require.NoError(t, err)
+	require.True(t, u.TwoFactor.Enabled)
+	require.Len(t, rcs, domain.RecoveryCodeCount)
+
+	return u, rcs
+}
+
+func TestTwoFactor(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Enrolling", func(t *testing.T) {
+		t.Parallel()
+		u := &domain.User{Email: "user@hesitant.com"}
+
+		_, err := u.ConfirmTOTP("123456", now)
+		assert.Equal(t, domain.ErrTwoFactorNotEnrolled, err)
+
+		_, err = u.EnrollTOTP()
+		require.NoError(t, err)
+		_, err = u.ConfirmTOTP("not a code", now)
+		assert.Equal(t, domain.ErrInvalidSecondFactor, err)
+		assert.False(t, u.TwoFactor.Enabled)
+
+		u, _ = enrolled(t, now)
+		_, err = u.EnrollTOTP()
+		assert.Equal(t, domain.ErrTwoFactorEnabled, err)
+
+		_, err = u.Validate()
+		assert.NoError(t, err)
+
+		u.DisableTOTP()
+		assert.Equal(t, domain.TwoFactor{}, u.TwoFactor)
+	})
+
+	t.Run("Codes can't be replayed", func(t *testing.T) {
+		t.Parallel()
+		u, _ := enrolled(t, now)
+
+		// The confirming code was already used
+		code, err := domain.TOTPCode(u.TwoFactor.Secret, now)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ErrInvalidSecondFactor, u.CheckSecondFactor(code, now))
+
+		later := now.Add(time.Minute)
+		code, err = domain.TOTPCode(u.TwoFactor.Secret, later)
+		require.NoError(t, err)
+		assert.NoError(t, u.CheckSecondFactor(code, later.Add(20*time.Second)), "clocks can drift")
+		assert.Equal(t, domain.ErrInvalidSecondFactor, u.CheckSecondFactor(code, later))
+	})
+
+	t.Run("Recovery codes are single use", func(t *testing.T) {
+		t.Parallel()
+		u, rcs := enrolled(t, now)
+
+		assert.NoError(t, u.CheckSecondFactor(strings.ToUpper(strings.ReplaceAll(rcs[3], "-", "")), now))
+		assert.Len(t, u.TwoFactor.RecoveryCodes, domain.RecoveryCodeCount-1)
+		assert.Equal(t, domain.ErrInvalidSecondFactor, u.CheckSecondFactor(rcs[3], now))
+		assert.NoError(t, u.CheckSecondFactor(rcs[4], now))
+	})
+
+	t.Run("Too many invalid codes locks", func(t *testing.T) {
+		t.Parallel()
+		u, rcs := enrolled(t, now)
+
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, domain.ErrInvalidSecondFactor, u.CheckSecondFactor("000000", now))
+		}
+		assert.NoError(t, u.CheckSecondFactor(rcs[0], now))
+		assert.Equal(t, 0, u.TwoFactor.FailedAttempts)
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, domain.ErrInvalidSecondFactor, u.CheckSecondFactor("000000", now))
+		}
+		assert.Equal(t, domain.ErrSecondFactorLocked, u.CheckSecondFactor(rcs[1], now.Add(4*time.Minute)))
+		assert.NoError(t, u.CheckSecondFactor(rcs[1], now.Add(6*time.Minute)))
+	})
+}
@@ -1,44 +1,130 @@
 package domain
 
 import (
+	"errors"
 	"strings"
 
 	"github.com/asaskevich/govalidator"
-	"golang.org/x/crypto/bcrypt"
 )
 
-// PasswordHash is an indicator that a string is a bcrypt hashed value.
+// PasswordHash is an indicator that a string is a PHC formatted hash (or a bcrypt hash from before PHC hashes were used).
 type PasswordHash = []byte
 
+// Role is the level of access a User has to other users' content.
+type Role string
+
+const (
+	// RoleUser is the default role, users can only manage their own content.
+	RoleUser Role = "user"
+	// RoleModerator can additionally hide articles and remove any comment.
+	RoleModerator Role = "moderator"
+	// RoleAdmin can additionally ban moderators and change the roles of other users.
+	RoleAdmin Role = "admin"
+)
+
+// rank orders the roles so they can be compared, unknown roles have the least access.
+func (r Role) rank() int {
+	switch r {
+	case RoleAdmin:
+		return 2
+	case RoleModerator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the role is one of the known roles.
+func (r Role) IsValid() bool {
+	return r == RoleUser || r == RoleModerator || r == RoleAdmin
+}
+
+// ErrReservedUsername indicates a username can't be used because it belongs to the application.
+var ErrReservedUsername = errors.New("username is reserved")
+
+// reservedUsernames could be mistaken for the application or its staff.
+var reservedUsernames = map[string]interface{}{
+	"admin":         nil,
+	"administrator": nil,
+	"anonymous":     nil,
+	"conduit":       nil,
+	"moderator":     nil,
+	"root":          nil,
+	"support":       nil,
+	"system":        nil,
+}
+
+// IsReservedUsername checks if the username is reserved for the application,
+// including the usernames of anonymized Users.
+func IsReservedUsername(username string) bool {
+	un := strings.ToLower(strings.TrimSpace(username))
+	if _, ok := reservedUsernames[un]; ok {
+		return true
+	}
+	return strings.HasPrefix(un, anonymousPrefix)
+}
+
 // User is an individual user in the application.
 // A user can be both the current client logged in (usually id'd by email)
 // and also an proile of someone that is followed (usually id'd by username).
 type User struct {
+	// ID never changes (unlike the email and username) and is what sessions identify the user by.
+	ID       string `valid:"-"`
 	Email    string `valid:"required,email"`
 	Username string `valid:"required"`
 	Bio      string
 	Image    string       `valid:"url,optional"`
 	Password PasswordHash `valid:"required"`
+	Role     Role         `valid:"in(user|moderator|admin),optional"`
+	Banned   bool
+	// Verified is whether the user has proven they own their email address.
+	Verified  bool
+	TwoFactor TwoFactor
 }
 
-// Fanboy is User with the Users they follow by email
+// Fanboy is User with the Users they follow, block and mute by email.
+// Emails are only how relations are passed around, the adapters store them by the ids of the users
+// so an email change doesn't touch them.
 type Fanboy struct {
 	User
 	Following map[string]interface{}
 	Favorites map[string]interface{}
+	Blocking  map[string]interface{}
+	Muting    map[string]interface{}
+}
+
+// FollowCounts is how many users follow, and are followed by, a User.
+type FollowCounts struct {
+	Followers int
+	Following int
 }
 
 // NewUserWithPassword creates a new partially-hydrated User with the provide information.
+// The password has to pass CheckPasswordStrength.
 func NewUserWithPassword(email string, username string, password string) (*User, error) {
-	pw, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if IsReservedUsername(username) {
+		return nil, ErrReservedUsername
+	}
+	if err := CheckPasswordStrength(password, email, username); err != nil {
+		return nil, err
+	}
+
+	pw, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := NewID()
 	if err != nil {
 		return nil, err
 	}
 
 	return (&User{
+		ID:       id,
 		Email:    email,
 		Username: username,
 		Password: pw,
+		Role:     RoleUser,
 	}).Validate()
 }
 
@@ -51,9 +137,25 @@ func (u *User) Validate() (*User, error) {
 	return u, nil
 }
 
-// SetPassword sets the password hash from the plain-text value
+// HasRole checks if the user has at least the access of the provided role.
+func (u *User) HasRole(r Role) bool {
+	return u.Role.rank() >= r.rank()
+}
+
+// SetPassword sets the password hash from the plain-text value,
+// the new password has to pass CheckPasswordStrength.
 func (u *User) SetPassword(password string) error {
-	pw, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if err := CheckPasswordStrength(password, u.Email, u.Username); err != nil {
+		return err
+	}
+
+	return u.RehashPassword(password)
+}
+
+// RehashPassword sets the password hash from the plain-text value using the current hasher.
+// Unlike SetPassword the strength isn't checked so it can be used to upgrade existing hashes.
+func (u *User) RehashPassword(password string) error {
+	pw, err := hashPassword(password)
 	if err != nil {
 		return err
 	}
@@ -62,18 +164,38 @@ func (u *User) SetPassword(password string) error {
 	return nil
 }
 
-// HasPassword checks if the provided password string matches the hash for the user.
-func (u *User) HasPassword(password string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(u.Password, []byte(password))
-	if err == bcrypt.ErrMismatchedHashAndPassword {
-		return false, nil
+// NeedsRehash checks if the password hash was made by an old hasher or with old parameters.
+func (u *User) NeedsRehash() bool {
+	return passwordHasher.NeedsRehash(u.Password)
+}
+
+// ChangeEmail sets the email address, a new address needs to be verified again.
+func (u *User) ChangeEmail(email string) {
+	if strings.EqualFold(u.Email, email) {
+		return
 	}
 
-	if err != nil {
-		return false, err
+	u.Email = email
+	u.Verified = false
+}
+
+// ChangeUsername sets the username, unless it's reserved.
+func (u *User) ChangeUsername(username string) error {
+	if strings.EqualFold(u.Username, username) {
+		u.Username = username
+		return nil
+	}
+	if IsReservedUsername(username) {
+		return ErrReservedUsername
 	}
 
-	return true, nil
+	u.Username = username
+	return nil
+}
+
+// HasPassword checks if the provided password string matches the hash for the user.
+func (u *User) HasPassword(password string) (bool, error) {
+	return verifyPassword(u.Password, password)
 }
 
 // FollowingEmails is the slice of user emails the user follows.
@@ -110,6 +232,74 @@ func (u *Fanboy) StopFollowing(email string) {
 	delete(u.Following, strings.ToLower(email))
 }
 
+// BlockingEmails is the slice of user emails the user blocks.
+func (u *Fanboy) BlockingEmails() []string {
+	emails := make([]string, 0)
+	for em := range u.Blocking {
+		if em != "" {
+			emails = append(emails, em)
+		}
+	}
+	return emails
+}
+
+// IsBlocking checks if the provided user is currently blocked by this user.
+func (u *Fanboy) IsBlocking(email string) bool {
+	_, ok := u.Blocking[strings.ToLower(email)]
+	return ok && email != ""
+}
+
+// Block tracks that the provided user should be blocked.
+// Users stop following the users they block.
+func (u *Fanboy) Block(email string) {
+	if !govalidator.IsEmail(email) {
+		return
+	}
+	u.StopFollowing(email)
+	u.Blocking[strings.ToLower(email)] = nil
+}
+
+// Unblock tracks that the provided user should be unblocked.
+func (u *Fanboy) Unblock(email string) {
+	delete(u.Blocking, strings.ToLower(email))
+}
+
+// MutingEmails is the slice of user emails the user mutes.
+func (u *Fanboy) MutingEmails() []string {
+	emails := make([]string, 0)
+	for em := range u.Muting {
+		if em != "" {
+			emails = append(emails, em)
+		}
+	}
+	return emails
+}
+
+// IsMuting checks if the provided user is currently muted by this user.
+func (u *Fanboy) IsMuting(email string) bool {
+	_, ok := u.Muting[strings.ToLower(email)]
+	return ok && email != ""
+}
+
+// Mute tracks that the provided user should be muted.
+func (u *Fanboy) Mute(email string) {
+	if !govalidator.IsEmail(email) {
+		return
+	}
+	u.Muting[strings.ToLower(email)] = nil
+}
+
+// Unmute tracks that the provided user should be unmuted.
+func (u *Fanboy) Unmute(email string) {
+	delete(u.Muting, strings.ToLower(email))
+}
+
+// Ignores checks if content by the provided user should be hidden from this user,
+// because they are either blocked or muted.
+func (u *Fanboy) Ignores(email string) bool {
+	return u.IsBlocking(email) || u.IsMuting(email)
+}
+
 // FavoritedSlugs is the slice of article slugs the user favors.
 func (u *Fanboy) FavoritedSlugs() []string {
 	slugs := make([]string, 0)
@@ -28,6 +28,26 @@ func TestNewUserWithPassword(t *testing.T) {
 		assert.NotNil(t, err)
 		assert.Nil(t, u)
 	})
+
+	t.Run("IDs are unique", func(t *testing.T) {
+		t.Parallel()
+
+		u1, err := domain.NewUserWithPassword("user@twin.com", "twin user", "Test1234!")
+		require.NoError(t, err)
+		u2, err := domain.NewUserWithPassword("user@twin.com", "twin user", "Test1234!")
+		require.NoError(t, err)
+		assert.Len(t, u1.ID, 36)
+		assert.NotEqual(t, u1.ID, u2.ID)
+	})
+
+	t.Run("Usernames can't be reserved", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := domain.NewUserWithPassword("user@sneaky.com", "Admin", "Test1234!")
+		assert.ErrorIs(t, err, domain.ErrReservedUsername)
+		_, err = domain.NewUserWithPassword("user@sneaky.com", "deleted-0123456789abcdef", "Test1234!")
+		assert.ErrorIs(t, err, domain.ErrReservedUsername)
+	})
 }
 
 func TestHasPassword(t *testing.T) {
@@ -108,6 +128,15 @@ func TestValidate(t *testing.T) {
 				Image:    "http://this is not an image url",
 			},
 		},
+		{
+			"Invalid Role",
+			&domain.User{
+				Email:    "user@spotty.com",
+				Username: "spotty user",
+				Password: []byte("required but not validated"),
+				Role:     domain.Role("superuser"),
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -130,6 +159,7 @@ func TestValidate(t *testing.T) {
 			Password: []byte("required but not validated"),
 			Bio:      "needy bio",
 			Image:    "https://profileimages.com/needy.gif",
+			Role:     domain.RoleModerator,
 		}
 
 		vu, err := u.Validate()
@@ -169,6 +199,62 @@ func TestUser_Following(t *testing.T) {
 		"because only valid emails can be followed")
 }
 
+func TestUser_Blocking(t *testing.T) {
+	t.Parallel()
+
+	f := domain.Fanboy{
+		Following: map[string]interface{}{
+			"user@rowdy.com": nil,
+		},
+		Blocking: map[string]interface{}{
+			"user@vexed.com": nil,
+		},
+		Muting: map[string]interface{}{},
+	}
+	assert.True(t, f.IsBlocking("user@vexed.com"))
+	assert.True(t, f.Ignores("user@vexed.com"))
+	assert.False(t, f.IsBlocking("user@rowdy.com"))
+
+	f.Block("user@rowdy.com")
+	assert.True(t, f.IsBlocking("user@rowdy.com"))
+	assert.False(t, f.IsFollowing("user@rowdy.com"),
+		"because blocking a user unfollows them")
+	assert.Len(t, f.BlockingEmails(), 2)
+
+	f.Unblock("user@vexed.com")
+	f.Unblock("user@vexed.com")
+	assert.False(t, f.IsBlocking("user@vexed.com"),
+		"because blocking is idempotent")
+
+	f.Block("definitely not an email")
+	assert.False(t, f.IsBlocking("definitely not an email"),
+		"because only valid emails can be blocked")
+}
+
+func TestUser_Muting(t *testing.T) {
+	t.Parallel()
+
+	f := domain.Fanboy{
+		Following: map[string]interface{}{
+			"user@chatty.com": nil,
+		},
+		Blocking: map[string]interface{}{},
+		Muting:   map[string]interface{}{},
+	}
+	assert.False(t, f.Ignores("user@chatty.com"))
+
+	f.Mute("user@chatty.com")
+	assert.True(t, f.IsMuting("user@chatty.com"))
+	assert.True(t, f.Ignores("user@chatty.com"))
+	assert.True(t, f.IsFollowing("user@chatty.com"),
+		"because muted users can still be followed")
+	assert.Len(t, f.MutingEmails(), 1)
+
+	f.Unmute("user@chatty.com")
+	assert.False(t, f.Ignores("user@chatty.com"))
+	assert.Empty(t, f.MutingEmails())
+}
+
 func TestUser_Favorites(t *testing.T) {
 	t.Parallel()
 
@@ -194,3 +280,37 @@ func TestUser_Favorites(t *testing.T) {
 	assert.False(t, f.Favors("tidy-title"),
 		"because favoring is idempotent")
 }
+
+func TestUser_HasRole(t *testing.T) {
+	t.Parallel()
+
+	u := domain.User{}
+	assert.True(t, u.HasRole(domain.RoleUser),
+		"because users without a role are regular users")
+	assert.False(t, u.HasRole(domain.RoleModerator))
+
+	u.Role = domain.RoleModerator
+	assert.True(t, u.HasRole(domain.RoleUser))
+	assert.True(t, u.HasRole(domain.RoleModerator))
+	assert.False(t, u.HasRole(domain.RoleAdmin))
+
+	u.Role = domain.RoleAdmin
+	assert.True(t, u.HasRole(domain.RoleModerator))
+	assert.True(t, u.HasRole(domain.RoleAdmin))
+}
+
+func TestUser_ChangeUsername(t *testing.T) {
+	t.Parallel()
+
+	u, err := domain.NewUserWithPassword("user@restless.com", "restless user", "Test1234!")
+	require.NoError(t, err)
+
+	require.NoError(t, u.ChangeUsername("Restless User"))
+	assert.Equal(t, "Restless User", u.Username)
+
+	assert.ErrorIs(t, u.ChangeUsername(" support "), domain.ErrReservedUsername)
+	assert.Equal(t, "Restless User", u.Username)
+
+	require.NoError(t, u.ChangeUsername("settled user"))
+	assert.Equal(t, "settled user", u.Username)
+}
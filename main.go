@@ -1,17 +1,303 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/brycekbargar/realworld-backend/adapters/boltdb"
+	"github.com/brycekbargar/realworld-backend/adapters/cache"
+	"github.com/brycekbargar/realworld-backend/adapters/filesystem"
 	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/metrics"
+	"github.com/brycekbargar/realworld-backend/adapters/oidc"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/adapters/postgres"
+	"github.com/brycekbargar/realworld-backend/adapters/s3"
+	"github.com/brycekbargar/realworld-backend/adapters/smtp"
+	"github.com/brycekbargar/realworld-backend/adapters/sqlite"
+	"github.com/brycekbargar/realworld-backend/adapters/tracing"
+	"github.com/brycekbargar/realworld-backend/domain"
 	"github.com/brycekbargar/realworld-backend/ports"
 	"github.com/brycekbargar/realworld-backend/ports/echohttp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
-	// TODO: Configure the port and secret
-	im := inmemory.NewInstance()
+	pg := flag.String("postgres", "", "postgres connection string, used instead of bolt or sqlite when set")
+	db := flag.String("sqlite", "", "path to the sqlite database, everything is kept in memory when empty")
+	kv := flag.String("bolt", "", "path to the bolt database, used instead of sqlite when set")
+	fixture := flag.String("fixture", "", "JSON or YAML fixture to start the in memory store with")
+	dump := flag.String("dump", "", "JSON or YAML file to dump the in memory store to when the server is stopped")
+	cached := flag.Int("cache", 0, "how many users, articles and tag lists to cache in memory, nothing is cached when 0")
+	ttl := flag.Duration("cache-ttl", cache.DefaultTTLs.Users, "how long cached users and articles are kept for")
+	observe := flag.Bool("metrics", true, "serve Prometheus metrics at /metrics")
+	hash := flag.String("password-hash", "argon2id", "algorithm (argon2id or bcrypt) new passwords are hashed with, older hashes are replaced at login")
+	argonMemory := flag.Uint("argon2id-memory", uint(domain.DefaultArgon2idParams.Memory), "KiB of memory argon2id uses for each password")
+	argonIterations := flag.Uint("argon2id-iterations", uint(domain.DefaultArgon2idParams.Iterations), "passes argon2id makes over its memory for each password")
+	argonParallelism := flag.Uint("argon2id-parallelism", uint(domain.DefaultArgon2idParams.Parallelism), "threads (up to 255) argon2id uses for each password")
+	bcryptCost := flag.Int("bcrypt-cost", domain.DefaultBcryptCost, "cost (between 4 and 31) of bcrypt hashes")
+	logins := flag.String("oidc", "", "comma separated names of OpenID Connect providers users can login with, "+
+		"each is configured by the OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and (optional) _SCOPES environment variables")
+	smtpHost := flag.String("smtp-host", "", "host of the SMTP relay verification and password reset emails are sent through")
+	smtpPort := flag.Int("smtp-port", 587, "port of the SMTP relay, STARTTLS is used whenever the relay supports it")
+	smtpFrom := flag.String("smtp-from", "", "address emails are sent from")
+	smtpUser := flag.String("smtp-username", "", "username to authenticate with the SMTP relay, emails are sent without authenticating when empty")
+	smtpPassword := flag.String("smtp-password", os.Getenv("SMTP_PASSWORD"), "password to authenticate with the SMTP relay, defaults to the SMTP_PASSWORD environment variable")
+	outboxFile := flag.String("outbox", "", "file to append emails to as lines of JSON instead of sending them, only for development and tests. "+
+		"Emails are kept in memory (and never delivered) when neither this nor -smtp-host is set")
+	port := flag.Int("port", 4123, "port the server listens on")
+	secret := flag.String("jwt-secret", envOr("JWT_SECRET", "Replace Me"), "secret sessions and emailed tokens are signed with, defaults to the JWT_SECRET environment variable")
+	uploads := flag.String("uploads", "uploads", "directory uploaded images are kept in when -s3-bucket isn't set")
+	s3Endpoint := flag.String("s3-endpoint", "https://s3.us-east-1.amazonaws.com", "scheme and host of the S3 compatible service uploaded images are kept in")
+	s3Region := flag.String("s3-region", "us-east-1", "region of the S3 bucket")
+	s3Bucket := flag.String("s3-bucket", "", "S3 bucket uploaded images are kept in instead of the -uploads directory")
+	s3AccessKey := flag.String("s3-access-key-id", os.Getenv("S3_ACCESS_KEY_ID"), "access key id for the S3 bucket, defaults to the S3_ACCESS_KEY_ID environment variable")
+	s3SecretKey := flag.String("s3-secret-access-key", os.Getenv("S3_SECRET_ACCESS_KEY"), "secret access key for the S3 bucket, defaults to the S3_SECRET_ACCESS_KEY environment variable")
+	otlp := flag.String("otlp", "", "host:port of an OTLP/HTTP collector to send traces to, nothing is traced when empty")
+	var level slog.Level
+	flag.TextVar(&level, "log-level", slog.LevelInfo, "least severe level (DEBUG, INFO, WARN or ERROR) of logs written to stderr")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [flags] [command [command flags]]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+		commandsUsage(flag.CommandLine.Output())
+	}
+	flag.Parse()
+
+	log := echohttp.NewLogger(os.Stderr, level)
+	slog.SetDefault(log)
+
+	params := domain.DefaultArgon2idParams
+	params.Memory = uint32(*argonMemory)
+	params.Iterations = uint32(*argonIterations)
+	params.Parallelism = uint8(*argonParallelism)
+	if *argonParallelism > 255 {
+		params.Parallelism = 0
+	}
+	hasher, err := passwordHasher(*hash, params, *bcryptCost)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	domain.SetPasswordHasher(hasher)
+	if flag.NArg() > 0 {
+		// Commands use the same store as the server, but only migrate it when they're asked to
+		os.Exit(runCommand(context.Background(), store{*pg, *kv, *db, *fixture}, flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	ml, err := mailer(*smtpHost, *smtpPort, *smtpFrom, *smtpUser, *smtpPassword, *outboxFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	bs, err := blobStore(*uploads, s3.Config{
+		Endpoint:        *s3Endpoint,
+		Region:          *s3Region,
+		Bucket:          *s3Bucket,
+		AccessKeyID:     *s3AccessKey,
+		SecretAccessKey: *s3SecretKey,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *port < 1 || *port > 65535 {
+		fmt.Fprintln(os.Stderr, "port must be between 1 and 65535")
+		os.Exit(2)
+	}
+	if *smtpHost == "" && *outboxFile == "" {
+		log.Warn("emails are kept in memory and never delivered, set -smtp-host to send them or -outbox to keep them in a file")
+	}
+
+	var tp trace.TracerProvider
+	if *otlp != "" {
+		exp, err := otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpoint(*otlp),
+			otlptracehttp.WithInsecure())
+		if err != nil {
+			panic(err)
+		}
+		sdk := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exp),
+			sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+				semconv.ServiceName("conduit"))))
+		defer sdk.Shutdown(context.Background())
+
+		// The postgres adapter traces its queries with the global provider
+		otel.SetTracerProvider(sdk)
+		tp = sdk
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var repo domain.Repository
+	if *pg != "" {
+		repo = postgres.MustNewInstance(*pg).MustMigrate()
+	} else if *kv != "" {
+		repo = boltdb.MustNewInstance(*kv)
+	} else if *db != "" {
+		repo = sqlite.MustNewInstance(*db).MustMigrate()
+	} else {
+		mem := inmemory.NewInstance()
+		if *fixture != "" {
+			fx, err := inmemory.ReadFixtureFile(*fixture)
+			if err != nil {
+				panic(err)
+			}
+			if err := mem.Import(fx); err != nil {
+				panic(err)
+			}
+		}
+		if *dump != "" {
+			go func() {
+				stop := make(chan os.Signal, 1)
+				signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+				<-stop
+
+				if err := mem.Export().WriteFile(*dump); err != nil {
+					log.Error("dumping the in memory store failed", slog.Any("err", err))
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}()
+		}
+		repo = mem
+	}
+	var reg *prometheus.Registry
+	if *observe {
+		reg = prometheus.NewRegistry()
+		reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		if c, ok := repo.(prometheus.Collector); ok {
+			// Some adapters have their own statistics (like the postgres connection pool)
+			reg.MustRegister(c)
+		}
+		metrics.ObservePasswordHashing(reg)
+		repo = metrics.NewInstance(repo, reg)
+	}
+	if *cached > 0 {
+		ttls := cache.DefaultTTLs
+		ttls.Users = *ttl
+		ttls.Articles = *ttl
+		c := cache.NewInstance(repo, cache.NewLRU(*cached), ttls)
+		if reg != nil {
+			metrics.ObserveCache(reg, c)
+		}
+		repo = c
+	}
+	if tp != nil {
+		repo = tracing.NewInstance(repo, tp)
+	}
+	var providers []domain.IdentityProvider
+	for _, name := range strings.Split(*logins, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		c, err := oidc.ConfigFromEnv(name, os.Getenv)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		// Discovery happens once at startup, providers that can't be reached stop the server from starting
+		p, err := oidc.NewInstance(context.Background(), c)
+		if err != nil {
+			panic(err)
+		}
+		providers = append(providers, p)
+	}
 	echohttp.Start(
-		ports.DefaultJWTConfig("Replace Me"),
-		4123,
-		im,
+		ports.DefaultJWTConfig(*secret),
+		*port,
+		repo,
+		bs,
+		ml,
+		providers,
+		reg,
+		tp,
+		log,
 	)
 }
+
+// passwordHasher creates the hasher new passwords are hashed with from the flags.
+func passwordHasher(alg string, params domain.Argon2idParams, cost int) (domain.PasswordHasher, error) {
+	switch alg {
+	case "argon2id":
+		// argon2 needs at least 8KiB of memory for each thread
+		if params.Iterations < 1 || params.Parallelism < 1 ||
+			params.Memory < 8*uint32(params.Parallelism) || params.Memory > domain.MaxArgon2idMemory {
+			return nil, fmt.Errorf("argon2id needs at least 1 iteration, between 1 and 255 threads and between 8 KiB of memory per thread and %v KiB in total", domain.MaxArgon2idMemory)
+		}
+		return domain.NewArgon2idHasher(params), nil
+	case "bcrypt":
+		if cost < 4 || cost > 31 {
+			return nil, fmt.Errorf("bcrypt cost must be between 4 and 31")
+		}
+		return domain.NewBcryptHasher(cost), nil
+	}
+	return nil, fmt.Errorf("unknown password hash %q, it must be argon2id or bcrypt", alg)
+}
+
+// mailer creates the mailer verification and password reset emails are sent with from the flags.
+func mailer(host string, port int, from string, username string, password string, outboxFile string) (domain.Mailer, error) {
+	switch {
+	case host != "" && outboxFile != "":
+		return nil, fmt.Errorf("emails can be sent through an SMTP relay or kept in an outbox, but not both")
+	case outboxFile != "":
+		return outbox.NewFileInstance(outboxFile), nil
+	case host == "":
+		// Nothing is delivered, but the server still starts without any flags for local development
+		return outbox.NewInstance(), nil
+	case port < 1 || port > 65535:
+		return nil, fmt.Errorf("smtp port must be between 1 and 65535")
+	case from == "":
+		return nil, fmt.Errorf("an address to send emails from (-smtp-from) is needed")
+	}
+	return smtp.NewInstance(smtp.Config{
+		Addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		Username: username,
+		Password: password,
+		From:     from,
+	}), nil
+}
+
+// blobStore creates the store uploaded images are kept in from the flags,
+// the bucket when one is configured and the directory otherwise.
+func blobStore(dir string, c s3.Config) (domain.BlobStore, error) {
+	if c.Bucket == "" {
+		if dir == "" {
+			return nil, fmt.Errorf("a directory (-uploads) or bucket (-s3-bucket) is needed to keep uploaded images in")
+		}
+		return filesystem.MustNewInstance(dir), nil
+	}
+
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("s3 endpoint %q must be a http(s) scheme and host", c.Endpoint)
+	}
+	if c.Region == "" || c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, fmt.Errorf("an s3 bucket needs a region, access key id and secret access key")
+	}
+	return s3.NewInstance(c), nil
+}
+
+// envOr gets the environment variable or the fallback when it isn't set.
+func envOr(name string, fallback string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return fallback
+}
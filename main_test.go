@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/adapters/s3"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	t.Parallel()
+
+	cheap := domain.DefaultArgon2idParams
+	cheap.Memory = 1024
+	cheap.Iterations = 1
+
+	h, err := passwordHasher("argon2id", cheap, domain.DefaultBcryptCost)
+	require.NoError(t, err)
+	hash, err := h.Hash("Test1234!")
+	require.NoError(t, err)
+	assert.Contains(t, string(hash), "m=1024,t=1,p=1")
+
+	h, err = passwordHasher("bcrypt", cheap, 4)
+	require.NoError(t, err)
+	hash, err = h.Hash("Test1234!")
+	require.NoError(t, err)
+	assert.False(t, h.NeedsRehash(hash))
+
+	for _, tc := range []struct {
+		name   string
+		alg    string
+		params func(*domain.Argon2idParams)
+		cost   int
+	}{
+		{"Unknown Algorithm", "md5", func(*domain.Argon2idParams) {}, 4},
+		{"No Iterations", "argon2id", func(p *domain.Argon2idParams) { p.Iterations = 0 }, 4},
+		{"No Threads", "argon2id", func(p *domain.Argon2idParams) { p.Parallelism = 0 }, 4},
+		{"Too Little Memory", "argon2id", func(p *domain.Argon2idParams) { p.Parallelism = 4; p.Memory = 16 }, 4},
+		{"Too Much Memory", "argon2id", func(p *domain.Argon2idParams) { p.Memory = domain.MaxArgon2idMemory + 1 }, 4},
+		{"Too Cheap", "bcrypt", func(*domain.Argon2idParams) {}, 3},
+		{"Too Expensive", "bcrypt", func(*domain.Argon2idParams) {}, 32},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := cheap
+			tc.params(&p)
+			_, err := passwordHasher(tc.alg, p, tc.cost)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMailer(t *testing.T) {
+	t.Parallel()
+
+	m, err := mailer("smtp.conduit.test", 587, "noreply@conduit.test", "", "", "")
+	require.NoError(t, err)
+	assert.NotNil(t, m)
+
+	m, err = mailer("", 587, "", "", "", "outbox.jsonl")
+	require.NoError(t, err)
+	assert.IsType(t, &outbox.Outbox{}, m)
+
+	m, err = mailer("", 587, "", "", "", "")
+	require.NoError(t, err)
+	assert.IsType(t, &outbox.Outbox{}, m, "because the server starts without any flags")
+
+	for _, tc := range []struct {
+		name   string
+		host   string
+		port   int
+		from   string
+		outbox string
+	}{
+		{"Both", "smtp.conduit.test", 587, "noreply@conduit.test", "outbox.jsonl"},
+		{"Bad Port", "smtp.conduit.test", 0, "noreply@conduit.test", ""},
+		{"No From", "smtp.conduit.test", 587, "", ""},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := mailer(tc.host, tc.port, tc.from, "", "", tc.outbox)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBlobStore(t *testing.T) {
+	t.Parallel()
+
+	bucket := s3.Config{
+		Endpoint:        "https://s3.conduit.test",
+		Region:          "us-east-1",
+		Bucket:          "uploads",
+		AccessKeyID:     "id",
+		SecretAccessKey: "secret",
+	}
+
+	b, err := blobStore(t.TempDir(), s3.Config{})
+	require.NoError(t, err)
+	assert.NotNil(t, b)
+	b, err = blobStore(t.TempDir(), bucket)
+	require.NoError(t, err)
+	assert.NotNil(t, b)
+
+	for _, tc := range []struct {
+		name string
+		dir  string
+		cfg  func(*s3.Config)
+	}{
+		{"Nowhere", "", func(c *s3.Config) { c.Bucket = "" }},
+		{"Bad Endpoint", "", func(c *s3.Config) { c.Endpoint = "s3.conduit.test" }},
+		{"No Region", "", func(c *s3.Config) { c.Region = "" }},
+		{"No Credentials", "", func(c *s3.Config) { c.SecretAccessKey = "" }},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := bucket
+			tc.cfg(&c)
+			_, err := blobStore(tc.dir, c)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package echohttp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+	"github.com/labstack/echo/v4"
+)
+
+type adminHandler struct {
+	repo   domain.Repository
+	authed echo.MiddlewareFunc
+	mod    *moderation
+}
+
+func newAdminHandler(
+	repo domain.Repository,
+	authed echo.MiddlewareFunc,
+	policy domain.Policy,
+) *adminHandler {
+	return &adminHandler{
+		repo,
+		authed,
+		&moderation{repo, policy},
+	}
+}
+
+func (h *adminHandler) mapRoutes(g *echo.Group) {
+	g.PUT("/admin/users/:username/ban", h.ban, h.authed)
+	g.DELETE("/admin/users/:username/ban", h.unban, h.authed)
+	g.PUT("/admin/users/:username/role", h.role, h.authed)
+
+	g.PUT("/admin/articles/:slug/hide", h.hide, h.authed)
+	g.DELETE("/admin/articles/:slug/hide", h.unhide, h.authed)
+	g.PUT("/admin/articles/:slug/comments/:id/hide", h.hideComment, h.authed)
+	g.DELETE("/admin/articles/:slug/comments/:id/hide", h.unhideComment, h.authed)
+	g.DELETE("/admin/articles/:slug/comments/:id", h.removeComment, h.authed)
+
+	g.GET("/admin/audit", h.audit, h.authed)
+}
+
+func (h *adminHandler) ban(ctx echo.Context) error {
+	return h.setBanned(ctx, domain.ActionSuspend)
+}
+
+func (h *adminHandler) unban(ctx echo.Context) error {
+	return h.setBanned(ctx, domain.ActionUnsuspend)
+}
+
+func (h *adminHandler) setBanned(ctx echo.Context, action domain.ModerationAction) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return moderationError(err)
+	}
+
+	updated, err := h.mod.setSuspended(ctx.Request().Context(),
+		mod,
+		domain.NewAuditEntry(mod.Email, action),
+		found.Email)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToModeratedUser(updated))
+}
+
+func (h *adminHandler) role(ctx echo.Context) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	role, err := serialization.ChangeRoleToRole(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		return moderationError(err)
+	}
+
+	updated, err := h.mod.changeRole(ctx.Request().Context(),
+		mod,
+		domain.NewAuditEntry(mod.Email, domain.ActionChangeRole),
+		found.Email,
+		role)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToModeratedUser(updated))
+}
+
+func (h *adminHandler) hide(ctx echo.Context) error {
+	return h.setHidden(ctx, domain.ActionHide)
+}
+
+func (h *adminHandler) unhide(ctx echo.Context) error {
+	return h.setHidden(ctx, domain.ActionUnhide)
+}
+
+func (h *adminHandler) setHidden(ctx echo.Context, action domain.ModerationAction) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.mod.setArticleHidden(ctx.Request().Context(),
+		mod,
+		domain.NewAuditEntry(mod.Email, action),
+		ctx.Param("slug"))
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.AuthoredArticleToArticle(updated, nil))
+}
+
+func (h *adminHandler) hideComment(ctx echo.Context) error {
+	return h.setCommentHidden(ctx, domain.ActionHide)
+}
+
+func (h *adminHandler) unhideComment(ctx echo.Context) error {
+	return h.setCommentHidden(ctx, domain.ActionUnhide)
+}
+
+func (h *adminHandler) setCommentHidden(ctx echo.Context, action domain.ModerationAction) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	cid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest
+	}
+
+	err = h.mod.setCommentHidden(ctx.Request().Context(),
+		mod,
+		domain.NewAuditEntry(mod.Email, action),
+		ctx.Param("slug"),
+		cid)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h *adminHandler) removeComment(ctx echo.Context) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	cid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest
+	}
+
+	err = h.mod.deleteComment(ctx.Request().Context(),
+		mod,
+		domain.NewAuditEntry(mod.Email, domain.ActionDelete),
+		ctx.Param("slug"),
+		cid)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h *adminHandler) audit(ctx echo.Context) error {
+	if _, err := h.mod.moderator(ctx); err != nil {
+		return err
+	}
+
+	limit, offset := page(ctx)
+
+	entries, err := h.repo.LatestAuditEntries(ctx.Request().Context(), limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.AuditEntriesToAuditTrail(entries))
+}
@@ -0,0 +1,197 @@
+package echohttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+)
+
+// scopedKey is set on the context once a route has checked the scopes of an API token.
+const scopedKey = "scoped"
+
+// apiTokenNotAllowed is returned when an API token is used for a route that needs a session.
+var apiTokenNotAllowed = echo.NewHTTPError(
+	http.StatusForbidden,
+	"api tokens can't be used for this action, login instead")
+
+type apiTokensHandler struct {
+	repo   domain.Repository
+	authed echo.MiddlewareFunc
+}
+
+func newAPITokensHandler(
+	repo domain.Repository,
+	authed echo.MiddlewareFunc,
+) *apiTokensHandler {
+	return &apiTokensHandler{
+		repo,
+		authed,
+	}
+}
+
+// API tokens can only be managed with a session so a leaked token can't mint more.
+func (h *apiTokensHandler) mapRoutes(g *echo.Group) {
+	g.GET("/user/tokens", h.list, h.authed)
+	g.POST("/user/tokens", h.create, h.authed)
+	g.DELETE("/user/tokens/:id", h.revoke, h.authed)
+}
+
+// apiTokenAuth lets personal API tokens be used in place of a session jwt, anything else goes to the jwt middleware.
+// API tokens become a jwt with the owner's email and the token's scopes so identity works the same for both.
+func apiTokenAuth(repo domain.Repository, jwtAuth echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withJWT := jwtAuth(next)
+		return func(ctx echo.Context) error {
+			auth := ctx.Request().Header.Get("Authorization")
+			if len(auth) < len("token ") || !strings.EqualFold(auth[:len("token ")], "token ") {
+				return withJWT(ctx)
+			}
+			raw := strings.TrimSpace(auth[len("token "):])
+			if !domain.IsAPIToken(raw) {
+				return withJWT(ctx)
+			}
+
+			t, err := repo.GetAPITokenByHash(ctx.Request().Context(), domain.HashAPIToken(raw))
+			if err == domain.ErrAPITokenNotFound {
+				return echo.NewHTTPError(
+					http.StatusUnauthorized,
+					"api token is invalid or has been revoked")
+			}
+			if err != nil {
+				return err
+			}
+
+			scopes := make([]interface{}, 0, len(t.Scopes))
+			for _, s := range t.Scopes {
+				scopes = append(scopes, string(s))
+			}
+			ctx.Set("user", &jwt.Token{
+				Raw: raw,
+				Claims: jwt.MapClaims{
+					"email":  t.OwnerEmail,
+					"scopes": scopes,
+				},
+				Valid: true,
+			})
+
+			err = next(ctx)
+			if err == identityNotOk && ctx.Get(scopedKey) == nil {
+				return apiTokenNotAllowed
+			}
+			return err
+		}
+	}
+}
+
+// scoped lets API tokens with the scope use the route, session jwts can use every route.
+// Routes without a scope can't be used with API tokens at all.
+func scoped(s domain.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if jt, ok := ctx.Get("user").(*jwt.Token); ok {
+				if claims, ok := jt.Claims.(jwt.MapClaims); ok {
+					if scopes, ok := claims["scopes"].([]interface{}); ok {
+						if !hasScope(scopes, s) {
+							return echo.NewHTTPError(
+								http.StatusForbidden,
+								fmt.Sprintf("api token needs the %v scope", s))
+						}
+						ctx.Set(scopedKey, true)
+					}
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+func hasScope(scopes []interface{}, s domain.Scope) bool {
+	for _, ts := range scopes {
+		if ts == string(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *apiTokensHandler) list(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	ts, err := h.repo.APITokensByOwner(ctx.Request().Context(), em)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ManyAPITokensToAPITokens(ts))
+}
+
+func (h *apiTokensHandler) create(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	t, raw, err := serialization.CreateAPITokenToAPIToken(ctx.Bind, u)
+	if err == domain.ErrInvalidScope {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	created, err := h.repo.CreateAPIToken(ctx.Request().Context(), t)
+	if err != nil {
+		if err == domain.ErrDuplicateAPIToken {
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				err.Error())
+		}
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.APITokenToAPIToken(created, raw))
+}
+
+func (h *apiTokensHandler) revoke(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := h.repo.DeleteAPIToken(ctx.Request().Context(), em, id); err != nil {
+		if err == domain.ErrAPITokenNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
@@ -0,0 +1,235 @@
+package echohttp_test
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenResponse is the api token returned when it is created.
+type tokenResponse struct {
+	Token struct {
+		ID     int      `json:"id"`
+		Token  string   `json:"token"`
+		Scopes []string `json:"scopes"`
+	} `json:"token"`
+}
+
+// createToken creates an api token using the session and returns it, tokens are named after their scopes.
+func createToken(t *testing.T, h http.Handler, session string, scopes ...domain.Scope) tokenResponse {
+	ss := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		ss = append(ss, string(s))
+	}
+
+	res := do(t, h, request{
+		method: http.MethodPost,
+		path:   "/api/user/tokens",
+		token:  session,
+		body: map[string]interface{}{
+			"token": map[string]interface{}{
+				"name":   strings.Join(ss, " "),
+				"scopes": ss,
+			},
+		},
+	})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var tr tokenResponse
+	decode(t, res, &tr)
+	require.NotEmpty(t, tr.Token.Token)
+	return tr
+}
+
+func newArticle(title string) map[string]interface{} {
+	return map[string]interface{}{
+		"article": map[string]interface{}{
+			"title":       title,
+			"description": "a description of " + title,
+			"body":        "the body of " + title,
+			"tagList":     []string{"tokens"},
+		},
+	}
+}
+
+func newComment(body string) map[string]interface{} {
+	return map[string]interface{}{
+		"comment": map[string]interface{}{
+			"body": body,
+		},
+	}
+}
+
+func Test_APITokenScopes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Read Tokens", func(t *testing.T) {
+		t.Parallel()
+		h, _, _ := newServer()
+		session := register(t, h, "user@bookish.com", "bookish")
+		res := do(t, h, request{
+			method: http.MethodPost,
+			path:   "/api/articles",
+			token:  session,
+			body:   newArticle("Bookish Thoughts"),
+		})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+		read := createToken(t, h, session, domain.ScopeRead).Token.Token
+		for _, r := range []request{
+			{method: http.MethodGet, path: "/api/user"},
+			{method: http.MethodGet, path: "/api/articles"},
+			{method: http.MethodGet, path: "/api/articles/feed"},
+			{method: http.MethodGet, path: "/api/articles/bookish-thoughts"},
+			{method: http.MethodGet, path: "/api/articles/bookish-thoughts/comments"},
+			{method: http.MethodGet, path: "/api/profiles/bookish"},
+		} {
+			r.token = read
+			res := do(t, h, r)
+			assert.Equal(t, http.StatusOK, res.Code, "%v %v %v", r.method, r.path, res.Body.String())
+		}
+
+		for _, r := range []request{
+			{method: http.MethodPost, path: "/api/articles", body: newArticle("Bookish Thoughts Again")},
+			{method: http.MethodPut, path: "/api/articles/bookish-thoughts", body: newArticle("Bookish Thoughts Edited")},
+			{method: http.MethodDelete, path: "/api/articles/bookish-thoughts"},
+			{method: http.MethodPost, path: "/api/articles/bookish-thoughts/favorite"},
+			{method: http.MethodPost, path: "/api/articles/bookish-thoughts/comments", body: newComment("bookish")},
+		} {
+			r.token = read
+			res := do(t, h, r)
+			assert.Equal(t, http.StatusForbidden, res.Code,
+				"because %v %v needs a write scope", r.method, r.path)
+		}
+	})
+
+	t.Run("Write Tokens", func(t *testing.T) {
+		t.Parallel()
+		h, _, _ := newServer()
+		session := register(t, h, "user@prolific.com", "prolific")
+
+		articles := createToken(t, h, session, domain.ScopeWriteArticles).Token.Token
+		res := do(t, h, request{
+			method: http.MethodPost,
+			path:   "/api/articles",
+			token:  articles,
+			body:   newArticle("Prolific Thoughts"),
+		})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+		res = do(t, h, request{
+			method: http.MethodPost,
+			path:   "/api/articles/prolific-thoughts/comments",
+			token:  articles,
+			body:   newComment("prolific"),
+		})
+		assert.Equal(t, http.StatusForbidden, res.Code,
+			"because commenting needs the write:comments scope")
+		res = do(t, h, request{
+			method: http.MethodGet,
+			path:   "/api/articles",
+			token:  articles,
+		})
+		assert.Equal(t, http.StatusForbidden, res.Code,
+			"because writing doesn't include reading")
+
+		comments := createToken(t, h, session, domain.ScopeWriteComments).Token.Token
+		res = do(t, h, request{
+			method: http.MethodPost,
+			path:   "/api/articles/prolific-thoughts/comments",
+			token:  comments,
+			body:   newComment("prolific"),
+		})
+		assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		res = do(t, h, request{
+			method: http.MethodPut,
+			path:   "/api/articles/prolific-thoughts",
+			token:  comments,
+			body:   newArticle("Prolific Thoughts Edited"),
+		})
+		assert.Equal(t, http.StatusForbidden, res.Code,
+			"because editing articles needs the write:articles scope")
+	})
+
+	t.Run("Session Only Routes", func(t *testing.T) {
+		t.Parallel()
+		h, _, _ := newServer()
+		session := register(t, h, "user@cautious.com", "cautious")
+
+		all := createToken(t, h, session,
+			domain.ScopeRead,
+			domain.ScopeWriteArticles,
+			domain.ScopeWriteComments)
+		for _, r := range []request{
+			{method: http.MethodGet, path: "/api/user/tokens"},
+			{method: http.MethodPost, path: "/api/user/tokens", body: map[string]interface{}{
+				"token": map[string]interface{}{"name": "minted", "scopes": []string{"read"}},
+			}},
+			{method: http.MethodDelete, path: "/api/user/tokens/" + strconv.Itoa(all.Token.ID)},
+			{method: http.MethodPut, path: "/api/user", body: map[string]interface{}{
+				"user": map[string]interface{}{"email": "user@reckless.com"},
+			}},
+			{method: http.MethodDelete, path: "/api/user", body: map[string]interface{}{
+				"user": map[string]interface{}{"password": password},
+			}},
+			{method: http.MethodPost, path: "/api/user/2fa"},
+		} {
+			r.token = all.Token.Token
+			res := do(t, h, r)
+			assert.Equal(t, http.StatusForbidden, res.Code,
+				"because %v %v needs a session", r.method, r.path)
+		}
+
+		res := do(t, h, request{method: http.MethodGet, path: "/api/user", token: all.Token.Token})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var u userResponse
+		decode(t, res, &u)
+		assert.Equal(t, "user@cautious.com", u.User.Email,
+			"because the token can't change the user")
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		t.Parallel()
+		h, _, _ := newServer()
+		session := register(t, h, "user@trusted.com", "trusted")
+
+		for _, r := range []request{
+			{method: http.MethodPost, path: "/api/articles", body: newArticle("Trusted Thoughts")},
+			{method: http.MethodGet, path: "/api/articles/trusted-thoughts"},
+			{method: http.MethodPost, path: "/api/articles/trusted-thoughts/comments", body: newComment("trusted")},
+			{method: http.MethodPost, path: "/api/articles/trusted-thoughts/favorite"},
+			{method: http.MethodGet, path: "/api/user"},
+			{method: http.MethodGet, path: "/api/user/tokens"},
+			{method: http.MethodPut, path: "/api/user", body: map[string]interface{}{
+				"user": map[string]interface{}{"bio": "trusted"},
+			}},
+		} {
+			r.token = session
+			res := do(t, h, r)
+			assert.Equal(t, http.StatusOK, res.Code, "%v %v %v", r.method, r.path, res.Body.String())
+		}
+	})
+
+	t.Run("Revoked Tokens", func(t *testing.T) {
+		t.Parallel()
+		h, _, _ := newServer()
+		session := register(t, h, "user@fickle.com", "fickle")
+
+		tr := createToken(t, h, session, domain.ScopeRead)
+		res := do(t, h, request{
+			method: http.MethodDelete,
+			path:   "/api/user/tokens/" + strconv.Itoa(tr.Token.ID),
+			token:  session,
+		})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+		res = do(t, h, request{method: http.MethodGet, path: "/api/user", token: tr.Token.Token})
+		assert.Equal(t, http.StatusUnauthorized, res.Code)
+	})
+}
@@ -1,7 +1,6 @@
 package echohttp
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -21,34 +20,37 @@ type articlesHandler struct {
 	repo        domain.Repository
 	authed      echo.MiddlewareFunc
 	maybeAuthed echo.MiddlewareFunc
+	policy      domain.Policy
 }
 
 func newArticlesHandler(
 	repo domain.Repository,
 	authed echo.MiddlewareFunc,
 	maybeAuthed echo.MiddlewareFunc,
+	policy domain.Policy,
 ) *articlesHandler {
 	return &articlesHandler{
 		repo,
 		authed,
 		maybeAuthed,
+		policy,
 	}
 }
 
 func (h *articlesHandler) mapRoutes(g *echo.Group) {
-	g.GET("/articles", h.list, h.maybeAuthed)
-	g.GET("/articles/feed", h.feed, h.authed)
-	g.GET("/articles/:slug", h.article, h.maybeAuthed)
-	g.POST("/articles", h.create, h.authed)
-	g.PUT("/articles/:slug", h.update, h.authed)
-	g.DELETE("/articles/:slug", h.delete, h.authed)
+	g.GET("/articles", h.list, h.maybeAuthed, scoped(domain.ScopeRead))
+	g.GET("/articles/feed", h.feed, h.authed, scoped(domain.ScopeRead))
+	g.GET("/articles/:slug", h.article, h.maybeAuthed, scoped(domain.ScopeRead))
+	g.POST("/articles", h.create, h.authed, scoped(domain.ScopeWriteArticles))
+	g.PUT("/articles/:slug", h.update, h.authed, scoped(domain.ScopeWriteArticles))
+	g.DELETE("/articles/:slug", h.delete, h.authed, scoped(domain.ScopeWriteArticles))
 
-	g.GET("/articles/:slug/comments", h.commentList, h.maybeAuthed)
-	g.POST("/articles/:slug/comments", h.addComment, h.authed)
-	g.DELETE("/articles/:slug/comments/:id", h.removeComment, h.authed)
+	g.GET("/articles/:slug/comments", h.commentList, h.maybeAuthed, scoped(domain.ScopeRead))
+	g.POST("/articles/:slug/comments", h.addComment, h.authed, scoped(domain.ScopeWriteComments))
+	g.DELETE("/articles/:slug/comments/:id", h.removeComment, h.authed, scoped(domain.ScopeWriteComments))
 
-	g.POST("/articles/:slug/favorite", h.favorite, h.authed)
-	g.DELETE("/articles/:slug/favorite", h.unfavorite, h.authed)
+	g.POST("/articles/:slug/favorite", h.favorite, h.authed, scoped(domain.ScopeWriteArticles))
+	g.DELETE("/articles/:slug/favorite", h.unfavorite, h.authed, scoped(domain.ScopeWriteArticles))
 
 	g.GET("/tags", h.tags)
 }
@@ -68,8 +70,11 @@ func (h *articlesHandler) list(ctx echo.Context) error {
 	}
 
 	lc := domain.ListCriteria{
-		Tag:   ctx.QueryParam("tag"),
-		Limit: 20,
+		Tag: ctx.QueryParam("tag"),
+	}
+	lc.Limit, lc.Offset = page(ctx)
+	if u != nil {
+		lc.ViewerEmail = u.Email
 	}
 
 	a := ctx.QueryParam("author")
@@ -84,15 +89,6 @@ func (h *articlesHandler) list(ctx echo.Context) error {
 			lc.FavoritedByUserEmail = fe.Email
 		}
 	}
-	l := ctx.QueryParam("limit")
-	if li, err := strconv.Atoi(l); err == nil {
-		lc.Limit = li
-	}
-	o := ctx.QueryParam("offset")
-	if oi, err := strconv.Atoi(o); err == nil {
-		lc.Offset = oi
-	}
-
 	// get all articles
 	al, err := h.repo.LatestArticlesByCriteria(ctx.Request().Context(), lc)
 	if err != nil {
@@ -112,18 +108,10 @@ func (h *articlesHandler) feed(ctx echo.Context) error {
 	}
 
 	lc := domain.ListCriteria{
-		Limit:        20,
 		AuthorEmails: u.FollowingEmails(),
+		ViewerEmail:  u.Email,
 	}
-	l := ctx.QueryParam("limit")
-	if li, err := strconv.Atoi(l); err == nil {
-		lc.Limit = li
-	}
-	o := ctx.QueryParam("offset")
-	if oi, err := strconv.Atoi(o); err == nil {
-		lc.Offset = oi
-	}
-
+	lc.Limit, lc.Offset = page(ctx)
 	// Get the feed articles
 	al, err := h.repo.LatestArticlesByCriteria(ctx.Request().Context(), lc)
 	if err != nil {
@@ -142,11 +130,20 @@ func (h *articlesHandler) article(ctx echo.Context) error {
 		u, _ = h.repo.GetUserByEmail(ctx.Request().Context(), em)
 	}
 
-	// get the article
+	// get the article, links can use the id instead since the slug changes with the title
 	ar, err := h.repo.GetArticleBySlug(ctx.Request().Context(), ctx.Param("slug"))
+	if err == domain.ErrArticleNotFound {
+		ar, err = h.repo.GetArticleByID(ctx.Request().Context(), ctx.Param("slug"))
+	}
 	if err != nil {
+		if err == domain.ErrArticleNotFound {
+			return echo.ErrNotFound
+		}
 		return err
 	}
+	if ar.Hidden && (u == nil || !h.policy.CanViewHidden(&u.User)) {
+		return echo.ErrNotFound
+	}
 
 	return ctx.JSON(
 		http.StatusOK,
@@ -160,6 +157,10 @@ func (h *articlesHandler) create(ctx echo.Context) error {
 		return identityNotOk
 	}
 
+	if !h.policy.CanPublish(&u.User) {
+		return forbidden
+	}
+
 	article, err := serialization.CreateToArticle(ctx.Bind, u)
 	if err != nil {
 		return echo.NewHTTPError(
@@ -199,14 +200,17 @@ func (h *articlesHandler) update(ctx echo.Context) error {
 	updated, err := h.repo.UpdateArticleBySlug(ctx.Request().Context(),
 		ctx.Param("slug"),
 		func(a *domain.Article) (*domain.Article, error) {
-			if a.AuthorEmail != em {
-				return nil, errors.New("articles can only be updated by their author")
+			if !h.policy.CanEditArticle(&u.User, a) {
+				return nil, domain.ErrForbidden
 			}
 
 			delta(a)
 			return a.Validate()
 		})
 	if err != nil {
+		if err == domain.ErrForbidden {
+			return forbidden
+		}
 		return err
 	}
 
@@ -217,7 +221,8 @@ func (h *articlesHandler) update(ctx echo.Context) error {
 
 func (h *articlesHandler) delete(ctx echo.Context) error {
 	em, _, ok := ctx.(*userContext).identity()
-	if !ok {
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
 		return identityNotOk
 	}
 
@@ -225,8 +230,8 @@ func (h *articlesHandler) delete(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
-	if ar.AuthorEmail != em {
-		return errors.New("articles can only be deleted by their author")
+	if !h.policy.CanDeleteArticle(&u.User, &ar.Article) {
+		return forbidden
 	}
 
 	if err = h.repo.DeleteArticle(ctx.Request().Context(), &ar.Article); err != nil {
@@ -247,6 +252,18 @@ func (h *articlesHandler) commentList(ctx echo.Context) error {
 	if err != nil {
 		return err
 	}
+	if ar.Hidden && (u == nil || !h.policy.CanViewHidden(&u.User)) {
+		return echo.ErrNotFound
+	}
+	if u != nil {
+		cs := make([]domain.Comment, 0, len(ar.Comments))
+		for _, c := range ar.Comments {
+			if !u.Ignores(c.AuthorEmail) {
+				cs = append(cs, c)
+			}
+		}
+		ar.Comments = cs
+	}
 
 	return ctx.JSON(
 		http.StatusOK,
@@ -264,11 +281,25 @@ func (h *articlesHandler) addComment(ctx echo.Context) error {
 		return identityNotOk
 	}
 
+	if !h.policy.CanPublish(&u.User) {
+		return forbidden
+	}
+
 	body, err := serialization.CommentToBody(ctx.Bind)
 	if err != nil {
 		return echo.ErrBadRequest
 	}
 
+	// Authors can keep people they've blocked out of their comments
+	ar, err := h.repo.GetArticleBySlug(ctx.Request().Context(), ctx.Param("slug"))
+	if err != nil {
+		return err
+	}
+	if au, err := h.repo.GetUserByEmail(ctx.Request().Context(), ar.AuthorEmail); err == nil &&
+		au.IsBlocking(em) {
+		return forbidden
+	}
+
 	// Make the thing
 	newc, err := h.repo.UpdateCommentsBySlug(ctx.Request().Context(),
 		ctx.Param("slug"),
@@ -286,23 +317,29 @@ func (h *articlesHandler) addComment(ctx echo.Context) error {
 
 func (h *articlesHandler) removeComment(ctx echo.Context) error {
 	em, _, ok := ctx.(*userContext).identity()
-	if !ok {
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
 		return identityNotOk
 	}
 
+	// Comments can be deleted by their uid as well as their numeric id
 	c := ctx.Param("id")
-	cid, err := strconv.Atoi(c)
-	if err != nil {
-		return echo.ErrBadRequest
-	}
+	cid, nerr := strconv.Atoi(c)
 
 	// Delete the thing
 	_, err = h.repo.UpdateCommentsBySlug(ctx.Request().Context(),
 		ctx.Param("slug"),
 		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			if nerr != nil {
+				fc := a.FindCommentByUID(c)
+				if fc == nil {
+					return nil, echo.ErrBadRequest
+				}
+				cid = fc.ID
+			}
 			for _, c := range a.Comments {
-				if c.ID == cid && c.AuthorEmail != em {
-					return nil, errors.New("comments can only be deleted by their author")
+				if c.ID == cid && !h.policy.CanDeleteComment(&u.User, &c) {
+					return nil, domain.ErrForbidden
 				}
 			}
 
@@ -310,6 +347,9 @@ func (h *articlesHandler) removeComment(ctx echo.Context) error {
 			return a, nil
 		})
 	if err != nil {
+		if err == domain.ErrForbidden {
+			return forbidden
+		}
 		return err
 	}
 
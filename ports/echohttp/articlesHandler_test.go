@@ -0,0 +1,59 @@
+package echohttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArticlesPaging(t *testing.T) {
+	t.Parallel()
+	h, _, _ := newServer()
+
+	author := register(t, h, "author@paging.com", "author")
+	reader := register(t, h, "reader@paging.com", "reader")
+	for i := 0; i < 3; i++ {
+		res := do(t, h, request{
+			method: http.MethodPost,
+			path:   "/api/articles",
+			token:  author,
+			body: map[string]interface{}{
+				"article": map[string]string{
+					"title":       fmt.Sprintf("Paging %d", i),
+					"description": "paged",
+					"body":        "paged",
+				},
+			},
+		})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	}
+	res := do(t, h, request{method: http.MethodPost, path: "/api/profiles/author/follow", token: reader})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	for _, c := range []struct {
+		query string
+		count int
+	}{
+		{"", 3},
+		{"?limit=2", 2},
+		{"?limit=2&offset=2", 1},
+		{"?limit=-1", 1},
+		{"?limit=0", 1},
+		{"?limit=9223372036854775807", 3},
+		{"?offset=-5", 3},
+	} {
+		for _, p := range []string{"/api/articles", "/api/articles/feed"} {
+			res := do(t, h, request{method: http.MethodGet, path: p + c.query, token: reader})
+			require.Equal(t, http.StatusOK, res.Code, p+c.query)
+
+			var body struct {
+				Articles []interface{} `json:"articles"`
+			}
+			decode(t, res, &body)
+			assert.Len(t, body.Articles, c.count, p+c.query)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package echohttp
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
+
+	"github.com/brycekbargar/realworld-backend/domain"
 )
 
 // identityNotOk is the common message for when the identity method returns "not ok".
@@ -12,6 +15,46 @@ var identityNotOk = echo.NewHTTPError(
 	http.StatusUnauthorized,
 	"email claim was not found in provide jwt token")
 
+// forbidden is the common message for when the policy doesn't allow the user to perform an action.
+var forbidden = echo.NewHTTPError(
+	http.StatusForbidden,
+	"user is not allowed to perform this action")
+
+// subjectAuth resolves the id a session jwt was issued to into the user's current email,
+// emails can change while the session is active so the jwt itself never carries it.
+// Sessions for users that no longer exist (and older jwts without an id) don't identify anyone.
+func subjectAuth(repo domain.Repository, jwtAuth echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return jwtAuth(func(ctx echo.Context) error {
+			jt, ok := ctx.Get("user").(*jwt.Token)
+			if !ok {
+				return next(ctx)
+			}
+			claims, ok := jt.Claims.(jwt.MapClaims)
+			if !ok {
+				return next(ctx)
+			}
+			// Single-use tokens emailed to users are identified by the email they were sent to.
+			if _, ok := claims["purpose"]; ok {
+				return next(ctx)
+			}
+
+			delete(claims, "email")
+			if sub, ok := claims["sub"].(string); ok && sub != "" {
+				u, err := repo.GetUserByID(ctx.Request().Context(), sub)
+				if err != nil && err != domain.ErrUserNotFound {
+					return err
+				}
+				if err == nil {
+					claims["email"] = u.Email
+				}
+			}
+
+			return next(ctx)
+		})
+	}
+}
+
 // userContext is the echo.Context + the currently logged in user based on the jwt token.
 // If the request is made anonymously email will be nil.
 type userContext struct {
@@ -27,11 +70,54 @@ func (uc *userContext) identity() (string, *jwt.Token, bool) {
 		return "", nil, false
 	}
 
-	jt := ju.(*jwt.Token)
-	email := jt.Claims.(jwt.MapClaims)["email"].(string)
+	jt, ok := ju.(*jwt.Token)
+	if !ok {
+		return "", nil, false
+	}
+	claims, ok := jt.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", jt, false
+	}
+	// Single-use tokens emailed to users only allow their one purpose, they aren't logins.
+	if _, ok := claims["purpose"]; ok {
+		return "", jt, false
+	}
+	// API tokens can only be used for routes that check their scopes.
+	if _, ok := claims["scopes"]; ok && uc.Get(scopedKey) == nil {
+		return "", jt, false
+	}
+
+	email, _ := claims["email"].(string)
 	if len(email) == 0 {
 		return "", jt, false
 	}
 
 	return email, jt, true
 }
+
+// maxPageSize is the most items a single page of a list can have.
+const maxPageSize = 100
+
+// page reads the limit and offset of a list from the query string.
+// The limit is kept between 1 and maxPageSize and the offset is never negative,
+// so adapters never see a page size that would panic or allocate unboundedly.
+func page(ctx echo.Context) (int, int) {
+	limit, offset := 20, 0
+	if li, err := strconv.Atoi(ctx.QueryParam("limit")); err == nil {
+		limit = li
+	}
+	if oi, err := strconv.Atoi(ctx.QueryParam("offset")); err == nil {
+		offset = oi
+	}
+
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
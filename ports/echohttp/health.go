@@ -0,0 +1,117 @@
+package echohttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+)
+
+// Version is the release being served, it's meant to be set when building with
+// -ldflags "-X github.com/brycekbargar/realworld-backend/ports/echohttp.Version=..."
+var Version = "dev"
+
+// probes are the routes polled by orchestrators,
+// they're not authenticated and are left out of logs and traces because there are so many of them.
+var probes = map[string]interface{}{
+	"/healthz": nil,
+	"/readyz":  nil,
+	"/version": nil,
+}
+
+func isProbe(c echo.Context) bool {
+	_, ok := probes[c.Path()]
+	return ok
+}
+
+// readyTimeout is how long the repository has to answer a readiness check.
+const readyTimeout = 2 * time.Second
+
+type healthHandler struct {
+	repo domain.Repository
+	log  *slog.Logger
+}
+
+func newHealthHandler(repo domain.Repository, log *slog.Logger) *healthHandler {
+	return &healthHandler{repo, log}
+}
+
+func (h *healthHandler) mapRoutes(s *echo.Echo) {
+	s.GET("/healthz", h.healthz)
+	s.GET("/readyz", h.readyz)
+	s.GET("/version", h.version)
+}
+
+// healthz is always ok as long as the server is up to answer it.
+func (h *healthHandler) healthz(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// readyz checks the repository can be reached and is migrated.
+// Why it isn't ready is logged instead of being returned so the database details stay private.
+func (h *healthHandler) readyz(ctx echo.Context) error {
+	c, cancel := context.WithTimeout(ctx.Request().Context(), readyTimeout)
+	defer cancel()
+
+	res := readiness{"ready", map[string]string{
+		"repository": "ok",
+		"migrations": "ok",
+	}}
+	status := http.StatusOK
+
+	if err := h.repo.Ping(c); err != nil {
+		h.log.Warn("readiness check failed", slog.Any("err", err))
+		res.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+		if errors.Is(err, domain.ErrMigrationsPending) {
+			res.Checks["migrations"] = "pending"
+		} else {
+			res.Checks["repository"] = "unreachable"
+			res.Checks["migrations"] = "unknown"
+		}
+	}
+
+	return ctx.JSON(status, res)
+}
+
+type buildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	BuiltAt   string `json:"builtAt,omitempty"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"goVersion"`
+}
+
+// version reports the release and the commit it was built from (when it was built from a checkout).
+func (h *healthHandler) version(ctx echo.Context) error {
+	res := buildInfo{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				res.Revision = s.Value
+			case "vcs.time":
+				res.BuiltAt = s.Value
+			case "vcs.modified":
+				res.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,96 @@
+package echohttp_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/echohttp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unready is a repository that can't be pinged.
+type unready struct {
+	domain.Repository
+	err error
+}
+
+func (r unready) Ping(context.Context) error {
+	return r.err
+}
+
+func TestReadyz(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		body   map[string]interface{}
+	}{
+		{"Ready", nil, http.StatusOK, map[string]interface{}{
+			"status": "ready",
+			"checks": map[string]interface{}{"repository": "ok", "migrations": "ok"},
+		}},
+		{"Migrations Pending", domain.ErrMigrationsPending, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "unavailable",
+			"checks": map[string]interface{}{"repository": "ok", "migrations": "pending"},
+		}},
+		{"Unreachable", errors.New("connection refused to db.internal:5432"), http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "unavailable",
+			"checks": map[string]interface{}{"repository": "unreachable", "migrations": "unknown"},
+		}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			var repo domain.Repository = inmemory.NewInstance()
+			if c.err != nil {
+				repo = unready{repo, c.err}
+			}
+			log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+			h := echohttp.NewServer(jc, repo, nil, outbox.NewInstance(), nil, nil, nil, log)
+
+			res := do(t, h, request{method: http.MethodGet, path: "/readyz"})
+			assert.Equal(t, c.status, res.Code)
+			var body map[string]interface{}
+			decode(t, res, &body)
+			assert.Equal(t, c.body, body)
+			assert.NotContains(t, res.Body.String(), "db.internal", "because database details stay private")
+		})
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	t.Parallel()
+	h, _, _ := newServer()
+
+	res := do(t, h, request{method: http.MethodGet, path: "/healthz"})
+	require.Equal(t, http.StatusOK, res.Code)
+	var body map[string]string
+	decode(t, res, &body)
+	assert.Equal(t, "ok", body["status"])
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+	h, _, _ := newServer()
+
+	res := do(t, h, request{method: http.MethodGet, path: "/version"})
+	require.Equal(t, http.StatusOK, res.Code)
+	var body map[string]interface{}
+	decode(t, res, &body)
+	assert.Equal(t, echohttp.Version, body["version"])
+	assert.NotEmpty(t, body["goVersion"])
+}
@@ -0,0 +1,151 @@
+package echohttp
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// loggerKey is set on the context to the logger for the request.
+const loggerKey = "logger"
+
+// redacted replaces the values of secrets in the logs.
+const redacted = "[REDACTED]"
+
+// secrets are the (lowercase) names of values that are never logged.
+// Names containing password, secret or token are also never logged.
+var secrets = map[string]interface{}{
+	"authorization": nil,
+	"cookie":        nil,
+	"code":          nil,
+	"state":         nil,
+	"otp":           nil,
+}
+
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := secrets[name]; ok {
+		return true
+	}
+	return strings.Contains(name, "password") ||
+		strings.Contains(name, "secret") ||
+		strings.Contains(name, "token")
+}
+
+// redact is a slog.HandlerOptions.ReplaceAttr that replaces the values of secrets.
+// It redacts by name so it applies to everything logged, not just requests.
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if isSecret(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+// NewLogger creates a logger writing JSON lines at or above the level with secrets redacted.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}))
+}
+
+// logger gets the logger for the request,
+// everything it logs has the request id (and trace id when it's traced).
+func logger(c echo.Context) *slog.Logger {
+	if l, ok := c.Get(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// logged logs every request (except the probes) once it's been handled.
+// Request bodies are never logged and query parameters are redacted like everything else
+// so routes taking passwords and single-use codes are safe to log.
+func logged(l *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isProbe(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			req := c.Request()
+
+			rl := l.With(slog.String("request_id", c.Response().Header().Get(echo.HeaderXRequestID)))
+			if sc := trace.SpanContextFromContext(req.Context()); sc.IsValid() {
+				rl = rl.With(slog.String("trace_id", sc.TraceID().String()))
+			}
+			c.Set(loggerKey, rl)
+
+			err := next(c)
+			if err != nil {
+				// The error has to be handled here to know what status it turns into
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			query := make([]interface{}, 0, len(req.URL.Query()))
+			for k, vs := range req.URL.Query() {
+				query = append(query, slog.String(k, strings.Join(vs, ",")))
+			}
+			status := c.Response().Status
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("route", route),
+				slog.String("path", req.URL.Path),
+				slog.Group("query", query...),
+				slog.Int("status", status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int64("bytes_in", req.ContentLength),
+				slog.Int64("bytes_out", c.Response().Size),
+				slog.String("remote_ip", c.RealIP()),
+				slog.String("user_agent", req.UserAgent()),
+			}
+			if user, auth := identified(c); user != "" {
+				attrs = append(attrs, slog.String("user", user), slog.String("auth", auth))
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			rl.LogAttrs(req.Context(), level, "request", attrs...)
+			return nil
+		}
+	}
+}
+
+// identified gets who made the request and how they authenticated (without checking what they're allowed to do).
+func identified(c echo.Context) (string, string) {
+	jt, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return "", ""
+	}
+	claims, ok := jt.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ""
+	}
+
+	email, _ := claims["email"].(string)
+	switch {
+	case claims["purpose"] != nil:
+		return email, "emailed_token"
+	case claims["scopes"] != nil:
+		return email, "api_token"
+	default:
+		return email, "session"
+	}
+}
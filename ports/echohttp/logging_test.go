@@ -0,0 +1,100 @@
+package echohttp_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/ports/echohttp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines reads every JSON line the logger wrote.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	lines := make([]map[string]interface{}, 0)
+	s := bufio.NewScanner(buf)
+	for s.Scan() {
+		var l map[string]interface{}
+		require.NoError(t, json.Unmarshal(s.Bytes(), &l), s.Text())
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func TestLogging(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := echohttp.NewLogger(&buf, slog.LevelDebug)
+	h := echohttp.NewServer(jc, inmemory.NewInstance(), nil, outbox.NewInstance(), nil, nil, nil, log)
+
+	for _, p := range []string{"/healthz", "/readyz", "/version"} {
+		res := do(t, h, request{method: http.MethodGet, path: p})
+		require.Equal(t, http.StatusOK, res.Code, p)
+	}
+	assert.Empty(t, buf.String(), "because probes aren't logged")
+
+	res := do(t, h, request{
+		method: http.MethodGet,
+		path:   "/api/tags?password=hunter2&token=t0k3n&code=123456&state=st4te&otp=654321&newPassword=hunter3&page=2",
+	})
+	require.Equal(t, http.StatusOK, res.Code)
+	register(t, h, "user@logged.com", "logged")
+
+	lines := logLines(t, &buf)
+	require.NotEmpty(t, lines)
+	for _, l := range lines {
+		assert.NotEmpty(t, l["request_id"], "every line has the request id: %v", l)
+	}
+
+	tags := lines[0]
+	assert.Equal(t, "request", tags["msg"])
+	assert.Equal(t, "/api/tags", tags["route"])
+	assert.Equal(t, res.Header().Get("X-Request-ID"), tags["request_id"])
+	assert.Equal(t, map[string]interface{}{
+		"password":    "[REDACTED]",
+		"token":       "[REDACTED]",
+		"code":        "[REDACTED]",
+		"state":       "[REDACTED]",
+		"otp":         "[REDACTED]",
+		"newPassword": "[REDACTED]",
+		"page":        "2",
+	}, tags["query"])
+
+	assert.NotContains(t, buf.String(), password, "because request bodies aren't logged")
+	for _, secret := range []string{"hunter2", "t0k3n", "123456", "st4te", "654321", "hunter3"} {
+		for _, l := range lines {
+			raw, err := json.Marshal(l)
+			require.NoError(t, err)
+			assert.NotContains(t, string(raw), secret)
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	log := echohttp.NewLogger(&buf, slog.LevelInfo)
+	log.Debug("too verbose")
+	log.Info("configured",
+		slog.String("smtp_password", "hunter2"),
+		slog.String("Authorization", "Token t0k3n"),
+		slog.Group("oidc", slog.String("client_secret", "s3cret"), slog.String("issuer", "https://issuer.test")))
+
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1, "because debug lines are below the level")
+	assert.Equal(t, "[REDACTED]", lines[0]["smtp_password"])
+	assert.Equal(t, "[REDACTED]", lines[0]["Authorization"])
+	assert.Equal(t, map[string]interface{}{
+		"client_secret": "[REDACTED]",
+		"issuer":        "https://issuer.test",
+	}, lines[0]["oidc"])
+}
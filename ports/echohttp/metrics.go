@@ -0,0 +1,49 @@
+package echohttp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// instrument times every request and counts their statuses by route with collectors registered with the registry.
+func instrument(reg prometheus.Registerer) echo.MiddlewareFunc {
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "conduit_http_request_duration_seconds",
+		Help:    "How long requests took, by method and route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	statuses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "conduit_http_requests_total",
+		Help: "How many requests there have been, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	reg.MustRegister(durations, statuses)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			if err := next(c); err != nil {
+				// The error has to be handled here to know what status it turns into
+				c.Error(err)
+			}
+
+			// Paths are the route with the :params in them so there are a fixed number of them
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			method := c.Request().Method
+			durations.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+			statuses.WithLabelValues(method, route, strconv.Itoa(c.Response().Status)).Inc()
+			return nil
+		}
+	}
+}
+
+// metricsHandler serves everything in the registry for Prometheus to scrape.
+func metricsHandler(reg *prometheus.Registry) echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+}
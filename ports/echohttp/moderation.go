@@ -0,0 +1,202 @@
+package echohttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/labstack/echo/v4"
+)
+
+// errUnaudited is returned by the moderation actions when they were taken
+// but couldn't be recorded in the audit trail afterwards.
+var errUnaudited = errors.New("the action was taken but not recorded in the audit trail")
+
+// moderation performs the actions available to moderators,
+// checking them against the policy and recording them in the audit trail.
+type moderation struct {
+	repo   domain.Repository
+	policy domain.Policy
+}
+
+// moderator gets the current user, only returning them when they are at least a moderator.
+func (m *moderation) moderator(ctx echo.Context) (*domain.User, error) {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := m.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return nil, identityNotOk
+	}
+
+	if u.Banned || !u.HasRole(domain.RoleModerator) {
+		return nil, forbidden
+	}
+
+	return &u.User, nil
+}
+
+// setArticleHidden hides the article for ActionHide and unhides it otherwise.
+func (m *moderation) setArticleHidden(ctx context.Context, mod *domain.User, e *domain.AuditEntry, slug string) (*domain.AuthoredArticle, error) {
+	updated, err := m.repo.UpdateArticleBySlug(ctx,
+		slug,
+		func(a *domain.Article) (*domain.Article, error) {
+			if !m.policy.CanHideArticle(mod, a) {
+				return nil, domain.ErrForbidden
+			}
+
+			a.Hidden = e.Action == domain.ActionHide
+			return a, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	e.ArticleSlug = updated.Slug
+	return updated, m.record(ctx, e)
+}
+
+// setCommentHidden hides the comment for ActionHide and unhides it otherwise.
+func (m *moderation) setCommentHidden(ctx context.Context, mod *domain.User, e *domain.AuditEntry, slug string, id int) error {
+	_, err := m.repo.UpdateCommentsBySlug(ctx,
+		slug,
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			c := a.FindComment(id)
+			if c == nil {
+				return nil, domain.ErrCommentNotFound
+			}
+			if !m.policy.CanHideComment(mod, c) {
+				return nil, domain.ErrForbidden
+			}
+
+			a.HideComment(id, e.Action == domain.ActionHide)
+			return a, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	e.ArticleSlug = slug
+	e.CommentID = id
+	return m.record(ctx, e)
+}
+
+// deleteArticle deletes the article.
+func (m *moderation) deleteArticle(ctx context.Context, mod *domain.User, e *domain.AuditEntry, slug string) error {
+	ar, err := m.repo.GetArticleBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if !m.policy.CanDeleteArticle(mod, &ar.Article) {
+		return domain.ErrForbidden
+	}
+
+	if err = m.repo.DeleteArticle(ctx, &ar.Article); err != nil {
+		return err
+	}
+
+	e.ArticleSlug = ar.Slug
+	return m.record(ctx, e)
+}
+
+// deleteComment deletes the comment from the article.
+func (m *moderation) deleteComment(ctx context.Context, mod *domain.User, e *domain.AuditEntry, slug string, id int) error {
+	_, err := m.repo.UpdateCommentsBySlug(ctx,
+		slug,
+		func(a *domain.CommentedArticle) (*domain.CommentedArticle, error) {
+			c := a.FindComment(id)
+			if c == nil {
+				return nil, domain.ErrCommentNotFound
+			}
+			if !m.policy.CanDeleteComment(mod, c) {
+				return nil, domain.ErrForbidden
+			}
+
+			a.RemoveComment(id)
+			return a, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	e.ArticleSlug = slug
+	e.CommentID = id
+	return m.record(ctx, e)
+}
+
+// setSuspended bans the user for ActionSuspend and lifts the ban otherwise.
+func (m *moderation) setSuspended(ctx context.Context, mod *domain.User, e *domain.AuditEntry, email string) (*domain.User, error) {
+	found, err := m.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if !m.policy.CanBanUser(mod, &found.User) {
+		return nil, domain.ErrForbidden
+	}
+
+	updated, err := m.repo.UpdateUserByEmail(ctx,
+		email,
+		func(u *domain.User) (*domain.User, error) {
+			u.Banned = e.Action == domain.ActionSuspend
+			return u, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	e.UserEmail = updated.Email
+	return updated, m.record(ctx, e)
+}
+
+// changeRole gives the user the new role.
+func (m *moderation) changeRole(ctx context.Context, mod *domain.User, e *domain.AuditEntry, email string, role domain.Role) (*domain.User, error) {
+	found, err := m.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if !m.policy.CanChangeRole(mod, &found.User, role) {
+		return nil, domain.ErrForbidden
+	}
+
+	updated, err := m.repo.UpdateUserByEmail(ctx,
+		email,
+		func(u *domain.User) (*domain.User, error) {
+			u.Role = role
+			return u.Validate()
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	e.UserEmail = updated.Email
+	e.Note = string(role)
+	return updated, m.record(ctx, e)
+}
+
+// record adds the action that was just taken to the audit trail.
+func (m *moderation) record(ctx context.Context, e *domain.AuditEntry) error {
+	if _, err := m.repo.CreateAuditEntry(ctx, e); err != nil {
+		return fmt.Errorf("%w: %v", errUnaudited, err)
+	}
+	return nil
+}
+
+// moderationError converts the errors from moderation actions into the matching http errors.
+func moderationError(err error) error {
+	switch err {
+	case domain.ErrForbidden:
+		return forbidden
+	case domain.ErrArticleNotFound,
+		domain.ErrCommentNotFound,
+		domain.ErrUserNotFound,
+		domain.ErrReportNotFound:
+		return echo.NewHTTPError(
+			http.StatusNotFound,
+			err.Error())
+	case domain.ErrReportResolved, domain.ErrInvalidAction:
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+package echohttp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports"
+)
+
+// oidcLoginCookie holds the state of a login between redirecting to the provider and its callback.
+const oidcLoginCookie = "oidc_login"
+
+// oidcLoginTTL is how long users have to login with the provider.
+const oidcLoginTTL = 10 * time.Minute
+
+type oidcHandler struct {
+	repo      domain.Repository
+	providers map[string]domain.IdentityProvider
+	jc        ports.JWTConfig
+}
+
+func newOIDCHandler(
+	repo domain.Repository,
+	providers []domain.IdentityProvider,
+	jc ports.JWTConfig,
+) *oidcHandler {
+	byName := make(map[string]domain.IdentityProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
+	return &oidcHandler{
+		repo,
+		byName,
+		jc,
+	}
+}
+
+func (h *oidcHandler) mapRoutes(g *echo.Group) {
+	g.GET("/users/oidc/:provider", h.start)
+	g.GET("/users/oidc/:provider/callback", h.callback)
+}
+
+// start redirects to the provider with a new state, nonce and PKCE verifier,
+// they're kept in a signed cookie to be checked in the callback.
+func (h *oidcHandler) start(ctx echo.Context) error {
+	p, ok := h.providers[ctx.Param("provider")]
+	if !ok {
+		return echo.ErrNotFound
+	}
+
+	state, nonce, verifier := randomToken(), randomToken(), randomToken()
+
+	token := jwt.New(h.jc.Method)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["purpose"] = "oidc-login"
+	claims["provider"] = p.Name()
+	claims["state"] = state
+	claims["nonce"] = nonce
+	claims["verifier"] = verifier
+	claims["exp"] = time.Now().Add(oidcLoginTTL).Unix()
+	signed, err := token.SignedString(h.jc.Key)
+	if err != nil {
+		return err
+	}
+
+	ctx.SetCookie(h.cookie(ctx, signed, oidcLoginTTL))
+	return ctx.Redirect(http.StatusFound, p.AuthCodeURL(state, nonce, verifier))
+}
+
+func (h *oidcHandler) cookie(ctx echo.Context, value string, ttl time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    value,
+		Path:     "/api/users/oidc/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   ctx.Scheme() == "https",
+		// Lax so the cookie comes back with the provider's redirect
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// loginState checks the cookie from start is signed, unexpired and for the provider.
+// It returns the state, nonce and PKCE verifier of the login.
+func (h *oidcHandler) loginState(ctx echo.Context, provider string) (string, string, string, bool) {
+	c, err := ctx.Cookie(oidcLoginCookie)
+	if err != nil {
+		return "", "", "", false
+	}
+
+	token, err := jwt.Parse(c.Value, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != h.jc.Method.Name {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return h.jc.Key, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", "", false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", "", false
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != "oidc-login" {
+		return "", "", "", false
+	}
+	if p, _ := claims["provider"].(string); p != provider {
+		return "", "", "", false
+	}
+
+	state, _ := claims["state"].(string)
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["verifier"].(string)
+	return state, nonce, verifier, state != "" && nonce != "" && verifier != ""
+}
+
+// callback finishes the login after the provider redirects back,
+// the user is found by their linked identity, linked by their verified email or created.
+func (h *oidcHandler) callback(ctx echo.Context) error {
+	p, ok := h.providers[ctx.Param("provider")]
+	if !ok {
+		return echo.ErrNotFound
+	}
+
+	// The login can only be attempted once
+	ctx.SetCookie(h.cookie(ctx, "", -time.Second))
+
+	if e := ctx.QueryParam("error"); e != "" {
+		return echo.NewHTTPError(
+			http.StatusUnauthorized,
+			fmt.Sprintf("%v login failed: %v", p.Name(), e))
+	}
+
+	state, nonce, verifier, ok := h.loginState(ctx, p.Name())
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(ctx.QueryParam("state"))) != 1 {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			"login has expired or was started somewhere else")
+	}
+
+	id, err := p.Exchange(ctx.Request().Context(), ctx.QueryParam("code"), verifier, nonce)
+	if err != nil {
+		logger(ctx).Warn("exchanging the oidc code failed", slog.String("provider", p.Name()), slog.Any("err", err))
+		return echo.NewHTTPError(
+			http.StatusUnauthorized,
+			fmt.Sprintf("%v login failed", p.Name()))
+	}
+
+	u, err := h.userFor(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return loggedIn(ctx, h.jc, u)
+}
+
+func (h *oidcHandler) userFor(ctx echo.Context, id *domain.ExternalIdentity) (*domain.User, error) {
+	linked, err := h.repo.GetUserByIdentity(ctx.Request().Context(), id.Provider, id.Subject)
+	if err != domain.ErrUserNotFound {
+		return linked, err
+	}
+
+	existing, err := h.repo.GetUserByEmail(ctx.Request().Context(), id.Email)
+	if err == nil {
+		// Otherwise whoever controls the unverified side could take over the account
+		if !id.EmailVerified || !existing.Verified {
+			return nil, echo.NewHTTPError(
+				http.StatusConflict,
+				"a user with this email already exists, both the provider and the user need to have verified it before they can be linked")
+		}
+
+		if err := h.repo.LinkIdentity(ctx.Request().Context(), existing.Email, id); err != nil {
+			return nil, err
+		}
+		return &existing.User, nil
+	}
+	if err != domain.ErrUserNotFound {
+		return nil, err
+	}
+
+	u, err := domain.NewUserFromIdentity(id)
+	if err != nil {
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+
+	// Usernames from the provider can be taken, try adding a number
+	base := u.Username
+	created, err := h.repo.CreateUser(ctx.Request().Context(), u)
+	for n := 2; err == domain.ErrDuplicateUser && n <= 20; n++ {
+		u.Username = fmt.Sprintf("%v%v", base, n)
+		created, err = h.repo.CreateUser(ctx.Request().Context(), u)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repo.LinkIdentity(ctx.Request().Context(), created.Email, id); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// randomToken is an unguessable url safe value.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
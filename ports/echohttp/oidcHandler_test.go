@@ -0,0 +1,201 @@
+package echohttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/oidc"
+	"github.com/brycekbargar/realworld-backend/adapters/oidc/oidctest"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const callbackURL = "http://conduit.test/api/users/oidc/test/callback"
+
+// newOIDCServer creates a server that users can login to with a test provider.
+func newOIDCServer(t *testing.T) (*oidctest.Server, http.Handler, domain.Repository, *outbox.Outbox) {
+	s := oidctest.NewServer("conduit", "conduit secret")
+	t.Cleanup(s.Close)
+
+	p, err := oidc.NewInstance(context.Background(), oidc.Config{
+		Name:         "test",
+		Issuer:       s.URL,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  callbackURL,
+	})
+	require.NoError(t, err)
+
+	h, repo, ob := newServer(p)
+	return s, h, repo, ob
+}
+
+// startLogin starts a login and follows the provider's redirect,
+// it returns the login cookie and the callback the provider redirected back to.
+func startLogin(t *testing.T, h http.Handler) (*http.Cookie, *url.URL) {
+	res := do(t, h, request{method: http.MethodGet, path: "/api/users/oidc/test"})
+	require.Equal(t, http.StatusFound, res.Code, res.Body.String())
+
+	cookies := res.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	pres, err := client.Get(res.Header().Get("Location"))
+	require.NoError(t, err)
+	pres.Body.Close()
+	require.Equal(t, http.StatusFound, pres.StatusCode)
+
+	cb, err := url.Parse(pres.Header.Get("Location"))
+	require.NoError(t, err)
+	return cookies[0], cb
+}
+
+// finishLogin sends the provider's redirect back to the callback.
+func finishLogin(t *testing.T, h http.Handler, cookie *http.Cookie, cb *url.URL) *httptest.ResponseRecorder {
+	return do(t, h, request{
+		method:  http.MethodGet,
+		path:    cb.RequestURI(),
+		cookies: []*http.Cookie{cookie},
+	})
+}
+
+// login logs in with the provider's current identity.
+func login(t *testing.T, h http.Handler) *httptest.ResponseRecorder {
+	cookie, cb := startLogin(t, h)
+	return finishLogin(t, h, cookie, cb)
+}
+
+func Test_OIDCCallback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Creates Users", func(t *testing.T) {
+		t.Parallel()
+		s, h, repo, _ := newOIDCServer(t)
+		s.SetIdentity(oidctest.Identity{
+			Subject:           "novel subject",
+			Email:             "user@novel.com",
+			EmailVerified:     true,
+			PreferredUsername: "novel",
+		})
+
+		res := login(t, h)
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var u userResponse
+		decode(t, res, &u)
+		assert.Equal(t, "user@novel.com", u.User.Email)
+		assert.Equal(t, "novel", u.User.Username)
+		assert.NotEmpty(t, u.User.Token)
+
+		linked, err := repo.GetUserByIdentity(context.Background(), "test", "novel subject")
+		require.NoError(t, err)
+		assert.Equal(t, u.User.ID, linked.ID)
+
+		again := login(t, h)
+		require.Equal(t, http.StatusOK, again.Code, again.Body.String())
+		decode(t, again, &u)
+		assert.Equal(t, linked.ID, u.User.ID, "because the identity is already linked")
+	})
+
+	t.Run("State Mismatch", func(t *testing.T) {
+		t.Parallel()
+		_, h, _, _ := newOIDCServer(t)
+
+		cookie, cb := startLogin(t, h)
+		q := cb.Query()
+		q.Set("state", "forged state")
+		cb.RawQuery = q.Encode()
+		res := finishLogin(t, h, cookie, cb)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+
+		other, _ := startLogin(t, h)
+		_, cb = startLogin(t, h)
+		res = finishLogin(t, h, other, cb)
+		assert.Equal(t, http.StatusBadRequest, res.Code,
+			"because the login was started in a different browser")
+
+		_, cb = startLogin(t, h)
+		res = do(t, h, request{method: http.MethodGet, path: cb.RequestURI()})
+		assert.Equal(t, http.StatusBadRequest, res.Code,
+			"because there isn't a login cookie")
+	})
+
+	t.Run("Links Verified Emails", func(t *testing.T) {
+		t.Parallel()
+		s, h, repo, _ := newOIDCServer(t)
+
+		existing, err := domain.NewUserWithPassword("user@sincere.com", "sincere", password)
+		require.NoError(t, err)
+		existing.Verified = true
+		existing, err = repo.CreateUser(context.Background(), existing)
+		require.NoError(t, err)
+
+		s.SetIdentity(oidctest.Identity{
+			Subject:       "sincere subject",
+			Email:         "user@sincere.com",
+			EmailVerified: true,
+		})
+		res := login(t, h)
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var u userResponse
+		decode(t, res, &u)
+		assert.Equal(t, existing.ID, u.User.ID)
+
+		linked, err := repo.GetUserByIdentity(context.Background(), "test", "sincere subject")
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, linked.ID)
+	})
+
+	t.Run("Doesn't Link Unverified Emails", func(t *testing.T) {
+		t.Parallel()
+		s, h, repo, _ := newOIDCServer(t)
+
+		unverified, err := domain.NewUserWithPassword("user@dubious.com", "dubious", password)
+		require.NoError(t, err)
+		_, err = repo.CreateUser(context.Background(), unverified)
+		require.NoError(t, err)
+		verified, err := domain.NewUserWithPassword("user@wary.com", "wary", password)
+		require.NoError(t, err)
+		verified.Verified = true
+		_, err = repo.CreateUser(context.Background(), verified)
+		require.NoError(t, err)
+
+		s.SetIdentity(oidctest.Identity{
+			Subject:       "dubious subject",
+			Email:         "user@dubious.com",
+			EmailVerified: true,
+		})
+		res := login(t, h)
+		assert.Equal(t, http.StatusConflict, res.Code,
+			"because the user hasn't verified their email")
+
+		s.SetIdentity(oidctest.Identity{
+			Subject:       "wary subject",
+			Email:         "user@wary.com",
+			EmailVerified: false,
+		})
+		res = login(t, h)
+		assert.Equal(t, http.StatusConflict, res.Code,
+			"because the provider hasn't verified the email")
+
+		for _, sub := range []string{"dubious subject", "wary subject"} {
+			_, err := repo.GetUserByIdentity(context.Background(), "test", sub)
+			assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		}
+	})
+
+	t.Run("Unknown Provider", func(t *testing.T) {
+		t.Parallel()
+		_, h, _, _ := newOIDCServer(t)
+
+		res := do(t, h, request{method: http.MethodGet, path: "/api/users/oidc/unknown"})
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
@@ -0,0 +1,242 @@
+package echohttp
+
+import (
+	"net/http"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+	"github.com/labstack/echo/v4"
+)
+
+type readingListsHandler struct {
+	repo   domain.Repository
+	authed echo.MiddlewareFunc
+	policy domain.Policy
+}
+
+func newReadingListsHandler(
+	repo domain.Repository,
+	authed echo.MiddlewareFunc,
+	policy domain.Policy,
+) *readingListsHandler {
+	return &readingListsHandler{
+		repo,
+		authed,
+		policy,
+	}
+}
+
+// Reading lists are private so they only live under the current user.
+func (h *readingListsHandler) mapRoutes(g *echo.Group) {
+	g.GET("/user/lists", h.lists, h.authed, scoped(domain.ScopeRead))
+	g.POST("/user/lists", h.create, h.authed)
+	g.GET("/user/lists/:list", h.list, h.authed, scoped(domain.ScopeRead))
+	g.PUT("/user/lists/:list", h.update, h.authed)
+	g.DELETE("/user/lists/:list", h.delete, h.authed)
+	g.GET("/user/lists/:list/articles", h.articles, h.authed, scoped(domain.ScopeRead))
+	g.POST("/user/lists/:list/articles/:slug", h.add, h.authed)
+	g.DELETE("/user/lists/:list/articles/:slug", h.remove, h.authed)
+}
+
+func (h *readingListsHandler) lists(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	ls, err := h.repo.ReadingListsByOwner(ctx.Request().Context(), em)
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ManyReadingListsToReadingLists(ls))
+}
+
+func (h *readingListsHandler) create(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	l, err := serialization.CreateReadingListToReadingList(ctx.Bind, u)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+	for _, s := range l.ArticleSlugs {
+		if !h.canSave(ctx, &u.User, s) {
+			return echo.ErrNotFound
+		}
+	}
+
+	created, err := h.repo.CreateReadingList(ctx.Request().Context(), l)
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReadingListToReadingList(created))
+}
+
+func (h *readingListsHandler) list(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	l, err := h.repo.GetReadingList(ctx.Request().Context(), em, ctx.Param("list"))
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReadingListToReadingList(l))
+}
+
+func (h *readingListsHandler) update(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	delta, err := serialization.UpdateReadingListToDelta(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	updated, err := h.repo.UpdateReadingList(ctx.Request().Context(),
+		em,
+		ctx.Param("list"),
+		func(l *domain.ReadingList) (*domain.ReadingList, error) {
+			return l, delta(l)
+		})
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReadingListToReadingList(updated))
+}
+
+func (h *readingListsHandler) delete(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	l, err := h.repo.GetReadingList(ctx.Request().Context(), em, ctx.Param("list"))
+	if err != nil {
+		return readingListError(err)
+	}
+
+	if err := h.repo.DeleteReadingList(ctx.Request().Context(), l); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func (h *readingListsHandler) articles(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	as, err := h.repo.ReadingListArticles(ctx.Request().Context(), em, ctx.Param("list"))
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ManyAuthoredArticlesToArticles(as, u))
+}
+
+func (h *readingListsHandler) add(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	if !h.canSave(ctx, &u.User, ctx.Param("slug")) {
+		return echo.ErrNotFound
+	}
+
+	updated, err := h.repo.UpdateReadingList(ctx.Request().Context(),
+		em,
+		ctx.Param("list"),
+		func(l *domain.ReadingList) (*domain.ReadingList, error) {
+			l.Add(ctx.Param("slug"))
+			return l, nil
+		})
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReadingListToReadingList(updated))
+}
+
+func (h *readingListsHandler) remove(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	updated, err := h.repo.UpdateReadingList(ctx.Request().Context(),
+		em,
+		ctx.Param("list"),
+		func(l *domain.ReadingList) (*domain.ReadingList, error) {
+			l.Remove(ctx.Param("slug"))
+			return l, nil
+		})
+	if err != nil {
+		return readingListError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReadingListToReadingList(updated))
+}
+
+// canSave checks the article exists and is visible to the user saving it.
+func (h *readingListsHandler) canSave(ctx echo.Context, u *domain.User, s string) bool {
+	ar, err := h.repo.GetArticleBySlug(ctx.Request().Context(), s)
+	if err != nil {
+		return false
+	}
+	return !ar.Hidden || h.policy.CanViewHidden(u)
+}
+
+func readingListError(err error) error {
+	switch err {
+	case domain.ErrReadingListNotFound, domain.ErrUserNotFound:
+		return echo.NewHTTPError(
+			http.StatusNotFound,
+			err.Error())
+	case domain.ErrDuplicateReadingList, domain.ErrInvalidOrder:
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	if _, ok := err.(govalidator.Errors); ok {
+		// Renaming the list can make it invalid
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	return err
+}
@@ -0,0 +1,208 @@
+package echohttp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+	"github.com/labstack/echo/v4"
+)
+
+type reportsHandler struct {
+	repo   domain.Repository
+	authed echo.MiddlewareFunc
+	policy domain.Policy
+	mod    *moderation
+}
+
+func newReportsHandler(
+	repo domain.Repository,
+	authed echo.MiddlewareFunc,
+	policy domain.Policy,
+) *reportsHandler {
+	return &reportsHandler{
+		repo,
+		authed,
+		policy,
+		&moderation{repo, policy},
+	}
+}
+
+func (h *reportsHandler) mapRoutes(g *echo.Group) {
+	g.POST("/reports", h.create, h.authed)
+	g.GET("/reports", h.queue, h.authed)
+	g.GET("/reports/:id", h.report, h.authed)
+	g.POST("/reports/:id/resolve", h.resolve, h.authed)
+}
+
+func (h *reportsHandler) create(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	if !h.policy.CanPublish(&u.User) {
+		return forbidden
+	}
+
+	rep, err := serialization.CreateReportToReport(ctx.Bind, u)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	ar, err := h.repo.GetCommentsBySlug(ctx.Request().Context(), rep.ArticleSlug)
+	if err != nil || ar.Hidden {
+		return echo.ErrNotFound
+	}
+	if rep.IsComment() && ar.FindComment(rep.CommentID) == nil {
+		return echo.ErrNotFound
+	}
+
+	created, err := h.repo.CreateReport(ctx.Request().Context(), rep)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReportToReport(created))
+}
+
+func (h *reportsHandler) queue(ctx echo.Context) error {
+	if _, err := h.mod.moderator(ctx); err != nil {
+		return err
+	}
+
+	status := domain.ReportOpen
+	if s := ctx.QueryParam("status"); s != "" {
+		status = domain.ReportStatus(s)
+	}
+	limit, offset := page(ctx)
+
+	reps, err := h.repo.ReportsByStatus(ctx.Request().Context(), status, limit, offset)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ManyReportsToReports(reps))
+}
+
+func (h *reportsHandler) report(ctx echo.Context) error {
+	if _, err := h.mod.moderator(ctx); err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest
+	}
+
+	rep, err := h.repo.GetReportByID(ctx.Request().Context(), id)
+	if err != nil {
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReportToReport(rep))
+}
+
+func (h *reportsHandler) resolve(ctx echo.Context) error {
+	mod, err := h.mod.moderator(ctx)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return echo.ErrBadRequest
+	}
+
+	action, note, err := serialization.ResolveToAction(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	// The report is claimed before acting on it so concurrent resolves can't both moderate the content
+	rctx := ctx.Request().Context()
+	rep, err := h.repo.UpdateReportByID(rctx,
+		id,
+		func(r *domain.Report) (*domain.Report, error) {
+			return r, r.Resolve(mod.Email, action)
+		})
+	if err != nil {
+		return moderationError(err)
+	}
+
+	e := domain.NewAuditEntry(mod.Email, action)
+	e.ReportID = rep.ID
+	e.Note = note
+
+	switch {
+	case action == domain.ActionDismiss:
+		e.ArticleSlug = rep.ArticleSlug
+		e.CommentID = rep.CommentID
+		// Dismissing is only the audit entry so nothing is done without it
+		_, err = h.repo.CreateAuditEntry(rctx, e)
+	case action == domain.ActionHide && rep.IsComment():
+		err = h.mod.setCommentHidden(rctx, mod, e, rep.ArticleSlug, rep.CommentID)
+	case action == domain.ActionHide:
+		_, err = h.mod.setArticleHidden(rctx, mod, e, rep.ArticleSlug)
+	case action == domain.ActionDelete && rep.IsComment():
+		err = h.mod.deleteComment(rctx, mod, e, rep.ArticleSlug, rep.CommentID)
+	case action == domain.ActionDelete:
+		err = h.mod.deleteArticle(rctx, mod, e, rep.ArticleSlug)
+	case action == domain.ActionSuspend:
+		var author string
+		author, err = h.reportedAuthor(ctx, rep)
+		if err == nil {
+			_, err = h.mod.setSuspended(rctx, mod, e, author)
+		}
+	}
+	if errors.Is(err, errUnaudited) {
+		// The content was already moderated so the report stays resolved
+		return err
+	}
+	if err != nil {
+		// Nothing was moderated so the report goes back in the queue
+		if _, rerr := h.repo.UpdateReportByID(rctx,
+			rep.ID,
+			func(r *domain.Report) (*domain.Report, error) {
+				r.Reopen()
+				return r, nil
+			}); rerr != nil {
+			return rerr
+		}
+		return moderationError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ReportToReport(rep))
+}
+
+// reportedAuthor finds the email of whoever wrote the reported content.
+func (h *reportsHandler) reportedAuthor(ctx echo.Context, rep *domain.Report) (string, error) {
+	ar, err := h.repo.GetCommentsBySlug(ctx.Request().Context(), rep.ArticleSlug)
+	if err != nil {
+		return "", err
+	}
+	if !rep.IsComment() {
+		return ar.AuthorEmail, nil
+	}
+
+	c := ar.FindComment(rep.CommentID)
+	if c == nil {
+		return "", domain.ErrCommentNotFound
+	}
+	return c.AuthorEmail, nil
+}
@@ -0,0 +1,88 @@
+package echohttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/echohttp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unaudited is a repository that can't record anything in the audit trail.
+type unaudited struct {
+	domain.Repository
+}
+
+func (unaudited) CreateAuditEntry(context.Context, *domain.AuditEntry) (*domain.AuditEntry, error) {
+	return nil, errors.New("the audit trail is unavailable")
+}
+
+func TestResolveUnaudited(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		action string
+		status domain.ReportStatus
+		hidden bool
+	}{
+		{"hide", domain.ReportActioned, true},
+		{"dismiss", domain.ReportOpen, false},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.action, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			repo := inmemory.NewInstance()
+			log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+			h := echohttp.NewServer(jc, unaudited{repo}, nil, outbox.NewInstance(), nil, nil, nil, log)
+
+			register(t, h, "author@unaudited.com", "author")
+			register(t, h, "reporter@unaudited.com", "reporter")
+			mod := register(t, h, "moderator@unaudited.com", "unaudited")
+			_, err := repo.UpdateUserByEmail(ctx, "moderator@unaudited.com", func(u *domain.User) (*domain.User, error) {
+				u.Role = domain.RoleModerator
+				return u, nil
+			})
+			require.NoError(t, err)
+
+			a, err := domain.NewArticle("Unaudited", "unaudited", "unaudited", "author@unaudited.com")
+			require.NoError(t, err)
+			_, err = repo.CreateArticle(ctx, a)
+			require.NoError(t, err)
+			rep, err := domain.NewReport("reporter@unaudited.com", a.Slug, 0, "spam")
+			require.NoError(t, err)
+			rep, err = repo.CreateReport(ctx, rep)
+			require.NoError(t, err)
+
+			res := do(t, h, request{
+				method: http.MethodPost,
+				path:   fmt.Sprintf("/api/reports/%v/resolve", rep.ID),
+				token:  mod,
+				body: map[string]interface{}{
+					"resolution": map[string]string{"action": c.action},
+				},
+			})
+			assert.Equal(t, http.StatusInternalServerError, res.Code, res.Body.String())
+
+			found, err := repo.GetReportByID(ctx, rep.ID)
+			require.NoError(t, err)
+			assert.Equal(t, c.status, found.Status, "because the report is only reopened when nothing was moderated")
+			ar, err := repo.GetArticleBySlug(ctx, a.Slug)
+			require.NoError(t, err)
+			assert.Equal(t, c.hidden, ar.Hidden)
+		})
+	}
+}
@@ -4,53 +4,88 @@
 package echohttp
 
 import (
-	"net/http"
+	"log/slog"
 	"strconv"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/brycekbargar/realworld-backend/domain"
 	"github.com/brycekbargar/realworld-backend/ports"
 )
 
 // Start starts the given server after performing Echo specific setup.
+// Metrics are only served when there's a registry for them and requests are only traced when there's a provider.
+// Requests are logged with the default logger when there isn't one.
 func Start(
 	jc ports.JWTConfig,
 	port int,
 	repo domain.Repository,
+	blobs domain.BlobStore,
+	mailer domain.Mailer,
+	providers []domain.IdentityProvider,
+	metrics *prometheus.Registry,
+	tracing trace.TracerProvider,
+	log *slog.Logger,
 ) error {
+	if log == nil {
+		log = slog.Default()
+	}
+	s := NewServer(jc, repo, blobs, mailer, providers, metrics, tracing, log)
+
+	log.Info("starting server", slog.Int("port", port))
+	return s.Start(":" + strconv.Itoa(port))
+}
+
+// NewServer performs the Echo specific setup for Start without starting the server,
+// it can be used as an http.Handler on its own.
+func NewServer(
+	jc ports.JWTConfig,
+	repo domain.Repository,
+	blobs domain.BlobStore,
+	mailer domain.Mailer,
+	providers []domain.IdentityProvider,
+	metrics *prometheus.Registry,
+	tracing trace.TracerProvider,
+	log *slog.Logger,
+) *echo.Echo {
+	if log == nil {
+		log = slog.Default()
+	}
+
 	s := echo.New()
+	// Everything is logged as JSON so the banner would only get in the way
+	s.HideBanner = true
+	s.HidePort = true
 	s.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			uc := &userContext{c}
 			return next(uc)
 		}
 	})
-	s.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Skipper: func(c echo.Context) bool {
-			// TODO: Figure out how not to leak the route details?
-			// TODO: Also figure out how this is _really_ done.
-			// These routes take a password in the parameters so we want to leave them out of logs.
-			if strings.HasPrefix(strings.ToLower(c.Path()), "/api/users/login") {
-				return true
-			}
-			if strings.HasPrefix(strings.ToLower(c.Path()), "/api/user") &&
-				(c.Request().Method == http.MethodPost || c.Request().Method == http.MethodPut) {
-				return true
-			}
-
-			return false
-		},
-	}))
+	s.Use(middleware.RequestID())
+	if tracing != nil {
+		s.Use(traced(tracing, otel.GetTextMapPropagator()))
+	}
+	if metrics != nil {
+		s.Use(instrument(metrics))
+		s.GET("/metrics", metricsHandler(metrics))
+	}
+	s.Use(logged(log))
+	// Panics become 500s inside the logger so they're still recorded against the request
+	s.Use(middleware.Recover())
+	newHealthHandler(repo, log).mapRoutes(s)
 
-	fullAuth := middleware.JWTWithConfig(middleware.JWTConfig{
+	fullAuth := apiTokenAuth(repo, subjectAuth(repo, middleware.JWTWithConfig(middleware.JWTConfig{
 		SigningKey:    jc.Key,
 		SigningMethod: jc.Method.Name,
 		AuthScheme:    "Token",
-	})
-	maybeAuth := middleware.JWTWithConfig(middleware.JWTConfig{
+	})))
+	maybeAuth := apiTokenAuth(repo, subjectAuth(repo, middleware.JWTWithConfig(middleware.JWTConfig{
 		SigningKey:    jc.Key,
 		SigningMethod: jc.Method.Name,
 		AuthScheme:    "Token",
@@ -60,11 +95,20 @@ func Start(
 			auth := c.Request().Header.Get("Authorization")
 			return len(strings.TrimPrefix(strings.ToLower(auth), "token ")) == 0
 		},
-	})
+	})))
+
+	policy := domain.NewRolePolicy()
 
 	api := s.Group("/api")
-	newUsersHandler(repo, fullAuth, maybeAuth, jc).mapRoutes(api)
-	newArticlesHandler(repo, fullAuth, maybeAuth).mapRoutes(api)
+	newUsersHandler(repo, fullAuth, maybeAuth, jc, mailer).mapRoutes(api)
+	newTwoFactorHandler(repo, fullAuth, jc).mapRoutes(api)
+	newOIDCHandler(repo, providers, jc).mapRoutes(api)
+	newArticlesHandler(repo, fullAuth, maybeAuth, policy).mapRoutes(api)
+	newAdminHandler(repo, fullAuth, policy).mapRoutes(api)
+	newReportsHandler(repo, fullAuth, policy).mapRoutes(api)
+	newReadingListsHandler(repo, fullAuth, policy).mapRoutes(api)
+	newUploadsHandler(repo, blobs, fullAuth, policy).mapRoutes(api)
+	newAPITokensHandler(repo, fullAuth).mapRoutes(api)
 
-	return s.Start(":" + strconv.Itoa(port))
+	return s
 }
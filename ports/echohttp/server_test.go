@@ -0,0 +1,117 @@
+package echohttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/inmemory"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports"
+	"github.com/brycekbargar/realworld-backend/ports/echohttp"
+
+	"github.com/stretchr/testify/require"
+)
+
+var jc = ports.DefaultJWTConfig("echohttp test secret")
+
+// password is strong enough to register with.
+const password = "quizzical-ostrich-9417"
+
+func TestMain(m *testing.M) {
+	// The default parameters are much slower than tests need
+	domain.SetPasswordHasher(domain.NewArgon2idHasher(domain.Argon2idParams{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}))
+	os.Exit(m.Run())
+}
+
+// newServer creates a server backed by an empty in memory store that doesn't log anything.
+func newServer(providers ...domain.IdentityProvider) (http.Handler, domain.Repository, *outbox.Outbox) {
+	repo := inmemory.NewInstance()
+	ob := outbox.NewInstance()
+	log := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	return echohttp.NewServer(jc, repo, nil, ob, providers, nil, nil, log), repo, ob
+}
+
+// request is sent to the server by do.
+type request struct {
+	method string
+	path   string
+	// body is sent as json when it isn't nil.
+	body interface{}
+	// token is sent as the Authorization when it isn't empty.
+	token   string
+	cookies []*http.Cookie
+}
+
+// do sends the request to the server and records its response.
+func do(t *testing.T, h http.Handler, r request) *httptest.ResponseRecorder {
+	var body io.Reader
+	if r.body != nil {
+		raw, err := json.Marshal(r.body)
+		require.NoError(t, err)
+		body = bytes.NewReader(raw)
+	}
+
+	req := httptest.NewRequest(r.method, r.path, body)
+	if r.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Token "+r.token)
+	}
+	for _, c := range r.cookies {
+		req.AddCookie(c)
+	}
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	return res
+}
+
+// decode reads the json response into v.
+func decode(t *testing.T, res *httptest.ResponseRecorder, v interface{}) {
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), v), res.Body.String())
+}
+
+// userResponse is the user (and their session) returned when registering or logging in.
+type userResponse struct {
+	User struct {
+		ID       string `json:"id"`
+		Email    string `json:"email"`
+		Token    string `json:"token"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// register creates a new user with the password and returns their session token.
+func register(t *testing.T, h http.Handler, email string, username string) string {
+	res := do(t, h, request{
+		method: http.MethodPost,
+		path:   "/api/users",
+		body: map[string]interface{}{
+			"user": map[string]string{
+				"email":    email,
+				"username": username,
+				"password": password,
+			},
+		},
+	})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var u userResponse
+	decode(t, res, &u)
+	return u.User.Token
+}
@@ -0,0 +1,59 @@
+package echohttp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports"
+)
+
+// makePurposeJwt creates a single-use token that only lets the user do the one purpose.
+// The token carries the user's fingerprint for the purpose so it stops working once it's used.
+func makePurposeJwt(jc ports.JWTConfig, u *domain.User, p domain.TokenPurpose) (string, error) {
+	token := jwt.New(jc.Method)
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims["email"] = u.Email
+	claims["purpose"] = string(p)
+	claims["fingerprint"] = u.TokenFingerprint(p)
+	claims["exp"] = time.Now().Add(p.TTL()).Unix()
+
+	return token.SignedString(jc.Key)
+}
+
+// parsePurposeJwt checks the token is signed, unexpired and for the purpose.
+// It returns the email and fingerprint the token was issued for,
+// the fingerprint still needs to be checked against the user before the token is used.
+func parsePurposeJwt(jc ports.JWTConfig, raw string, p domain.TokenPurpose) (string, string, error) {
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jc.Method.Name {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return jc.Key, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", domain.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", domain.ErrInvalidToken
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return "", "", domain.ErrInvalidToken
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != string(p) {
+		return "", "", domain.ErrInvalidToken
+	}
+
+	email, _ := claims["email"].(string)
+	fingerprint, _ := claims["fingerprint"].(string)
+	if email == "" || fingerprint == "" {
+		return "", "", domain.ErrInvalidToken
+	}
+
+	return email, fingerprint, nil
+}
@@ -0,0 +1,59 @@
+package echohttp
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traced creates a span for every request (except the probes),
+// continuing the trace of the caller when the request has trace context headers.
+// The span is in the request context so everything called with it (like the repository) is part of the trace.
+func traced(tp trace.TracerProvider, prop propagation.TextMapPropagator) echo.MiddlewareFunc {
+	tracer := tp.Tracer("github.com/brycekbargar/realworld-backend/ports/echohttp")
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isProbe(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			ctx := prop.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracer.Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", req.URL.Path),
+				))
+			defer span.End()
+
+			c.SetRequest(req.WithContext(ctx))
+			// Let callers find the trace this request was part of
+			prop.Inject(ctx, propagation.HeaderCarrier(c.Response().Header()))
+
+			if err := next(c); err != nil {
+				// The error has to be handled here to know what status it turns into
+				span.RecordError(err)
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			return nil
+		}
+	}
+}
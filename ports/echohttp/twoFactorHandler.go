@@ -0,0 +1,213 @@
+package echohttp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+)
+
+type twoFactorHandler struct {
+	repo   domain.Repository
+	authed echo.MiddlewareFunc
+	jc     ports.JWTConfig
+}
+
+func newTwoFactorHandler(
+	repo domain.Repository,
+	authed echo.MiddlewareFunc,
+	jc ports.JWTConfig,
+) *twoFactorHandler {
+	return &twoFactorHandler{
+		repo,
+		authed,
+		jc,
+	}
+}
+
+func (h *twoFactorHandler) mapRoutes(g *echo.Group) {
+	g.POST("/users/login/2fa", h.login)
+	g.POST("/user/2fa", h.enroll, h.authed)
+	g.PUT("/user/2fa", h.confirm, h.authed)
+	g.DELETE("/user/2fa", h.disable, h.authed)
+}
+
+// login finishes logging in with the challenge token from the first step and a two-factor code.
+func (h *twoFactorHandler) login(ctx echo.Context) error {
+	raw, code, err := serialization.SecondFactorToCredentials(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	em, fp, err := parsePurposeJwt(h.jc, raw, domain.PurposeSecondFactor)
+	if err != nil {
+		return tokenError(err)
+	}
+
+	authed, err := h.checkCode(ctx, em, code, func(u *domain.User) error {
+		if u.TokenFingerprint(domain.PurposeSecondFactor) != fp {
+			return domain.ErrInvalidToken
+		}
+		return nil
+	})
+	if err == domain.ErrUserNotFound {
+		return tokenError(domain.ErrInvalidToken)
+	}
+	if err != nil {
+		return twoFactorError(err)
+	}
+
+	return startSession(ctx, h.jc, authed)
+}
+
+// checkCode checks the user's two-factor code after the precondition passes.
+// Invalid codes are still saved so they count towards the lockout.
+func (h *twoFactorHandler) checkCode(
+	ctx echo.Context,
+	em string,
+	code string,
+	precondition func(*domain.User) error,
+) (*domain.User, error) {
+	var checkErr error
+	u, err := h.repo.UpdateUserByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.User) (*domain.User, error) {
+			if err := precondition(u); err != nil {
+				return nil, err
+			}
+			checkErr = u.CheckSecondFactor(code, time.Now())
+			return u, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	if checkErr != nil {
+		return nil, checkErr
+	}
+
+	return u, nil
+}
+
+func (h *twoFactorHandler) enroll(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	enrolled, err := h.repo.UpdateUserByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.User) (*domain.User, error) {
+			if _, err := u.EnrollTOTP(); err != nil {
+				return nil, err
+			}
+			return u, nil
+		})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return twoFactorError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToEnrollment(enrolled))
+}
+
+func (h *twoFactorHandler) confirm(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	code, err := serialization.TwoFactorToCode(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	var codes []string
+	_, err = h.repo.UpdateUserByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.User) (*domain.User, error) {
+			cs, err := u.ConfirmTOTP(code, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			codes = cs
+			return u, nil
+		})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return twoFactorError(err)
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.RecoveryCodesToTwoFactor(codes))
+}
+
+// disable turns off two-factor authentication, it needs a code so a stolen session can't.
+func (h *twoFactorHandler) disable(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	code, err := serialization.TwoFactorToCode(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	if _, err := h.checkCode(ctx, em, code, func(*domain.User) error {
+		return nil
+	}); err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return twoFactorError(err)
+	}
+
+	_, err = h.repo.UpdateUserByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.User) (*domain.User, error) {
+			u.DisableTOTP()
+			return u, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func twoFactorError(err error) error {
+	switch err {
+	case domain.ErrInvalidToken,
+		domain.ErrInvalidSecondFactor,
+		domain.ErrTwoFactorNotEnrolled:
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	case domain.ErrTwoFactorEnabled:
+		return echo.NewHTTPError(
+			http.StatusConflict,
+			err.Error())
+	case domain.ErrSecondFactorLocked:
+		return echo.NewHTTPError(
+			http.StatusTooManyRequests,
+			err.Error())
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package echohttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// challengeResponse is returned instead of a user when they still need a two-factor code.
+type challengeResponse struct {
+	Challenge struct {
+		Email     string `json:"email"`
+		Token     string `json:"token"`
+		TwoFactor bool   `json:"twoFactor"`
+	} `json:"challenge"`
+}
+
+// twoFactorResponse is returned when enrolling in and confirming two-factor authentication.
+type twoFactorResponse struct {
+	TwoFactor struct {
+		Enabled       bool     `json:"enabled"`
+		Secret        string   `json:"secret"`
+		RecoveryCodes []string `json:"recoveryCodes"`
+	} `json:"twoFactor"`
+}
+
+// enroll turns on two-factor authentication for the session's user and returns their secret and recovery codes.
+func enroll(t *testing.T, h http.Handler, session string) (string, []string) {
+	res := do(t, h, request{method: http.MethodPost, path: "/api/user/2fa", token: session})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var tf twoFactorResponse
+	decode(t, res, &tf)
+	require.NotEmpty(t, tf.TwoFactor.Secret)
+
+	code, err := domain.TOTPCode(tf.TwoFactor.Secret, time.Now())
+	require.NoError(t, err)
+	res = do(t, h, request{
+		method: http.MethodPut,
+		path:   "/api/user/2fa",
+		token:  session,
+		body:   map[string]interface{}{"twoFactor": map[string]string{"code": code}},
+	})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	secret := tf.TwoFactor.Secret
+	decode(t, res, &tf)
+	require.NotEmpty(t, tf.TwoFactor.RecoveryCodes)
+
+	return secret, tf.TwoFactor.RecoveryCodes
+}
+
+func passwordLogin(t *testing.T, h http.Handler, email string) challengeResponse {
+	res := do(t, h, request{
+		method: http.MethodPost,
+		path:   "/api/users/login",
+		body: map[string]interface{}{
+			"user": map[string]string{"email": email, "password": password},
+		},
+	})
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	var c challengeResponse
+	decode(t, res, &c)
+	return c
+}
+
+func secondFactor(t *testing.T, h http.Handler, token string, code string) *httptest.ResponseRecorder {
+	return do(t, h, request{
+		method: http.MethodPost,
+		path:   "/api/users/login/2fa",
+		body: map[string]interface{}{
+			"user": map[string]string{"token": token, "code": code},
+		},
+	})
+}
+
+func Test_TwoFactorLogin(t *testing.T) {
+	t.Parallel()
+
+	h, _, _ := newServer()
+	session := register(t, h, "user@guarded.com", "guarded")
+	secret, recovery := enroll(t, h, session)
+
+	c := passwordLogin(t, h, "user@guarded.com")
+	assert.True(t, c.Challenge.TwoFactor)
+	require.NotEmpty(t, c.Challenge.Token)
+
+	res := do(t, h, request{method: http.MethodGet, path: "/api/user", token: c.Challenge.Token})
+	assert.Equal(t, http.StatusUnauthorized, res.Code,
+		"because the challenge isn't a session")
+
+	res = secondFactor(t, h, c.Challenge.Token, "000000")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+
+	// The code used to confirm enrollment can't be used again so use the next one
+	code, err := domain.TOTPCode(secret, time.Now().Add(30*time.Second))
+	require.NoError(t, err)
+	res = secondFactor(t, h, c.Challenge.Token, code)
+	require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	var u userResponse
+	decode(t, res, &u)
+	assert.Equal(t, "user@guarded.com", u.User.Email)
+	require.NotEmpty(t, u.User.Token)
+
+	res = do(t, h, request{method: http.MethodGet, path: "/api/user", token: u.User.Token})
+	assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+
+	res = secondFactor(t, h, c.Challenge.Token, code)
+	assert.Equal(t, http.StatusBadRequest, res.Code,
+		"because codes can't be replayed")
+
+	c = passwordLogin(t, h, "user@guarded.com")
+	res = secondFactor(t, h, c.Challenge.Token, recovery[0])
+	assert.Equal(t, http.StatusOK, res.Code, res.Body.String())
+	res = secondFactor(t, h, c.Challenge.Token, recovery[0])
+	assert.Equal(t, http.StatusBadRequest, res.Code,
+		"because recovery codes are single use")
+}
@@ -0,0 +1,134 @@
+package echohttp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/brycekbargar/realworld-backend/domain"
+	"github.com/brycekbargar/realworld-backend/ports/serialization"
+	"github.com/labstack/echo/v4"
+)
+
+type uploadsHandler struct {
+	repo   domain.Repository
+	blobs  domain.BlobStore
+	authed echo.MiddlewareFunc
+	policy domain.Policy
+}
+
+func newUploadsHandler(
+	repo domain.Repository,
+	blobs domain.BlobStore,
+	authed echo.MiddlewareFunc,
+	policy domain.Policy,
+) *uploadsHandler {
+	return &uploadsHandler{
+		repo,
+		blobs,
+		authed,
+		policy,
+	}
+}
+
+func (h *uploadsHandler) mapRoutes(g *echo.Group) {
+	g.POST("/uploads", h.upload, h.authed, scoped(domain.ScopeWriteArticles))
+	g.GET("/uploads/:key", h.download)
+}
+
+func (h *uploadsHandler) upload(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	if !h.policy.CanPublish(&u.User) {
+		return forbidden
+	}
+
+	// Leave some room for the rest of the multipart form
+	ctx.Request().Body = http.MaxBytesReader(ctx.Response(), ctx.Request().Body, domain.MaxImageBytes+1<<20)
+	fh, err := ctx.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return uploadError(domain.ErrImageTooLarge)
+	}
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			"a single image is required in the file field")
+	}
+	if fh.Size > domain.MaxImageBytes {
+		return uploadError(domain.ErrImageTooLarge)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, domain.MaxImageBytes+1))
+	if err != nil {
+		return err
+	}
+
+	img, err := domain.NewImage(data)
+	if err != nil {
+		return uploadError(err)
+	}
+	if err := h.blobs.PutBlob(ctx.Request().Context(), img.Key, img.ContentType, img.Data); err != nil {
+		return err
+	}
+
+	var thumb *domain.Image
+	if ctx.FormValue("kind") == "avatar" {
+		if thumb, err = img.Thumbnail(domain.AvatarThumbnailSize); err != nil {
+			return uploadError(err)
+		}
+		if err := h.blobs.PutBlob(ctx.Request().Context(), thumb.Key, thumb.ContentType, thumb.Data); err != nil {
+			return err
+		}
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.ImageToUpload(img, thumb, uploadURL(ctx)))
+}
+
+// uploadURL makes absolute urls for uploads so they can be used as a User's image.
+func uploadURL(ctx echo.Context) func(string) string {
+	return func(key string) string {
+		return ctx.Scheme() + "://" + ctx.Request().Host + "/api/uploads/" + key
+	}
+}
+
+func (h *uploadsHandler) download(ctx echo.Context) error {
+	data, ct, err := h.blobs.GetBlob(ctx.Request().Context(), ctx.Param("key"))
+	if err != nil {
+		if err == domain.ErrBlobNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	// Uploads are keyed by their content so they never change
+	ctx.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	ctx.Response().Header().Set("X-Content-Type-Options", "nosniff")
+	return ctx.Blob(http.StatusOK, ct, data)
+}
+
+func uploadError(err error) error {
+	switch err {
+	case domain.ErrImageTooLarge:
+		return echo.NewHTTPError(
+			http.StatusRequestEntityTooLarge,
+			err.Error())
+	case domain.ErrUnsupportedImage:
+		return echo.NewHTTPError(
+			http.StatusUnsupportedMediaType,
+			err.Error())
+	}
+	return err
+}
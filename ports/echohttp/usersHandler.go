@@ -1,7 +1,15 @@
 package echohttp
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -17,6 +25,7 @@ type usersHandler struct {
 	authed      echo.MiddlewareFunc
 	maybeAuthed echo.MiddlewareFunc
 	jc          ports.JWTConfig
+	mailer      domain.Mailer
 }
 
 func newUsersHandler(
@@ -24,34 +33,50 @@ func newUsersHandler(
 	authed echo.MiddlewareFunc,
 	maybeAuthed echo.MiddlewareFunc,
 	jc ports.JWTConfig,
+	mailer domain.Mailer,
 ) *usersHandler {
 	return &usersHandler{
 		repo,
 		authed,
 		maybeAuthed,
 		jc,
+		mailer,
 	}
 }
 
 func (r *usersHandler) mapRoutes(g *echo.Group) {
 	g.POST("/users", r.create)
 	g.POST("/users/login", r.login)
-	g.GET("/user", r.user, r.authed)
+	g.POST("/users/verify", r.verify)
+	g.POST("/user/verify", r.resendVerification, r.authed)
+	g.POST("/users/password-reset", r.requestPasswordReset)
+	g.PUT("/users/password-reset", r.resetPassword)
+	g.GET("/user", r.user, r.authed, scoped(domain.ScopeRead))
 	g.PUT("/user", r.update, r.authed)
+	g.DELETE("/user", r.delete, r.authed)
+	g.POST("/user/deletion", r.requestDeletion, r.authed)
+	g.GET("/user/export", r.export, r.authed)
 
-	g.GET("/profiles/:username", r.profile, r.maybeAuthed)
+	g.GET("/profiles/:username", r.profile, r.maybeAuthed, scoped(domain.ScopeRead))
 	g.POST("/profiles/:username/follow", r.follow, r.authed)
 	g.DELETE("/profiles/:username/follow", r.unfollow, r.authed)
+	g.GET("/profiles/:username/followers", r.followers, r.maybeAuthed, scoped(domain.ScopeRead))
+	g.GET("/profiles/:username/following", r.following, r.maybeAuthed, scoped(domain.ScopeRead))
+	g.POST("/profiles/:username/block", r.block, r.authed)
+	g.DELETE("/profiles/:username/block", r.unblock, r.authed)
+	g.POST("/profiles/:username/mute", r.mute, r.authed)
+	g.DELETE("/profiles/:username/mute", r.unmute, r.authed)
 }
 
-func makeJwt(r *usersHandler, e string) (string, error) {
-	token := jwt.New(r.jc.Method)
+// makeJwt starts a session for the user, it's identified by their id so it outlives email changes.
+func makeJwt(jc ports.JWTConfig, u *domain.User) (string, error) {
+	token := jwt.New(jc.Method)
 
 	claims := token.Claims.(jwt.MapClaims)
-	claims["email"] = e
+	claims["sub"] = u.ID
 	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
 
-	t, err := token.SignedString(r.jc.Key)
+	t, err := token.SignedString(jc.Key)
 	if err != nil {
 		return "", err
 	}
@@ -61,6 +86,14 @@ func makeJwt(r *usersHandler, e string) (string, error) {
 
 func (h *usersHandler) create(ctx echo.Context) error {
 	user, err := serialization.RegisterToUser(ctx.Bind)
+	if isWeakPassword(err) {
+		return passwordError(err)
+	}
+	if err == domain.ErrReservedUsername {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
 	if err != nil {
 		return echo.NewHTTPError(
 			http.StatusBadRequest,
@@ -72,12 +105,17 @@ func (h *usersHandler) create(ctx echo.Context) error {
 		if err == domain.ErrDuplicateUser {
 			return echo.NewHTTPError(
 				http.StatusBadRequest,
-				err)
+				err.Error())
 		}
 		return err
 	}
 
-	token, err := makeJwt(h, created.Email)
+	if err := h.sendVerification(ctx, created); err != nil {
+		// The user still gets created, they can ask for the email again
+		logger(ctx).Error("sending the verification email failed", slog.Any("err", err))
+	}
+
+	token, err := makeJwt(h.jc, created)
 	if err != nil {
 		return err
 	}
@@ -103,15 +141,220 @@ func (h *usersHandler) login(ctx echo.Context) error {
 	if ok, err := authed.HasPassword(pw); !ok || err != nil {
 		return echo.ErrUnauthorized
 	}
+	if authed.Banned {
+		return echo.NewHTTPError(
+			http.StatusForbidden,
+			"user has been banned")
+	}
+
+	// Hashes can only be upgraded while we have the plain-text password
+	u := &authed.User
+	if authed.NeedsRehash() {
+		rehashed, err := h.repo.UpdateUserByEmail(ctx.Request().Context(),
+			authed.Email,
+			func(u *domain.User) (*domain.User, error) {
+				if !bytes.Equal(u.Password, authed.Password) {
+					// The password changed since logging in, leave it alone
+					return u, nil
+				}
+				return u, u.RehashPassword(pw)
+			})
+		if err != nil {
+			logger(ctx).Error("rehashing the password failed", slog.Any("err", err))
+		} else {
+			u = rehashed
+		}
+	}
 
-	token, err := makeJwt(h, authed.Email)
+	return loggedIn(ctx, h.jc, u)
+}
+
+func (h *usersHandler) sendVerification(ctx echo.Context, u *domain.User) error {
+	token, err := makePurposeJwt(h.jc, u, domain.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.SendMail(ctx.Request().Context(), domain.NewVerificationEmail(u, token))
+}
+
+// redeem uses a single-use token emailed to a user to apply the change for its purpose.
+func (h *usersHandler) redeem(
+	ctx echo.Context,
+	raw string,
+	p domain.TokenPurpose,
+	change func(*domain.User) error,
+) (*domain.User, error) {
+	em, fp, err := parsePurposeJwt(h.jc, raw, p)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := h.repo.UpdateUserByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.User) (*domain.User, error) {
+			if u.TokenFingerprint(p) != fp {
+				return nil, domain.ErrInvalidToken
+			}
+			if err := change(u); err != nil {
+				return nil, err
+			}
+			return u.Validate()
+		})
+	if err == domain.ErrUserNotFound {
+		return nil, domain.ErrInvalidToken
+	}
+	return u, err
+}
+
+// loggedIn responds with the user and a new token after they've proven who they are,
+// users with two-factor authentication get a challenge to finish logging in with their code instead.
+func loggedIn(ctx echo.Context, jc ports.JWTConfig, u *domain.User) error {
+	if u.TwoFactor.Enabled && !u.Banned {
+		token, err := makePurposeJwt(jc, u, domain.PurposeSecondFactor)
+		if err != nil {
+			return err
+		}
+
+		return ctx.JSON(
+			http.StatusOK,
+			serialization.UserToChallenge(u, token))
+	}
+
+	return startSession(ctx, jc, u)
+}
+
+// startSession responds with the user and a new token once they've passed every factor.
+func startSession(ctx echo.Context, jc ports.JWTConfig, u *domain.User) error {
+	if u.Banned {
+		return echo.NewHTTPError(
+			http.StatusForbidden,
+			"user has been banned")
+	}
+
+	token, err := makeJwt(jc, u)
 	if err != nil {
 		return err
 	}
 
 	return ctx.JSON(
 		http.StatusOK,
-		serialization.UserToUser(&authed.User, token))
+		serialization.UserToUser(u, token))
+}
+
+func (h *usersHandler) verify(ctx echo.Context) error {
+	raw, err := serialization.VerifyToToken(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	verified, err := h.redeem(ctx, raw, domain.PurposeVerifyEmail, func(u *domain.User) error {
+		u.Verified = true
+		return nil
+	})
+	if err != nil {
+		return tokenError(err)
+	}
+
+	return loggedIn(ctx, h.jc, verified)
+}
+
+func (h *usersHandler) resendVerification(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	found, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+	if found.Verified {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			"email has already been verified")
+	}
+
+	if err := h.sendVerification(ctx, &found.User); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (h *usersHandler) requestPasswordReset(ctx echo.Context) error {
+	em, err := serialization.PasswordResetToEmail(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	// The response is the same whether or not the user exists so emails can't be discovered
+	found, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if err == nil {
+		token, err := makePurposeJwt(h.jc, &found.User, domain.PurposeResetPassword)
+		if err == nil {
+			err = h.mailer.SendMail(ctx.Request().Context(), domain.NewPasswordResetEmail(&found.User, token))
+		}
+		if err != nil {
+			logger(ctx).Error("sending the password reset email failed", slog.Any("err", err))
+		}
+	} else if err != domain.ErrUserNotFound {
+		logger(ctx).Error("finding the user to reset the password of failed", slog.Any("err", err))
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (h *usersHandler) resetPassword(ctx echo.Context) error {
+	raw, pw, err := serialization.ResetPasswordToCredentials(ctx.Bind)
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	reset, err := h.redeem(ctx, raw, domain.PurposeResetPassword, func(u *domain.User) error {
+		// Getting the email proves they own the address too
+		u.Verified = true
+		return u.SetPassword(pw)
+	})
+	if err != nil {
+		return tokenError(err)
+	}
+
+	return loggedIn(ctx, h.jc, reset)
+}
+
+func tokenError(err error) error {
+	if err == domain.ErrInvalidToken {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	return passwordError(err)
+}
+
+func isWeakPassword(err error) bool {
+	return err == domain.ErrPasswordTooShort ||
+		err == domain.ErrPasswordTooLong ||
+		err == domain.ErrPasswordBreached ||
+		err == domain.ErrPasswordGuessable
+}
+
+func passwordError(err error) error {
+	if isWeakPassword(err) {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	return err
 }
 
 func (h *usersHandler) user(ctx echo.Context) error {
@@ -149,18 +392,36 @@ func (h *usersHandler) update(ctx echo.Context) error {
 	updated, err := h.repo.UpdateUserByEmail(ctx.Request().Context(),
 		em,
 		func(u *domain.User) (*domain.User, error) {
-			delta(u)
+			if err := delta(u); err != nil {
+				return nil, err
+			}
 			return u.Validate()
 		})
 	if err != nil {
 		if err == domain.ErrUserNotFound {
 			return echo.ErrNotFound
 		}
-		return err
+		if err == domain.ErrDuplicateUser || err == domain.ErrReservedUsername {
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				err.Error())
+		}
+		return passwordError(err)
 	}
 
-	// Users can change their email so we need to make sure we're giving them a new token.
-	token, err := makeJwt(h, updated.Email)
+	if !strings.EqualFold(em, updated.Email) {
+		// The previous address hears about the change in case it wasn't the user
+		if err := h.mailer.SendMail(ctx.Request().Context(), domain.NewEmailChangedEmail(updated, em)); err != nil {
+			logger(ctx).Error("sending the email changed email failed", slog.Any("err", err))
+		}
+		if !updated.Verified {
+			if err := h.sendVerification(ctx, updated); err != nil {
+				logger(ctx).Error("sending the verification email failed", slog.Any("err", err))
+			}
+		}
+	}
+
+	token, err := makeJwt(h.jc, updated)
 	if err != nil {
 		return err
 	}
@@ -170,6 +431,131 @@ func (h *usersHandler) update(ctx echo.Context) error {
 		serialization.UserToUser(updated, token))
 }
 
+func (h *usersHandler) delete(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	u, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if !ok || err != nil {
+		return identityNotOk
+	}
+
+	pw, raw, mode, err := serialization.DeleteUserToCredentials(ctx.Bind)
+	if err == domain.ErrInvalidDeletionMode {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(
+			http.StatusBadRequest,
+			err)
+	}
+
+	// A stolen session shouldn't be enough to delete an account,
+	// users without a password (from an identity provider) confirm with an emailed token instead
+	if raw != "" {
+		tem, fp, err := parsePurposeJwt(h.jc, raw, domain.PurposeDeleteAccount)
+		if err != nil ||
+			!strings.EqualFold(tem, u.Email) ||
+			fp != u.TokenFingerprint(domain.PurposeDeleteAccount) {
+			return tokenError(domain.ErrInvalidToken)
+		}
+	} else if ok, err := u.HasPassword(pw); !ok || err != nil {
+		return echo.ErrUnauthorized
+	}
+
+	if err := h.repo.DeleteUser(ctx.Request().Context(), em, mode); err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// requestDeletion emails the user a token they can delete their account with instead of their password.
+func (h *usersHandler) requestDeletion(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	found, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	token, err := makePurposeJwt(h.jc, &found.User, domain.PurposeDeleteAccount)
+	if err != nil {
+		return err
+	}
+	if err := h.mailer.SendMail(ctx.Request().Context(), domain.NewDeleteAccountEmail(&found.User, token)); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (h *usersHandler) export(ctx echo.Context) error {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return identityNotOk
+	}
+
+	x, err := h.repo.ExportUserByEmail(ctx.Request().Context(), em)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	if ctx.QueryParam("format") != "zip" &&
+		!strings.Contains(ctx.Request().Header.Get(echo.HeaderAccept), "application/zip") {
+		return ctx.JSON(
+			http.StatusOK,
+			serialization.UserExportToExport(x))
+	}
+
+	files := serialization.UserExportToArchive(x)
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, n := range names {
+		b, err := json.MarshalIndent(files[n], "", "  ")
+		if err != nil {
+			return err
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     n,
+			Method:   zip.Deflate,
+			Modified: x.CreatedAtUTC,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(b); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="%v-export.zip"`, x.User.Username))
+	return ctx.Blob(http.StatusOK, "application/zip", buf.Bytes())
+}
+
 func (h *usersHandler) profile(ctx echo.Context) (err error) {
 	em, _, _ := ctx.(*userContext).identity()
 
@@ -180,27 +566,46 @@ func (h *usersHandler) profile(ctx echo.Context) (err error) {
 	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
 	if err != nil {
 		if err == domain.ErrUserNotFound {
-			return echo.ErrNotFound
+			return h.renamed(ctx, ctx.Param("username"))
 		}
 		return err
 	}
 
-	following := serialization.NotFollowing
+	counts, err := h.repo.GetFollowCountsByEmail(ctx.Request().Context(), found.Email)
+	if err != nil {
+		return err
+	}
+
+	var cu *domain.Fanboy
 	if len(em) > 0 {
-		cu, err := h.repo.GetUserByEmail(ctx.Request().Context(), em)
+		cu, err = h.repo.GetUserByEmail(ctx.Request().Context(), em)
 		if err != nil {
 			if err == domain.ErrUserNotFound {
 				return echo.ErrNotFound
 			}
 			return err
 		}
-
-		following = serialization.MaybeFollowing(cu)
 	}
 
 	return ctx.JSON(
 		http.StatusOK,
-		serialization.UserToProfile(found, following))
+		serialization.UserToProfileFor(found, cu, counts))
+}
+
+// renamed redirects to the profile of the user who used to have the username.
+func (h *usersHandler) renamed(ctx echo.Context, un string) error {
+	found, err := h.repo.GetUserByPreviousUsername(ctx.Request().Context(), un)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return echo.ErrNotFound
+		}
+		return err
+	}
+
+	u := *ctx.Request().URL
+	u.Path = strings.Replace(u.Path, "/profiles/"+un, "/profiles/"+found.Username, 1)
+	u.RawPath = ""
+	return ctx.Redirect(http.StatusMovedPermanently, u.String())
 }
 
 func (h *usersHandler) follow(ctx echo.Context) error {
@@ -213,13 +618,16 @@ func (h *usersHandler) follow(ctx echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
-	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	found, err := h.fanboyByUsername(ctx, ctx.Param("username"))
 	if err != nil {
 		if err == domain.ErrUserNotFound {
 			return echo.ErrNotFound
 		}
 		return err
 	}
+	if found.IsBlocking(em) {
+		return forbidden
+	}
 
 	err = h.repo.UpdateFanboyByEmail(ctx.Request().Context(),
 		em,
@@ -236,7 +644,7 @@ func (h *usersHandler) follow(ctx echo.Context) error {
 
 	return ctx.JSON(
 		http.StatusOK,
-		serialization.UserToProfile(found, serialization.Following))
+		serialization.UserToProfile(&found.User, serialization.Following))
 }
 
 func (h *usersHandler) unfollow(ctx echo.Context) error {
@@ -274,3 +682,160 @@ func (h *usersHandler) unfollow(ctx echo.Context) error {
 		http.StatusOK,
 		serialization.UserToProfile(found, serialization.NotFollowing))
 }
+
+func (h *usersHandler) fanboyByUsername(ctx echo.Context, un string) (*domain.Fanboy, error) {
+	u, err := h.repo.GetUserByUsername(ctx.Request().Context(), un)
+	if err != nil {
+		return nil, err
+	}
+	return h.repo.GetUserByEmail(ctx.Request().Context(), u.Email)
+}
+
+// relate applies a change to how the current user relates to another profile.
+func (h *usersHandler) relate(
+	ctx echo.Context,
+	change func(*domain.Fanboy, string),
+) (*domain.User, *domain.Fanboy, error) {
+	em, _, ok := ctx.(*userContext).identity()
+	if !ok {
+		return nil, nil, identityNotOk
+	}
+
+	if len(ctx.Param("username")) == 0 {
+		return nil, nil, echo.ErrBadRequest
+	}
+
+	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, nil, echo.ErrNotFound
+		}
+		return nil, nil, err
+	}
+	if found.Email == em {
+		return nil, nil, echo.ErrBadRequest
+	}
+
+	var cu *domain.Fanboy
+	err = h.repo.UpdateFanboyByEmail(ctx.Request().Context(),
+		em,
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			change(u, found.Email)
+			cu = u
+			return u, nil
+		})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, nil, echo.ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	return found, cu, nil
+}
+
+func (h *usersHandler) block(ctx echo.Context) error {
+	found, cu, err := h.relate(ctx, (*domain.Fanboy).Block)
+	if err != nil {
+		return err
+	}
+
+	// Blocked users don't get to keep following
+	err = h.repo.UpdateFanboyByEmail(ctx.Request().Context(),
+		found.Email,
+		func(u *domain.Fanboy) (*domain.Fanboy, error) {
+			u.StopFollowing(cu.Email)
+			return u, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToProfileFor(found, cu, nil))
+}
+
+func (h *usersHandler) unblock(ctx echo.Context) error {
+	found, cu, err := h.relate(ctx, (*domain.Fanboy).Unblock)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToProfileFor(found, cu, nil))
+}
+
+func (h *usersHandler) mute(ctx echo.Context) error {
+	found, cu, err := h.relate(ctx, (*domain.Fanboy).Mute)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToProfileFor(found, cu, nil))
+}
+
+func (h *usersHandler) unmute(ctx echo.Context) error {
+	found, cu, err := h.relate(ctx, (*domain.Fanboy).Unmute)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UserToProfileFor(found, cu, nil))
+}
+
+func (h *usersHandler) followers(ctx echo.Context) error {
+	return h.follows(ctx, h.repo.FollowersByEmail, func(c *domain.FollowCounts) int {
+		return c.Followers
+	})
+}
+
+func (h *usersHandler) following(ctx echo.Context) error {
+	return h.follows(ctx, h.repo.FollowingByEmail, func(c *domain.FollowCounts) int {
+		return c.Following
+	})
+}
+
+// follows lists a page of the users related to a profile by following.
+func (h *usersHandler) follows(
+	ctx echo.Context,
+	list func(context.Context, string, int, int) ([]domain.User, error),
+	total func(*domain.FollowCounts) int,
+) error {
+	em, _, ok := ctx.(*userContext).identity()
+	var cu *domain.Fanboy
+	if ok {
+		cu, _ = h.repo.GetUserByEmail(ctx.Request().Context(), em)
+	}
+
+	if len(ctx.Param("username")) == 0 {
+		return echo.ErrBadRequest
+	}
+
+	found, err := h.repo.GetUserByUsername(ctx.Request().Context(), ctx.Param("username"))
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return h.renamed(ctx, ctx.Param("username"))
+		}
+		return err
+	}
+
+	limit, offset := page(ctx)
+	us, err := list(ctx.Request().Context(), found.Email, limit, offset)
+	if err != nil {
+		return err
+	}
+	counts, err := h.repo.GetFollowCountsByEmail(ctx.Request().Context(), found.Email)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(
+		http.StatusOK,
+		serialization.UsersToProfiles(us, cu, total(counts)))
+}
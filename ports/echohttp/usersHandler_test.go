@@ -0,0 +1,115 @@
+package echohttp_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/brycekbargar/realworld-backend/adapters/oidc/oidctest"
+	"github.com/brycekbargar/realworld-backend/adapters/outbox"
+	"github.com/brycekbargar/realworld-backend/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emailedToken finds the token in the last email sent to the address.
+func emailedToken(t *testing.T, ob *outbox.Outbox, to string) string {
+	sent := ob.Sent(to)
+	require.NotEmpty(t, sent)
+
+	for _, l := range strings.Split(sent[len(sent)-1].Body, "\n") {
+		if strings.Count(l, ".") == 2 && !strings.Contains(l, " ") {
+			return l
+		}
+	}
+	require.FailNow(t, "the email doesn't have a token", sent[len(sent)-1].Body)
+	return ""
+}
+
+func deleteUser(t *testing.T, h http.Handler, session string, user map[string]string) int {
+	return do(t, h, request{
+		method: http.MethodDelete,
+		path:   "/api/user",
+		token:  session,
+		body:   map[string]interface{}{"user": user},
+	}).Code
+}
+
+func Test_DeleteUser(t *testing.T) {
+	t.Parallel()
+
+	t.Run("With A Password", func(t *testing.T) {
+		t.Parallel()
+		h, repo, _ := newServer()
+		session := register(t, h, "user@resolute.com", "resolute")
+
+		assert.Equal(t, http.StatusUnauthorized,
+			deleteUser(t, h, session, map[string]string{"password": "not-" + password}))
+		assert.Equal(t, http.StatusOK,
+			deleteUser(t, h, session, map[string]string{"password": password}))
+
+		_, err := repo.GetUserByEmail(context.Background(), "user@resolute.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	})
+
+	t.Run("With An Emailed Token", func(t *testing.T) {
+		t.Parallel()
+		s, h, repo, ob := newOIDCServer(t)
+		s.SetIdentity(oidctest.Identity{
+			Subject:       "passwordless subject",
+			Email:         "user@passwordless.com",
+			EmailVerified: true,
+		})
+		res := login(t, h)
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var u userResponse
+		decode(t, res, &u)
+		session := u.User.Token
+
+		other := register(t, h, "user@bystander.com", "bystander")
+		res = do(t, h, request{method: http.MethodPost, path: "/api/user/deletion", token: other})
+		require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+		assert.Equal(t, http.StatusBadRequest,
+			deleteUser(t, h, session, map[string]string{"token": emailedToken(t, ob, "user@bystander.com")}),
+			"because the token is for someone else")
+
+		res = do(t, h, request{method: http.MethodPost, path: "/api/user/deletion", token: session})
+		require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+		assert.Equal(t, http.StatusBadRequest,
+			deleteUser(t, h, session, map[string]string{"token": "not a token"}))
+		assert.Equal(t, http.StatusOK,
+			deleteUser(t, h, session, map[string]string{"token": emailedToken(t, ob, "user@passwordless.com")}))
+
+		_, err := repo.GetUserByEmail(context.Background(), "user@passwordless.com")
+		assert.ErrorIs(t, err, domain.ErrUserNotFound)
+		_, err = repo.GetUserByEmail(context.Background(), "user@bystander.com")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Tokens Are Cancelled By Password Changes", func(t *testing.T) {
+		t.Parallel()
+		h, _, ob := newServer()
+		session := register(t, h, "user@indecisive.com", "indecisive")
+
+		res := do(t, h, request{method: http.MethodPost, path: "/api/user/deletion", token: session})
+		require.Equal(t, http.StatusAccepted, res.Code, res.Body.String())
+		token := emailedToken(t, ob, "user@indecisive.com")
+
+		res = do(t, h, request{
+			method: http.MethodPut,
+			path:   "/api/user",
+			token:  session,
+			body: map[string]interface{}{
+				"user": map[string]string{"password": "reconsidered-" + password},
+			},
+		})
+		require.Equal(t, http.StatusOK, res.Code, res.Body.String())
+		var u userResponse
+		decode(t, res, &u)
+
+		assert.Equal(t, http.StatusBadRequest,
+			deleteUser(t, h, u.User.Token, map[string]string{"token": token}))
+	})
+}
@@ -1,6 +1,9 @@
 package serialization
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/brycekbargar/realworld-backend/domain"
 )
 
@@ -24,31 +27,36 @@ func RegisterToUser(
 		return nil, err
 	}
 
+	pw := ""
+	if r.User.Password != nil {
+		pw = *r.User.Password
+	}
+
 	return domain.NewUserWithPassword(
 		r.User.Email,
 		r.User.Username,
-		*r.User.Password,
+		pw,
 	)
 }
 
 // UpdateUserToDelta converts a input serializable user to a delta for a domain user.
+// The delta errors when the new password isn't strong enough.
 func UpdateUserToDelta(
 	bind func(interface{}) error,
-) (func(*domain.User), error) {
+) (func(*domain.User) error, error) {
 	r := new(register)
 	if err := bind(r); err != nil {
 		return nil, err
 	}
 
-	return func(u *domain.User) {
+	return func(u *domain.User) error {
 		if r.User.Email != "" {
-			u.Email = r.User.Email
+			u.ChangeEmail(r.User.Email)
 		}
 		if r.User.Username != "" {
-			u.Username = r.User.Username
-		}
-		if r.User.Password != nil && *r.User.Password != "" {
-			u.SetPassword(*r.User.Password)
+			if err := u.ChangeUsername(r.User.Username); err != nil {
+				return err
+			}
 		}
 		if r.User.Bio != nil {
 			u.Bio = *r.User.Bio
@@ -56,9 +64,38 @@ func UpdateUserToDelta(
 		if r.User.Image != nil {
 			u.Image = *r.User.Image
 		}
+		// The password is set last so it's checked against the new email and username
+		if r.User.Password != nil && *r.User.Password != "" {
+			return u.SetPassword(*r.User.Password)
+		}
+		return nil
 	}, nil
 }
 
+type changeRole struct {
+	User changeRoleUser `json:"user"`
+}
+type changeRoleUser struct {
+	Role string `json:"role"`
+}
+
+// ChangeRoleToRole converts a input serializable role change to a domain role.
+func ChangeRoleToRole(
+	bind func(interface{}) error,
+) (domain.Role, error) {
+	r := new(changeRole)
+	if err := bind(r); err != nil {
+		return "", err
+	}
+
+	role := domain.Role(r.User.Role)
+	if !role.IsValid() {
+		return "", fmt.Errorf("%v is not a valid role", r.User.Role)
+	}
+
+	return role, nil
+}
+
 type login struct {
 	User loginUser `json:"user"`
 }
@@ -79,6 +116,103 @@ func LoginToCredentials(
 	return l.User.Email, l.User.Password, nil
 }
 
+type redeemToken struct {
+	User redeemTokenUser `json:"user"`
+}
+type redeemTokenUser struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// VerifyToToken converts a input serializable email verification to its token.
+func VerifyToToken(
+	bind func(interface{}) error,
+) (string, error) {
+	v := new(redeemToken)
+	if err := bind(v); err != nil {
+		return "", err
+	}
+	if v.User.Token == "" {
+		return "", errors.New("token is required")
+	}
+
+	return v.User.Token, nil
+}
+
+// ResetPasswordToCredentials converts a input serializable password reset to its token and new password.
+func ResetPasswordToCredentials(
+	bind func(interface{}) error,
+) (token string, password string, err error) {
+	rp := new(redeemToken)
+	if err := bind(rp); err != nil {
+		return "", "", err
+	}
+	if rp.User.Token == "" {
+		return "", "", errors.New("token is required")
+	}
+	if rp.User.Password == "" {
+		return "", "", errors.New("password is required")
+	}
+
+	return rp.User.Token, rp.User.Password, nil
+}
+
+// SecondFactorToCredentials converts a input serializable second login step to its challenge token and two-factor code.
+func SecondFactorToCredentials(
+	bind func(interface{}) error,
+) (token string, code string, err error) {
+	sf := new(redeemToken)
+	if err := bind(sf); err != nil {
+		return "", "", err
+	}
+	if sf.User.Token == "" {
+		return "", "", errors.New("token is required")
+	}
+	if sf.User.Code == "" {
+		return "", "", errors.New("code is required")
+	}
+
+	return sf.User.Token, sf.User.Code, nil
+}
+
+type confirmTwoFactor struct {
+	TwoFactor confirmTwoFactorTwoFactor `json:"twoFactor"`
+}
+type confirmTwoFactorTwoFactor struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorToCode converts a input serializable two-factor confirmation to its code.
+func TwoFactorToCode(
+	bind func(interface{}) error,
+) (string, error) {
+	tf := new(confirmTwoFactor)
+	if err := bind(tf); err != nil {
+		return "", err
+	}
+	if tf.TwoFactor.Code == "" {
+		return "", errors.New("code is required")
+	}
+
+	return tf.TwoFactor.Code, nil
+}
+
+// PasswordResetToEmail converts a input serializable password reset request to the email of the user.
+func PasswordResetToEmail(
+	bind func(interface{}) error,
+) (string, error) {
+	l := new(login)
+	if err := bind(l); err != nil {
+		return "", err
+	}
+	if l.User.Email == "" {
+		return "", errors.New("email is required")
+	}
+
+	return l.User.Email, nil
+}
+
 type createArticle struct {
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
@@ -149,3 +283,165 @@ func CommentToBody(
 
 	return c.Comment.Body, nil
 }
+
+type createReport struct {
+	Report createReportReport `json:"report"`
+}
+type createReportReport struct {
+	Article string `json:"article"`
+	Comment int    `json:"comment"`
+	Reason  string `json:"reason"`
+}
+
+// CreateReportToReport converts a input serializable report to a domain report by the given reporter.
+func CreateReportToReport(
+	bind func(interface{}) error,
+	reporter domain.Author,
+) (*domain.Report, error) {
+	r := new(createReport)
+	if err := bind(r); err != nil {
+		return nil, err
+	}
+
+	return domain.NewReport(
+		reporter.GetEmail(),
+		r.Report.Article,
+		r.Report.Comment,
+		r.Report.Reason,
+	)
+}
+
+type resolve struct {
+	Resolution resolveResolution `json:"resolution"`
+}
+type resolveResolution struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+// ResolveToAction converts a input serializable resolution to the action used to resolve a report.
+func ResolveToAction(
+	bind func(interface{}) error,
+) (action domain.ModerationAction, note string, err error) {
+	r := new(resolve)
+	if err := bind(r); err != nil {
+		return "", "", err
+	}
+
+	action = domain.ModerationAction(r.Resolution.Action)
+	if !action.IsReportResolution() {
+		return "", "", fmt.Errorf("%v can't be used to resolve a report", r.Resolution.Action)
+	}
+
+	return action, r.Resolution.Note, nil
+}
+
+type saveReadingList struct {
+	ReadingList saveReadingListReadingList `json:"readingList"`
+}
+type saveReadingListReadingList struct {
+	Name     string   `json:"name"`
+	Articles []string `json:"articles,omitempty"`
+}
+
+// CreateReadingListToReadingList converts a input serializable reading list to a domain reading list for the given owner.
+func CreateReadingListToReadingList(
+	bind func(interface{}) error,
+	owner domain.Author,
+) (*domain.ReadingList, error) {
+	l := new(saveReadingList)
+	if err := bind(l); err != nil {
+		return nil, err
+	}
+
+	rl, err := domain.NewReadingList(owner.GetEmail(), l.ReadingList.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range l.ReadingList.Articles {
+		rl.Add(s)
+	}
+
+	return rl, nil
+}
+
+// UpdateReadingListToDelta converts a input serializable reading list to a delta for a domain reading list.
+// Articles in the input reorder the existing articles in the list.
+func UpdateReadingListToDelta(
+	bind func(interface{}) error,
+) (func(*domain.ReadingList) error, error) {
+	l := new(saveReadingList)
+	if err := bind(l); err != nil {
+		return nil, err
+	}
+
+	return func(rl *domain.ReadingList) error {
+		if l.ReadingList.Name != "" {
+			rl.SetName(l.ReadingList.Name)
+		}
+		if l.ReadingList.Articles != nil {
+			if err := rl.Reorder(l.ReadingList.Articles); err != nil {
+				return err
+			}
+		}
+		_, err := rl.Validate()
+		return err
+	}, nil
+}
+
+type createAPIToken struct {
+	Token createAPITokenToken `json:"token"`
+}
+type createAPITokenToken struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPITokenToAPIToken converts a input serializable api token to a domain api token for the given owner.
+// It also returns the token to give to the owner, only its hash is kept.
+func CreateAPITokenToAPIToken(
+	bind func(interface{}) error,
+	owner domain.Author,
+) (*domain.APIToken, string, error) {
+	t := new(createAPIToken)
+	if err := bind(t); err != nil {
+		return nil, "", err
+	}
+
+	scopes := make([]domain.Scope, 0, len(t.Token.Scopes))
+	for _, s := range t.Token.Scopes {
+		scopes = append(scopes, domain.Scope(s))
+	}
+
+	return domain.NewAPIToken(owner.GetEmail(), t.Token.Name, scopes)
+}
+
+type deleteUser struct {
+	User deleteUserUser `json:"user"`
+}
+type deleteUserUser struct {
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Content  string `json:"content"`
+}
+
+// DeleteUserToCredentials converts a input serializable account deletion to the password (or emailed token) confirming it
+// and what should happen to the user's content, which is anonymized unless asked otherwise.
+func DeleteUserToCredentials(
+	bind func(interface{}) error,
+) (password string, token string, mode domain.DeletionMode, err error) {
+	d := new(deleteUser)
+	if err := bind(d); err != nil {
+		return "", "", "", err
+	}
+
+	mode = domain.AnonymizeContent
+	if d.User.Content != "" {
+		mode = domain.DeletionMode(d.User.Content)
+	}
+	if !mode.IsValid() {
+		return "", "", "", domain.ErrInvalidDeletionMode
+	}
+
+	return d.User.Password, d.User.Token, mode, nil
+}
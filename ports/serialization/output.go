@@ -10,11 +10,15 @@ type user struct {
 	User userUser `json:"user"`
 }
 type userUser struct {
-	Email    string  `json:"email"`
-	Token    string  `json:"token"`
-	Username string  `json:"username"`
-	Bio      *string `json:"bio"`
-	Image    *string `json:"image"`
+	ID        string  `json:"id"`
+	Email     string  `json:"email"`
+	Token     string  `json:"token"`
+	Username  string  `json:"username"`
+	Bio       *string `json:"bio"`
+	Image     *string `json:"image"`
+	Role      string  `json:"role,omitempty"`
+	Verified  bool    `json:"verified"`
+	TwoFactor bool    `json:"twoFactor"`
 }
 
 // UserToUser converts a domain user to an output serializable user.
@@ -24,11 +28,102 @@ func UserToUser(
 ) interface{} {
 	return &user{
 		userUser{
+			ID:        u.ID,
+			Email:     u.Email,
+			Token:     t,
+			Username:  u.Username,
+			Bio:       optional(u.Bio),
+			Image:     optional(u.Image),
+			Role:      string(u.Role),
+			Verified:  u.Verified,
+			TwoFactor: u.TwoFactor.Enabled,
+		},
+	}
+}
+
+type challenge struct {
+	Challenge challengeChallenge `json:"challenge"`
+}
+type challengeChallenge struct {
+	Email     string `json:"email"`
+	Token     string `json:"token"`
+	TwoFactor bool   `json:"twoFactor"`
+}
+
+// UserToChallenge converts a domain user who still needs to provide a two-factor code into an output serializable challenge.
+func UserToChallenge(
+	u *domain.User,
+	t string,
+) interface{} {
+	return &challenge{
+		challengeChallenge{
+			Email:     u.Email,
+			Token:     t,
+			TwoFactor: true,
+		},
+	}
+}
+
+type twoFactor struct {
+	TwoFactor twoFactorTwoFactor `json:"twoFactor"`
+}
+type twoFactorTwoFactor struct {
+	Enabled       bool     `json:"enabled"`
+	Secret        string   `json:"secret,omitempty"`
+	URI           string   `json:"uri,omitempty"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// UserToEnrollment converts a domain user who has enrolled in two-factor authentication into an output serializable two-factor setup.
+func UserToEnrollment(
+	u *domain.User,
+) interface{} {
+	return &twoFactor{
+		twoFactorTwoFactor{
+			Enabled: u.TwoFactor.Enabled,
+			Secret:  u.TwoFactor.Secret,
+			URI:     u.ProvisioningURI(),
+		},
+	}
+}
+
+// RecoveryCodesToTwoFactor converts newly made recovery codes into an output serializable two-factor setup.
+func RecoveryCodesToTwoFactor(
+	codes []string,
+) interface{} {
+	return &twoFactor{
+		twoFactorTwoFactor{
+			Enabled:       true,
+			RecoveryCodes: codes,
+		},
+	}
+}
+
+type moderatedUser struct {
+	User moderatedUserUser `json:"user"`
+}
+type moderatedUserUser struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Banned   bool   `json:"banned"`
+}
+
+// UserToModeratedUser converts a domain user to an output serializable user with their moderation details.
+func UserToModeratedUser(
+	u *domain.User,
+) interface{} {
+	role := u.Role
+	if role == "" {
+		role = domain.RoleUser
+	}
+
+	return &moderatedUser{
+		moderatedUserUser{
 			Email:    u.Email,
-			Token:    t,
 			Username: u.Username,
-			Bio:      optional(u.Bio),
-			Image:    optional(u.Image),
+			Role:     string(role),
+			Banned:   u.Banned,
 		},
 	}
 }
@@ -45,6 +140,10 @@ type profileUser struct {
 	Bio       string `json:"bio"`
 	Image     string `json:"image"`
 	Following bool   `json:"following"`
+	Blocking  bool   `json:"blocking,omitempty"`
+	Muting    bool   `json:"muting,omitempty"`
+	Followers *int   `json:"followersCount,omitempty"`
+	Followed  *int   `json:"followingCount,omitempty"`
 }
 
 // Following is a convenience func for when the user is following a profile.
@@ -73,6 +172,62 @@ func UserToProfile(
 	}
 }
 
+// UserToProfileFor converts a domain user to an output serializable profile
+// as seen by the (optional) current user, including whether they're blocked or muted
+// and (optionally) how many users they follow and are followed by.
+func UserToProfileFor(
+	u *domain.User,
+	cu *domain.Fanboy,
+	c *domain.FollowCounts,
+) interface{} {
+	return &profile{profileFor(u, cu, c)}
+}
+
+func profileFor(
+	u *domain.User,
+	cu *domain.Fanboy,
+	c *domain.FollowCounts,
+) profileUser {
+	p := profileUser{
+		Username: u.Username,
+		Bio:      u.Bio,
+		Image:    u.Image,
+	}
+	if cu != nil {
+		p.Following = cu.IsFollowing(u.Email)
+		p.Blocking = cu.IsBlocking(u.Email)
+		p.Muting = cu.IsMuting(u.Email)
+	}
+	if c != nil {
+		p.Followers = &c.Followers
+		p.Followed = &c.Following
+	}
+	return p
+}
+
+type profileList struct {
+	Profiles      []profileUser `json:"profiles"`
+	ProfilesCount int           `json:"profilesCount"`
+}
+
+// UsersToProfiles converts a page of domain users to an output serializable list of profiles
+// as seen by the (optional) current user, with the total number of profiles across all pages.
+func UsersToProfiles(
+	us []domain.User,
+	cu *domain.Fanboy,
+	total int,
+) interface{} {
+	ps := make([]profileUser, 0, len(us))
+	for i := range us {
+		ps = append(ps, profileFor(&us[i], cu, nil))
+	}
+
+	return &profileList{
+		ps,
+		total,
+	}
+}
+
 type author struct {
 	Username  string `json:"username"`
 	Bio       string `json:"bio"`
@@ -81,16 +236,19 @@ type author struct {
 }
 
 type articleArticle struct {
+	ID             string    `json:"id"`
 	Slug           string    `json:"slug"`
 	Title          string    `json:"title"`
 	Description    string    `json:"description"`
 	Body           string    `json:"body"`
+	BodyHTML       string    `json:"bodyHtml"`
 	TagList        []string  `json:"tagList"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 	Favorited      bool      `json:"favorited"`
 	FavoritesCount int       `json:"favoritesCount"`
 	Author         author    `json:"author"`
+	Hidden         bool      `json:"hidden,omitempty"`
 }
 
 type article struct {
@@ -107,10 +265,12 @@ func internalArticle(
 	cu *domain.Fanboy,
 ) interface{} {
 	return &articleArticle{
+		ID:             a.ID,
 		Slug:           a.Slug,
 		Title:          a.Title,
 		Description:    a.Description,
 		Body:           a.Body,
+		BodyHTML:       a.HTML(),
 		TagList:        a.TagList,
 		CreatedAt:      a.CreatedAtUTC,
 		UpdatedAt:      a.UpdatedAtUTC,
@@ -122,6 +282,7 @@ func internalArticle(
 			Image:     a.GetImage(),
 			Following: cu != nil && cu.IsFollowing(a.GetEmail()),
 		},
+		Hidden: a.Hidden,
 	}
 }
 
@@ -151,9 +312,11 @@ func ManyAuthoredArticlesToArticles(
 
 type commentComment struct {
 	ID        int       `json:"id"`
+	UID       string    `json:"uid"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 	Body      string    `json:"body"`
+	BodyHTML  string    `json:"bodyHtml"`
 	Author    author    `json:"author"`
 }
 
@@ -172,14 +335,16 @@ func internalComment(
 ) interface{} {
 	return &commentComment{
 		c.ID,
+		c.UID,
 		c.CreatedAtUTC,
 		c.CreatedAtUTC,
 		c.Body,
+		c.HTML(),
 		author{
 			a.GetUsername(),
 			a.GetBio(),
 			a.GetImage(),
-			cu.IsFollowing(a.GetEmail()),
+			cu != nil && cu.IsFollowing(a.GetEmail()),
 		},
 	}
 
@@ -210,6 +375,113 @@ func ArticleToCommentList(
 	return res
 }
 
+type reportReport struct {
+	ID         int        `json:"id"`
+	Article    string     `json:"article"`
+	Comment    int        `json:"comment,omitempty"`
+	Reason     string     `json:"reason"`
+	Reporter   string     `json:"reporter"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Resolution string     `json:"resolution,omitempty"`
+	ResolvedBy string     `json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+type report struct {
+	Report interface{} `json:"report"`
+}
+
+type reportList struct {
+	Reports      []interface{} `json:"reports"`
+	ReportsCount int           `json:"reportsCount"`
+}
+
+func internalReport(
+	r *domain.Report,
+) interface{} {
+	var resolved *time.Time
+	if !r.ResolvedAtUTC.IsZero() {
+		at := r.ResolvedAtUTC
+		resolved = &at
+	}
+
+	return &reportReport{
+		ID:         r.ID,
+		Article:    r.ArticleSlug,
+		Comment:    r.CommentID,
+		Reason:     r.Reason,
+		Reporter:   r.ReporterEmail,
+		Status:     string(r.Status),
+		CreatedAt:  r.CreatedAtUTC,
+		Resolution: string(r.Resolution),
+		ResolvedBy: r.ResolverEmail,
+		ResolvedAt: resolved,
+	}
+}
+
+// ReportToReport converts a domain report into an output serializable report.
+func ReportToReport(
+	r *domain.Report,
+) interface{} {
+	return &report{internalReport(r)}
+}
+
+// ManyReportsToReports converts multiple domain reports into an output serializable list of reports.
+func ManyReportsToReports(
+	rs []domain.Report,
+) interface{} {
+	res := reportList{
+		make([]interface{}, 0, len(rs)),
+		len(rs),
+	}
+	for _, r := range rs {
+		res.Reports = append(res.Reports, internalReport(&r))
+	}
+
+	return res
+}
+
+type auditEntry struct {
+	ID        int       `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Article   string    `json:"article,omitempty"`
+	Comment   int       `json:"comment,omitempty"`
+	User      string    `json:"user,omitempty"`
+	Report    int       `json:"report,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type auditTrail struct {
+	AuditTrail []auditEntry `json:"auditTrail"`
+}
+
+// AuditEntriesToAuditTrail converts domain audit entries into an output serializable audit trail.
+func AuditEntriesToAuditTrail(
+	es []domain.AuditEntry,
+) interface{} {
+	res := auditTrail{
+		make([]auditEntry, 0, len(es)),
+	}
+	for _, e := range es {
+		res.AuditTrail = append(res.AuditTrail, auditEntry{
+			e.ID,
+			e.ActorEmail,
+			string(e.Action),
+			e.ArticleSlug,
+			e.CommentID,
+			e.UserEmail,
+			e.ReportID,
+			e.Note,
+			e.CreatedAtUTC,
+		})
+	}
+
+	return res
+}
+
 type tagList struct {
 	Tags []string `json:"tags"`
 }
@@ -220,3 +492,276 @@ func TagsToTaglist(
 ) interface{} {
 	return &tagList{ts}
 }
+
+type readingList struct {
+	ReadingList interface{} `json:"readingList"`
+}
+
+type readingListReadingList struct {
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Articles  []string  `json:"articles"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type readingListList struct {
+	ReadingLists      []interface{} `json:"readingLists"`
+	ReadingListsCount int           `json:"readingListsCount"`
+}
+
+func internalReadingList(
+	l *domain.ReadingList,
+) interface{} {
+	return &readingListReadingList{
+		Slug:      l.Slug,
+		Name:      l.Name,
+		Articles:  append(make([]string, 0, len(l.ArticleSlugs)), l.ArticleSlugs...),
+		CreatedAt: l.CreatedAtUTC,
+		UpdatedAt: l.UpdatedAtUTC,
+	}
+}
+
+// ReadingListToReadingList converts a domain reading list into an output serializable reading list.
+func ReadingListToReadingList(
+	l *domain.ReadingList,
+) interface{} {
+	return &readingList{
+		internalReadingList(l),
+	}
+}
+
+// ManyReadingListsToReadingLists converts multiple domain reading lists into an output serializable list of reading lists.
+func ManyReadingListsToReadingLists(
+	ls []domain.ReadingList,
+) interface{} {
+	res := readingListList{
+		make([]interface{}, 0, len(ls)),
+		len(ls),
+	}
+	for i := range ls {
+		res.ReadingLists = append(res.ReadingLists, internalReadingList(&ls[i]))
+	}
+
+	return res
+}
+
+type upload struct {
+	Upload uploadUpload `json:"upload"`
+}
+
+type uploadUpload struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	ContentType  string `json:"contentType"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// ImageToUpload converts an uploaded domain image (and its optional thumbnail) into an output serializable upload.
+func ImageToUpload(
+	img *domain.Image,
+	thumb *domain.Image,
+	url func(string) string,
+) interface{} {
+	u := uploadUpload{
+		URL:         url(img.Key),
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+	}
+	if thumb != nil {
+		u.ThumbnailURL = url(thumb.Key)
+	}
+
+	return &upload{u}
+}
+
+type apiToken struct {
+	Token interface{} `json:"token"`
+}
+
+type apiTokenToken struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"createdAt"`
+	Token     string    `json:"token,omitempty"`
+}
+
+type apiTokenList struct {
+	Tokens      []interface{} `json:"tokens"`
+	TokensCount int           `json:"tokensCount"`
+}
+
+func internalAPIToken(
+	t *domain.APIToken,
+	raw string,
+) interface{} {
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	return &apiTokenToken{
+		ID:        t.ID,
+		Name:      t.Name,
+		Scopes:    scopes,
+		CreatedAt: t.CreatedAtUTC,
+		Token:     raw,
+	}
+}
+
+// APITokenToAPIToken converts a newly created domain api token and the token itself into an output serializable api token.
+// This is the only time the token is shown.
+func APITokenToAPIToken(
+	t *domain.APIToken,
+	raw string,
+) interface{} {
+	return &apiToken{
+		internalAPIToken(t, raw),
+	}
+}
+
+// ManyAPITokensToAPITokens converts multiple domain api tokens into an output serializable list of api tokens (without the tokens themselves).
+func ManyAPITokensToAPITokens(
+	ts []domain.APIToken,
+) interface{} {
+	res := apiTokenList{
+		make([]interface{}, 0, len(ts)),
+		len(ts),
+	}
+	for i := range ts {
+		res.Tokens = append(res.Tokens, internalAPIToken(&ts[i], ""))
+	}
+
+	return res
+}
+
+type export struct {
+	Export exportExport `json:"export"`
+}
+
+type exportExport struct {
+	ExportedAt   time.Time       `json:"exportedAt"`
+	Profile      exportProfile   `json:"profile"`
+	Following    []string        `json:"following"`
+	Favorites    []string        `json:"favorites"`
+	Articles     []exportArticle `json:"articles"`
+	Comments     []exportComment `json:"comments"`
+	ReadingLists []interface{}   `json:"readingLists"`
+}
+
+type exportProfile struct {
+	ID                string   `json:"id"`
+	Email             string   `json:"email"`
+	Username          string   `json:"username"`
+	PreviousUsernames []string `json:"previousUsernames"`
+	Bio               string   `json:"bio"`
+	Image             string   `json:"image"`
+	Role              string   `json:"role"`
+	Verified          bool     `json:"verified"`
+	TwoFactor         bool     `json:"twoFactor"`
+	// Identities are the names of the identity providers they can login with.
+	Identities []string `json:"identities"`
+}
+
+type exportArticle struct {
+	ID          string    `json:"id"`
+	Slug        string    `json:"slug"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Body        string    `json:"body"`
+	TagList     []string  `json:"tagList"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Hidden      bool      `json:"hidden"`
+}
+
+type exportComment struct {
+	Article   string    `json:"article"`
+	ID        int       `json:"id"`
+	UID       string    `json:"uid"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+	Hidden    bool      `json:"hidden"`
+}
+
+func internalExport(
+	x *domain.UserExport,
+) *exportExport {
+	res := exportExport{
+		ExportedAt: x.CreatedAtUTC,
+		// Credentials (the password hash, the two factor secret and recovery codes) are never exported
+		Profile: exportProfile{
+			ID:                x.User.ID,
+			Email:             x.User.Email,
+			Username:          x.User.Username,
+			PreviousUsernames: append(make([]string, 0, len(x.PreviousUsernames)), x.PreviousUsernames...),
+			Bio:               x.User.Bio,
+			Image:             x.User.Image,
+			Role:              string(x.User.Role),
+			Verified:          x.User.Verified,
+			TwoFactor:         x.User.TwoFactor.Enabled,
+		},
+		Following:    append(make([]string, 0, len(x.Following)), x.Following...),
+		Favorites:    append(make([]string, 0, len(x.Favorites)), x.Favorites...),
+		Articles:     make([]exportArticle, 0, len(x.Articles)),
+		Comments:     make([]exportComment, 0, len(x.Comments)),
+		ReadingLists: make([]interface{}, 0, len(x.ReadingLists)),
+	}
+	res.Profile.Identities = make([]string, 0, len(x.Identities))
+	for _, i := range x.Identities {
+		res.Profile.Identities = append(res.Profile.Identities, i.Provider)
+	}
+	for _, a := range x.Articles {
+		res.Articles = append(res.Articles, exportArticle{
+			ID:          a.ID,
+			Slug:        a.Slug,
+			Title:       a.Title,
+			Description: a.Description,
+			Body:        a.Body,
+			TagList:     append(make([]string, 0, len(a.TagList)), a.TagList...),
+			CreatedAt:   a.CreatedAtUTC,
+			UpdatedAt:   a.UpdatedAtUTC,
+			Hidden:      a.Hidden,
+		})
+	}
+	for _, c := range x.Comments {
+		res.Comments = append(res.Comments, exportComment{
+			Article:   c.ArticleSlug,
+			ID:        c.ID,
+			UID:       c.UID,
+			Body:      c.Body,
+			CreatedAt: c.CreatedAtUTC,
+			Hidden:    c.Hidden,
+		})
+	}
+	for i := range x.ReadingLists {
+		res.ReadingLists = append(res.ReadingLists, internalReadingList(&x.ReadingLists[i]))
+	}
+
+	return &res
+}
+
+// UserExportToExport converts a domain user export into an output serializable export.
+func UserExportToExport(
+	x *domain.UserExport,
+) interface{} {
+	return &export{*internalExport(x)}
+}
+
+// UserExportToArchive converts a domain user export into output serializable files by name, for zipping up.
+func UserExportToArchive(
+	x *domain.UserExport,
+) map[string]interface{} {
+	e := internalExport(x)
+	return map[string]interface{}{
+		"profile.json":      e.Profile,
+		"following.json":    e.Following,
+		"favorites.json":    e.Favorites,
+		"articles.json":     e.Articles,
+		"comments.json":     e.Comments,
+		"readingLists.json": e.ReadingLists,
+	}
+}